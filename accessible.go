@@ -0,0 +1,70 @@
+package qml
+
+// AccessibleRole holds the role an item plays in the user interface,
+// as reported to assistive technologies such as screen readers.
+// The values match the QAccessible::Role enumeration in Qt.
+type AccessibleRole int
+
+const (
+	AccessibleNoRole      AccessibleRole = 0x00
+	AccessibleTitleBar    AccessibleRole = 0x01
+	AccessibleMenuBar     AccessibleRole = 0x02
+	AccessibleScrollBar   AccessibleRole = 0x03
+	AccessibleClient      AccessibleRole = 0x0A
+	AccessibleMenuItem    AccessibleRole = 0x0C
+	AccessibleToolTip     AccessibleRole = 0x0D
+	AccessiblePane        AccessibleRole = 0x10
+	AccessibleDialog      AccessibleRole = 0x12
+	AccessibleToolBar     AccessibleRole = 0x16
+	AccessibleLink        AccessibleRole = 0x1E
+	AccessibleList        AccessibleRole = 0x21
+	AccessibleListItem    AccessibleRole = 0x22
+	AccessiblePageTab     AccessibleRole = 0x25
+	AccessibleGraphic     AccessibleRole = 0x28
+	AccessibleStaticText  AccessibleRole = 0x29
+	AccessibleEditable    AccessibleRole = 0x2A
+	AccessibleButton      AccessibleRole = 0x2B
+	AccessibleCheckBox    AccessibleRole = 0x2C
+	AccessibleRadioButton AccessibleRole = 0x2D
+	AccessibleComboBox    AccessibleRole = 0x2E
+	AccessibleProgressBar AccessibleRole = 0x30
+	AccessibleSlider      AccessibleRole = 0x33
+	AccessibleSpinBox     AccessibleRole = 0x34
+)
+
+// SetAccessible sets the role, name, and description reported for item
+// to assistive technologies. It is equivalent to setting the respective
+// properties of the Accessible attached object in QML.
+func SetAccessible(item Object, role AccessibleRole, name, description string) error {
+	if err := item.Set("Accessible.role", int(role)); err != nil {
+		return err
+	}
+	if err := item.Set("Accessible.name", name); err != nil {
+		return err
+	}
+	return item.Set("Accessible.description", description)
+}
+
+// AccessibleAction identifies an action that assistive technologies
+// may request on an item.
+type AccessibleAction string
+
+const (
+	PressAction        AccessibleAction = "pressAction"
+	ToggleAction       AccessibleAction = "toggleAction"
+	IncreaseAction     AccessibleAction = "increaseAction"
+	DecreaseAction     AccessibleAction = "decreaseAction"
+	ScrollUpAction     AccessibleAction = "scrollUpAction"
+	ScrollDownAction   AccessibleAction = "scrollDownAction"
+	ScrollLeftAction   AccessibleAction = "scrollLeftAction"
+	ScrollRightAction  AccessibleAction = "scrollRightAction"
+	PreviousPageAction AccessibleAction = "previousPageAction"
+	NextPageAction     AccessibleAction = "nextPageAction"
+)
+
+// OnAccessibleAction arranges for f to be called whenever an assistive
+// technology requests the provided action on item. As with signal
+// handlers, f is run within the main GUI thread.
+func OnAccessibleAction(item Object, action AccessibleAction, f func()) {
+	item.On("Accessible."+string(action), f)
+}
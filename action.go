@@ -0,0 +1,194 @@
+package qml
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ActionRegistry holds the actions of an application, defined once in
+// Go and shared by all user interface surfaces that trigger them, such
+// as menus, toolbars, keyboard shortcuts and command palettes. For example:
+//
+//     actions := &qml.ActionRegistry{}
+//     actions.Add("save", "Save", "Ctrl+S", doc.Save, doc.Modified)
+//     actions.Add("quit", "Quit", "Ctrl+Q", app.Quit, nil)
+//     context.SetVar("actions", actions)
+//
+// and in QML:
+//
+//     Menu {
+//         Repeater {
+//             model: actions.names
+//             MenuItem {
+//                 property var action: actions.get(modelData)
+//                 text: action.text
+//                 enabled: action.enabled
+//                 onTriggered: action.trigger()
+//             }
+//         }
+//     }
+//     Repeater {
+//         model: actions.names
+//         Item {
+//             property var action: actions.get(modelData)
+//             Shortcut { sequence: action.shortcut; enabled: action.enabled; onActivated: action.trigger() }
+//         }
+//     }
+//
+// A command palette may list the names returned by Search as the user
+// types. The zero value is an empty registry ready to use. Handlers and
+// enabled predicates are run within the main GUI thread.
+type ActionRegistry struct {
+	// Names holds the names of all actions, in the order they were added.
+	Names []string
+
+	actions map[string]*Action
+}
+
+// Action is an operation of the application registered in an
+// ActionRegistry.
+type Action struct {
+	Name     string // Name identifying the action in the registry.
+	Text     string // Description of the action shown to the user.
+	Shortcut string // Key sequence in portable text form, such as "Ctrl+S".
+	Enabled  bool   // Whether the action may be triggered.
+
+	handler func()
+	enabled func() bool
+}
+
+// Add registers a new action with the provided name, text, and shortcut,
+// which runs handler when triggered. If enabled is not nil, it's called
+// whenever the registry is updated to tell whether the action may be
+// triggered. Otherwise the action is always enabled.
+// Add panics if the registry already has an action with the same name.
+func (r *ActionRegistry) Add(name, text, shortcut string, handler func(), enabled func() bool) *Action {
+	action := &Action{
+		Name:     name,
+		Text:     text,
+		Shortcut: shortcut,
+		Enabled:  true,
+		handler:  handler,
+		enabled:  enabled,
+	}
+	gui(func() {
+		if r.actions == nil {
+			r.actions = make(map[string]*Action)
+		}
+		if _, ok := r.actions[name]; ok {
+			panic(fmt.Sprintf("action registry already has an action named %q", name))
+		}
+		r.actions[name] = action
+		r.Names = append(r.Names, name)
+		Changed(r, &r.Names)
+		action.update()
+	})
+	return action
+}
+
+// Remove removes the named action from the registry, if present.
+func (r *ActionRegistry) Remove(name string) {
+	gui(func() {
+		if _, ok := r.actions[name]; !ok {
+			return
+		}
+		delete(r.actions, name)
+		names := make([]string, 0, len(r.Names)-1)
+		for _, n := range r.Names {
+			if n != name {
+				names = append(names, n)
+			}
+		}
+		r.Names = names
+		Changed(r, &r.Names)
+	})
+}
+
+// Get returns the named action, or nil if there is no such action.
+func (r *ActionRegistry) Get(name string) *Action {
+	var action *Action
+	gui(func() {
+		action = r.actions[name]
+	})
+	return action
+}
+
+// Trigger runs the handler of the named action. An error is returned
+// if there is no such action or if it is disabled.
+func (r *ActionRegistry) Trigger(name string) error {
+	action := r.Get(name)
+	if action == nil {
+		return fmt.Errorf("action registry has no action named %q", name)
+	}
+	if !action.Trigger() {
+		return fmt.Errorf("action %q is disabled", name)
+	}
+	return nil
+}
+
+// Update calls the enabled predicates of all actions again, for when
+// the state they depend upon has changed.
+func (r *ActionRegistry) Update() {
+	gui(func() {
+		for _, name := range r.Names {
+			r.actions[name].update()
+		}
+	})
+}
+
+// Search returns the names of the enabled actions whose text or name
+// contains all the words in query, ignoring case, in the order they were
+// added. All enabled actions are returned if query is empty.
+func (r *ActionRegistry) Search(query string) []string {
+	words := strings.Fields(strings.ToLower(query))
+	var names []string
+	gui(func() {
+	NextAction:
+		for _, name := range r.Names {
+			action := r.actions[name]
+			if !action.Enabled {
+				continue
+			}
+			haystack := strings.ToLower(action.Text + " " + action.Name)
+			for _, word := range words {
+				if !strings.Contains(haystack, word) {
+					continue NextAction
+				}
+			}
+			names = append(names, name)
+		}
+	})
+	return names
+}
+
+// Trigger runs the action handler if the action is enabled, and returns
+// whether it was run.
+func (a *Action) Trigger() bool {
+	var run bool
+	gui(func() {
+		a.update()
+		if a.Enabled && a.handler != nil {
+			a.handler()
+			run = true
+		}
+	})
+	return run
+}
+
+// SetShortcut changes the key sequence of the action.
+func (a *Action) SetShortcut(shortcut string) {
+	gui(func() {
+		if a.Shortcut != shortcut {
+			a.Shortcut = shortcut
+			Changed(a, &a.Shortcut)
+		}
+	})
+}
+
+func (a *Action) update() {
+	enabled := a.enabled == nil || a.enabled()
+	if a.Enabled != enabled {
+		a.Enabled = enabled
+		Changed(a, &a.Enabled)
+	}
+}
@@ -4,5 +4,6 @@
 #include "cpp/govaluetype.cpp"
 #include "cpp/idletimer.cpp"
 #include "cpp/connector.cpp"
+#include "cpp/network.cpp"
 
 #include "cpp/moc_all.cpp"
@@ -0,0 +1,3 @@
+// +build qmlcharts
+
+#include "cpp/charts.cpp"
@@ -0,0 +1,3 @@
+// +build !qmldbus
+
+#include "cpp/dbus_stub.cpp"
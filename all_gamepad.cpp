@@ -0,0 +1,3 @@
+// +build qmlgamepad
+
+#include "cpp/gamepad.cpp"
@@ -0,0 +1,2 @@
+
+#include "cpp/freedesktop.cpp"
@@ -0,0 +1,3 @@
+// +build qmlmultimedia
+
+#include "cpp/multimedia.cpp"
@@ -0,0 +1,3 @@
+// +build !qmlpositioning
+
+#include "cpp/positioning_stub.cpp"
@@ -0,0 +1,3 @@
+// +build !qmlsensors
+
+#include "cpp/sensors_stub.cpp"
@@ -0,0 +1,3 @@
+// +build !qmlsvg
+
+#include "cpp/svg_stub.cpp"
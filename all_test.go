@@ -1,19 +1,35 @@
 package qml_test
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/niemeyer/qml"
+	"github.com/niemeyer/qml/qmlfuzz"
+	"github.com/niemeyer/qml/qmltest"
 	"image"
 	"image/color"
+	"image/jpeg"
+	"image/png"
 	"io/ioutil"
 	. "launchpad.net/gocheck"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -121,6 +137,269 @@ func (ts *TestType) IncrementInt() {
 	ts.IntValue++
 }
 
+func (ts *TestType) ObjectMethod() qml.Object {
+	return ts.ObjectValue
+}
+
+func (ts *TestType) PromiseMethod(s string) *qml.Promise {
+	promise := qml.NewPromise()
+	if s == "" {
+		promise.Reject(fmt.Errorf("<empty>"))
+	} else {
+		promise.Resolve(s + s)
+	}
+	return promise
+}
+
+func (ts *TestType) StreamMethod(n int) <-chan int {
+	ch := make(chan int)
+	go func() {
+		for i := 0; i < n; i++ {
+			ch <- i
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+func (ts *TestType) ContextMethod(ctx context.Context, s string) *qml.Promise {
+	promise := qml.NewPromise()
+	go func() {
+		<-ctx.Done()
+		promise.Reject(fmt.Errorf("%s: %v", s, ctx.Err()))
+	}()
+	return promise
+}
+
+func (ts *TestType) FuncMethod(s string, f *qml.Func) interface{} {
+	defer f.Release()
+	return f.Call(s, 42)
+}
+
+type testWorker struct {
+	calls int
+}
+
+func (w *testWorker) Double(n int) int {
+	w.calls++
+	return n * 2
+}
+
+type testTransport struct{}
+
+func (testTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set("X-Transport", "<go>")
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+type testSocketConn struct {
+	messages chan []byte
+	done     chan bool
+}
+
+func (conn *testSocketConn) ReadMessage() ([]byte, error) {
+	select {
+	case data := <-conn.messages:
+		return data, nil
+	case <-conn.done:
+		return nil, fmt.Errorf("connection closed")
+	}
+}
+
+func (conn *testSocketConn) WriteMessage(data []byte) error {
+	conn.messages <- append([]byte("echo: "), data...)
+	return nil
+}
+
+func (conn *testSocketConn) Close() error {
+	close(conn.done)
+	return nil
+}
+
+type testShimmed struct {
+	Text  string
+	shims int
+}
+
+func (v *testShimmed) Upper() string {
+	return strings.ToUpper(v.Text)
+}
+
+var testShimmedShim = &qml.TypeShim{
+	Fields: []qml.FieldShim{{
+		Name: "Text",
+		Get: func(v interface{}) interface{} {
+			v.(*testShimmed).shims++
+			return v.(*testShimmed).Text
+		},
+		Set: func(v, a interface{}) {
+			v.(*testShimmed).shims++
+			v.(*testShimmed).Text = a.(string)
+		},
+	}},
+	Methods: []qml.MethodShim{{
+		Name: "Upper",
+		Call: func(v interface{}, a []interface{}) interface{} {
+			v.(*testShimmed).shims++
+			return v.(*testShimmed).Upper()
+		},
+	}},
+}
+
+type testTextValue struct {
+	Addr net.IP
+}
+
+func (v *testTextValue) IsLoopback(ip net.IP) bool {
+	return ip.IsLoopback()
+}
+
+type testNullable struct {
+	Name sql.NullString
+	Age  sql.NullInt64
+}
+
+// testDecimal is a non-negative decimal type holding hundredths, with
+// the methods of common decimal packages.
+type testDecimal int64
+
+func (d testDecimal) StringFixed(places int32) string {
+	s := fmt.Sprintf("%d.%02d", d/100, d%100)
+	return s[:len(s)-2+int(places)]
+}
+
+func (d testDecimal) String() string {
+	return d.StringFixed(2)
+}
+
+func (d *testDecimal) UnmarshalText(text []byte) error {
+	f, err := strconv.ParseFloat(string(text), 64)
+	*d = testDecimal(f*100 + 0.5)
+	return err
+}
+
+type testPrice struct {
+	Amount testDecimal
+	Total  testDecimal `qml:"precision=1,currency"`
+}
+
+var testPalette *qml.Palette
+
+type testTheme struct {
+	Colors  testThemeColors
+	Spacing testThemeSpacing
+}
+
+type testThemeColors struct {
+	Primary, Background color.RGBA
+}
+
+type testThemeSpacing struct {
+	Small, Medium int
+}
+
+var testThemeTokens = &testTheme{}
+
+type testNamed struct {
+	Text string
+}
+
+type testMatrix struct {
+	Rows [][]int
+}
+
+func (m *testMatrix) SetRows(rows [][]int) {
+	m.Rows = rows
+}
+
+type testSettings struct {
+	Values   map[string]int
+	Defaults map[string]int
+}
+
+func (s *testSettings) SetDefaults(defaults map[string]int) {
+	s.Defaults = defaults
+}
+
+type testBooking struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (b *testBooking) Days() int {
+	return int(b.End.Sub(b.Start).Hours() / 24)
+}
+
+type testPaint struct {
+	Fill  color.NRGBA
+	Shade color.Gray
+	Hex   testHexColor
+	Label testLabelColor
+}
+
+// testHexColor is an opaque color held as 0xRRGGBB, with its own model.
+type testHexColor uint32
+
+func (c testHexColor) RGBA() (r, g, b, a uint32) {
+	return uint32(c>>16&0xff) * 0x101, uint32(c>>8&0xff) * 0x101, uint32(c&0xff) * 0x101, 0xffff
+}
+
+func (c testHexColor) ColorModel() color.Model {
+	return color.ModelFunc(func(c color.Color) color.Color {
+		r, g, b, _ := c.RGBA()
+		return testHexColor(r>>8<<16 | g>>8<<8 | b>>8)
+	})
+}
+
+// testLabelColor is a color without a model of its own.
+type testLabelColor color.RGBA
+
+func (c testLabelColor) RGBA() (r, g, b, a uint32) {
+	return color.RGBA(c).RGBA()
+}
+
+type testReleased struct {
+	Name string
+}
+
+type testRouteParams struct {
+	Id int
+}
+
+type testAccount struct {
+	Name       string
+	Subscribed bool
+	Age        int    `qml:"form=ageBox"`
+	Notes      string `qml:"form=-"`
+}
+
+type testDocument struct {
+	Title    string
+	Pages    int
+	Tags     []string
+	Owner    *testOwner
+	OnChange func()
+}
+
+type testOwner struct {
+	Name string
+}
+
+type testSignup struct {
+	Email    string
+	Password string
+	Confirm  string
+}
+
+type testHighlighter struct {
+	blocks []string
+}
+
+func (h *testHighlighter) HighlightBlock(text string) []qml.FormatRange {
+	h.blocks = append(h.blocks, text)
+	return []qml.FormatRange{{Start: 0, Length: 4, Format: qml.TextFormat{Bold: true}}}
+}
+
 func (s *S) TestEngineDestroyedUse(c *C) {
 	s.engine.Destroy()
 	s.engine.Destroy()
@@ -190,6 +469,432 @@ func (s *S) TestContextSetVars(c *C) {
 	c.Assert(s.context.Var("objectValue").(qml.Object).Int("width"), Equals, 42)
 }
 
+func (s *S) TestInitOptionsFlags(c *C) {
+	var options qml.InitOptions
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	options.RegisterFlags(fs)
+	err := fs.Parse([]string{"--qml-debug=1234", "--software-render", "--scale-factor=1.5"})
+	c.Assert(err, IsNil)
+	c.Assert(options, Equals, qml.InitOptions{DebugPort: 1234, SoftwareRender: true, ScaleFactor: 1.5})
+}
+
+type strictInner struct {
+	Counts map[int]int
+}
+
+type strictOuter struct {
+	Name  string
+	Inner *strictInner
+}
+
+func (s *S) TestStrict(c *C) {
+	qml.SetStrict(true)
+	defer qml.SetStrict(false)
+
+	c.Assert(func() { s.context.SetVar("value", &strictOuter{}) }, PanicMatches,
+		`cannot hand \*qml_test.strictOuter value to QML: strictOuter.Inner.Counts has unsupported type map\[int\]int`)
+
+	s.context.SetVar("value", &testWorker{})
+	s.context.SetVar("value", &testSettings{})
+	s.context.SetVar("value", "string")
+	s.context.SetVar("value", []string{"a"})
+
+	s.engine.SetConversionPolicy(qml.JSONValues)
+	s.context.SetVar("value", &strictOuter{})
+
+	s.engine.SetConversionPolicy(qml.RejectValues)
+	c.Assert(func() { s.context.SetVar("value", &strictOuter{}) }, PanicMatches,
+		`cannot hand \*qml_test.strictOuter value to QML: strictOuter.Inner.Counts has type map\[int\]int, which the conversion policy rejects`)
+}
+
+func (s *S) TestDecodeImageOrientation(c *C) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			if x < 8 {
+				img.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	c.Assert(jpeg.Encode(&buf, img, nil), IsNil)
+
+	// An EXIF segment with a single orientation entry requesting a
+	// clockwise rotation, in big-endian TIFF form.
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(exif)+2))
+	data := append(append(append([]byte{}, buf.Bytes()[:2]...), append(segment, exif...)...), buf.Bytes()[2:]...)
+
+	upright, err := qml.DecodeImage(data)
+	c.Assert(err, IsNil)
+	c.Assert(upright.Bounds(), Equals, image.Rect(0, 0, 8, 16))
+	r, _, b, _ := upright.At(4, 2).RGBA()
+	c.Assert(r > b, Equals, true)
+	r, _, b, _ = upright.At(4, 13).RGBA()
+	c.Assert(r < b, Equals, true)
+
+	plain, err := qml.DecodeImage(buf.Bytes())
+	c.Assert(err, IsNil)
+	c.Assert(plain.Bounds(), Equals, image.Rect(0, 0, 16, 8))
+}
+
+func (s *S) TestImageCache(c *C) {
+	cache := qml.NewImageCache(2 * 10 * 10 * 4)
+	var loads []string
+	var evicted []qml.ImageKey
+	cache.OnEvict(func(key qml.ImageKey, img image.Image) { evicted = append(evicted, key) })
+	f := cache.Provider("thumbs", func(id string, width, height int) image.Image {
+		loads = append(loads, id)
+		return image.NewRGBA(image.Rect(0, 0, 10, 10))
+	})
+
+	img := f("a", 0, 0)
+	c.Assert(f("a", 0, 0), Equals, img)
+	f("b", 0, 0)
+	c.Assert(loads, DeepEquals, []string{"a", "b"})
+	c.Assert(cache.Size(), Equals, int64(800))
+
+	// Loading a third image evicts the least recently used one.
+	f("a", 0, 0)
+	f("c", 0, 0)
+	c.Assert(evicted, DeepEquals, []qml.ImageKey{{Provider: "thumbs", Id: "b"}})
+	_, ok := cache.Get(qml.ImageKey{Provider: "thumbs", Id: "a"})
+	c.Assert(ok, Equals, true)
+
+	cache.Prewarm(qml.ImageKey{Provider: "thumbs", Id: "d", Width: 10, Height: 10})
+	for i := 0; i < 100; i++ {
+		if _, ok := cache.Get(qml.ImageKey{Provider: "thumbs", Id: "d", Width: 10, Height: 10}); ok {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, ok = cache.Get(qml.ImageKey{Provider: "thumbs", Id: "d", Width: 10, Height: 10})
+	c.Assert(ok, Equals, true)
+
+	cache.Purge()
+	c.Assert(cache.Size(), Equals, int64(0))
+	c.Assert(func() { cache.Provider("thumbs", nil) }, PanicMatches, `image cache already has a provider named "thumbs"`)
+}
+
+func (s *S) TestPrewarmCache(c *C) {
+	dir := c.MkDir()
+	good := filepath.Join(dir, "Good.qml")
+	bad := filepath.Join(dir, "Bad.qml")
+	c.Assert(ioutil.WriteFile(good, []byte("import QtQuick 2.0\nItem {}\n"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(bad, []byte("import QtQuick 2.0\nItem { bogus: 1 }\n"), 0644), IsNil)
+
+	c.Assert(s.engine.PrewarmCache(good), IsNil)
+	err := s.engine.PrewarmCache(good, bad)
+	c.Assert(err, ErrorMatches, `(?s).*Bad\.qml.*bogus.*`)
+	c.Assert(qml.DiskCachePath(), Not(Equals), "")
+}
+
+func (s *S) TestPreload(c *C) {
+	dir := c.MkDir()
+	good := filepath.Join(dir, "Good.qml")
+	bad := filepath.Join(dir, "Bad.qml")
+	c.Assert(ioutil.WriteFile(good, []byte("import QtQuick 2.0\nItem { width: 42 }\n"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(bad, []byte("import QtQuick 2.0\nItem { bogus: 1 }\n"), 0644), IsNil)
+
+	err := s.engine.Preload(good, bad)
+	c.Assert(err, ErrorMatches, `(?s).*Bad\.qml.*bogus.*`)
+
+	// The file is gone, so it must come from the preloaded component.
+	c.Assert(os.Remove(good), IsNil)
+	component, err := s.engine.LoadFile(good)
+	c.Assert(err, IsNil)
+	c.Assert(component.Create(nil).Int("width"), Equals, 42)
+}
+
+func (s *S) TestPool(c *C) {
+	component, err := s.engine.LoadString("file.qml", "import QtQuick 2.0\nItem { width: 42 }")
+	c.Assert(err, IsNil)
+
+	pool := qml.NewPool(component, nil, 2)
+	defer pool.Close()
+
+	for i := 0; i < 100 && pool.Len() < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(pool.Len(), Equals, 2)
+
+	obj := pool.Get()
+	c.Assert(obj.Int("width"), Equals, 42)
+	obj.Destroy()
+
+	for i := 0; i < 100 && pool.Len() < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(pool.Len(), Equals, 2)
+}
+
+func (s *S) TestConversionFuzz(c *C) {
+	err := qmlfuzz.Run(s.engine, qmlfuzz.Config{Seed: 42, Iterations: 500})
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestLogCategory(c *C) {
+	category := qml.NewLogCategory("qmltest.category")
+	var texts []string
+	qml.HandleLogCategory("qmltest.*", func(msg qml.LogMessage) {
+		texts = append(texts, msg.Category()+": "+msg.Text())
+	})
+
+	category.Debugf("hello %d", 1)
+	qml.EnableLogCategory("qmltest.category.debug", false)
+	c.Assert(category.Enabled(qml.LogDebug), Equals, false)
+	category.Debugf("hidden")
+	category.Warningf("warned")
+	qml.EnableLogCategory("qmltest.category.debug", true)
+
+	c.Assert(texts, DeepEquals, []string{"qmltest.category: hello 1", "qmltest.category: warned"})
+}
+
+func (s *S) TestSandbox(c *C) {
+	allowed := c.MkDir()
+	other := c.MkDir()
+	outside := "file://" + filepath.ToSlash(filepath.Join(other, "Outside.qml"))
+	c.Assert(ioutil.WriteFile(filepath.Join(allowed, "Good.qml"), []byte("import QtQuick 2.0\nItem {}\n"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(other, "Outside.qml"), []byte("import QtQuick 2.0\nItem {}\n"), 0644), IsNil)
+
+	s.engine.SetSandbox(&qml.Sandbox{
+		DenyNetwork:        true,
+		AllowedDirs:        []string{allowed},
+		DenyXMLHttpRequest: true,
+	})
+
+	component, err := s.engine.LoadString(filepath.Join(allowed, "main.qml"), `
+		import QtQuick 2.0
+		Item {
+			id: root
+			property alias good: good.status
+			property alias outside: outside.status
+			property alias remote: remote.status
+			property var loader: Qt.createQmlObject('import QtQuick 2.0; Loader { source: "`+outside+`" }', root)
+			property int created: loader.status
+			property string fetched: "pending"
+			Loader { id: good; source: "Good.qml" }
+			Loader { id: outside; source: "`+outside+`" }
+			Image { id: remote; source: "http://127.0.0.1:1/image.png" }
+			Component.onCompleted: {
+				var xhr = new XMLHttpRequest()
+				xhr.onreadystatechange = function() {
+					if (xhr.readyState == XMLHttpRequest.DONE) {
+						fetched = xhr.responseText
+					}
+				}
+				xhr.open("GET", "Good.qml")
+				xhr.send()
+			}
+		}
+	`)
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	const loading, ready, failed = 2, 1, 3
+	for i := 0; i < 100 && (root.Int("outside") == loading || root.Int("created") == loading || root.Int("remote") == loading || root.String("fetched") == "pending"); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(root.Int("good"), Equals, ready)
+	c.Assert(root.Int("outside"), Equals, failed)
+	c.Assert(root.Int("created"), Equals, failed)
+	c.Assert(root.Int("remote"), Equals, failed)
+	c.Assert(root.String("fetched"), Equals, "")
+
+	s.engine.SetSandbox(nil)
+}
+
+func (s *S) TestContextSpawn(c *C) {
+	s.context.SetVar("parentVar", "parent")
+	child := s.context.Spawn()
+	defer child.Destroy()
+	child.SetVar("childVar", "child")
+
+	c.Assert(child.Var("parentVar"), Equals, "parent")
+	c.Assert(child.Var("childVar"), Equals, "child")
+	c.Assert(s.context.Var("childVar"), IsNil)
+
+	c.Assert(s.context.Destroy, PanicMatches, "cannot destroy the root context of an engine")
+}
+
+func (s *S) TestLintHookImports(c *C) {
+	dir := c.MkDir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "Red.qml"), []byte("import QtQuick 2.0\nRectangle { color: \"red\" }\n"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "Plain.qml"), []byte("import QtQuick 2.0\nItem {}\n"), 0644), IsNil)
+
+	var mu sync.Mutex
+	var linted, warnings []string
+	qml.HandleLogCategory("qml.lint", func(msg qml.LogMessage) {
+		mu.Lock()
+		warnings = append(warnings, msg.Text())
+		mu.Unlock()
+	})
+	s.engine.SetLintHook(func(file string, src []byte) []qml.Issue {
+		mu.Lock()
+		linted = append(linted, filepath.Base(file))
+		mu.Unlock()
+		if bytes.Contains(src, []byte("color:")) {
+			return []qml.Issue{{Line: 2, Column: 13, Message: "colors must come from Theme"}}
+		}
+		return nil
+	})
+	defer s.engine.SetLintHook(nil)
+
+	_, err := s.engine.LoadString(filepath.Join(dir, "plain.qml"), "import QtQuick 2.0\nItem { Plain {} }")
+	c.Assert(err, IsNil)
+	_, err = s.engine.LoadString(filepath.Join(dir, "red.qml"), "import QtQuick 2.0\nItem { Red {} }")
+	c.Assert(err, NotNil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	c.Assert(linted, DeepEquals, []string{"plain.qml", "Plain.qml", "red.qml", "Red.qml"})
+	c.Assert(warnings, DeepEquals, []string{filepath.Join(dir, "Red.qml") + ":2:13: colors must come from Theme"})
+}
+
+func (s *S) TestTypeInfoReleasedWithLastEngine(c *C) {
+	before := qml.Stats()
+	s.context.SetVar("released", &testReleased{Name: "value"})
+	during := qml.Stats()
+	c.Assert(during.TypesCached, Equals, before.TypesCached+1)
+
+	s.engine.Destroy()
+	for retries := 30; retries > 0 && qml.Stats().ValuesAlive > 0; retries-- {
+		runtime.GC()
+		time.Sleep(100 * time.Millisecond)
+	}
+	after := qml.Stats()
+	c.Assert(after.ValuesAlive, Equals, 0)
+	c.Assert(after.TypesCached, Equals, before.TypesCached)
+	c.Assert(after.CAllocations < during.CAllocations, Equals, true)
+}
+
+func (s *S) TestChangeCoalescingReleasedWithValue(c *C) {
+	value := &TestType{}
+	s.context.SetVar("value", value)
+	qml.SetChangeCoalescing(value, time.Hour)
+
+	s.engine.Destroy()
+	for retries := 30; retries > 0 && qml.Stats().ValuesAlive > 0; retries-- {
+		runtime.GC()
+		time.Sleep(100 * time.Millisecond)
+	}
+	c.Assert(qml.Stats().ValuesAlive, Equals, 0)
+
+	s.engine = qml.NewEngine()
+	s.context = s.engine.Context()
+	s.context.SetVar("value", value)
+	component, err := s.engine.LoadString("file.qml", "import QtQuick 2.0\nItem { property int n: value.intValue }")
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	for i := 1; i <= 3; i++ {
+		value.IntValue = i
+		qml.Changed(value, &value.IntValue)
+		c.Assert(root.Int("n"), Equals, i)
+	}
+}
+
+func (s *S) TestAudioStopReleasesStream(c *C) {
+	before := qml.AudioStreams()
+	format := qml.AudioFormat{SampleRate: 8000, ChannelCount: 1, SampleSize: 16}
+	output := qml.NewAudioOutput(format, bytes.NewReader(make([]byte, 16000)))
+	input := qml.NewAudioInput(format, ioutil.Discard)
+	c.Assert(qml.AudioStreams(), Equals, before+2)
+
+	output.Stop()
+	c.Assert(qml.AudioStreams(), Equals, before+1)
+	input.Stop()
+	c.Assert(qml.AudioStreams(), Equals, before)
+
+	output.Stop()
+	input.Stop()
+	c.Assert(qml.AudioStreams(), Equals, before)
+}
+
+type testRegisteredTwice struct {
+	Name string
+}
+
+func (*testRegisteredTwice) Greet(name string) string { return "Hello " + name }
+
+func (s *S) TestRegisterTypeTwiceStats(c *C) {
+	spec := func(name string) []qml.TypeSpec {
+		return []qml.TypeSpec{{Name: name, New: func() interface{} { return &testRegisteredTwice{} }}}
+	}
+	before := qml.Stats()
+	qml.RegisterTypes("GoTypesTwice", 1, 0, spec("First"))
+	first := qml.Stats()
+	c.Assert(first.TypesCached, Equals, before.TypesCached+1)
+
+	// The type information and the interned member names are shared.
+	qml.RegisterTypes("GoTypesTwice", 1, 0, spec("Second"))
+	second := qml.Stats()
+	c.Assert(second.TypesCached, Equals, first.TypesCached)
+	c.Assert(second.CAllocations, Equals, first.CAllocations)
+
+	component, err := s.engine.LoadString("file.qml", "import GoTypesTwice 1.0\nSecond { name: \"second\" }")
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+	c.Assert(root.String("name"), Equals, "second")
+	c.Assert(root.Call("greet", "world"), Equals, "Hello world")
+}
+
+type testCrasher struct{}
+
+func (*testCrasher) Crash() { panic("crash requested") }
+
+func (s *S) TestHandleCrashes(c *C) {
+	if dir := os.Getenv("QML_TEST_CRASH_DIR"); dir != "" {
+		// Running as the child process started below.
+		qml.HandleCrashes(dir, func(report *qml.CrashReport) {
+			fmt.Printf("crash report written to %s\n", report.File)
+		})
+		if os.Getenv("QML_TEST_CRASH_DISABLE") != "" {
+			qml.HandleCrashes("", nil)
+		}
+		s.context.SetVar("crasher", &testCrasher{})
+		component, err := s.engine.LoadString("file.qml", "import QtQuick 2.0\nItem { function crash() { crasher.crash() } }")
+		c.Assert(err, IsNil)
+		component.Create(nil).Call("crash")
+		c.Fatalf("application did not crash")
+	}
+
+	crashChild := func(dir string, disable bool) string {
+		cmd := exec.Command(os.Args[0], "-gocheck.f", "TestHandleCrashes$")
+		cmd.Env = append(os.Environ(), "QML_TEST_CRASH_DIR="+dir)
+		if disable {
+			cmd.Env = append(cmd.Env, "QML_TEST_CRASH_DISABLE=1")
+		}
+		out, err := cmd.CombinedOutput()
+		c.Assert(err, ErrorMatches, "exit status 2", Commentf("%s", out))
+		return string(out)
+	}
+
+	dir := c.MkDir()
+	out := crashChild(dir, false)
+	files, err := filepath.Glob(filepath.Join(dir, "crash-*.txt"))
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 1)
+	c.Assert(out, Matches, "(?s).*crash report written to "+regexp.QuoteMeta(files[0])+"\n.*")
+	data, err := ioutil.ReadFile(files[0])
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Matches, "(?s)Time: .*\nReason: panic: crash requested\n.*Go stack:.*testCrasher.*")
+
+	dir = c.MkDir()
+	out = crashChild(dir, true)
+	files, err = filepath.Glob(filepath.Join(dir, "*"))
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 0)
+	c.Assert(out, Matches, "(?s).*panic: crash requested.*")
+	c.Assert(strings.Contains(out, "crash report written"), Equals, false)
+}
+
 func (s *S) TestComponentSetDataError(c *C) {
 	_, err := s.engine.LoadString("file.qml", "Item{}")
 	c.Assert(err, ErrorMatches, "file:.*/file.qml:1 Item is not a type")
@@ -885,23 +1590,1790 @@ var tests = []struct {
 		QML:     `Item{}`,
 		Done:    func(d *TestData) { d.Assert(d.root.TypeName(), Equals, "QQuickItem") },
 	},
-}
-
-var tablef = flag.String("tablef", "", "if provided, TestTable only runs tests with a summary matching the regexp")
-
-func (s *S) TestTable(c *C) {
-	var goTypeValue *TestType = &TestType{}
-
-	types := []qml.TypeSpec{{
-		Name: "GoType",
-		New:  func() interface{} { return goTypeValue },
-	}, {
-		Name:      "GoSingleton",
-		New:       func() interface{} { return goTypeValue },
-		Singleton: true,
-	}}
-
-	qml.RegisterTypes("GoTypes", 4, 2, types)
+	{
+		Summary: "Control a media player",
+		QML:     `import QtMultimedia 5.0; MediaPlayer { volume: 0.5 }`,
+		Done: func(d *TestData) {
+			player := qml.NewMediaPlayer(d.root)
+			d.Check(player.Volume(), Equals, 0.5)
+			player.SetVolume(0.25)
+			d.Check(player.Volume(), Equals, 0.25)
+			d.Check(player.State(), Equals, qml.MediaStopped)
+			d.Check(player.Position(), Equals, time.Duration(0))
+		},
+	},
+	{
+		Summary: "Video probing requires a media source",
+		QML:     `Item {}`,
+		Done: func(d *TestData) {
+			probe, err := qml.NewVideoProbe(d.root, func(image.Image) {})
+			d.Check(probe, IsNil)
+			d.Check(err, ErrorMatches, "source does not support video frame probing")
+		},
+	},
+	{
+		Summary: "Bulk update a plotting series",
+		QML: `
+			Item {
+				property var points: []
+				function append(x, y) { points.push(x + ":" + y) }
+				function clear() { points = [] }
+				function dump() { console.log("Points:", points.join(" ")) }
+			}
+		`,
+		Done: func(d *TestData) {
+			series := qml.NewSeries(d.root)
+			series.Append([]float64{1, 2}, []float64{10, 20})
+			series.AppendFloat32([]float32{3}, []float32{30})
+			d.root.Call("dump")
+			series.Replace([]float64{4}, []float64{40})
+			d.root.Call("dump")
+			d.Check(func() { series.Append([]float64{1}, nil) }, Panics, "series coordinate slices have different lengths")
+		},
+		DoneLog: "Points: 1:10 2:20 3:30.*Points: 4:40",
+	},
+	{
+		Summary: "Set and read grouped and attached properties via dotted paths",
+		QML:     `Item { Rectangle { id: r; objectName: "r"; border.width: 2 } }`,
+		Done: func(d *TestData) {
+			rect := d.root.ObjectByName("r")
+			d.Check(rect.Int("border.width"), Equals, 2)
+			d.Check(rect.Set("border.width", 3), IsNil)
+			d.Check(rect.Int("border.width"), Equals, 3)
+			d.Check(rect.Set("border.missing", 3), ErrorMatches, `object does not have a "border.missing" property`)
+			d.Check(func() { rect.Property("border.missing") }, Panics, `object does not have a "border.missing" property`)
+		},
+	},
+	{
+		Summary: "Set accessibility information on an item",
+		QML:     `Item {}`,
+		Done: func(d *TestData) {
+			err := qml.SetAccessible(d.root, qml.AccessibleButton, "<name>", "<description>")
+			d.Assert(err, IsNil)
+			d.Check(d.root.String("Accessible.name"), Equals, "<name>")
+			d.Check(d.root.String("Accessible.description"), Equals, "<description>")
+			d.Check(d.root.Int("Accessible.role"), Equals, int(qml.AccessibleButton))
+		},
+	},
+	{
+		Summary: "Format and parse numbers with a locale",
+		QML:     `Item {}`,
+		Done: func(d *TestData) {
+			locale := qml.NewLocale("de_DE")
+			d.Check(locale.Name(), Equals, "de_DE")
+			d.Check(locale.FormatFloat(1234.5, 'f', 1), Equals, "1.234,5")
+			d.Check(locale.FormatInt(1234567), Equals, "1.234.567")
+			f, err := locale.ParseFloat("1.234,5")
+			d.Check(err, IsNil)
+			d.Check(f, Equals, 1234.5)
+			_, err = locale.ParseInt("foo")
+			d.Check(err, ErrorMatches, `cannot parse "foo" as an integer in locale de_DE`)
+		},
+	},
+	{
+		Summary: "Watch files and directories for changes",
+		QML:     `Item {}`,
+		Done: func(d *TestData) {
+			dir := d.MkDir()
+			watcher, err := qml.NewWatcher(dir)
+			d.Assert(err, IsNil)
+			defer watcher.Close()
+			d.Check(watcher.Paths(), DeepEquals, []string{dir})
+			err = watcher.Add(dir + "/missing")
+			d.Check(err, ErrorMatches, "cannot watch .*/missing")
+			watcher.Remove(dir)
+			d.Check(watcher.Paths(), IsNil)
+		},
+	},
+	{
+		Summary: "Edit the document of a TextEdit",
+		QML:     `TextEdit { text: "Hello\nworld" }`,
+		Done: func(d *TestData) {
+			doc, err := qml.NewTextDocument(d.root)
+			d.Assert(err, IsNil)
+			d.Check(doc.BlockCount(), Equals, 2)
+			text, pos := doc.Block(1)
+			d.Check(text, Equals, "world")
+			d.Check(pos, Equals, 6)
+
+			var changes []int
+			doc.OnChanged(func(position, removed, added int) {
+				changes = append(changes, position, removed, added)
+			})
+			doc.Replace(0, 5, "Goodbye")
+			d.Check(d.root.String("text"), Equals, "Goodbye\nworld")
+			d.Check(changes, DeepEquals, []int{0, 5, 7})
+
+			cursor := doc.Cursor()
+			defer cursor.Close()
+			cursor.Select(8, 13)
+			d.Check(cursor.SelectedText(), Equals, "world")
+			cursor.MergeFormat(qml.TextFormat{Bold: true})
+			d.Check(doc.Len(), Equals, 13)
+		},
+	},
+	{
+		Summary: "Highlight a text document block by block",
+		QML:     `TextEdit { text: "func main() {\n}" }`,
+		Done: func(d *TestData) {
+			doc, err := qml.NewTextDocument(d.root)
+			d.Assert(err, IsNil)
+			h := &testHighlighter{}
+			doc.SetHighlighter(h)
+			d.Check(h.blocks, DeepEquals, []string{"func main() {", "}"})
+			h.blocks = nil
+			doc.Replace(5, 4, "init")
+			d.Check(h.blocks, DeepEquals, []string{"func init() {"})
+			doc.SetHighlighter(nil)
+		},
+	},
+	{
+		Summary: "List the connected screens",
+		QML:     `Item {}`,
+		Done: func(d *TestData) {
+			screens := qml.Screens()
+			d.Assert(len(screens) > 0, Equals, true)
+			primary := 0
+			for i, screen := range screens {
+				d.Check(screen.Index, Equals, i)
+				d.Check(screen.Geometry.Empty(), Equals, false)
+				if screen.Primary {
+					primary++
+				}
+			}
+			d.Check(primary, Equals, 1)
+		},
+	},
+	{
+		Summary: "Touch handling requires a visual item",
+		QML:     `QtObject { property var item: Item {} }`,
+		Done: func(d *TestData) {
+			err := qml.HandleTouch(d.root, qml.TouchHandler{})
+			d.Check(err, ErrorMatches, "object is not a visual item")
+			err = qml.HandleTouch(d.root.Object("item"), qml.TouchHandler{})
+			d.Check(err, IsNil)
+		},
+	},
+	{
+		Summary: "Hand a Go-created object back to QML preserving its identity",
+		QML: `
+			Item {
+				property var comp: Component { Item { objectName: "made" } }
+				function check() {
+					var a = value.objectMethod()
+					var b = value.objectMethod()
+					gc()
+					return a === b && a.objectName === "made"
+				}
+			}
+		`,
+		Done: func(d *TestData) {
+			obj := d.root.Object("comp").Create(nil)
+			defer obj.Destroy()
+			d.value.ObjectValue = obj
+			d.Check(d.root.Call("check"), Equals, true)
+			qml.Flush()
+			d.Check(obj.String("objectName"), Equals, "made")
+		},
+	},
+	{
+		Summary: "Create objects with a Go factory",
+		QML: `
+			import GoTypes 4.2
+			Factory {
+				Component.onCompleted: {
+					var obj = create("GoType", {stringValue: "<content>"})
+					console.log("String is", obj.stringValue)
+					console.log("Missing is", create("Missing"), error)
+				}
+			}
+		`,
+		QMLLog: "String is <content>.*Missing is null unknown type Missing",
+	},
+	{
+		Summary: "Return a promise from a Go method",
+		QML: `
+			Item {
+				property string result
+				property string failure
+				function run() {
+					value.promiseMethod("ab").then(function(v) { result = v })
+					value.promiseMethod("").catch(function(e) { failure = e.message })
+				}
+			}
+		`,
+		Done: func(d *TestData) {
+			d.root.Call("run")
+			qml.Flush()
+			d.Check(d.root.String("result"), Equals, "abab")
+			d.Check(d.root.String("failure"), Equals, "<empty>")
+		},
+	},
+	{
+		Summary: "Pass a JavaScript function to a Go method",
+		QML:     `Item { Component.onCompleted: console.log("Result is", value.funcMethod("ab", function(s, n) { return s + n })) }`,
+		QMLLog:  "Result is ab42",
+	},
+	{
+		Summary: "Stream values from a Go channel",
+		QML: `
+			Item {
+				property string result
+				property bool finished
+				function run() {
+					var stream = value.streamMethod(3)
+					stream.element.connect(function(v) { result += v })
+					stream.finished.connect(function() { finished = true })
+				}
+			}
+		`,
+		Done: func(d *TestData) {
+			d.root.Call("run")
+			for i := 0; i < 100 && !d.root.Bool("finished"); i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			d.Check(d.root.String("result"), Equals, "012")
+			d.Check(d.root.Bool("finished"), Equals, true)
+		},
+	},
+	{
+		Summary: "Cancel the context of a Go method call",
+		QML: `
+			Item {
+				property string failure
+				property bool cancelled
+				function run() {
+					var op = value.contextMethod("ab")
+					op.result.catch(function(e) { failure = e.message })
+					op.cancel()
+					cancelled = op.cancelled
+				}
+			}
+		`,
+		Done: func(d *TestData) {
+			d.root.Call("run")
+			for i := 0; i < 100 && d.root.String("failure") == ""; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			d.Check(d.root.String("failure"), Equals, "ab: context canceled")
+			d.Check(d.root.Bool("cancelled"), Equals, true)
+		},
+	},
+	{
+		Summary: "Run Go methods in a worker",
+		Init: func(d *TestData) {
+			w := &testWorker{}
+			qml.MoveToWorker(w)
+			d.context.SetVar("worker", w)
+		},
+		QML: `
+			Item {
+				property string result
+				function run() {
+					worker.double(21).then(function(n) { result += n })
+					worker.double(2).then(function(n) { result += "," + n })
+				}
+			}
+		`,
+		Done: func(d *TestData) {
+			d.root.Call("run")
+			for i := 0; i < 100 && d.root.String("result") != "42,4"; i++ {
+				time.Sleep(10 * time.Millisecond)
+				qml.Flush()
+			}
+			d.Check(d.root.String("result"), Equals, "42,4")
+		},
+	},
+	{
+		Summary: "Report progress to QML",
+		Init: func(d *TestData) {
+			d.context.SetVar("progress", qml.NewProgress())
+		},
+		QML: `
+			Item {
+				property real fraction: progress.fraction
+				property string message: progress.message
+				function cancel() { progress.cancel() }
+			}
+		`,
+		Done: func(d *TestData) {
+			progress := d.context.Var("progress").(*qml.Progress)
+			progress.Set(1, 4)
+			progress.SetMessage("<working>")
+			d.Check(d.root.Float64("fraction"), Equals, 0.25)
+			d.Check(d.root.String("message"), Equals, "<working>")
+			d.Check(progress.Context().Err(), IsNil)
+			d.root.Call("cancel")
+			d.Check(progress.Cancelled, Equals, true)
+			d.Check(progress.Context().Err(), NotNil)
+		},
+	},
+	{
+		Summary: "Route network requests through a Go HTTP client",
+		Init: func(d *TestData) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				fmt.Fprintf(w, "%s %s", req.Method, req.Header.Get("X-Transport"))
+			}))
+			d.context.SetVar("serverURL", server.URL+"/")
+			d.engine.SetHTTPClient(server.URL+"/", &http.Client{Transport: testTransport{}})
+		},
+		QML: `
+			Item {
+				property string result
+				function run() {
+					var req = new XMLHttpRequest()
+					req.onreadystatechange = function() {
+						if (req.readyState == XMLHttpRequest.DONE) { result = req.status + " " + req.responseText }
+					}
+					req.open("GET", serverURL + "path")
+					req.send()
+				}
+			}
+		`,
+		Done: func(d *TestData) {
+			d.root.Call("run")
+			for i := 0; i < 100 && d.root.String("result") == ""; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			d.Check(d.root.String("result"), Equals, "200 GET <go>")
+		},
+	},
+	{
+		Summary: "Exchange messages with a GoSocket",
+		QML: `
+			import GoTypes 4.2
+			GoSocket {
+				property string received
+				url: "echo:"
+				onMessageChanged: received = message
+			}
+		`,
+		Done: func(d *TestData) {
+			d.root.Call("connect")
+			for i := 0; i < 100 && d.root.Int("status") != int(qml.SocketOpen); i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			d.Assert(d.root.Int("status"), Equals, int(qml.SocketOpen))
+			d.Check(d.root.Call("send", "hi"), Equals, true)
+			for i := 0; i < 100 && d.root.String("received") == ""; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			d.Check(d.root.String("received"), Equals, "echo: hi")
+			d.root.Call("close")
+			d.Check(d.root.Int("status"), Equals, int(qml.SocketClosed))
+		},
+	},
+	{
+		Summary: "Application state singleton",
+		QML: `
+			import GoTypes 4.2
+			Item { Component.onCompleted: console.log("State:", AppState.screenCount > 0, typeof AppState.active, typeof AppState.clipboardText) }
+		`,
+		QMLLog: "State: true boolean string",
+	},
+	{
+		Summary: "Bind a property to a Go function",
+		QML:     `Item { width: 100 }`,
+		Done: func(d *TestData) {
+			factor := 2.0
+			binding, err := qml.Bind(d.root, "height", func() float64 {
+				return d.root.Float64("width") / factor
+			}, qml.Dependency{d.root, "width"})
+			d.Assert(err, IsNil)
+			d.Check(d.root.Float64("height"), Equals, 50.0)
+			d.root.Set("width", 300)
+			d.Check(d.root.Float64("height"), Equals, 150.0)
+			factor = 3
+			binding.Update()
+			d.Check(d.root.Float64("height"), Equals, 100.0)
+
+			_, err = qml.Bind(d.root, "missing", func() int { return 0 })
+			d.Check(err, ErrorMatches, `object does not have a writable "missing" property`)
+		},
+	},
+	{
+		Summary: "Animate a property from Go",
+		QML:     `Item { opacity: 1 }`,
+		Done: func(d *TestData) {
+			anim, err := qml.Animate(d.root, "opacity", nil, 0, 50*time.Millisecond, qml.OutQuad)
+			d.Assert(err, IsNil)
+			done := make(chan bool, 1)
+			anim.OnFinished(func() { done <- true })
+			<-done
+			d.Check(d.root.Float64("opacity"), Equals, 0.0)
+
+			_, err = qml.Animate(d.root, "missing", nil, 0, time.Second, qml.Linear)
+			d.Check(err, ErrorMatches, `object does not have a "missing" property`)
+		},
+	},
+	{
+		Summary: "Drive QML states with a state machine",
+		QML: `
+			Item {
+				state: "closed"
+				states: [State { name: "closed" }, State { name: "opened" }]
+			}
+		`,
+		Done: func(d *TestData) {
+			machine := qml.NewStateMachine(d.root)
+			machine.AddTransition("closed", "open", "opened")
+			machine.AddTransition(qml.AnyState, "close", "closed")
+			var changes []string
+			machine.OnStateChanged(func(from, to string) { changes = append(changes, from+">"+to) })
+
+			d.Check(machine.Fire("open"), IsNil)
+			d.Check(d.root.String("state"), Equals, "opened")
+			d.Check(machine.Fire("open"), ErrorMatches, `no transition for event "open" in state "opened"`)
+			d.Check(machine.Fire("close"), IsNil)
+			d.root.Set("state", "opened")
+			d.Check(machine.State(), Equals, "opened")
+			d.Check(changes, DeepEquals, []string{"closed>opened", "opened>closed", "closed>opened"})
+		},
+	},
+	{
+		Summary: "Map item coordinates",
+		QML:     `Rectangle { width: 300; height: 200; Item { objectName: "child"; x: 10; y: 20 } }`,
+		Done: func(d *TestData) {
+			win := d.component.CreateWindow(nil)
+			root := win.Root()
+			child := root.ObjectByName("child").Common()
+			x, y := child.MapToItem(root, 1, 2)
+			d.Check([]float64{x, y}, DeepEquals, []float64{11, 22})
+			x, y = child.MapToItem(win.ContentItem(), 1, 2)
+			d.Check([]float64{x, y}, DeepEquals, []float64{11, 22})
+			x, y = root.Common().MapToItem(nil, 5, 5)
+			d.Check([]float64{x, y}, DeepEquals, []float64{5, 5})
+			d.Check(func() { win.Common.MapToGlobal(0, 0) }, Panics, "object is not a visual item")
+			root.Destroy()
+		},
+	},
+	{
+		Summary: "Find the item under a window point",
+		QML: `
+			Rectangle {
+				width: 300; height: 200
+				Rectangle { objectName: "a"; x: 10; y: 10; width: 100; height: 100; z: 1 }
+				Rectangle {
+					objectName: "b"; x: 50; y: 50; width: 100; height: 100
+					Item { objectName: "c"; width: 10; height: 10 }
+				}
+			}
+		`,
+		Done: func(d *TestData) {
+			win := d.component.CreateWindow(nil)
+			name := func(obj qml.Object) string {
+				if obj == nil {
+					return ""
+				}
+				return obj.String("objectName")
+			}
+			d.Check(name(win.ItemAt(60, 60, nil)), Equals, "a")
+			d.Check(name(win.ItemAt(55, 55, func(item qml.Object) bool { return name(item) != "a" })), Equals, "c")
+			d.Check(name(win.ItemAt(140, 140, nil)), Equals, "b")
+			d.Check(name(win.ItemAt(400, 400, nil)), Equals, "")
+			win.Root().Destroy()
+		},
+	},
+	{
+		Summary: "Open popups from Go",
+		QML: `
+			import QtQuick.Controls 2.0
+			Rectangle { width: 300; height: 200; Popup { objectName: "popup" } }
+		`,
+		Done: func(d *TestData) {
+			win := d.component.CreateWindow(nil)
+			root := win.Root()
+			popup := qml.NewPopup(root.ObjectByName("popup"))
+			closed := false
+			popup.OnClosed(func() { closed = true })
+			popup.OpenAt(root, 10, 20)
+			d.Check(popup.Opened(), Equals, true)
+			d.Check(popup.Float64("x"), Equals, 10.0)
+			popup.Close()
+			for i := 0; i < 100 && !closed; i++ {
+				time.Sleep(10 * time.Millisecond)
+				qml.Flush()
+			}
+			d.Check(closed, Equals, true)
+
+			overlay, err := win.Overlay()
+			d.Assert(err, IsNil)
+			d.Check(overlay.TypeName(), Equals, "QQuickOverlay")
+			root.Destroy()
+		},
+	},
+	{
+		Summary: "Move the focus from Go",
+		QML: `
+			Rectangle {
+				width: 300; height: 200
+				TextInput { objectName: "first"; activeFocusOnTab: true }
+				TextInput { objectName: "second"; activeFocusOnTab: true }
+			}
+		`,
+		Done: func(d *TestData) {
+			win := d.component.CreateWindow(nil)
+			win.Show()
+			root := win.Root()
+			first := root.ObjectByName("first").Common()
+			second := root.ObjectByName("second").Common()
+			var changes []string
+			win.OnActiveFocusItemChanged(func(item qml.Object) {
+				if item != nil {
+					changes = append(changes, item.String("objectName"))
+				}
+			})
+			first.ForceActiveFocus()
+			d.Check(first.HasActiveFocus(), Equals, true)
+			next := first.NextItemInFocusChain(true)
+			d.Check(next.String("objectName"), Equals, "second")
+			next.Common().ForceActiveFocus()
+			d.Check(win.ActiveFocusItem().String("objectName"), Equals, "second")
+			d.Check(second.HasActiveFocus(), Equals, true)
+			d.Check(changes, DeepEquals, []string{"first", "second"})
+			win.Hide()
+			root.Destroy()
+		},
+	},
+	{
+		Summary: "List and remap shortcuts",
+		QML: `
+			Item {
+				Shortcut { objectName: "save"; sequence: "Ctrl+S" }
+				Item { Shortcut { objectName: "quit"; sequence: "Ctrl+Q" } }
+			}
+		`,
+		Done: func(d *TestData) {
+			d.Check(qml.ShortcutMap(d.root), DeepEquals, map[string]string{"save": "Ctrl+S", "quit": "Ctrl+Q"})
+			err := qml.RemapShortcuts(d.root, map[string]string{"save": "Ctrl+Shift+S", "missing": "F1"})
+			d.Check(err, ErrorMatches, "cannot find shortcuts: missing")
+			shortcuts := qml.Shortcuts(d.root)
+			d.Assert(shortcuts, HasLen, 2)
+			d.Check(shortcuts[0].Name, Equals, "save")
+			d.Check(shortcuts[0].Sequence, Equals, "Ctrl+Shift+S")
+		},
+	},
+	{
+		Summary: "Undo and redo commands",
+		Init: func(d *TestData) {
+			d.context.SetVar("undoStack", &qml.UndoStack{})
+		},
+		QML: `
+			Item {
+				property bool canUndo: undoStack.canUndo
+				property string undoText: undoStack.undoText
+				function undo() { undoStack.undo() }
+			}
+		`,
+		Done: func(d *TestData) {
+			stack := d.context.Var("undoStack").(*qml.UndoStack)
+			n := 0
+			add := func(delta int) {
+				stack.Push("Add", func() { n += delta }, func() { n -= delta })
+			}
+			add(1)
+			stack.BeginMacro("Add twice")
+			add(10)
+			add(100)
+			stack.EndMacro()
+			d.Check(n, Equals, 111)
+			d.Check(d.root.Bool("canUndo"), Equals, true)
+			d.Check(d.root.String("undoText"), Equals, "Add twice")
+			d.Check(stack.Modified, Equals, true)
+
+			d.root.Call("undo")
+			d.Check(n, Equals, 1)
+			d.Check(stack.CanRedo, Equals, true)
+			stack.Undo()
+			d.Check(n, Equals, 0)
+			d.Check(d.root.Bool("canUndo"), Equals, false)
+			d.Check(stack.Modified, Equals, false)
+			stack.Redo()
+			d.Check(n, Equals, 1)
+			add(5)
+			d.Check(n, Equals, 6)
+			d.Check(stack.CanRedo, Equals, false)
+		},
+	},
+	{
+		Summary: "Save and restore the session",
+		QML:     `Rectangle { objectName: "main"; width: 300; height: 200 }`,
+		Done: func(d *TestData) {
+			dir := d.MkDir()
+			path := dir + "/session.json"
+			session, err := qml.OpenSession(path)
+			d.Assert(err, IsNil)
+			win := d.component.CreateWindow(nil)
+			d.Assert(session.Track(win), IsNil)
+			win.Set("width", 123)
+			session.AddState("extra", func() []byte { return []byte("<state>") }, nil)
+			d.Assert(session.Save(), IsNil)
+			win.Root().Destroy()
+
+			session, err = qml.OpenSession(path)
+			d.Assert(err, IsNil)
+			var restored []byte
+			session.AddState("extra", nil, func(state []byte) { restored = state })
+			d.Check(string(restored), Equals, "<state>")
+			win = d.component.CreateWindow(nil)
+			d.Assert(session.Track(win), IsNil)
+			d.Check(win.Int("width"), Equals, 123)
+			win.Root().Destroy()
+		},
+	},
+	{
+		Summary: "Manage recent files",
+		Init: func(d *TestData) {
+			d.context.SetVar("recentFiles", &qml.RecentFiles{Max: 2})
+		},
+		QML: `
+			Item {
+				property int count: recentFiles.count
+				property string first: count > 0 ? recentFiles.name(0) : ""
+			}
+		`,
+		Done: func(d *TestData) {
+			recent := d.context.Var("recentFiles").(*qml.RecentFiles)
+			recent.SetFiles([]string{"/a/one.txt", "/a/two.txt"})
+			d.Check(d.root.Int("count"), Equals, 2)
+			d.Check(d.root.String("first"), Equals, "one.txt")
+			recent.SetFiles([]string{"/a/three.txt", "/a/one.txt", "/a/two.txt"})
+			d.Check(recent.Files(), DeepEquals, []string{"/a/three.txt", "/a/one.txt"})
+			d.Check(d.root.String("first"), Equals, "three.txt")
+			recent.Remove("/a/three.txt")
+			d.Check(recent.File(0), Equals, "/a/one.txt")
+			d.Check(d.root.Int("count"), Equals, 1)
+		},
+	},
+	{
+		Summary: "Count objects created from components in statistics",
+		QML:     `Item {}`,
+		Done: func(d *TestData) {
+			stats := qml.Stats()
+			d.Check(stats.ComponentsAlive > 0, Equals, true)
+			d.Check(stats.TypesCached > 0, Equals, true)
+			d.Check(stats.CAllocations > 0, Equals, true)
+
+			obj := d.component.Create(nil)
+			d.Check(qml.Stats().ObjectsAlive, Equals, stats.ObjectsAlive+1)
+			obj.Destroy()
+			for i := 0; i < 100 && qml.Stats().ObjectsAlive != stats.ObjectsAlive; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			d.Check(qml.Stats().ObjectsAlive, Equals, stats.ObjectsAlive)
+		},
+	},
+	{
+		Summary: "Collect JavaScript garbage",
+		QML: `
+			Item {
+				property var values: []
+				function fill() { for (var i = 0; i < 1000; i++) values.push({n: i}) }
+			}
+		`,
+		Done: func(d *TestData) {
+			d.root.Call("fill")
+			stop := d.engine.CollectGarbageWhenIdle(time.Hour)
+			defer stop()
+			d.engine.CollectGarbage()
+			if size, ok := d.engine.JSHeapSize(); ok {
+				d.Check(size > 0, Equals, true)
+			}
+		},
+	},
+	{
+		Summary: "Assign large slices to QML in bulk",
+		QML: `
+			Item {
+				property var values
+				function sum() { var s = 0; for (var i = 0; i < values.length; i++) s += values[i]; return s }
+			}
+		`,
+		Done: func(d *TestData) {
+			values := make([]float64, 100000)
+			for i := range values {
+				values[i] = 0.5
+			}
+			d.root.Set("values", values)
+			d.Check(fmt.Sprint(d.root.Call("sum")), Equals, "50000")
+
+			d.root.Set("values", []int{1, 2, 3})
+			d.Check(fmt.Sprint(d.root.Call("sum")), Equals, "6")
+
+			d.root.Set("values", []string{"a", "", "bc", "ü"})
+			d.Check(d.root.Call("sum"), Equals, "0abcü")
+
+			d.root.Set("values", []bool{})
+			d.Check(fmt.Sprint(d.root.Call("sum")), Equals, "0")
+		},
+	},
+	{
+		Summary: "Access fields and methods via static shims",
+		QML: `
+			Item {
+				property string text: value.text
+				function update() { value.text = "<after>"; return value.upper() }
+			}
+		`,
+		Init: func(d *TestData) {
+			d.context.SetVar("value", &testShimmed{Text: "<before>"})
+		},
+		Done: func(d *TestData) {
+			value := d.context.Var("value").(*testShimmed)
+			d.Check(d.root.String("text"), Equals, "<before>")
+
+			var buf bytes.Buffer
+			recorder := qml.StartRecording(&buf)
+			recorder.Name(value, "value")
+			d.Check(d.root.Call("update"), Equals, "<AFTER>")
+			d.Assert(recorder.Stop(), IsNil)
+
+			d.Check(value.Text, Equals, "<after>")
+			// Reads, writes, and calls all went through the shim.
+			d.Check(value.shims >= 3, Equals, true)
+			// Interactions via the shim are recorded as well.
+			d.Check(buf.String(), Equals, `{"value":"value","set":"Text","assign":"\u003cafter\u003e"}`+"\n"+`{"value":"value","call":"Upper"}`+"\n")
+		},
+	},
+	{
+		Summary: "Convert values per the engine conversion policy",
+		QML: `
+			Item {
+				property var value
+				function describe() { return JSON.stringify(value) }
+			}
+		`,
+		Done: func(d *TestData) {
+			d.engine.SetConversionPolicy(qml.JSONValues)
+			d.root.Set("value", map[string]interface{}{"a": []int{1, 2}})
+			d.Check(d.root.Call("describe"), Equals, `{"a":[1,2]}`)
+
+			d.engine.SetConversionPolicy(qml.StringValues)
+			d.root.Set("value", 1500*time.Millisecond)
+			d.Check(d.root.Call("describe"), Equals, `"1.5s"`)
+		},
+	},
+	{
+		Summary: "Hand text marshalers to QML as strings",
+		QML: `
+			Item {
+				property string addr: value.addr
+				function update() { value.addr = "10.0.0.1"; return value.isLoopback("127.0.0.1") }
+			}
+		`,
+		Init: func(d *TestData) {
+			d.context.SetVar("value", &testTextValue{Addr: net.ParseIP("192.168.0.1")})
+		},
+		Done: func(d *TestData) {
+			value := d.context.Var("value").(*testTextValue)
+			d.Check(d.root.String("addr"), Equals, "192.168.0.1")
+			d.Check(d.root.Call("update"), Equals, true)
+			d.Check(value.Addr.String(), Equals, "10.0.0.1")
+		},
+	},
+	{
+		Summary: "Hand nullable database values to QML",
+		QML: `
+			Item {
+				property var name: value.name
+				property var age: value.age
+				function update() { value.name = "Alice"; value.age = null }
+			}
+		`,
+		Init: func(d *TestData) {
+			d.context.SetVar("value", &testNullable{Age: sql.NullInt64{Int64: 42, Valid: true}})
+		},
+		Done: func(d *TestData) {
+			value := d.context.Var("value").(*testNullable)
+			d.Check(d.root.Property("name"), IsNil)
+			d.Check(d.root.Property("age"), Equals, int64(42))
+			d.root.Call("update")
+			d.Check(value.Name, Equals, sql.NullString{String: "Alice", Valid: true})
+			d.Check(value.Age.Valid, Equals, false)
+		},
+	},
+	{
+		Summary: "Hand decimal values to QML as locale-formatted strings",
+		QML: `
+			Item {
+				function amount() { return value.amount }
+				function total() { return value.total }
+				function update() { value.amount = "2,000.25"; value.total = 3.5 }
+			}
+		`,
+		Init: func(d *TestData) {
+			d.context.SetVar("value", &testPrice{Amount: 123456789, Total: 150})
+		},
+		Done: func(d *TestData) {
+			value := d.context.Var("value").(*testPrice)
+			defer qml.SetDefaultLocale(qml.DefaultLocale().Name())
+			qml.SetDefaultLocale("en_US")
+
+			d.Check(d.root.Call("amount"), Equals, "1,234,567.89")
+			d.Check(d.root.Call("total"), Equals, "$1.5")
+			d.root.Call("update")
+			d.Check(value.Amount, Equals, testDecimal(200025))
+			d.Check(value.Total, Equals, testDecimal(350))
+
+			digits, err := qml.NewLocale("de_DE").ParseDecimal("-1.234,50 €")
+			d.Check(err, IsNil)
+			d.Check(digits, Equals, "-1234.50")
+			d.Check(qml.NewLocale("de_DE").FormatDecimal(digits), Equals, "-1.234,50")
+		},
+	},
+	{
+		Summary: "Load image from asynchronous Go provider",
+		Init: func(d *TestData) {
+			d.engine.AddAsyncImageProvider("async", func(ctx context.Context, id string, width, height int) (image.Image, error) {
+				if id == "missing" {
+					return nil, fmt.Errorf("no such image")
+				}
+				return image.NewRGBA(image.Rect(0, 0, 200, 100)), nil
+			})
+		},
+		QML: `
+			Item {
+				Image {
+					source: "image://async/myid.png"
+					onStatusChanged: if (status == Image.Ready) console.log("Size:", width, height)
+				}
+				Image {
+					source: "image://async/missing"
+					onStatusChanged: if (status == Image.Error) console.log("Error")
+				}
+			}
+		`,
+		Done: func(d *TestData) {
+			// The images load in the background, after the QML is created.
+			loaded := func() bool {
+				log := d.GetTestLog()
+				return strings.Contains(log, "Size:") && strings.Contains(log, "Error")
+			}
+			for i := 0; i < 100 && !loaded(); i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			d.Check(d.GetTestLog(), Matches, "(?s).*Size: 200 100.*")
+			d.Check(d.GetTestLog(), Matches, "(?s).*Error.*")
+		},
+	},
+	{
+		Summary: "Load thumbnails of image files",
+		Init: func(d *TestData) {
+			dir := d.MkDir()
+			f, err := os.Create(filepath.Join(dir, "photo.png"))
+			d.Assert(err, IsNil)
+			d.Assert(png.Encode(f, image.NewRGBA(image.Rect(0, 0, 400, 200))), IsNil)
+			f.Close()
+			qml.RegisterThumbnailer(".fake", func(ctx context.Context, path string, width, height int) (image.Image, error) {
+				return image.NewRGBA(image.Rect(0, 0, width, height)), nil
+			})
+			d.engine.AddThumbnailProvider(qml.NewImageCache(1 << 20))
+			d.context.SetVar("dir", filepath.ToSlash(dir))
+		},
+		QML: `
+			Item {
+				Image {
+					source: "image://thumb/" + dir + "/photo.png?w=100&h=100"
+					onStatusChanged: if (status == Image.Ready) console.log("Photo:", implicitWidth, implicitHeight)
+				}
+				Image {
+					source: "image://thumb/" + dir + "/doc.FAKE?w=30&h=40"
+					onStatusChanged: if (status == Image.Ready) console.log("Custom:", implicitWidth, implicitHeight)
+				}
+			}
+		`,
+		Done: func(d *TestData) {
+			loaded := func() bool {
+				log := d.GetTestLog()
+				return strings.Contains(log, "Photo:") && strings.Contains(log, "Custom:")
+			}
+			for i := 0; i < 100 && !loaded(); i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			d.Check(d.GetTestLog(), Matches, "(?s).*Photo: 100 50.*")
+			d.Check(d.GetTestLog(), Matches, "(?s).*Custom: 30 40.*")
+		},
+	},
+	{
+		Summary: "Compute colors in Go as QML does and expose them as a palette",
+		QML: `
+			Item {
+				property color darker: Qt.darker("#336699", 1.5)
+				property color lighter: Qt.lighter("#336699", 1.2)
+				property color alpha: Qt.alpha("#336699", 0.5)
+				property color hsla: Qt.hsla(0.3, 0.6, 0.4, 0.8)
+				property color tint: Qt.tint("#336699", "#80ff0000")
+				property color accent: palette.accent
+			}
+		`,
+		Init: func(d *TestData) {
+			testPalette = qml.NewPalette(d.engine)
+			testPalette.SetColor("accent", color.RGBA{0x33, 0x66, 0x99, 0xff})
+			d.context.SetVar("palette", testPalette)
+		},
+		Done: func(d *TestData) {
+			base := color.RGBA{0x33, 0x66, 0x99, 0xff}
+			d.Check(qml.Darker(base, 1.5), Equals, d.root.Color("darker"))
+			d.Check(qml.Lighter(base, 1.2), Equals, d.root.Color("lighter"))
+			d.Check(qml.Alpha(base, 0.5), Equals, d.root.Color("alpha"))
+			d.Check(qml.HSLA(0.3, 0.6, 0.4, 0.8), Equals, d.root.Color("hsla"))
+			d.Check(qml.Tint(base, color.RGBA{0xff, 0, 0, 0x80}), Equals, d.root.Color("tint"))
+
+			palette := testPalette
+			d.Check(d.root.Color("accent"), Equals, base)
+			palette.SetColors(map[string]color.RGBA{"accent": qml.Darker(base, 2)})
+			d.Check(d.root.Color("accent"), Equals, qml.Darker(base, 2))
+			d.Check(palette.Names(), DeepEquals, []string{"accent"})
+			palette.Destroy()
+		},
+	},
+	{
+		Summary: "Control item layering",
+		QML: `
+			Item {
+				property Component effect: Component { ShaderEffect { property var tex } }
+				Rectangle { objectName: "rect"; width: 100; height: 50 }
+			}
+		`,
+		Done: func(d *TestData) {
+			rect := d.root.ObjectByName("rect").Common()
+			d.Check(rect.Layer().Enabled, Equals, false)
+
+			effect := d.root.Object("effect")
+			rect.SetLayer(qml.Layer{Enabled: true, Smooth: true, TextureWidth: 50, TextureHeight: 25, Effect: effect, SamplerName: "tex"})
+			layer := rect.Layer()
+			d.Check(layer.Enabled, Equals, true)
+			d.Check(layer.Smooth, Equals, true)
+			d.Check(layer.Mipmap, Equals, false)
+			d.Check(layer.TextureWidth, Equals, 50)
+			d.Check(layer.TextureHeight, Equals, 25)
+			d.Check(layer.SamplerName, Equals, "tex")
+			d.Check(layer.Effect, NotNil)
+
+			rect.SetLayerEnabled(false)
+			d.Check(rect.Bool("layer.enabled"), Equals, false)
+			d.Check(func() { d.root.Object("effect").Common().SetLayerEnabled(true) }, PanicMatches, "object is not a visual item")
+		},
+	},
+	{
+		Summary: "Report frame timings of a window",
+		QML: `
+			Rectangle {
+				width: 100; height: 100
+				NumberAnimation on rotation { from: 0; to: 360; duration: 1000; loops: Animation.Infinite }
+			}
+		`,
+		Done: func(d *TestData) {
+			win := d.component.CreateWindow(nil)
+			timings := make(chan qml.FrameTiming, 100)
+			stop := win.OnFrameTiming(func(timing qml.FrameTiming) {
+				select {
+				case timings <- timing:
+				default:
+				}
+			})
+			win.Show()
+			var timing qml.FrameTiming
+			select {
+			case timing = <-timings:
+			case <-time.After(5 * time.Second):
+				d.Fatalf("no frames were rendered")
+			}
+			d.Check(timing.Sync >= 0, Equals, true)
+			d.Check(timing.Render >= 0, Equals, true)
+			d.Check(timing.Missed >= 0, Equals, true)
+			stop()
+			stop()
+			win.Hide()
+			win.Destroy()
+		},
+	},
+	{
+		Summary: "Release scene graph resources and estimate texture memory",
+		QML: `
+			Item {
+				width: 200; height: 200
+				Rectangle { width: 100; height: 50; layer.enabled: true }
+				Rectangle { width: 10; height: 10; layer.enabled: true; layer.textureSize: Qt.size(20, 20) }
+			}
+		`,
+		Done: func(d *TestData) {
+			win := d.component.CreateWindow(nil)
+			memory := win.TextureMemory()
+			d.Check(memory >= (100*50+20*20)*4, Equals, true)
+			win.SetPersistentResources(false)
+			win.ReleaseResources()
+			qml.ReleaseResources()
+			win.Destroy()
+		},
+	},
+	{
+		Summary: "Navigate between pages with a router",
+		QML: `
+			import QtQuick.Controls 2.0
+			StackView {
+				width: 300; height: 200
+				function profile() { router.push("user", {id: 42}) }
+				function back() { router.pop() }
+				function userId() { return router.params.id }
+			}
+		`,
+		Done: func(d *TestData) {
+			home, err := d.engine.LoadString("home.qml", "import QtQuick 2.0\nItem { objectName: \"home\" }")
+			d.Assert(err, IsNil)
+			user, err := d.engine.LoadString("user.qml", "import QtQuick 2.0\nItem { objectName: \"user\" }")
+			d.Assert(err, IsNil)
+
+			router := qml.NewRouter(d.root)
+			router.Handle("home", home, nil)
+			router.Handle("user", user, testRouteParams{})
+			var events []string
+			router.OnNavigate(func(e qml.NavigationEvent) { events = append(events, e.From+">"+e.To) })
+			d.context.SetVar("router", router)
+
+			d.Assert(router.Push("home", nil), IsNil)
+			d.Check(router.Pop(), ErrorMatches, "cannot pop the last page of the router")
+			d.Check(router.Push("nowhere", nil), ErrorMatches, `router has no route named "nowhere"`)
+			d.Check(router.Push("user", map[string]interface{}{"name": "x"}), ErrorMatches, `cannot set parameters of route "user": qml_test.testRouteParams has no field "name"`)
+
+			d.root.Call("profile")
+			d.Check(router.Depth, Equals, 2)
+			d.Check(router.Current, Equals, "user")
+			d.Check(router.Params, DeepEquals, &testRouteParams{Id: 42})
+			d.Check(d.root.Call("userId"), Equals, 42)
+			d.Check(d.root.Object("currentItem").String("objectName"), Equals, "user")
+
+			d.Check(router.Replace("user", &testRouteParams{Id: 7}), IsNil)
+			d.Check(d.root.Call("userId"), Equals, 7)
+			d.root.Call("back")
+			d.Check(router.Current, Equals, "home")
+			d.Check(router.Params, IsNil)
+			d.Check(events, DeepEquals, []string{">home", "home>user", "user>user", "user>home"})
+		},
+	},
+	{
+		Summary: "Wait for the choice made in a dialog",
+		QML:     `import QtQuick 2.0; Item { width: 300; height: 200 }`,
+		Done: func(d *TestData) {
+			confirm, err := d.engine.LoadString("confirm.qml", "import QtQuick.Controls 2.0\nDialog { standardButtons: Dialog.Ok | Dialog.Cancel }")
+			d.Assert(err, IsNil)
+			win := d.component.CreateWindow(nil)
+			defer win.Destroy()
+
+			f := qml.ShowDialog(confirm, win.Root(), map[string]interface{}{"title": "Sure?"})
+			d.Check(f.Dialog().Opened(), Equals, true)
+			d.Check(f.Dialog().String("title"), Equals, "Sure?")
+			d.Check(f.Dialog().Bool("modal"), Equals, true)
+			var then []qml.DialogResult
+			f.Then(func(r qml.DialogResult) { then = append(then, r) })
+			f.Dialog().Call("accept")
+			d.Check(f.Wait(), Equals, qml.DialogResult{Accepted: true, Code: 1})
+			d.Check(then, DeepEquals, []qml.DialogResult{{Accepted: true, Code: 1}})
+
+			f = qml.ShowDialog(confirm, win.Root(), nil)
+			f.Close()
+			select {
+			case <-f.Done():
+			case <-time.After(5 * time.Second):
+				d.Fatalf("dialog was not closed")
+			}
+			d.Check(f.Wait(), Equals, qml.DialogResult{Accepted: false, Code: 0})
+		},
+	},
+	{
+		Summary: "Bind a Go struct to form fields",
+		QML: `
+			import QtQuick.Controls 2.0
+			Column {
+				TextField { objectName: "name" }
+				CheckBox { objectName: "subscribed" }
+				SpinBox { objectName: "ageBox"; to: 1000 }
+				TextField { objectName: "notes" }
+			}
+		`,
+		Done: func(d *TestData) {
+			account := &testAccount{Name: "Alice", Age: 30, Notes: "hidden"}
+			form, err := qml.BindForm(d.root, account)
+			d.Assert(err, IsNil)
+			d.Check(d.root.ObjectByName("name").String("text"), Equals, "Alice")
+			d.Check(d.root.ObjectByName("ageBox").Int("value"), Equals, 30)
+			d.Check(d.root.ObjectByName("notes").String("text"), Equals, "")
+			d.Check(form.Dirty, Equals, false)
+
+			var changes []string
+			form.OnChange(func(field string) { changes = append(changes, field) })
+			form.OnValidate(func(field string, value interface{}) error {
+				if field == "Age" && value.(int) > 150 {
+					return fmt.Errorf("too old")
+				}
+				return nil
+			})
+
+			d.root.ObjectByName("name").Set("text", "Bob")
+			d.root.ObjectByName("subscribed").Set("checked", true)
+			d.root.ObjectByName("ageBox").Set("value", 200)
+			d.Check(account.Name, Equals, "Bob")
+			d.Check(account.Subscribed, Equals, true)
+			d.Check(account.Age, Equals, 30)
+			d.Check(form.Error("Age"), ErrorMatches, "too old")
+			d.Check(form.Dirty, Equals, true)
+			d.Check(changes, DeepEquals, []string{"Name", "Subscribed"})
+
+			form.Revert()
+			d.Check(account.Name, Equals, "Alice")
+			d.Check(d.root.ObjectByName("subscribed").Bool("checked"), Equals, false)
+			d.Check(form.Error("Age"), IsNil)
+			d.Check(form.Dirty, Equals, false)
+
+			account.Name = "Carol"
+			form.Load()
+			d.Check(d.root.ObjectByName("name").String("text"), Equals, "Carol")
+
+			_, err = qml.BindForm(d.root, &struct {
+				X string `qml:"form=missing"`
+			}{})
+			d.Check(err, ErrorMatches, `cannot find form item "missing" for field X`)
+		},
+	},
+	{
+		Summary: "Validate form fields with Go rules",
+		QML: `
+			import QtQuick.Controls 2.0
+			Column {
+				TextField { objectName: "email" }
+				TextField { objectName: "password" }
+				TextField { objectName: "confirm" }
+				function emailError() { return validation.errors.email }
+				function valid() { return validation.isValid }
+			}
+		`,
+		Done: func(d *TestData) {
+			signup := &testSignup{}
+			form, err := qml.BindForm(d.root, signup)
+			d.Assert(err, IsNil)
+			validation := qml.NewValidation(d.engine, signup)
+			defer validation.Destroy()
+			validation.Rule("Email", func(value interface{}) error {
+				if !strings.Contains(value.(string), "@") {
+					return fmt.Errorf("not an email address")
+				}
+				return nil
+			})
+			validation.CrossRule("Confirm", func() error {
+				if signup.Confirm != signup.Password {
+					return fmt.Errorf("passwords do not match")
+				}
+				return nil
+			})
+			validation.Watch(form)
+			d.context.SetVar("validation", validation)
+
+			d.Check(d.root.Call("valid"), Equals, true)
+			d.Check(d.root.Call("emailError"), Equals, "")
+
+			d.Check(validation.Validate(), Equals, false)
+			d.Check(d.root.Call("valid"), Equals, false)
+			d.Check(d.root.Call("emailError"), Equals, "not an email address")
+			d.Check(validation.Error("Confirm"), Equals, "")
+
+			d.root.ObjectByName("email").Set("text", "joe@example.com")
+			d.root.ObjectByName("password").Set("text", "secret")
+			d.Check(d.root.Call("emailError"), Equals, "")
+			d.Check(validation.Error("Confirm"), Equals, "passwords do not match")
+			d.Check(validation.IsValid, Equals, false)
+
+			d.root.ObjectByName("confirm").Set("text", "secret")
+			d.Check(validation.IsValid, Equals, true)
+			d.Check(d.root.Call("valid"), Equals, true)
+
+			d.Check(func() { validation.Rule("Bogus", nil) }, PanicMatches, `qml_test.testSignup has no field Bogus`)
+		},
+	},
+	{
+		Summary: "Share actions between menus and a command palette",
+		Init: func(d *TestData) {
+			d.context.SetVar("actions", &qml.ActionRegistry{})
+		},
+		QML: `
+			import QtQuick 2.0
+			Item {
+				Repeater { objectName: "menu"; model: actions.names; Item { property var action: actions.get(modelData) } }
+				function trigger(name) { actions.get(name).trigger() }
+			}
+		`,
+		Done: func(d *TestData) {
+			actions := d.context.Var("actions").(*qml.ActionRegistry)
+			saved, modified := 0, false
+			actions.Add("save", "Save document", "Ctrl+S", func() { saved++ }, func() bool { return modified })
+			actions.Add("saveAll", "Save all", "", func() {}, nil)
+			d.Check(d.root.ObjectByName("menu").Int("count"), Equals, 2)
+			d.Check(func() { actions.Add("save", "", "", nil, nil) }, PanicMatches, `action registry already has an action named "save"`)
+
+			d.Check(actions.Get("save").Enabled, Equals, false)
+			d.Check(actions.Trigger("save"), ErrorMatches, `action "save" is disabled`)
+			d.Check(actions.Trigger("open"), ErrorMatches, `action registry has no action named "open"`)
+			d.Check(actions.Search("save"), DeepEquals, []string{"saveAll"})
+
+			modified = true
+			actions.Update()
+			d.Check(actions.Get("save").Enabled, Equals, true)
+			d.root.Call("trigger", "save")
+			d.Check(saved, Equals, 1)
+			d.Check(actions.Search("SAVE doc"), DeepEquals, []string{"save"})
+
+			actions.Get("save").SetShortcut("Ctrl+Shift+S")
+			d.Check(actions.Get("save").Shortcut, Equals, "Ctrl+Shift+S")
+			actions.Remove("saveAll")
+			d.Check(actions.Names, DeepEquals, []string{"save"})
+			d.Check(d.root.ObjectByName("menu").Int("count"), Equals, 1)
+		},
+	},
+	{
+		Summary: "Run background tasks in a task queue",
+		Init: func(d *TestData) {
+			d.context.SetVar("tasks", qml.NewTaskQueue(1))
+		},
+		QML: `
+			import QtQuick 2.0
+			Item {
+				property int running: tasks.running
+				function state(id) { return tasks.get(id).state }
+				function cancel(id) { tasks.get(id).cancel() }
+			}
+		`,
+		Done: func(d *TestData) {
+			tasks := d.context.Var("tasks").(*qml.TaskQueue)
+			release := make(chan bool)
+			a := tasks.Submit("A", func(p *qml.Progress) error {
+				p.Set(1, 2)
+				<-release
+				return nil
+			})
+			b := tasks.Submit("B", func(p *qml.Progress) error { return nil })
+			d.Check(d.root.Int("running"), Equals, 1)
+			d.Check(d.root.Call("state", a.Id), Equals, qml.TaskRunning)
+			d.Check(d.root.Call("state", b.Id), Equals, qml.TaskQueued)
+
+			d.root.Call("cancel", b.Id)
+			d.Check(b.Wait(), IsNil)
+			d.Check(b.State, Equals, qml.TaskCancelled)
+
+			release <- true
+			d.Check(a.Wait(), IsNil)
+			d.Check(a.State, Equals, qml.TaskFinished)
+			d.Check(a.Progress.Fraction, Equals, 0.5)
+
+			c := tasks.Submit("C", func(p *qml.Progress) error { return fmt.Errorf("boom") })
+			d.Check(c.Wait(), ErrorMatches, "boom")
+			d.Check(c.State, Equals, qml.TaskFailed)
+			d.Check(c.Error, Equals, "boom")
+			d.Check(tasks.Ids, DeepEquals, []int{a.Id, b.Id, c.Id})
+
+			tasks.ClearFinished()
+			d.Check(tasks.Ids, HasLen, 0)
+			d.Check(tasks.Running, Equals, 0)
+		},
+	},
+	{
+		Summary: "Share values between Go and QML in the engine store",
+		Init: func(d *TestData) {
+			d.context.SetVar("store", d.engine.Store())
+		},
+		QML: `
+			import QtQuick 2.0
+			Item {
+				property string selection: store.selection || ""
+				function select(path) { store.selection = path }
+			}
+		`,
+		Done: func(d *TestData) {
+			store := d.engine.Store()
+			path := filepath.Join(d.MkDir(), "store.json")
+			d.Assert(store.Persist(path), IsNil)
+
+			var seen []interface{}
+			store.Watch("selection", func(value interface{}) { seen = append(seen, value) })
+
+			store.Put("selection", "a.txt")
+			d.Check(d.root.String("selection"), Equals, "a.txt")
+			d.root.Call("select", "b.txt")
+			value, ok := store.Get("selection")
+			d.Check(ok, Equals, true)
+			d.Check(value, Equals, "b.txt")
+			store.Put("count", 3)
+			d.Check(store.Keys(), DeepEquals, []string{"count", "selection"})
+			store.Delete("selection")
+			d.Check(seen, DeepEquals, []interface{}{"a.txt", "b.txt", nil})
+
+			other := qml.NewEngine()
+			defer other.Destroy()
+			d.Assert(other.Store().Persist(path), IsNil)
+			value, _ = other.Store().Get("count")
+			d.Check(value, Equals, 3.0)
+		},
+	},
+	{
+		Summary: "Publish and subscribe to events on the bus",
+		QML: `
+			import GoTypes 4.2
+			Item {
+				property string got
+				property int subscription
+				Component.onCompleted: subscription = Bus.subscribe("note.*", function(payload, name) { got = name + ":" + payload })
+				function select() { Bus.publish("user.selected", {id: 42}) }
+				function unsubscribe() { Bus.unsubscribe(subscription) }
+			}
+		`,
+		Done: func(d *TestData) {
+			var selected []testRouteParams
+			cancel := qml.Bus().Subscribe("user.*", func(e qml.Event) {
+				var params testRouteParams
+				d.Check(e.Decode(&params), IsNil)
+				selected = append(selected, params)
+			})
+			defer cancel()
+
+			qml.Bus().Publish("note.added", "hello")
+			d.Check(d.root.String("got"), Equals, "note.added:hello")
+			qml.Bus().Publish("other", "ignored")
+			d.Check(d.root.String("got"), Equals, "note.added:hello")
+
+			d.root.Call("select")
+			d.Check(selected, DeepEquals, []testRouteParams{{Id: 42}})
+
+			var n int
+			err := qml.Event{Name: "x", Payload: map[string]interface{}{"bogus": 1}}.Decode(&testRouteParams{})
+			d.Check(err, ErrorMatches, `cannot decode payload of event "x": .* has no field "bogus"`)
+			d.Check(qml.Event{Name: "n", Payload: 3.0}.Decode(&n), IsNil)
+			d.Check(n, Equals, 3)
+
+			d.root.Call("unsubscribe")
+			qml.Bus().Publish("note.removed", "bye")
+			d.Check(d.root.String("got"), Equals, "note.added:hello")
+		},
+	},
+	{
+		Summary: "Replace context variables preserving QML references",
+		Init: func(d *TestData) {
+			d.context.SetVar("backend", &TestType{StringValue: "old"})
+			d.context.SetVar("other", 1)
+		},
+		QML: `
+			Item {
+				property var held: backend
+				property string bound: backend.stringValue
+				property int otherBound: other
+				function heldValue() { return held.stringValue }
+			}
+		`,
+		Done: func(d *TestData) {
+			value := &TestType{StringValue: "new"}
+			d.context.ReplaceVar("backend", value)
+			d.Check(d.context.Var("backend"), Equals, value)
+			d.Check(d.root.String("bound"), Equals, "new")
+			d.Check(d.root.Call("heldValue"), Equals, "new")
+
+			value.StringValue = "newer"
+			qml.Changed(value, &value.StringValue)
+			d.Check(d.root.String("bound"), Equals, "newer")
+
+			d.context.ReplaceVar("other", 2)
+			d.Check(d.root.Int("otherBound"), Equals, 2)
+		},
+	},
+	{
+		Summary: "Snapshot and restore the state of Go values",
+		Init: func(d *TestData) {
+			d.context.SetVar("doc", &testDocument{Title: "Draft", Pages: 3, Tags: []string{"a"}, Owner: &testOwner{"Joe"}})
+		},
+		QML: `Item { property string title: doc.title; property string owner: doc.owner.name }`,
+		Done: func(d *TestData) {
+			doc := d.context.Var("doc").(*testDocument)
+			data, err := qml.Snapshot(doc)
+			d.Assert(err, IsNil)
+			d.Check(string(data), Equals, `{"Owner":{"Name":"Joe"},"Pages":3,"Tags":["a"],"Title":"Draft"}`)
+
+			owner := doc.Owner
+			doc.Title = "Final"
+			doc.Pages = 10
+			doc.Owner.Name = "Ann"
+			qml.Changed(doc, &doc.Title)
+			d.Check(d.root.String("title"), Equals, "Final")
+
+			d.Assert(qml.Restore(doc, data), IsNil)
+			d.Check(doc.Pages, Equals, 3)
+			d.Check(doc.Owner, Equals, owner)
+			d.Check(doc.Owner.Name, Equals, "Joe")
+			d.Check(d.root.String("title"), Equals, "Draft")
+			d.Check(d.root.String("owner"), Equals, "Joe")
+
+			d.Check(qml.Restore(doc, []byte(`{"Pages":"many"}`)), ErrorMatches, `cannot restore testDocument.Pages: .*`)
+		},
+	},
+	{
+		Summary: "Interpolate numeric properties set from Go",
+		QML:     `Item { x: 0; y: 0 }`,
+		Done: func(d *TestData) {
+			ip := qml.NewInterpolator(d.root)
+			ip.Smooth("x", 100*time.Millisecond, qml.Linear)
+
+			d.Check(ip.Set("y", 50), IsNil)
+			d.Check(d.root.Int("y"), Equals, 50)
+
+			d.Check(ip.Set("x", 100), IsNil)
+			d.Check(d.root.Float64("x") < 100, Equals, true)
+			for i := 0; i < 100 && d.root.Float64("x") != 100; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			d.Check(d.root.Float64("x"), Equals, 100.0)
+
+			d.Check(ip.Set("x", 0), IsNil)
+			d.Check(d.root.Float64("x") > 0, Equals, true)
+			for i := 0; i < 100 && d.root.Float64("x") != 0; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			d.Check(d.root.Float64("x"), Equals, 0.0)
+
+			ip.Smooth("bogus", time.Second, qml.Linear)
+			d.Check(ip.Set("bogus", 1), ErrorMatches, `object does not have a "bogus" property`)
+		},
+	},
+	{
+		Summary: "Coalesce rapid change notifications",
+		Init: func(d *TestData) {
+			d.context.SetVar("value", &TestType{})
+		},
+		QML: `Item { property int n: value.intValue }`,
+		Done: func(d *TestData) {
+			value := d.context.Var("value").(*TestType)
+			qml.SetChangeCoalescing(value, 200*time.Millisecond)
+			defer qml.SetChangeCoalescing(value, 0)
+
+			value.IntValue = 1
+			qml.Changed(value, &value.IntValue)
+			d.Check(d.root.Int("n"), Equals, 1)
+			for i := 2; i <= 10; i++ {
+				value.IntValue = i
+				qml.Changed(value, &value.IntValue)
+			}
+			d.Check(d.root.Int("n"), Equals, 1)
+			for i := 0; i < 100 && d.root.Int("n") != 10; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			d.Check(d.root.Int("n"), Equals, 10)
+
+			value.IntValue = 11
+			qml.Changed(value, &value.IntValue)
+			qml.SetChangeCoalescing(value, 0)
+			d.Check(d.root.Int("n"), Equals, 11)
+		},
+	},
+	{
+		Summary: "Drive QML timers and animations with a fake clock",
+		Init: func(d *TestData) {
+			qml.UseFakeClock(true)
+		},
+		QML: `
+			Item {
+				property int ticks
+				property real x: 0
+				Timer { interval: 100; repeat: true; running: true; onTriggered: ticks++ }
+				NumberAnimation on x { from: 0; to: 100; duration: 1000 }
+			}
+		`,
+		Done: func(d *TestData) {
+			defer qml.UseFakeClock(false)
+
+			qmltest.AdvanceTime(500 * time.Millisecond)
+			d.Check(d.root.Int("ticks"), Equals, 5)
+			x := d.root.Float64("x")
+			d.Check(x > 0 && x < 100, Equals, true)
+
+			qmltest.AdvanceTime(time.Second)
+			d.Check(d.root.Int("ticks"), Equals, 15)
+			d.Check(d.root.Float64("x"), Equals, 100.0)
+		},
+	},
+	{
+		Summary: "Record and replay interactions of QML with Go values",
+		QML: `
+			Item {
+				function run() {
+					value.stringValue = "<recorded>"
+					value.changeString("<changed>")
+					value.incrementInt()
+					value.intValue = value.intValue + 41
+				}
+			}
+		`,
+		Done: func(d *TestData) {
+			var buf bytes.Buffer
+			recorder := qml.StartRecording(&buf)
+			recorder.Name(d.value, "value")
+			d.root.Call("run")
+			d.Assert(recorder.Stop(), IsNil)
+
+			replayed := &TestType{}
+			d.Assert(qml.Replay(bytes.NewReader(buf.Bytes()), map[string]interface{}{"value": replayed}), IsNil)
+			d.Check(replayed.StringValue, Equals, "<changed>")
+			d.Check(replayed.IntValue, Equals, 42)
+			d.Check(replayed.stringValueChanged, Equals, 1)
+
+			err := qml.Replay(bytes.NewReader(buf.Bytes()), nil)
+			d.Check(err, ErrorMatches, "cannot replay interaction with value: value not provided")
+		},
+	},
+	{
+		Summary: "Publish design tokens as a theme singleton",
+		QML: `
+			import GoTypes 4.2
+			Item {
+				property color background: Theme.colors.background
+				property int margin: Theme.spacing.medium
+			}
+		`,
+		Done: func(d *TestData) {
+			err := qml.LoadTheme(testThemeTokens, []byte(`{"colors": {"background": "#102030"}, "spacing": {"medium": 8}}`), json.Unmarshal)
+			d.Assert(err, IsNil)
+			d.Check(testThemeTokens.Colors.Background, Equals, color.RGBA{0x10, 0x20, 0x30, 0xff})
+			d.Check(d.root.Color("background"), Equals, color.RGBA{0x10, 0x20, 0x30, 0xff})
+			d.Check(d.root.Int("margin"), Equals, 8)
+
+			qml.SetTheme(testThemeTokens, testTheme{Spacing: testThemeSpacing{Medium: 12}})
+			d.Check(d.root.Int("margin"), Equals, 12)
+			d.Check(d.root.Color("background"), Equals, color.RGBA{})
+
+			err = qml.LoadTheme(testThemeTokens, []byte(`{"colors": {"primary": "blue"}}`), json.Unmarshal)
+			d.Check(err, ErrorMatches, `cannot load theme: invalid color for colors.primary: "blue"`)
+			err = qml.LoadTheme(testThemeTokens, []byte(`{"sizes": {}}`), json.Unmarshal)
+			d.Check(err, ErrorMatches, `cannot load theme: unknown token sizes`)
+		},
+	},
+	{
+		Summary: "Name objects wrapping Go values",
+		Init: func(d *TestData) {
+			qml.SetObjectNamer(qml.TypeCounterName)
+			d.context.SetVar("named", &testNamed{})
+			qml.SetObjectNamer(nil)
+			d.context.SetVar("anonymous", &testNamed{})
+		},
+		QML: `Item { property string named: named.objectName; property string anonymous: anonymous.objectName }`,
+		Done: func(d *TestData) {
+			d.Check(d.root.String("named"), Equals, "testNamed_1")
+			d.Check(d.root.String("anonymous"), Equals, "")
+		},
+	},
+	{
+		Summary: "Hand slices to QML as lists",
+		Init: func(d *TestData) {
+			d.context.SetVar("rows", [][]string{{"a", "b"}, {"c"}})
+			d.context.SetVar("items", []testNamed{{Text: "x"}, {Text: "y"}})
+			d.context.SetVar("matrix", &testMatrix{})
+		},
+		QML: `
+			Item {
+				property int rowCount: rows.length
+				property string cell: rows[0][1]
+				property string text: items[1].text
+				property alias repeated: repeater.count
+				Repeater { id: repeater; model: items; Item {} }
+				Component.onCompleted: matrix.setRows([[1, 2], [3]])
+			}
+		`,
+		Done: func(d *TestData) {
+			d.Check(d.root.Int("rowCount"), Equals, 2)
+			d.Check(d.root.String("cell"), Equals, "b")
+			d.Check(d.root.String("text"), Equals, "y")
+			d.Check(d.root.Int("repeated"), Equals, 2)
+
+			items := d.context.Var("items").(*qml.List)
+			d.Check(items.Len(), Equals, 2)
+			matrix := d.context.Var("matrix").(*testMatrix)
+			d.Check(matrix.Rows, DeepEquals, [][]int{{1, 2}, {3}})
+		},
+	},
+	{
+		Summary: "Hand string-keyed maps to QML as JavaScript objects",
+		Init: func(d *TestData) {
+			d.context.SetVar("config", map[string]interface{}{
+				"name":   "x",
+				"nested": map[string]int{"a": 1},
+			})
+			d.context.SetVar("settings", &testSettings{Values: map[string]int{"width": 10}})
+		},
+		QML: `
+			Item {
+				property string name: config.name
+				property int nestedA: config.nested.a
+				property int width: settings.values.width
+				Component.onCompleted: {
+					settings.values = {"width": 20, "height": 30}
+					settings.setDefaults({"width": 5})
+				}
+			}
+		`,
+		Done: func(d *TestData) {
+			d.Check(d.root.String("name"), Equals, "x")
+			d.Check(d.root.Int("nestedA"), Equals, 1)
+			d.Check(d.root.Int("width"), Equals, 10)
+
+			settings := d.context.Var("settings").(*testSettings)
+			d.Check(settings.Values, DeepEquals, map[string]int{"width": 20, "height": 30})
+			d.Check(settings.Defaults, DeepEquals, map[string]int{"width": 5})
+		},
+	},
+	{
+		Summary: "Lint components before loading them",
+		QML:     `Item {}`,
+		Done: func(d *TestData) {
+			var linted []string
+			d.engine.SetLintHook(func(file string, src []byte) []qml.Issue {
+				linted = append(linted, file)
+				if bytes.Contains(src, []byte("color:")) {
+					return []qml.Issue{{Line: 2, Column: 8, Message: "colors must come from Theme"}, {Message: "second issue"}}
+				}
+				return nil
+			})
+			defer d.engine.SetLintHook(nil)
+
+			_, err := d.engine.LoadString("red.qml", "import QtQuick 2.0\nRectangle { color: \"red\" }")
+			d.Check(err, ErrorMatches, "red.qml:2:8: colors must come from Theme\nred.qml: second issue")
+			_, err = d.engine.LoadString("plain.qml", "import QtQuick 2.0\nItem {}")
+			d.Check(err, IsNil)
+			d.Check(linted, DeepEquals, []string{"red.qml", "plain.qml"})
+		},
+	},
+	{
+		Summary: "Hand times to QML as dates",
+		Init: func(d *TestData) {
+			start := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+			d.context.SetVar("booking", &testBooking{Start: start})
+		},
+		QML: `
+			Item {
+				property int startYear: booking.start.getUTCFullYear()
+				property int startHour: booking.start.getUTCHours()
+				property bool endUnset: !booking.end
+				property var local: new Date(2024, 2, 5, 9, 15)
+				Component.onCompleted: booking.end = new Date(Date.UTC(2024, 2, 4, 12, 30))
+			}
+		`,
+		Done: func(d *TestData) {
+			d.Check(d.root.Int("startYear"), Equals, 2024)
+			d.Check(d.root.Int("startHour"), Equals, 12)
+			d.Check(d.root.Bool("endUnset"), Equals, true)
+
+			booking := d.context.Var("booking").(*testBooking)
+			d.Check(booking.End.Equal(time.Date(2024, 3, 4, 12, 30, 0, 0, time.UTC)), Equals, true)
+			d.Check(booking.Days(), Equals, 3)
+
+			local, ok := d.root.Property("local").(time.Time)
+			d.Assert(ok, Equals, true)
+			d.Check(local.Location(), Equals, time.Local)
+			d.Check(local.Format("2006-01-02 15:04"), Equals, "2024-03-05 09:15")
+
+			d.root.Set("local", time.Time{})
+			d.Check(d.root.Property("local"), IsNil)
+		},
+	},
+	{
+		Summary: "Hand colors of any type to QML as colors",
+		Init: func(d *TestData) {
+			d.context.SetVar("paint", &testPaint{Fill: color.NRGBA{255, 0, 0, 128}, Shade: color.Gray{64}})
+		},
+		QML: `
+			Rectangle {
+				color: paint.fill
+				property color shade: paint.shade
+				Component.onCompleted: {
+					paint.fill = Qt.rgba(0, 1, 0, 1)
+					paint.shade = "#808080"
+				}
+			}
+		`,
+		Done: func(d *TestData) {
+			d.Check(d.root.Color("color"), Equals, color.RGBA{255, 0, 0, 128})
+			d.Check(d.root.Color("shade"), Equals, color.RGBA{64, 64, 64, 255})
+
+			paint := d.context.Var("paint").(*testPaint)
+			d.Check(paint.Fill, Equals, color.NRGBA{0, 255, 0, 255})
+			d.Check(paint.Shade, Equals, color.Gray{128})
+		},
+	},
+	{
+		Summary: "Write colors from QML into custom color types",
+		Init: func(d *TestData) {
+			d.context.SetVar("paint", &testPaint{Hex: 0x102030})
+		},
+		QML: `
+			Item {
+				property color hex
+				Component.onCompleted: {
+					hex = paint.hex
+					paint.hex = "#405060"
+					paint.label = "#708090"
+				}
+			}
+		`,
+		Done: func(d *TestData) {
+			d.Check(d.root.Color("hex"), Equals, color.RGBA{0x10, 0x20, 0x30, 0xff})
+
+			paint := d.context.Var("paint").(*testPaint)
+			d.Check(paint.Hex, Equals, testHexColor(0x405060))
+			d.Check(paint.Label, Equals, testLabelColor{0x70, 0x80, 0x90, 0xff})
+		},
+	},
+	{
+		Summary: "Hand byte slices to QML as array buffers",
+		Init:    func(d *TestData) { d.context.SetVar("data", []byte{1, 2, 3}) },
+		QML: `
+			Item {
+				property int size: data.byteLength
+				property int second: new Uint8Array(data)[1]
+				property var tail: data.slice(1)
+			}
+		`,
+		Done: func(d *TestData) {
+			d.Check(d.root.Int("size"), Equals, 3)
+			d.Check(d.root.Int("second"), Equals, 2)
+			d.Check(d.root.Property("tail"), DeepEquals, []byte{2, 3})
+		},
+	},
+}
+
+var tablef = flag.String("tablef", "", "if provided, TestTable only runs tests with a summary matching the regexp")
+
+func (s *S) TestTable(c *C) {
+	var goTypeValue *TestType = &TestType{}
+
+	types := []qml.TypeSpec{{
+		Name: "GoType",
+		New:  func() interface{} { return goTypeValue },
+	}, {
+		Name:      "GoSingleton",
+		New:       func() interface{} { return goTypeValue },
+		Singleton: true,
+	}}
+
+	qml.RegisterTypes("GoTypes", 4, 2, types)
+	qml.RegisterFactory("GoTypes", 4, 2, func(typeName string, props map[string]interface{}) (interface{}, error) {
+		if typeName != "GoType" {
+			return nil, fmt.Errorf("unknown type %s", typeName)
+		}
+		value := &TestType{}
+		value.StringValue, _ = props["stringValue"].(string)
+		return value, nil
+	})
+	qml.RegisterAppState("GoTypes", 4, 2)
+	qml.RegisterBus("GoTypes", 4, 2)
+	qml.RegisterTheme("GoTypes", 4, 2, "Theme", testThemeTokens)
+	qml.RegisterShim(&testShimmed{}, testShimmedShim)
+	qml.RegisterSocket("GoTypes", 4, 2, func(url string) (qml.SocketConn, error) {
+		if url != "echo:" {
+			return nil, fmt.Errorf("cannot dial %s", url)
+		}
+		return &testSocketConn{messages: make(chan []byte, 1), done: make(chan bool)}, nil
+	})
 
 	filter := regexp.MustCompile("")
 	if tablef != nil {
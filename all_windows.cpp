@@ -0,0 +1 @@
+#include "cpp/windows.cpp"
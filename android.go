@@ -0,0 +1,158 @@
+// +build android
+
+package qml
+
+// #cgo LDFLAGS: -lQt5AndroidExtras
+//
+// #include <stdlib.h>
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// Intent describes an Android intent to be started with StartActivity.
+//
+// See the Android documentation for details:
+//
+//   http://developer.android.com/reference/android/content/Intent.html
+//
+type Intent struct {
+	Action string            // For example, "android.intent.action.VIEW".
+	Data   string            // Optional data URI.
+	Type   string            // Optional MIME type.
+	Extras map[string]string // Optional string extras.
+}
+
+// Android activity result codes.
+const (
+	ActivityResultOK       = -1
+	ActivityResultCanceled = 0
+)
+
+var androidCallbacks = make(map[unsafe.Pointer]bool)
+
+// RequestPermissions requests the provided Android runtime permissions
+// from the user, such as "android.permission.CAMERA", and calls f with
+// the subset of permissions that were granted once the user answers.
+// As with signal handlers, f is run within the main GUI thread.
+//
+// RequestPermissions is only available on Android.
+func RequestPermissions(permissions []string, f func(granted []string)) {
+	cperms := cstrings(permissions)
+	gui(func() {
+		funcp := unsafe.Pointer(&f)
+		androidCallbacks[funcp] = true
+		C.androidRequestPermissions(cperms, C.int(len(permissions)), funcp)
+	})
+	freeCStrings(cperms, len(permissions))
+}
+
+//export hookAndroidPermissions
+func hookAndroidPermissions(funcp unsafe.Pointer, cgranted *C.char, cgrantedLen C.int) {
+	delete(androidCallbacks, funcp)
+	f := *(*func(granted []string))(funcp)
+	var granted []string
+	if cgrantedLen > 0 {
+		granted = strings.Split(strings.TrimSuffix(C.GoStringN(cgranted, cgrantedLen), "\n"), "\n")
+	}
+	f(granted)
+}
+
+// StartActivity starts an Android activity for the provided intent, and
+// calls f with the activity result code and the result data URI once
+// the activity finishes. If f is nil, the result is ignored.
+// As with signal handlers, f is run within the main GUI thread.
+//
+// StartActivity is only available on Android.
+func StartActivity(intent Intent, f func(resultCode int, data string)) error {
+	if f == nil {
+		f = func(int, string) {}
+	}
+	caction := C.CString(intent.Action)
+	cdata := C.CString(intent.Data)
+	ctype := C.CString(intent.Type)
+	defer C.free(unsafe.Pointer(caction))
+	defer C.free(unsafe.Pointer(cdata))
+	defer C.free(unsafe.Pointer(ctype))
+	var extras []string
+	for key, value := range intent.Extras {
+		extras = append(extras, key, value)
+	}
+	cextras := cstrings(extras)
+	defer freeCStrings(cextras, len(extras))
+
+	var cerr *C.error
+	gui(func() {
+		funcp := unsafe.Pointer(&f)
+		androidCallbacks[funcp] = true
+		cerr = C.androidStartActivity(caction, cdata, ctype, cextras, C.int(len(extras)), 0, funcp)
+		if cerr != nil {
+			delete(androidCallbacks, funcp)
+		}
+	})
+	if cerr != nil {
+		return cerror(cerr)
+	}
+	return nil
+}
+
+//export hookAndroidActivityResult
+func hookAndroidActivityResult(funcp unsafe.Pointer, resultCode C.int, cdata *C.char, cdataLen C.int) {
+	delete(androidCallbacks, funcp)
+	f := *(*func(resultCode int, data string))(funcp)
+	f(int(resultCode), C.GoStringN(cdata, cdataLen))
+}
+
+// cstrings returns a C array with copies of the provided strings.
+// The result must be released with freeCStrings.
+func cstrings(strs []string) **C.char {
+	if len(strs) == 0 {
+		return nil
+	}
+	array := (*[1 << 20]*C.char)(C.malloc(C.size_t(len(strs)) * ptrSize))
+	for i, s := range strs {
+		array[i] = C.CString(s)
+	}
+	return &array[0]
+}
+
+func freeCStrings(cstrs **C.char, n int) {
+	if cstrs == nil {
+		return
+	}
+	array := (*[1 << 20]*C.char)(unsafe.Pointer(cstrs))
+	for i := 0; i < n; i++ {
+		C.free(unsafe.Pointer(array[i]))
+	}
+	C.free(unsafe.Pointer(cstrs))
+}
+
+func setSystemBarStyle(bar SystemBar, argb uint32, darkIcons bool) {
+	cdark := C.int(0)
+	if darkIcons {
+		cdark = 1
+	}
+	C.androidSetSystemBarStyle(C.int(bar), C.uint(argb), cdark)
+}
+
+func hapticFeedback(effect HapticEffect) {
+	C.androidHapticFeedback(C.int(effect))
+}
+
+func vibrate(d time.Duration) {
+	C.androidVibrate(C.int64_t(d / time.Millisecond))
+}
+
+func inhibitSleep(reason string) (release func()) {
+	keepAwake(true, func(on bool) { C.androidKeepScreenOn(cbool(on)) })
+	return func() {
+		keepAwake(false, func(on bool) { C.androidKeepScreenOn(cbool(on)) })
+	}
+}
+
+func connectPowerEvents() {}
@@ -0,0 +1,202 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"fmt"
+	"time"
+)
+
+// Animation offers typed access to a QML animation element, such as a
+// NumberAnimation or a SequentialAnimation, or to an animation created
+// with Animate, so that transitions may be orchestrated from Go code.
+//
+// For example:
+//
+//     fade := qml.NewAnimation(root.ObjectByName("fadeOut"))
+//     fade.OnFinished(func() { window.Hide() })
+//     fade.Start()
+//
+type Animation struct {
+	Object
+}
+
+// NewAnimation returns an Animation that controls obj, which must be a
+// QML animation element.
+func NewAnimation(obj Object) *Animation {
+	return &Animation{obj}
+}
+
+// Easing defines the curve used to interpolate values over the course
+// of an animation. The curves are the same as those available to QML
+// animations under the Easing namespace.
+type Easing int
+
+const (
+	Linear Easing = iota
+	InQuad
+	OutQuad
+	InOutQuad
+	OutInQuad
+	InCubic
+	OutCubic
+	InOutCubic
+	OutInCubic
+	InQuart
+	OutQuart
+	InOutQuart
+	OutInQuart
+	InQuint
+	OutQuint
+	InOutQuint
+	OutInQuint
+	InSine
+	OutSine
+	InOutSine
+	OutInSine
+	InExpo
+	OutExpo
+	InOutExpo
+	OutInExpo
+	InCirc
+	OutCirc
+	InOutCirc
+	OutInCirc
+	InElastic
+	OutElastic
+	InOutElastic
+	OutInElastic
+	InBack
+	OutBack
+	InOutBack
+	OutInBack
+	InBounce
+	OutBounce
+	InOutBounce
+	OutInBounce
+)
+
+// Animate starts animating the named property of obj from the from value
+// to the to value over the provided duration, following the easing curve.
+// If from is nil, the animation starts at the current property value.
+//
+// For example:
+//
+//     qml.Animate(panel, "opacity", nil, 0.0, 300*time.Millisecond, qml.OutQuad)
+//
+// The returned animation may be used to control the animation further,
+// and is destroyed together with obj.
+func Animate(obj Object, property string, from, to interface{}, duration time.Duration, easing Easing) (*Animation, error) {
+	common := obj.Common()
+	cproperty, cpropertyLen := unsafeStringData(property)
+	addr := nilPtr
+	gui(func() {
+		var cfrom, cto C.DataValue
+		packDataValue(from, &cfrom, common.engine, cppOwner)
+		packDataValue(to, &cto, common.engine, cppOwner)
+		addr = C.objectAnimate(common.addr, cproperty, cpropertyLen, &cfrom, &cto, C.int(duration/time.Millisecond), C.int(easing))
+	})
+	if addr == nilPtr {
+		return nil, fmt.Errorf("object does not have a %q property", property)
+	}
+	return &Animation{&Common{engine: common.engine, addr: addr}}, nil
+}
+
+// Start starts the animation from the beginning.
+func (a *Animation) Start() {
+	a.Call("start")
+}
+
+// Stop stops the animation.
+func (a *Animation) Stop() {
+	a.Call("stop")
+}
+
+// Pause pauses the animation at its current position.
+func (a *Animation) Pause() {
+	a.Call("pause")
+}
+
+// Resume resumes a paused animation.
+func (a *Animation) Resume() {
+	a.Call("resume")
+}
+
+// OnFinished arranges for f to be called whenever the animation runs
+// to its end. As with all signal handlers, f is run within the main
+// GUI thread.
+func (a *Animation) OnFinished(f func()) {
+	a.On("finished", f)
+}
+
+// Interpolator sets numeric properties of an object on behalf of Go
+// logic, animating smoothly from the current value to the new one for
+// properties configured with Smooth. This suits telemetry-like values
+// updated often by Go code, without declaring a Behavior element for
+// every property. For example:
+//
+//     gauge := qml.NewInterpolator(root.ObjectByName("gauge"))
+//     gauge.Smooth("value", 200*time.Millisecond, qml.OutCubic)
+//     for reading := range readings {
+//         gauge.Set("value", reading)
+//     }
+//
+// Setting a property while it's being animated retargets the running
+// animation, so that it continues from the value reached so far.
+type Interpolator struct {
+	obj   Object
+	props map[string]*interpolatedProperty
+}
+
+type interpolatedProperty struct {
+	duration time.Duration
+	easing   Easing
+	anim     *Animation
+}
+
+// NewInterpolator returns an Interpolator that sets properties of obj.
+func NewInterpolator(obj Object) *Interpolator {
+	return &Interpolator{obj: obj, props: make(map[string]*interpolatedProperty)}
+}
+
+// Smooth configures the named property to be animated over duration
+// following the easing curve whenever it is set via the interpolator.
+// A zero duration disables the animation.
+func (ip *Interpolator) Smooth(property string, duration time.Duration, easing Easing) {
+	gui(func() {
+		prop := ip.props[property]
+		if prop == nil {
+			prop = &interpolatedProperty{}
+			ip.props[property] = prop
+		}
+		prop.duration = duration
+		prop.easing = easing
+		if prop.anim != nil {
+			prop.anim.Stop()
+			prop.anim.Destroy()
+			prop.anim = nil
+		}
+	})
+}
+
+// Set sets the named property of the object to value, animating the
+// change if the property was configured with Smooth.
+func (ip *Interpolator) Set(property string, value interface{}) error {
+	var err error
+	gui(func() {
+		prop := ip.props[property]
+		switch {
+		case prop == nil || prop.duration <= 0:
+			err = ip.obj.Set(property, value)
+		case prop.anim == nil:
+			prop.anim, err = Animate(ip.obj, property, nil, value, prop.duration, prop.easing)
+		default:
+			var cto C.DataValue
+			packDataValue(value, &cto, prop.anim.Common().engine, cppOwner)
+			C.animationRetarget(prop.anim.Common().addr, &cto)
+		}
+	})
+	return err
+}
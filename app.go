@@ -0,0 +1,38 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+// ApplicationState holds the state of the application as a whole,
+// as reported by the platform.
+type ApplicationState int
+
+const (
+	ApplicationSuspended ApplicationState = 0x0 // About to be suspended (mobile).
+	ApplicationHidden    ApplicationState = 0x1 // Running in the background.
+	ApplicationInactive  ApplicationState = 0x2 // Visible but not in front.
+	ApplicationActive    ApplicationState = 0x4 // Visible and in front.
+)
+
+var appStateHandlers []func(state ApplicationState)
+
+// OnApplicationStateChanged arranges for f to be called whenever the
+// application state changes, such as when a mobile application is sent
+// to the background or brought back to the foreground. As with signal
+// handlers, f is run within the main GUI thread.
+func OnApplicationStateChanged(f func(state ApplicationState)) {
+	gui(func() {
+		if appStateHandlers == nil {
+			C.applicationConnectState()
+		}
+		appStateHandlers = append(appStateHandlers, f)
+	})
+}
+
+//export hookApplicationStateChanged
+func hookApplicationStateChanged(state C.int) {
+	for _, f := range appStateHandlers {
+		f(ApplicationState(state))
+	}
+}
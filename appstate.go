@@ -0,0 +1,101 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+// AppState is the Go value behind the AppState QML singleton registered
+// by RegisterAppState. Its fields are kept up-to-date by the package,
+// and must not be modified.
+type AppState struct {
+	// State holds the current ApplicationState.
+	State int
+
+	// Active holds whether the application is visible and in front.
+	Active bool
+
+	// FocusWindow holds the window that has the keyboard focus, or nil
+	// if no window of the application has it.
+	FocusWindow Object
+
+	// ClipboardText holds the text currently in the clipboard.
+	ClipboardText string
+
+	// ScreenCount holds the number of screens connected.
+	ScreenCount int
+}
+
+var appState *AppState
+
+// RegisterAppState registers the AppState singleton for use by QML code,
+// which offers the state of the application as bindable properties. The
+// singleton is available under the provided location and major.minor
+// version numbers, as with RegisterTypes.
+//
+// For example, after registering it under "GoExtensions" 1.0:
+//
+//     import GoExtensions 1.0
+//
+//     Rectangle {
+//         opacity: AppState.active ? 1.0 : 0.5
+//         Connections {
+//             target: AppState
+//             onClipboardTextChanged: pasteButton.enabled = AppState.clipboardText != ""
+//         }
+//     }
+//
+func RegisterAppState(location string, major, minor int) {
+	RegisterTypes(location, major, minor, []TypeSpec{{
+		Name:      "AppState",
+		Singleton: true,
+		New:       newAppState,
+	}})
+}
+
+// newAppState returns the AppState value, initializing it and starting
+// to track changes on the first call. It must be run from the main GUI
+// thread.
+func newAppState() interface{} {
+	if appState != nil {
+		return appState
+	}
+	appState = &AppState{}
+	appState.setState(ApplicationState(C.applicationState()))
+	appState.FocusWindow = focusWindow()
+	appState.ClipboardText = cstringResult(C.applicationClipboardText())
+	appState.ScreenCount = int(C.screenCount())
+	OnApplicationStateChanged(appState.setState)
+	C.applicationConnectAppState()
+	return appState
+}
+
+func focusWindow() Object {
+	addr := C.applicationFocusWindow()
+	if addr == nilPtr {
+		return nil
+	}
+	return &Common{addr: addr}
+}
+
+func (s *AppState) setState(state ApplicationState) {
+	s.State = int(state)
+	s.Active = state == ApplicationActive
+	Changed(s, &s.State)
+	Changed(s, &s.Active)
+}
+
+//export hookAppStateChanged
+func hookAppStateChanged(what C.int) {
+	s := appState
+	switch what {
+	case C.AppStateFocusWindow:
+		s.FocusWindow = focusWindow()
+		Changed(s, &s.FocusWindow)
+	case C.AppStateClipboard:
+		s.ClipboardText = cstringResult(C.applicationClipboardText())
+		Changed(s, &s.ClipboardText)
+	case C.AppStateScreens:
+		s.ScreenCount = int(C.screenCount())
+		Changed(s, &s.ScreenCount)
+	}
+}
@@ -0,0 +1,198 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"io"
+	"reflect"
+	"unsafe"
+)
+
+// AudioFormat describes the layout of raw PCM audio samples.
+// Samples are little-endian, and signed unless SampleSize is 8.
+type AudioFormat struct {
+	SampleRate   int // Samples per second, such as 44100.
+	ChannelCount int // Number of interleaved channels, such as 2 for stereo.
+	SampleSize   int // Bits per sample: 8, 16, or 32.
+}
+
+// audioStream buffers PCM data between a goroutine performing the
+// actual Go I/O and the Qt audio device, which runs in the main GUI
+// thread and must never block on it.
+type audioStream struct {
+	chunks  chan []byte
+	pending []byte
+	done    chan struct{}
+}
+
+const audioChunkSize = 4096
+
+var audioStreams = make(map[*audioStream]bool)
+
+func newAudioStream() *audioStream {
+	return &audioStream{
+		chunks: make(chan []byte, 16),
+		done:   make(chan struct{}),
+	}
+}
+
+// AudioOutput plays PCM samples on the default audio output device,
+// through the same multimedia stack used by QML. Audio is only played
+// when the package is built with the qmlmultimedia build tag, which
+// requires the Qt Multimedia module.
+type AudioOutput struct {
+	addr   unsafe.Pointer
+	stream *audioStream
+}
+
+// NewAudioOutput starts playing the PCM samples read from r on the
+// default audio output device, with the provided format. Playback
+// continues until r returns an error or Stop is called.
+//
+// Reading from r is done on a separate goroutine, so r may block while
+// waiting for more samples. If samples are not available in time, the
+// output is silent until they are.
+func NewAudioOutput(format AudioFormat, r io.Reader) *AudioOutput {
+	output := &AudioOutput{stream: newAudioStream()}
+	go output.stream.readFrom(r)
+	gui(func() {
+		audioStreams[output.stream] = true
+		output.addr = C.newAudioOutput(C.int(format.SampleRate), C.int(format.ChannelCount), C.int(format.SampleSize), unsafe.Pointer(output.stream))
+	})
+	return output
+}
+
+// Stop interrupts playback and releases the audio device.
+//
+// It is safe to call Stop more than once.
+func (output *AudioOutput) Stop() {
+	gui(func() {
+		if audioStreams[output.stream] {
+			C.delAudioOutput(output.addr)
+			output.addr = nilPtr
+			close(output.stream.done)
+			delete(audioStreams, output.stream)
+		}
+	})
+}
+
+func (stream *audioStream) readFrom(r io.Reader) {
+	for {
+		buf := make([]byte, audioChunkSize)
+		n, err := r.Read(buf)
+		if n > 0 {
+			select {
+			case stream.chunks <- buf[:n]:
+			case <-stream.done:
+				return
+			}
+		}
+		if err != nil {
+			close(stream.chunks)
+			return
+		}
+	}
+}
+
+// AudioInput records PCM samples from the default audio input device,
+// through the same multimedia stack used by QML. As with AudioOutput,
+// nothing is recorded unless the package is built with the qmlmultimedia
+// build tag.
+type AudioInput struct {
+	addr   unsafe.Pointer
+	stream *audioStream
+}
+
+// NewAudioInput starts recording PCM samples from the default audio
+// input device with the provided format, and writes them to w until
+// Stop is called or w returns an error.
+//
+// Writing to w is done on a separate goroutine. If w falls too far
+// behind the device, the excess samples are dropped.
+func NewAudioInput(format AudioFormat, w io.Writer) *AudioInput {
+	input := &AudioInput{stream: newAudioStream()}
+	go input.stream.writeTo(w)
+	gui(func() {
+		audioStreams[input.stream] = true
+		input.addr = C.newAudioInput(C.int(format.SampleRate), C.int(format.ChannelCount), C.int(format.SampleSize), unsafe.Pointer(input.stream))
+	})
+	return input
+}
+
+// Stop interrupts recording and releases the audio device.
+//
+// It is safe to call Stop more than once.
+func (input *AudioInput) Stop() {
+	gui(func() {
+		if audioStreams[input.stream] {
+			C.delAudioInput(input.addr)
+			input.addr = nilPtr
+			close(input.stream.chunks)
+			delete(audioStreams, input.stream)
+		}
+	})
+}
+
+func (stream *audioStream) writeTo(w io.Writer) {
+	for chunk := range stream.chunks {
+		if _, err := w.Write(chunk); err != nil {
+			break
+		}
+	}
+	// Keep draining so the audio device never blocks.
+	for _ = range stream.chunks {
+	}
+}
+
+// cbytes returns a Go slice backed by C data. The slice must not be
+// used after the C data is released.
+func cbytes(data *C.char, size C.int) []byte {
+	var b []byte
+	bh := (*reflect.SliceHeader)(unsafe.Pointer(&b))
+	bh.Data = uintptr(unsafe.Pointer(data))
+	bh.Len = int(size)
+	bh.Cap = int(size)
+	return b
+}
+
+//export hookAudioRead
+func hookAudioRead(streamp unsafe.Pointer, data *C.char, maxLen C.int) C.int {
+	stream := (*audioStream)(streamp)
+	buf := cbytes(data, maxLen)
+	n := 0
+	for n < len(buf) {
+		if len(stream.pending) == 0 {
+			select {
+			case chunk, ok := <-stream.chunks:
+				if !ok {
+					if n == 0 {
+						return -1
+					}
+					return C.int(n)
+				}
+				stream.pending = chunk
+			default:
+				return C.int(n)
+			}
+		}
+		copied := copy(buf[n:], stream.pending)
+		stream.pending = stream.pending[copied:]
+		n += copied
+	}
+	return C.int(n)
+}
+
+//export hookAudioWrite
+func hookAudioWrite(streamp unsafe.Pointer, data *C.char, length C.int) C.int {
+	stream := (*audioStream)(streamp)
+	chunk := make([]byte, int(length))
+	copy(chunk, cbytes(data, length))
+	select {
+	case stream.chunks <- chunk:
+	default:
+		// The writer is too slow. Drop the samples.
+	}
+	return length
+}
@@ -0,0 +1,94 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"reflect"
+)
+
+// Dependency identifies a property of a QML object that a binding
+// created with Bind depends upon.
+type Dependency struct {
+	Object   Object
+	Property string
+}
+
+// Binding is the Go value evaluating a binding created with Bind.
+type Binding struct {
+	// Revision is incremented by Update to force the re-evaluation
+	// of the binding.
+	Revision int
+
+	f    reflect.Value
+	deps []Dependency
+}
+
+// Bind installs a binding on the named property of obj that assigns to
+// it the result of f, which must be a function with no parameters and
+// a single result. Just like bindings declared in QML, the binding is
+// re-evaluated whenever any of the dependency properties change, and is
+// removed when the property is assigned a value.
+//
+// For example:
+//
+//     qml.Bind(sidebar, "width", func() float64 {
+//         return math.Max(200, window.Float64("width")/4)
+//     }, qml.Dependency{window, "width"})
+//
+// Changes to Go state that f depends upon may be reported by calling
+// Update on the returned binding. The function is run within the main
+// GUI thread.
+func Bind(obj Object, property string, f interface{}, deps ...Dependency) (*Binding, error) {
+	fv := reflect.ValueOf(f)
+	if fv.Kind() != reflect.Func || fv.Type().NumIn() != 0 || fv.Type().NumOut() != 1 {
+		panic("Bind must be given a function with no parameters and a single result")
+	}
+	b := &Binding{f: fv, deps: deps}
+	common := obj.Common()
+	cproperty, cpropertyLen := unsafeStringData(property)
+	var cerr *C.error
+	gui(func() {
+		var dvalue C.DataValue
+		packDataValue(b, &dvalue, common.engine, jsOwner)
+		cerr = C.objectBind(common.engine.addr, common.addr, cproperty, cpropertyLen, &dvalue)
+	})
+	if cerr != nil {
+		return nil, cerror(cerr)
+	}
+	return b, nil
+}
+
+// Update forces the binding to be re-evaluated, for when Go state that
+// the binding function depends upon has changed.
+func (b *Binding) Update() {
+	gui(func() {
+		b.Revision++
+		Changed(b, &b.Revision)
+	})
+}
+
+// Evaluate returns the result of the binding function.
+// It is called by the QML binding itself.
+func (b *Binding) Evaluate() interface{} {
+	return b.f.Call(nil)[0].Interface()
+}
+
+// DepCount returns the number of dependencies of the binding.
+// It is called by the QML binding itself.
+func (b *Binding) DepCount() int {
+	return len(b.deps)
+}
+
+// Dep returns the object of the dependency at index i.
+// It is called by the QML binding itself.
+func (b *Binding) Dep(i int) Object {
+	return b.deps[i].Object
+}
+
+// DepProperty returns the property name of the dependency at index i.
+// It is called by the QML binding itself.
+func (b *Binding) DepProperty(i int) string {
+	return b.deps[i].Property
+}
@@ -16,6 +16,7 @@ package qml
 import "C"
 
 import (
+	"context"
 	"fmt"
 	"github.com/niemeyer/qml/tref"
 	"reflect"
@@ -29,12 +30,16 @@ import (
 var hookWaiting C.int
 
 // guiLoop runs the main GUI thread event loop in C++ land.
-func guiLoop() {
+func guiLoop(options *InitOptions) {
 	runtime.LockOSThread()
-	guiLoopRef = tref.Ref()
+	atomic.StoreUintptr(&guiLoopRef, tref.Ref())
 	C.newGuiApplication()
+	if options.DebugPort > 0 {
+		C.applicationEnableDebugger(C.int(options.DebugPort))
+	}
 	C.idleTimerInit(&hookWaiting)
 	guiLoopReady.Unlock()
+	defer handleGuiPanic()
 	C.applicationExec()
 }
 
@@ -43,12 +48,23 @@ var (
 	guiDone      = make(chan struct{})
 	guiLock      = 0
 	guiLoopReady sync.Mutex
-	guiLoopRef   uintptr
+
+	// guiLoopRef identifies the thread running the GUI loop. It's set
+	// once and then only atomically loaded, so that checking whether
+	// the current code is already running within the GUI thread, as
+	// is the case for callbacks, takes no locks.
+	guiLoopRef uintptr
 )
 
+// onGuiThread returns whether the current code is running within
+// the main GUI thread.
+func onGuiThread() bool {
+	return tref.Ref() == atomic.LoadUintptr(&guiLoopRef)
+}
+
 // gui runs f in the main GUI thread and waits for f to return.
 func gui(f func()) {
-	if tref.Ref() == guiLoopRef {
+	if onGuiThread() {
 		// Already within the GUI thread. Attempting to wait would deadlock.
 		f()
 		return
@@ -125,7 +141,15 @@ func Changed(value, fieldAddr interface{}) {
 	if !(0 <= offset && offset < valuev.Type().Size()) {
 		panic("provided field is not a member of the given value")
 	}
+	if coalesceChange(value, offset) {
+		return
+	}
+	activateChanged(value, offset)
+}
 
+// activateChanged notifies the QML bindings of all values wrapping value
+// that the field at offset has changed.
+func activateChanged(value interface{}, offset uintptr) {
 	gui(func() {
 		tinfo := typeInfo(value)
 		for _, engine := range engines {
@@ -177,6 +201,7 @@ type valueFold struct {
 	prev   *valueFold
 	next   *valueFold
 	owner  valueOwner
+	shim   *typeShim
 }
 
 type valueOwner uint8
@@ -191,6 +216,7 @@ const (
 //
 // This must be run from the main GUI thread.
 func wrapGoValue(engine *Engine, gvalue interface{}, owner valueOwner) (cvalue unsafe.Pointer) {
+	assertGui("wrapGoValue")
 	gvaluev := reflect.ValueOf(gvalue)
 	gvaluek := gvaluev.Kind()
 	if gvaluek == reflect.Struct && !hashable(gvalue) {
@@ -208,6 +234,9 @@ func wrapGoValue(engine *Engine, gvalue interface{}, owner valueOwner) (cvalue u
 	if ok && (prev.owner == owner || owner != cppOwner) {
 		return prev.cvalue
 	}
+	if err := checkStrict(gvalue, engine); err != nil {
+		panic(err.Error())
+	}
 
 	parent := nilPtr
 	if owner == cppOwner {
@@ -217,8 +246,10 @@ func wrapGoValue(engine *Engine, gvalue interface{}, owner valueOwner) (cvalue u
 		engine: engine,
 		gvalue: gvalue,
 		owner:  owner,
+		shim:   shimFor(gvalue),
 	}
 	fold.cvalue = C.newGoValue(unsafe.Pointer(fold), typeInfo(gvalue), parent)
+	nameObject(fold.cvalue, gvalue)
 	if prev != nil {
 		prev.next = fold
 		fold.prev = prev
@@ -251,16 +282,26 @@ var typeNew = make(map[*valueFold]bool)
 
 //export hookGoValueTypeNew
 func hookGoValueTypeNew(cvalue unsafe.Pointer, specp unsafe.Pointer) (foldp unsafe.Pointer) {
+	gvalue := (*TypeSpec)(specp).New()
 	fold := &valueFold{
-		gvalue: (*TypeSpec)(specp).New(),
+		gvalue: gvalue,
 		cvalue: cvalue,
 		owner:  jsOwner,
+		shim:   shimFor(gvalue),
 	}
 	typeNew[fold] = true
 	stats.valuesAlive(+1)
 	return unsafe.Pointer(fold)
 }
 
+// hookGoValueTypeCreated is called once the object wrapping a value
+// created by a registered type is fully constructed.
+//
+//export hookGoValueTypeCreated
+func hookGoValueTypeCreated(cvalue unsafe.Pointer, foldp unsafe.Pointer) {
+	nameObject(cvalue, (*valueFold)(foldp).gvalue)
+}
+
 //export hookGoValueDestroyed
 func hookGoValueDestroyed(enginep unsafe.Pointer, foldp unsafe.Pointer) {
 	fold := (*valueFold)(foldp)
@@ -271,6 +312,7 @@ func hookGoValueDestroyed(enginep unsafe.Pointer, foldp unsafe.Pointer) {
 		if len(typeNew) == before {
 			panic("destroying value without an associated engine; who created the value?")
 		}
+		releaseChangeCoalescing(fold.gvalue)
 	} else if engines[engine.addr] == nil {
 		// Must never do that. The engine holds memory references that C++ depends on.
 		panic(fmt.Sprintf("engine %p was released from global list while its values were still alive", engine.addr))
@@ -294,8 +336,9 @@ func hookGoValueDestroyed(enginep unsafe.Pointer, foldp unsafe.Pointer) {
 			if len(engine.values) == before {
 				panic("destroying value that knows about the engine, but the engine doesn't know about the value; who cleared the engine?")
 			}
+			releaseChangeCoalescing(fold.gvalue)
 			if engine.destroyed && len(engine.values) == 0 {
-				delete(engines, engine.addr)
+				releaseEngine(engine)
 			}
 		}
 	}
@@ -305,6 +348,12 @@ func hookGoValueDestroyed(enginep unsafe.Pointer, foldp unsafe.Pointer) {
 //export hookGoValueReadField
 func hookGoValueReadField(enginep, foldp unsafe.Pointer, reflectIndex C.int, resultdv *C.DataValue) {
 	fold := ensureEngine(enginep, foldp)
+	if fold.shim != nil {
+		if get := fold.shim.getters[reflectIndex]; get != nil {
+			packDataValue(get(fold.gvalue), resultdv, fold.engine, jsOwner)
+			return
+		}
+	}
 	v := reflect.ValueOf(fold.gvalue)
 	for v.Type().Kind() == reflect.Ptr {
 		v = v.Elem()
@@ -316,7 +365,7 @@ func hookGoValueReadField(enginep, foldp unsafe.Pointer, reflectIndex C.int, res
 		field = field.Elem()
 		fieldk = field.Kind()
 	}
-	if fieldk == reflect.Slice || fieldk == reflect.Struct && field.Type() != typeRGBA {
+	if fieldk == reflect.Slice && field.Type() == typeObjSlice || fieldk == reflect.Struct && !packsByValue(field.Type()) {
 		if field.CanAddr() {
 			field = field.Addr()
 		} else if !hashable(field.Interface()) {
@@ -327,6 +376,9 @@ func hookGoValueReadField(enginep, foldp unsafe.Pointer, reflectIndex C.int, res
 	if field.IsValid() {
 		gvalue = field.Interface()
 	}
+	if dec, ok := gvalue.(decimalValue); ok {
+		gvalue = formatDecimal(dec, parseDecimalTag(v.Type().Field(int(reflectIndex)).Tag))
+	}
 
 	// TODO Strings are being passed in an unsafe manner here. There is a
 	// small chance that the field is changed and the garbage collector is run
@@ -339,31 +391,62 @@ func hookGoValueReadField(enginep, foldp unsafe.Pointer, reflectIndex C.int, res
 //export hookGoValueWriteField
 func hookGoValueWriteField(enginep, foldp unsafe.Pointer, reflectIndex, onChangedIndex C.int, assigndv *C.DataValue) {
 	fold := ensureEngine(enginep, foldp)
+	assign := unpackDataValue(assigndv, fold.engine)
+	if fold.shim != nil {
+		if set := fold.shim.setters[reflectIndex]; set != nil {
+			set(fold.gvalue, assign)
+			if activeRecorder() != nil {
+				ve := reflect.ValueOf(fold.gvalue).Elem()
+				recordSet(fold.gvalue, ve.Type().Field(int(reflectIndex)).Name, ve.Field(int(reflectIndex)))
+			}
+			if onChangedIndex != -1 {
+				if m := fold.shim.methods[onChangedIndex]; m != nil {
+					m.Call(fold.gvalue, nil)
+				} else {
+					reflect.ValueOf(fold.gvalue).Method(int(onChangedIndex)).Call(nil)
+				}
+			}
+			return
+		}
+	}
 	v := reflect.ValueOf(fold.gvalue)
 	ve := v
 	for ve.Type().Kind() == reflect.Ptr {
 		ve = ve.Elem()
 	}
 	field := ve.Field(int(reflectIndex))
-	assign := unpackDataValue(assigndv, fold.engine)
 
 	// TODO Return false to the call site if it fails. That's how Qt seems to handle it internally.
-	convertAndSet(field, reflect.ValueOf(assign))
+	if !scanDecimal(field, assign) && !scanNullable(field, assign) && !unmarshalText(field, assign) {
+		convertAndSet(field, reflect.ValueOf(assign))
+	}
+	recordSet(fold.gvalue, ve.Type().Field(int(reflectIndex)).Name, field)
 
 	if onChangedIndex != -1 {
 		v.Method(int(onChangedIndex)).Call(nil)
 	}
 }
 
-var listType = reflect.TypeOf(&List{})
+var (
+	listType     = reflect.TypeOf(&List{})
+	typeJSObject = reflect.TypeOf(map[string]interface{}(nil))
+)
 
 func convertAndSet(to, from reflect.Value) {
+	if from.IsValid() && scanColor(to, from.Interface()) {
+		return
+	}
 	defer func() {
 		if v := recover(); v != nil {
 			// TODO This should be an error. Test and fix.
 			panic("FIXME attempted to set a field with the wrong type; this should be an error")
 		}
 	}()
+	if !from.IsValid() {
+		// Null, such as for a cleared date.
+		to.Set(reflect.Zero(to.Type()))
+		return
+	}
 	toType := to.Type()
 	fromType := from.Type()
 	if toType == fromType {
@@ -371,9 +454,22 @@ func convertAndSet(to, from reflect.Value) {
 	} else if fromType == listType && to.Kind() == reflect.Slice {
 		list := from.Interface().(*List)
 		to.Set(reflect.MakeSlice(toType, len(list.data), len(list.data)))
-		elemType := toType.Elem()
 		for i, elem := range list.data {
-			to.Index(i).Set(reflect.ValueOf(elem).Convert(elemType))
+			if elem == nil {
+				continue // Left as the zero value.
+			}
+			// Elements may be lists themselves, for nested slices.
+			convertAndSet(to.Index(i), reflect.ValueOf(elem))
+		}
+	} else if fromType == typeJSObject && to.Kind() == reflect.Map && toType.Key().Kind() == reflect.String {
+		m := from.Interface().(map[string]interface{})
+		to.Set(reflect.MakeMapWithSize(toType, len(m)))
+		for key, value := range m {
+			elem := reflect.New(toType.Elem()).Elem()
+			if value != nil {
+				convertAndSet(elem, reflect.ValueOf(value))
+			}
+			to.SetMapIndex(reflect.ValueOf(key).Convert(toType.Key()), elem)
 		}
 	} else {
 		to.Set(from.Convert(toType))
@@ -388,6 +484,24 @@ var (
 //export hookGoValueCallMethod
 func hookGoValueCallMethod(enginep, foldp unsafe.Pointer, reflectIndex C.int, args *C.DataValue) {
 	fold := ensureEngine(enginep, foldp)
+	if fold.shim != nil && workers[fold.gvalue] == nil {
+		if m := fold.shim.methods[reflectIndex]; m != nil {
+			var params [C.MaxParams]interface{}
+			for i := 0; i < m.NumIn; i++ {
+				paramdv := (*C.DataValue)(unsafe.Pointer(uintptr(unsafe.Pointer(args)) + uintptr(i+1)*dataValueSize))
+				params[i] = unpackDataValue(paramdv, fold.engine)
+			}
+			if activeRecorder() != nil {
+				args := make([]reflect.Value, m.NumIn)
+				for i := range args {
+					args[i] = reflect.ValueOf(params[i])
+				}
+				recordCall(fold.gvalue, m.Name, args)
+			}
+			packDataValue(m.Call(fold.gvalue, params[:m.NumIn]), args, fold.engine, jsOwner)
+			return
+		}
+	}
 	v := reflect.ValueOf(fold.gvalue)
 
 	// TODO Must assert that v is necessarily a pointer here, but we shouldn't have to manipulate
@@ -403,10 +517,27 @@ func hookGoValueCallMethod(enginep, foldp unsafe.Pointer, reflectIndex C.int, ar
 	var err error
 
 	numIn := methodt.NumIn()
-	for i := 0; i < numIn; i++ {
-		paramdv := (*C.DataValue)(unsafe.Pointer(uintptr(unsafe.Pointer(args)) + (uintptr(i)+1)*dataValueSize))
-		param := reflect.ValueOf(unpackDataValue(paramdv, fold.engine))
-		if argt := methodt.In(i); param.Type() != argt {
+	first := 0
+	var op *operation
+	if takesContext(methodt, 0) {
+		var ctx context.Context
+		op = &operation{}
+		ctx, op.cancel = context.WithCancel(context.Background())
+		params[0] = reflect.ValueOf(ctx)
+		first = 1
+	}
+	for i := first; i < numIn; i++ {
+		paramdv := (*C.DataValue)(unsafe.Pointer(uintptr(unsafe.Pointer(args)) + (uintptr(i-first)+1)*dataValueSize))
+		paramv := unpackDataValue(paramdv, fold.engine)
+		param := reflect.ValueOf(paramv)
+		if argt := methodt.In(i); !param.IsValid() && nillable(argt) {
+			params[i] = reflect.Zero(argt)
+			continue
+		} else if !param.IsValid() || param.Type() != argt {
+			if arg := reflect.New(argt).Elem(); scanNullable(arg, paramv) || unmarshalText(arg, paramv) || scanList(arg, paramv) || scanMap(arg, paramv) || scanColor(arg, paramv) {
+				params[i] = arg
+				continue
+			}
 			param, err = convertParam(methodName, i, param, argt)
 			if err != nil {
 				panic(err.Error())
@@ -415,9 +546,37 @@ func hookGoValueCallMethod(enginep, foldp unsafe.Pointer, reflectIndex C.int, ar
 		params[i] = param
 	}
 
+	recordCall(fold.gvalue, methodName, params[first:numIn])
+
+	if w := workers[fold.gvalue]; w != nil {
+		promise := w.call(method, params[:numIn])
+		if op != nil {
+			if promise != nil {
+				op.Result = promise
+			}
+			packDataValue(op, args, fold.engine, jsOwner)
+		} else if promise != nil {
+			packDataValue(promise, args, fold.engine, jsOwner)
+		}
+		return
+	}
+
 	result := method.Call(params[:numIn])
 
-	if len(result) == 1 {
+	if op != nil {
+		if len(result) > 1 {
+			panic("methods taking a context.Context must have at most one result")
+		}
+		if len(result) == 1 && isStreamChan(result[0]) {
+			op.Result = &Common{engine: fold.engine, addr: newStream(fold.engine, result[0])}
+		} else if len(result) == 1 {
+			op.Result = result[0].Interface()
+		}
+		packDataValue(op, args, fold.engine, jsOwner)
+	} else if len(result) == 1 && isStreamChan(result[0]) {
+		args.dataType = C.DTObject
+		*(*unsafe.Pointer)(unsafe.Pointer(&args.data)) = newStream(fold.engine, result[0])
+	} else if len(result) == 1 {
 		packDataValue(result[0].Interface(), args, fold.engine, jsOwner)
 	} else if len(result) > 1 {
 		if len(result) > len(dataValueArray) {
@@ -431,6 +590,16 @@ func hookGoValueCallMethod(enginep, foldp unsafe.Pointer, reflectIndex C.int, ar
 	}
 }
 
+// nillable returns whether nil from QML may be handed over as the zero
+// value of t.
+func nillable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return true
+	}
+	return false
+}
+
 func convertParam(methodName string, index int, param reflect.Value, argt reflect.Type) (newv reflect.Value, err error) {
 	defer func() {
 		if panicv := recover(); panicv != nil {
@@ -0,0 +1,164 @@
+package qml
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"sync"
+)
+
+// Event is a named event published on the event bus.
+type Event struct {
+	Name    string
+	Payload interface{}
+}
+
+// Decode assigns the event payload to the value pointed to by ptr,
+// converting it as done when QML logic sets a field of a Go value.
+// Payloads published by QML logic as JavaScript objects may be decoded
+// into a struct, in which case each property is assigned to the field
+// known to QML by the same name.
+func (e Event) Decode(ptr interface{}) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		panic(fmt.Sprintf("Event.Decode must be given a non-nil pointer, got %T", ptr))
+	}
+	v = v.Elem()
+	if m, ok := e.Payload.(map[string]interface{}); ok && v.Kind() == reflect.Struct {
+		if err := assignFields(v, m); err != nil {
+			return fmt.Errorf("cannot decode payload of event %q: %v", e.Name, err)
+		}
+		return nil
+	}
+	if err := assignValue(v, e.Payload); err != nil {
+		return fmt.Errorf("cannot decode payload of event %q: %v", e.Name, err)
+	}
+	return nil
+}
+
+// EventBus delivers events published by Go or QML logic to all
+// subscribers with a matching pattern, so that components may
+// communicate without holding references to each other.
+type EventBus struct {
+	mu     sync.Mutex
+	subs   []*busSubscription
+	lastId int
+}
+
+type busSubscription struct {
+	id      int
+	pattern string
+	f       func(e Event)
+}
+
+var bus = &EventBus{}
+
+// Bus returns the event bus of the application. QML logic may use it
+// after RegisterBus is called.
+func Bus() *EventBus {
+	return bus
+}
+
+// Publish delivers an event with the provided name and payload to all
+// subscribers with a matching pattern, and returns once they were all
+// called. Publish may be called from any goroutine.
+func (b *EventBus) Publish(name string, payload interface{}) {
+	e := Event{Name: name, Payload: payload}
+	b.mu.Lock()
+	var subs []*busSubscription
+	for _, sub := range b.subs {
+		if ok, _ := path.Match(sub.pattern, name); ok {
+			subs = append(subs, sub)
+		}
+	}
+	b.mu.Unlock()
+	gui(func() {
+		for _, sub := range subs {
+			sub.f(e)
+		}
+	})
+}
+
+// Subscribe arranges for f to be called with every event published with
+// a name matching pattern, and returns a function that cancels the
+// subscription. Patterns have the syntax of path.Match, so "user.*"
+// matches both "user.login" and "user.logout".
+// As with all signal handlers, f is run within the main GUI thread.
+func (b *EventBus) Subscribe(pattern string, f func(e Event)) (cancel func()) {
+	id := b.subscribe(pattern, f)
+	return func() { b.unsubscribe(id) }
+}
+
+func (b *EventBus) subscribe(pattern string, f func(e Event)) int {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic(fmt.Sprintf("invalid event pattern %q", pattern))
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastId++
+	b.subs = append(b.subs, &busSubscription{id: b.lastId, pattern: pattern, f: f})
+	return b.lastId
+}
+
+func (b *EventBus) unsubscribe(id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, sub := range b.subs {
+		if sub.id == id {
+			b.subs = append(b.subs[:i], b.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// busSingleton is the Go value behind the Bus QML singleton
+// registered by RegisterBus.
+type busSingleton struct {
+	bus *EventBus
+}
+
+var busValue = &busSingleton{bus}
+
+// Publish publishes an event on the bus.
+// It is called by QML logic.
+func (s *busSingleton) Publish(name string, payload interface{}) {
+	s.bus.Publish(name, payload)
+}
+
+// Subscribe subscribes f to events with a name matching pattern, and
+// returns the subscription identifier for Unsubscribe.
+// It is called by QML logic.
+func (s *busSingleton) Subscribe(pattern string, f *Func) int {
+	return s.bus.subscribe(pattern, func(e Event) { f.Call(e.Payload, e.Name) })
+}
+
+// Unsubscribe cancels the subscription with the provided identifier.
+// It is called by QML logic.
+func (s *busSingleton) Unsubscribe(id int) {
+	s.bus.unsubscribe(id)
+}
+
+// RegisterBus registers the Bus singleton for use by QML code, which
+// publishes events on the application event bus and subscribes to them.
+// The singleton is available under the provided location and major.minor
+// version numbers, as with RegisterTypes.
+//
+// For example, after registering it under "GoExtensions" 1.0:
+//
+//     import GoExtensions 1.0
+//
+//     Item {
+//         property int subscription
+//         Component.onCompleted: subscription = Bus.subscribe("user.*", function(payload, name) { ... })
+//         Component.onDestruction: Bus.unsubscribe(subscription)
+//         MouseArea { onClicked: Bus.publish("user.selected", {id: 42}) }
+//     }
+//
+// Subscribers in QML are called with the payload and the event name.
+func RegisterBus(location string, major, minor int) {
+	RegisterTypes(location, major, minor, []TypeSpec{{
+		Name:      "Bus",
+		Singleton: true,
+		New:       func() interface{} { return busValue },
+	}})
+}
@@ -0,0 +1,98 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"unsafe"
+)
+
+// Series offers efficient bulk updates of the points held by a QtCharts
+// XY series (LineSeries, SplineSeries, ScatterSeries, etc), or by any
+// plotting item that exposes equivalent append(x, y) and clear() methods.
+//
+// Each update crosses into C++ a single time and reads the provided
+// slices directly. When the package is built with the qmlcharts tag,
+// which links against the Qt Charts module, QtCharts series receive all
+// the points of an update at once, so large batches of points are cheap
+// to transfer. Otherwise, and for plotting items implemented in QML,
+// the points are handed to the append method one at a time.
+type Series struct {
+	Object
+}
+
+// NewSeries returns a Series that updates obj.
+func NewSeries(obj Object) *Series {
+	return &Series{obj}
+}
+
+// Append appends the points with coordinates xs[i], ys[i] to the series.
+// Append panics if xs and ys have different lengths.
+func (s *Series) Append(xs, ys []float64) {
+	if len(xs) != len(ys) {
+		panic("series coordinate slices have different lengths")
+	}
+	if len(xs) > 0 {
+		s.update(unsafe.Pointer(&xs[0]), unsafe.Pointer(&ys[0]), len(xs), false, false)
+	}
+}
+
+// AppendFloat32 works like Append, but takes float32 coordinates
+// without converting them into float64 values first.
+func (s *Series) AppendFloat32(xs, ys []float32) {
+	if len(xs) != len(ys) {
+		panic("series coordinate slices have different lengths")
+	}
+	if len(xs) > 0 {
+		s.update(unsafe.Pointer(&xs[0]), unsafe.Pointer(&ys[0]), len(xs), true, false)
+	}
+}
+
+// Replace replaces all the points in the series by the points with
+// coordinates xs[i], ys[i].
+// Replace panics if xs and ys have different lengths.
+func (s *Series) Replace(xs, ys []float64) {
+	if len(xs) != len(ys) {
+		panic("series coordinate slices have different lengths")
+	}
+	if len(xs) == 0 {
+		s.Clear()
+	} else {
+		s.update(unsafe.Pointer(&xs[0]), unsafe.Pointer(&ys[0]), len(xs), false, true)
+	}
+}
+
+// ReplaceFloat32 works like Replace, but takes float32 coordinates
+// without converting them into float64 values first.
+func (s *Series) ReplaceFloat32(xs, ys []float32) {
+	if len(xs) != len(ys) {
+		panic("series coordinate slices have different lengths")
+	}
+	if len(xs) == 0 {
+		s.Clear()
+	} else {
+		s.update(unsafe.Pointer(&xs[0]), unsafe.Pointer(&ys[0]), len(xs), true, true)
+	}
+}
+
+// Clear removes all the points from the series.
+func (s *Series) Clear() {
+	s.Call("clear")
+}
+
+func (s *Series) update(xs, ys unsafe.Pointer, n int, isFloat32, replace bool) {
+	cfloat32 := C.int(0)
+	if isFloat32 {
+		cfloat32 = 1
+	}
+	creplace := C.int(0)
+	if replace {
+		creplace = 1
+	}
+	var cerr *C.error
+	gui(func() {
+		cerr = C.seriesAppend(s.Common().addr, xs, ys, C.int(n), cfloat32, creplace)
+	})
+	cmust(cerr)
+}
@@ -0,0 +1,7 @@
+// +build qmlcharts
+
+package qml
+
+// #cgo pkg-config: Qt5Charts
+//
+import "C"
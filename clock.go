@@ -0,0 +1,39 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"time"
+)
+
+var fakeClock bool
+
+// UseFakeClock replaces the clock driving QML timers and animations with
+// a fake one that only advances when AdvanceFakeClock is called, so that
+// tests may verify time-dependent logic, such as animations and debounced
+// input, deterministically and without real sleeps. Calling UseFakeClock
+// with enabled set to false restores the real clock.
+//
+// Tests usually rely on the qmltest package rather than calling this
+// function directly.
+func UseFakeClock(enabled bool) {
+	gui(func() {
+		C.useFakeClock(cbool(enabled))
+		fakeClock = enabled
+	})
+}
+
+// AdvanceFakeClock advances the fake clock installed by UseFakeClock by d,
+// running any QML timers and animation frames that are due meanwhile,
+// with millisecond resolution. Time advances in steps of about one frame,
+// so repeating timers fire once per interval elapsed.
+func AdvanceFakeClock(d time.Duration) {
+	gui(func() {
+		if !fakeClock {
+			panic("AdvanceFakeClock called without UseFakeClock")
+		}
+		C.advanceFakeClock(C.int64_t(d / time.Millisecond))
+	})
+}
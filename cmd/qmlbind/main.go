@@ -0,0 +1,299 @@
+// The qmlbind command generates static binding shims for Go types that
+// are handed to QML logic, so that reading and writing their fields and
+// calling their methods from QML does not go through reflection.
+//
+// It is meant to be run via go generate. For example:
+//
+//     //go:generate qmlbind -type Person,Account
+//
+// The shims are written into qmlbind.go in the package directory, and
+// are registered with the qml package on initialization. Fields and
+// methods that cannot be handed to QML at all, such as channel fields or
+// variadic methods, are reported as errors. Members with types that
+// the shims do not cover continue to work via reflection.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of struct type names; required")
+	output    = flag.String("output", "qmlbind.go", "name of the output file within the package directory")
+)
+
+// maxParams must match MaxParams in the qml package.
+const maxParams = 10
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: qmlbind -type T[,T...] [-output file] [directory]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *typeNames == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	if err := run(dir, strings.Split(*typeNames, ",")); err != nil {
+		fmt.Fprintf(os.Stderr, "qmlbind: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, names []string) error {
+	fset := token.NewFileSet()
+	filter := func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != *output
+	}
+	pkgs, err := parser.ParseDir(fset, dir, filter, 0)
+	if err != nil {
+		return err
+	}
+	if len(pkgs) != 1 {
+		return fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+	var pkg *ast.Package
+	for _, p := range pkgs {
+		pkg = p
+	}
+
+	g := &generator{fset: fset}
+	for _, name := range names {
+		st, methods := lookupType(pkg, name)
+		if st == nil {
+			return fmt.Errorf("cannot find struct type %s in %s", name, dir)
+		}
+		g.shim(name, st, methods)
+	}
+	if len(g.errors) > 0 {
+		return fmt.Errorf("cannot generate shims:\n\t%s", strings.Join(g.errors, "\n\t"))
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by qmlbind; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg.Name)
+	fmt.Fprintf(&buf, "import \"github.com/niemeyer/qml\"\n\n")
+	fmt.Fprintf(&buf, "func init() {\n%s}\n", g.buf.String())
+	buf.WriteString(helpers)
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("internal error formatting the generated code: %v", err)
+	}
+	return ioutil.WriteFile(filepath.Join(dir, *output), src, 0644)
+}
+
+// lookupType returns the struct type with the given name in pkg and
+// the methods declared with it as the receiver.
+func lookupType(pkg *ast.Package, name string) (*ast.StructType, []*ast.FuncDecl) {
+	var st *ast.StructType
+	var methods []*ast.FuncDecl
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == name {
+						st, _ = ts.Type.(*ast.StructType)
+					}
+				}
+			case *ast.FuncDecl:
+				if decl.Recv != nil && decl.Name.IsExported() && receiverName(decl) == name {
+					methods = append(methods, decl)
+				}
+			}
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name.Name < methods[j].Name.Name })
+	return st, methods
+}
+
+func receiverName(decl *ast.FuncDecl) string {
+	expr := decl.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+type generator struct {
+	fset   *token.FileSet
+	buf    bytes.Buffer
+	errors []string
+}
+
+func (g *generator) errorf(pos token.Pos, format string, args ...interface{}) {
+	g.errors = append(g.errors, g.fset.Position(pos).String()+": "+fmt.Sprintf(format, args...))
+}
+
+func (g *generator) shim(name string, st *ast.StructType, methods []*ast.FuncDecl) {
+	fmt.Fprintf(&g.buf, "qml.RegisterShim(&%s{}, &qml.TypeShim{\n", name)
+
+	fmt.Fprintf(&g.buf, "Fields: []qml.FieldShim{\n")
+	for _, field := range st.Fields.List {
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			switch ftype := field.Type.(type) {
+			case *ast.MapType:
+				if key, ok := ftype.Key.(*ast.Ident); ok && key.Name == "string" {
+					break // Handed over as a JavaScript object via reflection.
+				}
+				g.errorf(ident.Pos(), "field %s.%s has a map type without string keys, which cannot be handed to QML", name, ident.Name)
+				continue
+			case *ast.ChanType, *ast.FuncType:
+				g.errorf(ident.Pos(), "field %s.%s has a %s type, which cannot be handed to QML", name, ident.Name, kindName(field.Type))
+				continue
+			}
+			conv := conversion(field.Type, "a")
+			if conv == "" {
+				continue // Handled via reflection.
+			}
+			fmt.Fprintf(&g.buf, "{Name: %q,\n", ident.Name)
+			fmt.Fprintf(&g.buf, "Get: func(v interface{}) interface{} { return v.(*%s).%s },\n", name, ident.Name)
+			fmt.Fprintf(&g.buf, "Set: func(v, a interface{}) { v.(*%s).%s = %s }},\n", name, ident.Name, conv)
+		}
+	}
+	fmt.Fprintf(&g.buf, "},\n")
+
+	fmt.Fprintf(&g.buf, "Methods: []qml.MethodShim{\n")
+	for _, method := range methods {
+		mname := method.Name.Name
+		var args []string
+		supported := true
+		for _, param := range method.Type.Params.List {
+			if _, ok := param.Type.(*ast.Ellipsis); ok {
+				g.errorf(method.Pos(), "method %s.%s is variadic, which is not supported by QML", name, mname)
+				supported = false
+				break
+			}
+			n := len(param.Names)
+			if n == 0 {
+				n = 1
+			}
+			for i := 0; i < n; i++ {
+				conv := conversion(param.Type, fmt.Sprintf("a[%d]", len(args)))
+				if conv == "" {
+					supported = false
+				}
+				args = append(args, conv)
+			}
+		}
+		if len(args) > maxParams {
+			g.errorf(method.Pos(), "method %s.%s has more than %d parameters", name, mname, maxParams)
+			continue
+		}
+		results := 0
+		if method.Type.Results != nil {
+			for _, result := range method.Type.Results.List {
+				if n := len(result.Names); n > 1 {
+					results += n
+				} else {
+					results++
+				}
+				if conversion(result.Type, "") == "" {
+					supported = false
+				}
+			}
+		}
+		if !supported || results > 1 {
+			continue // Handled via reflection.
+		}
+		call := fmt.Sprintf("v.(*%s).%s(%s)", name, mname, strings.Join(args, ", "))
+		fmt.Fprintf(&g.buf, "{Name: %q, NumIn: %d,\n", mname, len(args))
+		if results == 1 {
+			fmt.Fprintf(&g.buf, "Call: func(v interface{}, a []interface{}) interface{} { return %s }},\n", call)
+		} else {
+			fmt.Fprintf(&g.buf, "Call: func(v interface{}, a []interface{}) interface{} { %s; return nil }},\n", call)
+		}
+	}
+	fmt.Fprintf(&g.buf, "},\n")
+
+	fmt.Fprintf(&g.buf, "})\n")
+}
+
+// conversion returns the expression converting the value unpacked from
+// QML in expr into typ, or the empty string if typ is not covered by
+// the shims.
+func conversion(typ ast.Expr, expr string) string {
+	ident, ok := typ.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	switch ident.Name {
+	case "string", "bool":
+		return fmt.Sprintf("%s.(%s)", expr, ident.Name)
+	case "int64":
+		return fmt.Sprintf("qmlbindInt64(%s)", expr)
+	case "int", "int32":
+		return fmt.Sprintf("%s(qmlbindInt64(%s))", ident.Name, expr)
+	case "float64":
+		return fmt.Sprintf("qmlbindFloat64(%s)", expr)
+	case "float32":
+		return fmt.Sprintf("float32(qmlbindFloat64(%s))", expr)
+	}
+	return ""
+}
+
+func kindName(typ ast.Expr) string {
+	switch typ.(type) {
+	case *ast.ChanType:
+		return "channel"
+	case *ast.FuncType:
+		return "function"
+	}
+	return "unsupported"
+}
+
+const helpers = `
+func qmlbindInt64(v interface{}) int64 {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case float64:
+		return int64(v)
+	case float32:
+		return int64(v)
+	}
+	panic("qmlbind: value is not a number")
+}
+
+func qmlbindFloat64(v interface{}) float64 {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	}
+	panic("qmlbind: value is not a number")
+}
+`
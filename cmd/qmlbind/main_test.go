@@ -0,0 +1,136 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copyFixture copies the package in testdata/name into dir/name.
+func copyFixture(t *testing.T, dir, name string) string {
+	pkgdir := filepath.Join(dir, name)
+	if err := os.MkdirAll(pkgdir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join("testdata", name, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(pkgdir, filepath.Base(file)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return pkgdir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qmlbind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pkgdir := copyFixture(t, dir, "person")
+	if err := run(pkgdir, []string{"Person"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(pkgdir, *output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(data)
+
+	for _, want := range []string{
+		`Set: func(v, a interface{}) { v.(*Person).Name = a.(string) }},`,
+		`Set: func(v, a interface{}) { v.(*Person).Age = int(qmlbindInt64(a)) }},`,
+		`Set: func(v, a interface{}) { v.(*Person).Rank = int32(qmlbindInt64(a)) }},`,
+		`Set: func(v, a interface{}) { v.(*Person).Score = float32(qmlbindFloat64(a)) }},`,
+		`Set: func(v, a interface{}) { v.(*Person).Balance = qmlbindFloat64(a) }},`,
+		`Set: func(v, a interface{}) { v.(*Person).Active = a.(bool) }},`,
+		`{Name: "Greet", NumIn: 1,`,
+		`return v.(*Person).Greet(a[0].(string)) }},`,
+		`{Name: "Move", NumIn: 2,`,
+		`v.(*Person).Move(qmlbindFloat64(a[0]), qmlbindFloat64(a[1]))
+`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, src)
+		}
+	}
+	// Members the shims do not cover are left to reflection, including
+	// methods with multiple results.
+	for _, unwanted := range []string{`"Tags"`, `"Born"`, `"private"`, `"reset"`, `"Lookup"`, `"Since"`} {
+		if strings.Contains(src, unwanted) {
+			t.Errorf("generated code contains %s:\n%s", unwanted, src)
+		}
+	}
+
+	// Compile the generated code against the shim API of the qml package,
+	// which unlike the rest of the package does not depend on Qt.
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found; not compiling the generated code")
+	}
+	qmldir := filepath.Join(dir, "qml")
+	if err := os.MkdirAll(qmldir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	shim, err := ioutil.ReadFile(filepath.Join("..", "..", "shim.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(qmldir, "shim.go"), string(shim))
+	writeFile(t, filepath.Join(qmldir, "go.mod"), "module github.com/niemeyer/qml\n")
+	writeFile(t, filepath.Join(pkgdir, "go.mod"), "module person\n\nrequire github.com/niemeyer/qml v0.0.0\n\nreplace github.com/niemeyer/qml => ../qml\n")
+	cmd := exec.Command("go", "vet", ".")
+	cmd.Dir = pkgdir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("cannot compile the generated code: %v\n%s\n%s", err, out, src)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qmlbind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pkgdir := copyFixture(t, dir, "invalid")
+	err = run(pkgdir, []string{"Invalid"})
+	if err == nil {
+		t.Fatal("run succeeded with invalid members")
+	}
+	file := filepath.Join(pkgdir, "invalid.go")
+	want := "cannot generate shims:\n\t" + strings.Join([]string{
+		file + ":5:2: field Invalid.Index has a map type without string keys, which cannot be handed to QML",
+		file + ":6:2: field Invalid.Updates has a channel type, which cannot be handed to QML",
+		file + ":7:2: field Invalid.OnDone has a function type, which cannot be handed to QML",
+		file + ":10:1: method Invalid.Printf is variadic, which is not supported by QML",
+	}, "\n\t")
+	if err.Error() != want {
+		t.Fatalf("unexpected error:\n%v\nwant:\n%s", err, want)
+	}
+	if _, err := os.Stat(filepath.Join(pkgdir, *output)); !os.IsNotExist(err) {
+		t.Fatalf("run wrote %s despite the errors", *output)
+	}
+
+	err = run(pkgdir, []string{"Missing"})
+	if err == nil || err.Error() != "cannot find struct type Missing in "+pkgdir {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+package invalid
+
+type Invalid struct {
+	Valid   string
+	Index   map[int]string
+	Updates chan string
+	OnDone  func()
+}
+
+func (v *Invalid) Printf(format string, args ...interface{}) {}
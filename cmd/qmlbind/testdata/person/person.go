@@ -0,0 +1,31 @@
+package person
+
+import "time"
+
+type Person struct {
+	Name    string
+	Age     int
+	Rank    int32
+	Score   float32
+	Balance float64
+	Active  bool
+	Tags    map[string]string
+	Born    time.Time
+	private int
+}
+
+func (p *Person) Greet(greeting string) string {
+	return greeting + ", " + p.Name
+}
+
+func (p *Person) Move(x, y float64) {}
+
+func (p *Person) Lookup(key string) (string, bool) {
+	return p.Tags[key], true
+}
+
+func (p *Person) Since(t time.Time) int64 {
+	return 0
+}
+
+func (p *Person) reset() {}
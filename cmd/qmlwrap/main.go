@@ -0,0 +1,401 @@
+// The qmlwrap command generates typed Go wrappers over qml.Object for
+// the QML types described in plugins.qmltypes files, such as the ones
+// installed with QtCharts or third-party QML modules, so that Go logic
+// may set properties, call methods, and connect to signals of these
+// types without spelling out their names and types by hand.
+//
+// For example:
+//
+//     qmlwrap -package charts -type ChartView,LineSeries $QT/qml/QtCharts/plugins.qmltypes
+//
+// generates into qmlwrap.go a ChartView type with methods such as
+// Title and SetTitle for the title property, ZoomIn for the zoomIn
+// method, and OnSeriesAdded for the seriesAdded signal. Wrappers embed
+// qml.Object, and are obtained from any object of the respective type:
+//
+//     chart := charts.ChartView{Object: root.ObjectByName("chart")}
+//     chart.SetTitle("Temperature")
+//
+// Members inherited from the prototypes described in the same files are
+// included. Members with names that clash with the methods of qml.Object
+// or of the wrapper itself are reported and skipped.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	pkgName   = flag.String("package", "", "name of the package of the generated file; required")
+	typeNames = flag.String("type", "", "comma-separated list of QML type names to wrap; all exported types if empty")
+	output    = flag.String("output", "qmlwrap.go", "name of the output file")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: qmlwrap -package name [-type T[,T...]] [-output file] file.qmltypes...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *pkgName == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+	if err := run(flag.Args(), names); err != nil {
+		fmt.Fprintf(os.Stderr, "qmlwrap: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(paths []string, names []string) error {
+	var components []*object
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		module, err := parse(string(data))
+		if err != nil {
+			return fmt.Errorf("cannot parse %s: %v", path, err)
+		}
+		for _, child := range module.children {
+			if child.kind == "Component" {
+				components = append(components, child)
+			}
+		}
+	}
+
+	g := &generator{byName: make(map[string]*object), wrapped: make(map[string]bool)}
+	for _, c := range components {
+		g.byName[c.str("name")] = c
+	}
+	exported := make(map[string]*object)
+	for _, c := range components {
+		for _, export := range c.strs("exports") {
+			// Exports look like "QtCharts/ChartView 2.0".
+			name := export
+			if i := strings.LastIndex(name, "/"); i >= 0 {
+				name = name[i+1:]
+			}
+			if i := strings.Index(name, " "); i >= 0 {
+				name = name[:i]
+			}
+			if prev, ok := exported[name]; ok && prev != c {
+				g.warnf("type %s is exported by both %s and %s; using the former", name, prev.str("name"), c.str("name"))
+				continue
+			}
+			exported[name] = c
+		}
+	}
+	if names == nil {
+		for name := range exported {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	for _, name := range names {
+		c, ok := exported[name]
+		if !ok {
+			return fmt.Errorf("cannot find exported type %s", name)
+		}
+		g.wrap(name, c)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by qmlwrap; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", *pkgName)
+	if g.usesColor {
+		fmt.Fprintf(&buf, "import (\n\"image/color\"\n\n\"github.com/niemeyer/qml\"\n)\n\n")
+	} else {
+		fmt.Fprintf(&buf, "import \"github.com/niemeyer/qml\"\n\n")
+	}
+	buf.Write(g.buf.Bytes())
+	buf.WriteString(helpers)
+	if g.usesColor {
+		buf.WriteString(colorHelper)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("internal error formatting the generated code: %v", err)
+	}
+	return ioutil.WriteFile(*output, src, 0644)
+}
+
+// objectMethods holds the names of the methods of qml.Object, which
+// wrappers inherit and must not clash with.
+var objectMethods = map[string]bool{
+	"Common": true, "TypeName": true, "Interface": true, "Set": true,
+	"Property": true, "Int": true, "Int64": true, "Float64": true,
+	"Bool": true, "String": true, "Color": true, "Object": true,
+	"Slice": true, "ObjectByName": true, "Call": true, "Create": true,
+	"CreateWindow": true, "Destroy": true, "On": true,
+}
+
+type generator struct {
+	buf       bytes.Buffer
+	byName    map[string]*object // Components by C++ name.
+	wrapped   map[string]bool
+	usesColor bool
+}
+
+func (g *generator) warnf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "qmlwrap: warning: "+format+"\n", args...)
+}
+
+// wrap generates the wrapper for component c exported as name.
+func (g *generator) wrap(name string, c *object) {
+	if g.wrapped[name] {
+		return
+	}
+	g.wrapped[name] = true
+
+	fmt.Fprintf(&g.buf, "// %s wraps a QML object of type %s.\n", name, name)
+	fmt.Fprintf(&g.buf, "type %s struct {\nqml.Object\n}\n\n", name)
+
+	// Members of derived types take precedence over the ones of
+	// their prototypes.
+	used := make(map[string]bool)
+	for k := range objectMethods {
+		used[k] = true
+	}
+	seen := make(map[string]bool)
+	var enums, props, methods, signals []*object
+	for proto := c; proto != nil && !seen[proto.str("name")]; proto = g.byName[proto.str("prototype")] {
+		seen[proto.str("name")] = true
+		for _, member := range proto.children {
+			switch member.kind {
+			case "Enum":
+				enums = append(enums, member)
+			case "Property":
+				props = append(props, member)
+			case "Method":
+				methods = append(methods, member)
+			case "Signal":
+				signals = append(signals, member)
+			}
+		}
+	}
+
+	claim := func(kind, member, goName string) bool {
+		if used[goName] {
+			g.warnf("skipping %s %s.%s, as %s.%s is already defined", kind, name, member, name, goName)
+			return false
+		}
+		used[goName] = true
+		return true
+	}
+
+	for _, enum := range enums {
+		values, ok := enum.props["values"].(map[string]interface{})
+		if !ok {
+			continue // Qt 6 lists the names only, without their values.
+		}
+		var keys []string
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return values[keys[i]].(float64) < values[keys[j]].(float64) })
+		fmt.Fprintf(&g.buf, "// Values of the %s.%s enumeration.\nconst (\n", name, enum.str("name"))
+		for _, key := range keys {
+			fmt.Fprintf(&g.buf, "%s%s = %v\n", name, exportedName(key), values[key])
+		}
+		fmt.Fprintf(&g.buf, ")\n\n")
+	}
+
+	for _, prop := range props {
+		pname := prop.str("name")
+		if pname == "" || strings.HasPrefix(pname, "_") || !claim("property", pname, exportedName(pname)) {
+			continue
+		}
+		goType, getter := g.goType(prop)
+		fmt.Fprintf(&g.buf, "// %s returns the value of the %s property.\n", exportedName(pname), pname)
+		if getter == "Property" {
+			fmt.Fprintf(&g.buf, "func (o %s) %s() %s { return o.Property(%q) }\n\n", name, exportedName(pname), goType, pname)
+		} else {
+			fmt.Fprintf(&g.buf, "func (o %s) %s() %s { return o.%s(%q) }\n\n", name, exportedName(pname), goType, getter, pname)
+		}
+		if prop.bool("isReadonly") || prop.bool("isList") {
+			continue
+		}
+		setter := "Set" + exportedName(pname)
+		if !claim("property", pname, setter) {
+			continue
+		}
+		fmt.Fprintf(&g.buf, "// %s sets the value of the %s property.\n", setter, pname)
+		fmt.Fprintf(&g.buf, "func (o %s) %s(value %s) error { return o.Set(%q, value) }\n\n", name, setter, goType, pname)
+	}
+
+	for _, method := range methods {
+		mname := method.str("name")
+		if mname == "" || strings.HasPrefix(mname, "_") || !claim("method", mname, exportedName(mname)) {
+			continue
+		}
+		params, args := g.params(method)
+		fmt.Fprintf(&g.buf, "// %s calls the %s method.\n", exportedName(mname), mname)
+		call := fmt.Sprintf("o.Call(%s)", strings.Join(append([]string{strconv.Quote(mname)}, args...), ", "))
+		result := method.str("type")
+		if result == "" || result == "void" {
+			fmt.Fprintf(&g.buf, "func (o %s) %s(%s) { %s }\n\n", name, exportedName(mname), params, call)
+			continue
+		}
+		goType, _ := g.goType(method)
+		fmt.Fprintf(&g.buf, "func (o %s) %s(%s) %s { return %s }\n\n", name, exportedName(mname), params, goType, resultConversion(goType, call))
+	}
+
+	for _, signal := range signals {
+		sname := signal.str("name")
+		goName := "On" + exportedName(sname)
+		if sname == "" || strings.HasPrefix(sname, "_") || !claim("signal", sname, goName) {
+			continue
+		}
+		params, _ := g.params(signal)
+		fmt.Fprintf(&g.buf, "// %s arranges for f to be called whenever the %s signal is emitted.\n", goName, sname)
+		fmt.Fprintf(&g.buf, "func (o %s) %s(f func(%s)) { o.On(%q, f) }\n\n", name, goName, params, sname)
+	}
+}
+
+// params returns the Go parameter list for the parameters of member,
+// and the names of the parameters as arguments.
+func (g *generator) params(member *object) (params string, args []string) {
+	var list []string
+	for i, param := range member.children {
+		if param.kind != "Parameter" {
+			continue
+		}
+		pname := param.str("name")
+		if pname == "" || isKeyword(pname) {
+			pname = fmt.Sprintf("a%d", i)
+		}
+		goType, _ := g.goType(param)
+		list = append(list, pname+" "+goType)
+		args = append(args, pname)
+	}
+	return strings.Join(list, ", "), args
+}
+
+// goType returns the Go type for the type of member, and the qml.Object
+// method that returns a property of that type.
+func (g *generator) goType(member *object) (goType, getter string) {
+	typ := member.str("type")
+	if member.bool("isList") {
+		return "interface{}", "Property"
+	}
+	switch typ {
+	case "QString", "string", "QUrl", "url":
+		return "string", "String"
+	case "bool":
+		return "bool", "Bool"
+	case "int", "uint", "short", "ushort", "char", "uchar":
+		return "int", "Int"
+	case "qlonglong", "qulonglong", "qint64", "quint64":
+		return "int64", "Int64"
+	case "double", "float", "real", "qreal":
+		return "float64", "Float64"
+	case "QColor", "color":
+		g.usesColor = true
+		return "color.RGBA", "Color"
+	}
+	if member.bool("isPointer") || strings.HasSuffix(typ, "*") || g.byName[typ] != nil {
+		return "qml.Object", "Object"
+	}
+	return "interface{}", "Property"
+}
+
+// resultConversion returns the expression converting the result of call
+// into goType.
+func resultConversion(goType, call string) string {
+	switch goType {
+	case "int", "int64", "float64", "string", "bool", "qml.Object":
+		return fmt.Sprintf("qmlwrap%s(%s)", exportedName(strings.TrimPrefix(goType, "qml.")), call)
+	case "color.RGBA":
+		return fmt.Sprintf("qmlwrapColor(%s)", call)
+	}
+	return call
+}
+
+func exportedName(name string) string {
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func isKeyword(name string) bool {
+	switch name {
+	case "break", "case", "chan", "const", "continue", "default", "defer", "else",
+		"fallthrough", "for", "func", "go", "goto", "if", "import", "interface",
+		"map", "package", "range", "return", "select", "struct", "switch", "type", "var",
+		"o", "f", "value":
+		return true
+	}
+	return false
+}
+
+// helpers are included in the generated code to convert method results,
+// which QML hands over with the type of the JavaScript value.
+const helpers = `
+func qmlwrapInt(v interface{}) int {
+	return int(qmlwrapInt64(v))
+}
+
+func qmlwrapInt64(v interface{}) int64 {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+func qmlwrapFloat64(v interface{}) float64 {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	}
+	return 0
+}
+
+func qmlwrapString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func qmlwrapBool(v interface{}) bool {
+	b, _ := v.(bool)
+	return b
+}
+
+func qmlwrapObject(v interface{}) qml.Object {
+	obj, _ := v.(qml.Object)
+	return obj
+}
+`
+
+// colorHelper is included in the generated code when colors are used.
+const colorHelper = `
+func qmlwrapColor(v interface{}) color.RGBA {
+	c, _ := v.(color.RGBA)
+	return c
+}
+`
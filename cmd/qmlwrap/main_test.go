@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+var goldenTests = []struct {
+	golden string
+	names  []string
+}{
+	{"charts.golden", nil},
+	{"lineseries.golden", []string{"LineSeries"}},
+}
+
+func TestGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qmlwrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	*pkgName = "charts"
+	*output = filepath.Join(dir, "qmlwrap.go")
+	for _, test := range goldenTests {
+		if err := run([]string{"testdata/charts.qmltypes"}, test.names); err != nil {
+			t.Errorf("%s: %v", test.golden, err)
+			continue
+		}
+		got, err := ioutil.ReadFile(*output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		golden := filepath.Join("testdata", test.golden)
+		if *update {
+			if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: generated code differs from the golden file; got:\n%s", test.golden, got)
+		}
+	}
+}
+
+func TestUnknownType(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qmlwrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	*pkgName = "charts"
+	*output = filepath.Join(dir, "qmlwrap.go")
+	err = run([]string{"testdata/charts.qmltypes"}, []string{"PieSeries"})
+	if err == nil || err.Error() != "cannot find exported type PieSeries" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// object is an object in a qmltypes file, such as a Component,
+// with its property bindings and child objects.
+type object struct {
+	kind     string
+	props    map[string]interface{}
+	children []*object
+}
+
+// str returns the string bound to the named property, or "" if unset.
+func (o *object) str(name string) string {
+	s, _ := o.props[name].(string)
+	return s
+}
+
+// strs returns the list of strings bound to the named property.
+func (o *object) strs(name string) []string {
+	list, _ := o.props[name].([]interface{})
+	var result []string
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// bool returns whether the named property is bound to true.
+func (o *object) bool(name string) bool {
+	b, _ := o.props[name].(bool)
+	return b
+}
+
+// parse parses the content of a qmltypes file, which uses a subset of
+// the QML syntax with only literal values, and returns its root object.
+func parse(src string) (*object, error) {
+	p := &parser{src: src}
+	p.next()
+	for p.tok == "import" {
+		// import QtQuick.tooling 1.2
+		p.next()
+		p.next()
+		p.next()
+	}
+	root, err := p.object()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != "" {
+		return nil, p.errorf("unexpected %q after the root object", p.tok)
+	}
+	return root, nil
+}
+
+type parser struct {
+	src  string
+	pos  int
+	line int
+
+	tok    string // Current token, or "" at the end of the input.
+	quoted bool   // Whether tok is the content of a string literal.
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line+1, fmt.Sprintf(format, args...))
+}
+
+// next moves to the next token, skipping spaces and comments.
+func (p *parser) next() {
+	p.tok, p.quoted = "", false
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\n':
+			p.line++
+			p.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "//"):
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			end := strings.Index(p.src[p.pos+2:], "*/")
+			if end < 0 {
+				p.pos = len(p.src)
+			} else {
+				p.line += strings.Count(p.src[p.pos:p.pos+2+end], "\n")
+				p.pos += end + 4
+			}
+		case c == '"':
+			end := p.pos + 1
+			for end < len(p.src) && p.src[end] != '"' {
+				if p.src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if s, err := strconv.Unquote(p.src[p.pos : end+1]); err == nil {
+				p.tok = s
+			} else {
+				p.tok = p.src[p.pos+1 : end]
+			}
+			p.quoted = true
+			p.pos = end + 1
+			return
+		case strings.IndexByte("{}[]:;,", c) >= 0:
+			p.tok = string(c)
+			p.pos++
+			return
+		default:
+			end := p.pos
+			for end < len(p.src) && (unicode.IsLetter(rune(p.src[end])) || unicode.IsDigit(rune(p.src[end])) || strings.IndexByte("_.-+", p.src[end]) >= 0) {
+				end++
+			}
+			if end == p.pos {
+				end++
+			}
+			p.tok = p.src[p.pos:end]
+			p.pos = end
+			return
+		}
+	}
+}
+
+func (p *parser) expect(tok string) error {
+	if p.tok != tok || p.quoted {
+		return p.errorf("expected %q, found %q", tok, p.tok)
+	}
+	p.next()
+	return nil
+}
+
+// object parses an object such as: Component { name: "QQuickItem"; ... }
+func (p *parser) object() (*object, error) {
+	kind := p.tok
+	p.next()
+	return p.objectBody(kind)
+}
+
+// objectBody parses the body of an object of the provided kind, starting
+// at its opening brace.
+func (p *parser) objectBody(kind string) (*object, error) {
+	obj := &object{kind: kind, props: make(map[string]interface{})}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for p.tok != "}" {
+		if p.tok == "" {
+			return nil, p.errorf("unexpected end of input in %s", obj.kind)
+		}
+		name := p.tok
+		p.next()
+		switch p.tok {
+		case "{":
+			child, err := p.objectBody(name)
+			if err != nil {
+				return nil, err
+			}
+			obj.children = append(obj.children, child)
+		case ":":
+			p.next()
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			obj.props[name] = value
+			if p.tok == ";" {
+				p.next()
+			}
+		default:
+			return nil, p.errorf("unexpected %q after %s", p.tok, name)
+		}
+	}
+	p.next()
+	return obj, nil
+}
+
+// value parses a literal value: a string, number, boolean, identifier,
+// list of values, or JavaScript object with literal values.
+func (p *parser) value() (interface{}, error) {
+	switch {
+	case p.quoted:
+		s := p.tok
+		p.next()
+		return s, nil
+	case p.tok == "[":
+		p.next()
+		var list []interface{}
+		for p.tok != "]" {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+			if p.tok == "," {
+				p.next()
+			} else if p.tok != "]" {
+				return nil, p.errorf("expected \",\" or \"]\" in list, found %q", p.tok)
+			}
+		}
+		p.next()
+		return list, nil
+	case p.tok == "{":
+		p.next()
+		m := make(map[string]interface{})
+		for p.tok != "}" {
+			key := p.tok
+			p.next()
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+			if p.tok == "," {
+				p.next()
+			} else if p.tok != "}" {
+				return nil, p.errorf("expected \",\" or \"}\" in object, found %q", p.tok)
+			}
+		}
+		p.next()
+		return m, nil
+	case p.tok == "true" || p.tok == "false":
+		b := p.tok == "true"
+		p.next()
+		return b, nil
+	case p.tok == "" || strings.IndexByte("{}[]:;,", p.tok[0]) >= 0:
+		return nil, p.errorf("expected a value, found %q", p.tok)
+	}
+	tok := p.tok
+	p.next()
+	if f, err := strconv.ParseFloat(tok, 64); err == nil {
+		return f, nil
+	}
+	return tok, nil // Identifiers, such as enumeration values.
+}
@@ -0,0 +1,110 @@
+package qml
+
+import (
+	"sync"
+	"time"
+)
+
+type changeCoalescer struct {
+	interval time.Duration
+	last     time.Time
+	pending  map[uintptr]bool // Offsets of the fields changed meanwhile.
+	timer    *time.Timer
+}
+
+var coalescers = struct {
+	sync.Mutex
+	m map[interface{}]*changeCoalescer
+}{m: make(map[interface{}]*changeCoalescer)}
+
+// SetChangeCoalescing limits the change notifications reported via
+// Changed for the fields of value to at most one per interval, such as
+// 16*time.Millisecond for about one per frame. The first change after
+// a quiet period is notified at once, while further changes within the
+// interval are merged and notified together once it ends, so that Go
+// values updated at a high frequency by backend logic do not flood the
+// GUI thread with binding reevaluations.
+//
+// An interval of zero disables coalescing for value, and notifies any
+// pending changes at once. Coalescing is also disabled, and pending
+// changes dropped, once value is released by all engines holding it.
+func SetChangeCoalescing(value interface{}, interval time.Duration) {
+	coalescers.Lock()
+	c := coalescers.m[value]
+	if interval > 0 {
+		if c == nil {
+			c = &changeCoalescer{pending: make(map[uintptr]bool)}
+			coalescers.m[value] = c
+		}
+		c.interval = interval
+		coalescers.Unlock()
+		return
+	}
+	delete(coalescers.m, value)
+	coalescers.Unlock()
+	if c != nil {
+		flushChanges(value, c)
+	}
+}
+
+// coalesceChange returns whether the change of the field at offset of
+// value is delayed by change coalescing, rather than notified right away.
+func coalesceChange(value interface{}, offset uintptr) bool {
+	coalescers.Lock()
+	defer coalescers.Unlock()
+	c := coalescers.m[value]
+	if c == nil {
+		return false
+	}
+	now := time.Now()
+	elapsed := now.Sub(c.last)
+	if c.timer == nil && elapsed >= c.interval {
+		c.last = now
+		return false
+	}
+	c.pending[offset] = true
+	if c.timer == nil {
+		c.timer = time.AfterFunc(c.interval-elapsed, func() { flushChanges(value, c) })
+	}
+	return true
+}
+
+// releaseChangeCoalescing disables change coalescing for value
+// if no engine holds it anymore. Must be run from the GUI thread.
+func releaseChangeCoalescing(value interface{}) {
+	for _, engine := range engines {
+		if engine.values[value] != nil {
+			return
+		}
+	}
+	coalescers.Lock()
+	if c := coalescers.m[value]; c != nil {
+		if c.timer != nil {
+			c.timer.Stop()
+			c.timer = nil
+		}
+		delete(coalescers.m, value)
+	}
+	coalescers.Unlock()
+}
+
+// flushChanges notifies the changes of value delayed by c.
+func flushChanges(value interface{}, c *changeCoalescer) {
+	coalescers.Lock()
+	pending := c.pending
+	c.pending = make(map[uintptr]bool)
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	c.last = time.Now()
+	coalescers.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+	gui(func() {
+		for offset := range pending {
+			activateChanged(value, offset)
+		}
+	})
+}
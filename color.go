@@ -0,0 +1,183 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"image/color"
+	"reflect"
+	"sort"
+)
+
+// packColor packs c into the ARGB value of a QColor. The components of
+// color.RGBA values are handed over as they are, while colors of any
+// other type, such as color.NRGBA or color.Gray, are converted as done
+// by color.NRGBAModel, as QColor holds colors that are not
+// alpha-premultiplied.
+func packColor(c color.Color) uint32 {
+	if rgba, ok := c.(color.RGBA); ok {
+		return uint32(rgba.A)<<24 | uint32(rgba.R)<<16 | uint32(rgba.G)<<8 | uint32(rgba.B)
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return uint32(n.A)<<24 | uint32(n.R)<<16 | uint32(n.G)<<8 | uint32(n.B)
+}
+
+// unpackColor unpacks the ARGB value of a QColor.
+func unpackColor(c C.uint) color.RGBA {
+	return color.RGBA{byte(c >> 16), byte(c >> 8), byte(c), byte(c >> 24)}
+}
+
+// colorModels holds the models of the color types in image/color, so
+// that colors handed over by QML may be converted into any of them.
+var colorModels = map[reflect.Type]color.Model{
+	reflect.TypeOf(color.RGBA64{}):  color.RGBA64Model,
+	reflect.TypeOf(color.NRGBA{}):   color.NRGBAModel,
+	reflect.TypeOf(color.NRGBA64{}): color.NRGBA64Model,
+	reflect.TypeOf(color.Alpha{}):   color.AlphaModel,
+	reflect.TypeOf(color.Alpha16{}): color.Alpha16Model,
+	reflect.TypeOf(color.Gray{}):    color.GrayModel,
+	reflect.TypeOf(color.Gray16{}):  color.Gray16Model,
+	reflect.TypeOf(color.CMYK{}):    color.CMYKModel,
+	reflect.TypeOf(color.YCbCr{}):   color.YCbCrModel,
+	reflect.TypeOf(color.NYCbCrA{}): color.NYCbCrAModel,
+}
+
+// colorModeler is implemented by color types that define their own
+// color model.
+type colorModeler interface {
+	ColorModel() color.Model
+}
+
+// scanColor sets to to the color in from converted by the color model of
+// its type, and returns whether it did so. It does nothing unless from is
+// a color handed over by QML and to has a color type other than
+// color.RGBA with a known color model, either one of the types in
+// image/color or a type with a ColorModel method.
+func scanColor(to reflect.Value, from interface{}) bool {
+	c, ok := from.(color.RGBA)
+	if !ok || to.Kind() == reflect.Interface || to.Type() == typeRGBA || !to.Type().Implements(typeColor) {
+		return false
+	}
+	model, ok := colorModels[to.Type()]
+	if !ok {
+		modeler, ok := reflect.Zero(to.Type()).Interface().(colorModeler)
+		if !ok {
+			return false
+		}
+		model = modeler.ColorModel()
+	}
+	// QML colors are not alpha-premultiplied.
+	converted := reflect.ValueOf(model.Convert(color.NRGBA{c.R, c.G, c.B, c.A}))
+	if !converted.IsValid() || !converted.Type().AssignableTo(to.Type()) {
+		return false
+	}
+	to.Set(converted)
+	return true
+}
+
+// Darker returns a darker version of c, as done by Qt.darker in QML.
+// A factor of 2.0 returns a color with half the brightness of c.
+// Factors below 1.0 return a lighter color.
+func Darker(c color.RGBA, factor float64) color.RGBA {
+	return unpackColor(C.colorDarker(C.uint(packColor(c)), C.double(factor)))
+}
+
+// Lighter returns a lighter version of c, as done by Qt.lighter in QML.
+// A factor of 1.5 returns a color with 50% more brightness than c.
+// Factors below 1.0 return a darker color.
+func Lighter(c color.RGBA, factor float64) color.RGBA {
+	return unpackColor(C.colorLighter(C.uint(packColor(c)), C.double(factor)))
+}
+
+// Alpha returns c with its alpha set to alpha, as done by Qt.alpha in
+// QML. The alpha value ranges from 0.0 to 1.0.
+func Alpha(c color.RGBA, alpha float64) color.RGBA {
+	return unpackColor(C.colorAlpha(C.uint(packColor(c)), C.double(alpha)))
+}
+
+// HSLA returns the color with the provided hue, saturation, lightness,
+// and alpha, as done by Qt.hsla in QML. All values range from 0.0 to 1.0.
+func HSLA(hue, saturation, lightness, alpha float64) color.RGBA {
+	return unpackColor(C.colorHSLA(C.double(hue), C.double(saturation), C.double(lightness), C.double(alpha)))
+}
+
+// Tint returns base tinted with tint, as done by Qt.tint in QML.
+// The alpha of tint defines how much of it is blended into base.
+func Tint(base, tint color.RGBA) color.RGBA {
+	return unpackColor(C.colorTint(C.uint(packColor(base)), C.uint(packColor(tint))))
+}
+
+// Palette holds named colors, and is seen by QML logic as an object
+// with one color property per name. Bindings depending on the palette
+// are reevaluated whenever its colors change, so themes may be computed
+// and unit-tested in Go, and applied at runtime. For example:
+//
+//     palette := qml.NewPalette(engine)
+//     palette.SetColors(map[string]color.RGBA{"accent": accent, "accentHover": qml.Lighter(accent, 1.2)})
+//     context.SetVar("palette", palette)
+//
+// and in QML:
+//
+//     Rectangle { color: palette.accent }
+//
+type Palette struct {
+	Common
+	colors map[string]color.RGBA
+}
+
+// NewPalette returns a new empty palette for use with engine. The palette
+// must be destroyed with Destroy once it's no longer needed.
+func NewPalette(engine *Engine) *Palette {
+	palette := &Palette{colors: make(map[string]color.RGBA)}
+	palette.engine = engine
+	gui(func() {
+		palette.addr = C.newPropertyMap(engine.addr)
+	})
+	return palette
+}
+
+// SetColor sets the named color in the palette.
+func (p *Palette) SetColor(name string, c color.RGBA) {
+	p.SetColors(map[string]color.RGBA{name: c})
+}
+
+// SetColors sets all the provided colors in the palette at once.
+// Colors not provided are left unchanged.
+func (p *Palette) SetColors(colors map[string]color.RGBA) {
+	gui(func() {
+		if p.addr == nilPtr {
+			panic("palette was destroyed")
+		}
+		var dvalue C.DataValue
+		for name, c := range colors {
+			if old, ok := p.colors[name]; ok && old == c {
+				continue
+			}
+			p.colors[name] = c
+			packDataValue(c, &dvalue, p.engine, cppOwner)
+			cname, cnamelen := unsafeStringData(name)
+			C.propertyMapInsert(p.addr, cname, cnamelen, &dvalue)
+		}
+	})
+}
+
+// Color returns the named color in the palette, and whether it is set.
+func (p *Palette) Color(name string) (c color.RGBA, ok bool) {
+	gui(func() {
+		c, ok = p.colors[name]
+	})
+	return c, ok
+}
+
+// Names returns the names of all colors in the palette, sorted.
+func (p *Palette) Names() []string {
+	var names []string
+	gui(func() {
+		for name := range p.colors {
+			names = append(names, name)
+		}
+	})
+	sort.Strings(names)
+	return names
+}
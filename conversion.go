@@ -0,0 +1,180 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// ConversionPolicy defines how an engine hands to QML logic Go values
+// that have no direct QML counterpart, such as slices of structs, maps,
+// and values of named non-struct types.
+//
+// Values with a direct counterpart are always handed over as such,
+// whatever the policy: strings, booleans, and numbers, byte slices as
+// array buffers, slices of these basic types as lists, time.Time values
+// as dates, and color.Color values as colors. The following values,
+// including pointers to structs, are also handed over the same way
+// whatever the policy, checked in this order: decimal values as
+// strings, nullable values implementing driver.Valuer as the value they
+// hold or null, and values implementing encoding.TextMarshaler as the
+// text they marshal to. Other pointers to structs are always wrapped as
+// objects exposing their fields and methods.
+type ConversionPolicy int
+
+const (
+	// WrapValues hands slices over as lists and maps with string keys
+	// as objects, converting their elements recursively, and wraps other
+	// values as objects exposing their fields and methods, as done for
+	// pointers to structs. Values that are not structs cannot be wrapped,
+	// and cause a panic. This is the default.
+	WrapValues ConversionPolicy = iota
+
+	// StringValues converts values implementing fmt.Stringer into
+	// strings, and handles other values as WrapValues does.
+	StringValues
+
+	// JSONValues serializes values into JSON, and hands the resulting
+	// JavaScript value to QML logic.
+	JSONValues
+
+	// RejectValues panics with an error naming the value type.
+	RejectValues
+)
+
+// SetConversionPolicy defines how the engine hands to QML logic Go values
+// that have no direct QML counterpart. See ConversionPolicy for details.
+func (e *Engine) SetConversionPolicy(policy ConversionPolicy) {
+	gui(func() {
+		e.conversion = policy
+	})
+}
+
+// packConverted packs value into dvalue according to the conversion
+// policy of engine, and returns whether it did so. If it returns false,
+// the value must be wrapped.
+func packConverted(value interface{}, dvalue *C.DataValue, engine *Engine) bool {
+	if engine == nil || engine.conversion == WrapValues {
+		return false
+	}
+	if t := reflect.TypeOf(value); t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		return false
+	}
+	switch engine.conversion {
+	case StringValues:
+		if stringer, ok := value.(fmt.Stringer); ok {
+			packDataValue(stringer.String(), dvalue, engine, jsOwner)
+			return true
+		}
+		return false
+	case JSONValues:
+		data, err := json.Marshal(value)
+		if err != nil {
+			panic(fmt.Sprintf("cannot marshal %T value to JSON: %v", value, err))
+		}
+		dvalue.dataType = C.DTVariant
+		*(*unsafe.Pointer)(unsafe.Pointer(&dvalue.data)) = C.newVariantFromJSON((*C.char)(unsafe.Pointer(&data[0])), C.int(len(data)))
+		return true
+	case RejectValues:
+		panic(fmt.Sprintf("cannot hand %T value to QML", value))
+	}
+	return false
+}
+
+var typeTextUnmarshaler = reflect.TypeOf(new(encoding.TextUnmarshaler)).Elem()
+
+// packText packs the text marshaled from value into dvalue.
+func packText(value encoding.TextMarshaler, dvalue *C.DataValue, engine *Engine) {
+	text, err := value.MarshalText()
+	if err != nil {
+		panic(fmt.Sprintf("cannot marshal %T value to text: %v", value, err))
+	}
+	packDataValue(string(text), dvalue, engine, jsOwner)
+}
+
+// unmarshalText sets to by unmarshaling the text in from, and returns
+// whether to implements encoding.TextUnmarshaler and from is a string.
+// It panics if to cannot unmarshal the text.
+func unmarshalText(to reflect.Value, from interface{}) bool {
+	text, ok := from.(string)
+	if !ok || !to.CanAddr() || !to.Addr().Type().Implements(typeTextUnmarshaler) {
+		return false
+	}
+	if err := to.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+		panic(fmt.Sprintf("cannot unmarshal %q into %s value: %v", text, to.Type(), err))
+	}
+	return true
+}
+
+// assignValue assigns the value handed over by QML to the Go value to,
+// converting it as done when QML logic sets a field of a Go value.
+func assignValue(to reflect.Value, value interface{}) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("cannot use %#v as %s", value, to.Type())
+		}
+	}()
+	if value == nil {
+		to.Set(reflect.Zero(to.Type()))
+	} else if !scanDecimal(to, value) && !scanNullable(to, value) && !unmarshalText(to, value) {
+		convertAndSet(to, reflect.ValueOf(value))
+	}
+	return nil
+}
+
+// scanList sets to, which must be a slice, to the elements of from, and
+// returns whether from is a list handed over by QML that could be
+// converted into it. Nested lists are converted into nested slices.
+func scanList(to reflect.Value, from interface{}) bool {
+	list, ok := from.(*List)
+	if !ok || to.Kind() != reflect.Slice {
+		return false
+	}
+	return assignValue(to, list) == nil
+}
+
+// scanMap sets to, which must be a string-keyed map, to the properties of
+// from, and returns whether from is a JavaScript object handed over by QML
+// that could be converted into it.
+func scanMap(to reflect.Value, from interface{}) bool {
+	m, ok := from.(map[string]interface{})
+	if !ok || to.Kind() != reflect.Map || to.Type().Key().Kind() != reflect.String {
+		return false
+	}
+	return assignValue(to, m) == nil
+}
+
+// assignFields assigns the values in m, handed over by QML as the
+// properties of a JavaScript object, to the exported fields of the
+// struct v known to QML by the same names.
+func assignFields(v reflect.Value, m map[string]interface{}) error {
+	for name, value := range m {
+		field, ok := fieldByMemberName(v, name)
+		if !ok {
+			return fmt.Errorf("%s has no field %q", v.Type(), name)
+		}
+		if err := assignValue(field, value); err != nil {
+			return fmt.Errorf("cannot set field %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// fieldByMemberName returns the exported field of the struct v that
+// QML knows by name.
+func fieldByMemberName(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath == "" && (memberName(field.Name) == name || field.Name == name) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,146 @@
+#include <QtAndroid>
+#include <QAndroidJniObject>
+#include <QAndroidJniEnvironment>
+#include <QCoreApplication>
+
+#include "capi.h"
+
+void androidRequestPermissions(char **permissions, int permissionsLen, void *func)
+{
+    QStringList qpermissions;
+    for (int i = 0; i < permissionsLen; i++) {
+        qpermissions << QString::fromUtf8(permissions[i]);
+    }
+    QtAndroid::requestPermissions(qpermissions, [=](const QtAndroid::PermissionResultMap &results) {
+        QtAndroid::PermissionResultMap copy = results;
+        // The result may be delivered from the Android thread.
+        QMetaObject::invokeMethod(qApp, [=]() {
+            QByteArray granted;
+            QtAndroid::PermissionResultMap::const_iterator it;
+            for (it = copy.constBegin(); it != copy.constEnd(); ++it) {
+                if (it.value() == QtAndroid::PermissionResult::Granted) {
+                    granted.append(it.key().toUtf8());
+                    granted.append('\n');
+                }
+            }
+            hookAndroidPermissions(func, (char *)granted.constData(), granted.size());
+        }, Qt::QueuedConnection);
+    });
+}
+
+error *androidStartActivity(const char *action, const char *data, const char *type, char **extras, int extrasLen, int requestCode, void *func)
+{
+    QAndroidJniObject intent("android/content/Intent", "(Ljava/lang/String;)V",
+        QAndroidJniObject::fromString(QString::fromUtf8(action)).object<jstring>());
+    if (*data || *type) {
+        QAndroidJniObject uri;
+        if (*data) {
+            uri = QAndroidJniObject::callStaticObjectMethod("android/net/Uri", "parse", "(Ljava/lang/String;)Landroid/net/Uri;",
+                QAndroidJniObject::fromString(QString::fromUtf8(data)).object<jstring>());
+        }
+        if (*data && *type) {
+            intent.callObjectMethod("setDataAndType", "(Landroid/net/Uri;Ljava/lang/String;)Landroid/content/Intent;",
+                uri.object(), QAndroidJniObject::fromString(QString::fromUtf8(type)).object<jstring>());
+        } else if (*data) {
+            intent.callObjectMethod("setData", "(Landroid/net/Uri;)Landroid/content/Intent;", uri.object());
+        } else {
+            intent.callObjectMethod("setType", "(Ljava/lang/String;)Landroid/content/Intent;",
+                QAndroidJniObject::fromString(QString::fromUtf8(type)).object<jstring>());
+        }
+    }
+    for (int i = 0; i+1 < extrasLen; i += 2) {
+        intent.callObjectMethod("putExtra", "(Ljava/lang/String;Ljava/lang/String;)Landroid/content/Intent;",
+            QAndroidJniObject::fromString(QString::fromUtf8(extras[i])).object<jstring>(),
+            QAndroidJniObject::fromString(QString::fromUtf8(extras[i+1])).object<jstring>());
+    }
+
+    QAndroidJniEnvironment env;
+    QtAndroid::startActivity(intent, requestCode, [=](int receiverRequestCode, int resultCode, const QAndroidJniObject &resultData) {
+        QByteArray ba;
+        if (resultData.isValid()) {
+            ba = resultData.callObjectMethod("getDataString", "()Ljava/lang/String;").toString().toUtf8();
+        }
+        // The result may be delivered from the Android thread.
+        QMetaObject::invokeMethod(qApp, [=]() {
+            hookAndroidActivityResult(func, resultCode, (char *)ba.constData(), ba.size());
+        }, Qt::QueuedConnection);
+    });
+    if (env->ExceptionCheck()) {
+        env->ExceptionClear();
+        return errorf("cannot start activity for intent %s", action);
+    }
+    return 0;
+}
+
+void androidSetSystemBarStyle(int bar, unsigned int argb, int darkIcons)
+{
+    // Window changes must be done from the Android UI thread.
+    QtAndroid::runOnAndroidThread([=]() {
+        QAndroidJniObject window = QtAndroid::androidActivity().callObjectMethod("getWindow", "()Landroid/view/Window;");
+        if (!window.isValid()) {
+            return;
+        }
+        const int FLAG_DRAWS_SYSTEM_BAR_BACKGROUNDS = 0x80000000;
+        const int SYSTEM_UI_FLAG_LIGHT_STATUS_BAR = 0x2000;
+        const int SYSTEM_UI_FLAG_LIGHT_NAVIGATION_BAR = 0x10;
+
+        window.callMethod<void>("addFlags", "(I)V", FLAG_DRAWS_SYSTEM_BAR_BACKGROUNDS);
+        if (bar == 0) {
+            window.callMethod<void>("setStatusBarColor", "(I)V", (jint)argb);
+        } else {
+            window.callMethod<void>("setNavigationBarColor", "(I)V", (jint)argb);
+        }
+
+        QAndroidJniObject view = window.callObjectMethod("getDecorView", "()Landroid/view/View;");
+        int flags = view.callMethod<int>("getSystemUiVisibility", "()I");
+        int flag = bar == 0 ? SYSTEM_UI_FLAG_LIGHT_STATUS_BAR : SYSTEM_UI_FLAG_LIGHT_NAVIGATION_BAR;
+        if (darkIcons) {
+            flags |= flag;
+        } else {
+            flags &= ~flag;
+        }
+        view.callMethod<void>("setSystemUiVisibility", "(I)V", flags);
+    });
+}
+
+void androidHapticFeedback(int effect)
+{
+    // HapticFeedbackConstants, indexed by the Go HapticEffect values.
+    static const int constants[] = {3, 1, 0, 4}; // KEYBOARD_TAP, VIRTUAL_KEY, LONG_PRESS, CLOCK_TICK
+    if (effect < 0 || effect >= 4) {
+        return;
+    }
+    QtAndroid::runOnAndroidThread([=]() {
+        QAndroidJniObject window = QtAndroid::androidActivity().callObjectMethod("getWindow", "()Landroid/view/Window;");
+        if (!window.isValid()) {
+            return;
+        }
+        QAndroidJniObject view = window.callObjectMethod("getDecorView", "()Landroid/view/View;");
+        view.callMethod<jboolean>("performHapticFeedback", "(I)Z", constants[effect]);
+    });
+}
+
+void androidVibrate(int64_t msecs)
+{
+    // Requires the android.permission.VIBRATE permission.
+    QAndroidJniObject service = QAndroidJniObject::fromString("vibrator");
+    QAndroidJniObject vibrator = QtAndroid::androidActivity().callObjectMethod(
+        "getSystemService", "(Ljava/lang/String;)Ljava/lang/Object;", service.object<jstring>());
+    if (vibrator.isValid()) {
+        vibrator.callMethod<void>("vibrate", "(J)V", (jlong)msecs);
+    }
+}
+
+void androidKeepScreenOn(int on)
+{
+    QtAndroid::runOnAndroidThread([=]() {
+        QAndroidJniObject window = QtAndroid::androidActivity().callObjectMethod("getWindow", "()Landroid/view/Window;");
+        if (!window.isValid()) {
+            return;
+        }
+        const int FLAG_KEEP_SCREEN_ON = 0x80;
+        window.callMethod<void>(on ? "addFlags" : "clearFlags", "(I)V", FLAG_KEEP_SCREEN_ON);
+    });
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
@@ -4,8 +4,38 @@
 #include <QtQml>
 #include <QDebug>
 #include <QQuickImageProvider>
+#include <QStyleHints>
+#include <QPalette>
+#include <QInputMethod>
+#include <QFileSystemWatcher>
+#include <QQuickTextDocument>
+#include <QTextDocument>
+#include <QTextCursor>
+#include <QTextBlock>
+#include <QSyntaxHighlighter>
+#include <QScreen>
+#include <QKeySequence>
+#include <QPropertyAnimation>
+#include <QAnimationDriver>
+#include <QClipboard>
+#include <QMutex>
+#include <QDir>
+#include <QTimer>
+#include <QElapsedTimer>
+#include <QStandardPaths>
+#include <QJsonDocument>
+#include <QJsonArray>
+#include <QLoggingCategory>
+
+#include <private/qmetaobjectbuilder_p.h>
+#if QT_VERSION >= QT_VERSION_CHECK(5, 12, 0)
+#include <private/qv4engine_p.h>
+#include <private/qv4mm_p.h>
+#endif
 
 #include <string.h>
+#include <algorithm>
+#include <functional>
 
 #include "govalue.h"
 #include "govaluetype.h"
@@ -23,6 +53,12 @@ static char *local_strdup(const char *str)
     return strcopy;
 }
 
+static char *local_qstrdup(const QString &str)
+{
+    QByteArray ba = str.toUtf8();
+    return local_strdup(ba.constData());
+}
+
 error *errorf(const char *format, ...)
 {
     va_list ap;
@@ -54,229 +90,1397 @@ void newGuiApplication()
     qApp->setQuitOnLastWindowClosed(false);
 }
 
-void applicationExec()
+void applicationEnableDebugger(int port)
+{
+#if QT_VERSION >= QT_VERSION_CHECK(5, 6, 0)
+    QQmlDebuggingEnabler::startTcpDebugServer(port);
+#else
+    Q_UNUSED(port);
+    qWarning() << "QML debugging requires Qt 5.6 or later";
+#endif
+}
+
+const char *applicationQtVersion()
+{
+    return qVersion();
+}
+
+static void dumpObjectTree(QObject *obj, int depth, QString &out)
+{
+    out += QString(depth * 2, ' ') + obj->metaObject()->className();
+    if (!obj->objectName().isEmpty()) {
+        out += " \"" + obj->objectName() + "\"";
+    }
+    QQuickItem *item = qobject_cast<QQuickItem *>(obj);
+    if (item) {
+        out += QString(" (%1,%2 %3x%4)").arg(item->x()).arg(item->y()).arg(item->width()).arg(item->height());
+    }
+    out += '\n';
+    foreach (QObject *child, obj->children()) {
+        dumpObjectTree(child, depth + 1, out);
+    }
+}
+
+char *applicationObjectTree()
+{
+    QString out;
+    foreach (QWindow *win, QGuiApplication::topLevelWindows()) {
+        dumpObjectTree(win, 0, out);
+    }
+    return local_qstrdup(out);
+}
+
+void applicationExec()
+{
+    qApp->exec();
+}
+
+void applicationFlushAll()
+{
+    qApp->processEvents();
+}
+
+void applicationConnectState()
+{
+    QObject::connect(qGuiApp, &QGuiApplication::applicationStateChanged, [=](Qt::ApplicationState state) {
+        hookApplicationStateChanged(state);
+    });
+}
+
+int applicationState()
+{
+    return qGuiApp->applicationState();
+}
+
+QObject_ *applicationFocusWindow()
+{
+    return qGuiApp->focusWindow();
+}
+
+char *applicationClipboardText()
+{
+    return local_qstrdup(QGuiApplication::clipboard()->text());
+}
+
+void applicationConnectAppState()
+{
+    QObject::connect(qGuiApp, &QGuiApplication::focusWindowChanged, [=]() {
+        hookAppStateChanged(AppStateFocusWindow);
+    });
+    QObject::connect(QGuiApplication::clipboard(), &QClipboard::dataChanged, [=]() {
+        hookAppStateChanged(AppStateClipboard);
+    });
+    QObject::connect(qGuiApp, &QGuiApplication::screenAdded, [=]() {
+        hookAppStateChanged(AppStateScreens);
+    });
+    QObject::connect(qGuiApp, &QGuiApplication::screenRemoved, [=]() {
+        hookAppStateChanged(AppStateScreens);
+    });
+}
+
+int applicationColorScheme()
+{
+#if QT_VERSION >= QT_VERSION_CHECK(6, 5, 0)
+    switch (qGuiApp->styleHints()->colorScheme()) {
+    case Qt::ColorScheme::Light:
+        return 1;
+    case Qt::ColorScheme::Dark:
+        return 2;
+    default:
+        return 0;
+    }
+#else
+    // Infer it from the system palette.
+    QColor window = qGuiApp->palette().color(QPalette::Window);
+    return window.lightness() < 128 ? 2 : 1;
+#endif
+}
+
+void applicationConnectColorScheme()
+{
+#if QT_VERSION >= QT_VERSION_CHECK(6, 5, 0)
+    QObject::connect(qGuiApp->styleHints(), &QStyleHints::colorSchemeChanged, [=]() {
+        hookColorSchemeChanged(applicationColorScheme());
+    });
+#elif QT_VERSION >= QT_VERSION_CHECK(5, 13, 0)
+    QObject::connect(qGuiApp, &QGuiApplication::paletteChanged, [=]() {
+        hookColorSchemeChanged(applicationColorScheme());
+    });
+#endif
+}
+
+void applicationSetColorScheme(int scheme)
+{
+#if QT_VERSION >= QT_VERSION_CHECK(6, 8, 0)
+    switch (scheme) {
+    case 1:
+        qGuiApp->styleHints()->setColorScheme(Qt::ColorScheme::Light);
+        break;
+    case 2:
+        qGuiApp->styleHints()->setColorScheme(Qt::ColorScheme::Dark);
+        break;
+    default:
+        qGuiApp->styleHints()->unsetColorScheme();
+        break;
+    }
+#endif
+}
+
+void applicationSetFont(const char *family, double pointSize)
+{
+    QFont font = qGuiApp->font();
+    if (*family) {
+        font.setFamily(QString::fromUtf8(family));
+    }
+    if (pointSize > 0) {
+        font.setPointSizeF(pointSize);
+    }
+    qGuiApp->setFont(font);
+}
+
+int screenCount()
+{
+    return QGuiApplication::screens().size();
+}
+
+char *screenInfo(int index, int *x, int *y, int *width, int *height, double *pixelRatio, int *primary)
+{
+    QScreen *screen = QGuiApplication::screens().at(index);
+    QRect geometry = screen->geometry();
+    *x = geometry.x();
+    *y = geometry.y();
+    *width = geometry.width();
+    *height = geometry.height();
+    *pixelRatio = screen->devicePixelRatio();
+    *primary = screen == QGuiApplication::primaryScreen();
+    return local_qstrdup(screen->name());
+}
+
+class TouchFilter : public QObject
+{
+public:
+    TouchFilter(void *tracker, QObject *parent) : QObject(parent), tracker(tracker) {};
+
+protected:
+    bool eventFilter(QObject *watched, QEvent *event)
+    {
+        Q_UNUSED(watched);
+        switch (event->type()) {
+        case QEvent::TouchBegin:
+        case QEvent::TouchUpdate:
+        case QEvent::TouchEnd:
+        case QEvent::TouchCancel:
+            break;
+        default:
+            return false;
+        }
+        QTouchEvent *touch = static_cast<QTouchEvent *>(event);
+        const QList<QTouchEvent::TouchPoint> &qpoints = touch->touchPoints();
+        QVarLengthArray<TouchPoint, 5> points(qpoints.size());
+        for (int i = 0; i < qpoints.size(); i++) {
+            const QTouchEvent::TouchPoint &qpoint = qpoints[i];
+            points[i].id = qpoint.id();
+            points[i].state = event->type() == QEvent::TouchCancel ? Qt::TouchPointReleased : qpoint.state();
+            points[i].x = qpoint.pos().x();
+            points[i].y = qpoint.pos().y();
+            points[i].pressure = qpoint.pressure();
+        }
+        hookTouchEvent(tracker, points.data(), points.size());
+        event->accept();
+        return true;
+    }
+
+private:
+    void *tracker;
+};
+
+error *itemHandleTouch(QObject_ *item, void *tracker)
+{
+    QQuickItem *qitem = qobject_cast<QQuickItem *>(reinterpret_cast<QObject *>(item));
+    if (!qitem) {
+        return errorf("object is not a visual item");
+    }
+#if QT_VERSION >= QT_VERSION_CHECK(5, 10, 0)
+    qitem->setAcceptTouchEvents(true);
+#endif
+    qitem->installEventFilter(new TouchFilter(tracker, qitem));
+    return 0;
+}
+
+error *itemMapToItem(QObject_ *item, QObject_ *other, double *x, double *y)
+{
+    QQuickItem *qitem = qobject_cast<QQuickItem *>(reinterpret_cast<QObject *>(item));
+    if (!qitem) {
+        return errorf("object is not a visual item");
+    }
+    QPointF point(*x, *y);
+    if (other) {
+        QQuickItem *qother = qobject_cast<QQuickItem *>(reinterpret_cast<QObject *>(other));
+        if (!qother) {
+            return errorf("target object is not a visual item");
+        }
+        point = qitem->mapToItem(qother, point);
+    } else {
+        point = qitem->mapToScene(point);
+    }
+    *x = point.x();
+    *y = point.y();
+    return 0;
+}
+
+error *itemMapToGlobal(QObject_ *item, double *x, double *y)
+{
+    QQuickItem *qitem = qobject_cast<QQuickItem *>(reinterpret_cast<QObject *>(item));
+    if (!qitem) {
+        return errorf("object is not a visual item");
+    }
+    QPointF point = qitem->mapToGlobal(QPointF(*x, *y));
+    *x = point.x();
+    *y = point.y();
+    return 0;
+}
+
+error *itemSetLayer(QObject_ *item, int enabled, int smooth, int mipmap, int textureWidth, int textureHeight, QObject_ *effect, const char *samplerName)
+{
+    QQuickItem *qitem = qobject_cast<QQuickItem *>(reinterpret_cast<QObject *>(item));
+    if (!qitem) {
+        return errorf("object is not a visual item");
+    }
+    QQmlComponent *qeffect = 0;
+    if (effect) {
+        qeffect = qobject_cast<QQmlComponent *>(reinterpret_cast<QObject *>(effect));
+        if (!qeffect) {
+            return errorf("layer effect is not a component");
+        }
+    }
+    // Disable the layer first so the settings apply in one go
+    // when it's enabled again.
+    QQmlProperty(qitem, "layer.enabled").write(false);
+    QQmlProperty(qitem, "layer.smooth").write(bool(smooth));
+    QQmlProperty(qitem, "layer.mipmap").write(bool(mipmap));
+    QQmlProperty(qitem, "layer.textureSize").write(QSize(textureWidth, textureHeight));
+    QQmlProperty(qitem, "layer.effect").write(QVariant::fromValue(qeffect));
+    QQmlProperty(qitem, "layer.samplerName").write(QString::fromUtf8(samplerName));
+    QQmlProperty(qitem, "layer.enabled").write(bool(enabled));
+    return 0;
+}
+
+error *itemLayer(QObject_ *item, int *enabled, int *smooth, int *mipmap, int *textureWidth, int *textureHeight, QObject_ **effect, char **samplerName)
+{
+    QQuickItem *qitem = qobject_cast<QQuickItem *>(reinterpret_cast<QObject *>(item));
+    if (!qitem) {
+        return errorf("object is not a visual item");
+    }
+    *enabled = QQmlProperty(qitem, "layer.enabled").read().toBool();
+    *smooth = QQmlProperty(qitem, "layer.smooth").read().toBool();
+    *mipmap = QQmlProperty(qitem, "layer.mipmap").read().toBool();
+    QSize size = QQmlProperty(qitem, "layer.textureSize").read().toSize();
+    *textureWidth = size.width();
+    *textureHeight = size.height();
+    *effect = QQmlProperty(qitem, "layer.effect").read().value<QQmlComponent *>();
+    *samplerName = local_qstrdup(QQmlProperty(qitem, "layer.samplerName").read().toString());
+    return 0;
+}
+
+static bool itemLessZ(QQuickItem *a, QQuickItem *b)
+{
+    return a->z() < b->z();
+}
+
+// itemsAt appends to result the visible items under the scene point
+// within item and its descendants, topmost first.
+static void itemsAt(QQuickItem *item, const QPointF &scenePoint, QVariantList *result)
+{
+    if (!item->isVisible()) {
+        return;
+    }
+    bool inside = item->contains(item->mapFromScene(scenePoint));
+    if (item->clip() && !inside) {
+        return;
+    }
+    QList<QQuickItem *> children = item->childItems();
+    std::stable_sort(children.begin(), children.end(), itemLessZ);
+    for (int i = children.size()-1; i >= 0; i--) {
+        itemsAt(children[i], scenePoint, result);
+    }
+    if (inside) {
+        result->append(QVariant::fromValue(static_cast<QObject *>(item)));
+    }
+}
+
+void windowItemsAt(QQuickWindow_ *win, double x, double y, DataValue *result)
+{
+    QQuickItem *content = reinterpret_cast<QQuickWindow *>(win)->contentItem();
+    QVariantList items;
+    QList<QQuickItem *> children = content->childItems();
+    std::stable_sort(children.begin(), children.end(), itemLessZ);
+    for (int i = children.size()-1; i >= 0; i--) {
+        itemsAt(children[i], QPointF(x, y), &items);
+    }
+    QVariant var(items);
+    packDataValue(&var, result);
+}
+
+QObject_ *windowOverlay(QQuickWindow_ *win)
+{
+    // The overlay is created by Qt Quick Controls once a popup is
+    // first used in the window, and is a child of the content item.
+    QQuickItem *content = reinterpret_cast<QQuickWindow *>(win)->contentItem();
+    foreach (QQuickItem *child, content->childItems()) {
+        if (child->inherits("QQuickOverlay")) {
+            return child;
+        }
+    }
+    return 0;
+}
+
+QObject_ *windowContentItem(QQuickWindow_ *win)
+{
+    return reinterpret_cast<QQuickWindow *>(win)->contentItem();
+}
+
+void inputPanelSetVisible(int visible)
+{
+    qGuiApp->inputMethod()->setVisible(visible);
+}
+
+int inputPanelVisible()
+{
+    return qGuiApp->inputMethod()->isVisible();
+}
+
+void inputPanelRect(int *x, int *y, int *width, int *height)
+{
+    QRect rect = qGuiApp->inputMethod()->keyboardRectangle().toAlignedRect();
+    *x = rect.x();
+    *y = rect.y();
+    *width = rect.width();
+    *height = rect.height();
+}
+
+void inputPanelConnect()
+{
+    QInputMethod *im = qGuiApp->inputMethod();
+    QObject::connect(im, &QInputMethod::visibleChanged, [=]() {
+        hookInputPanelChanged();
+    });
+    QObject::connect(im, &QInputMethod::keyboardRectangleChanged, [=]() {
+        hookInputPanelChanged();
+    });
+}
+
+class InputMethodFilter : public QObject
+{
+protected:
+    bool eventFilter(QObject *watched, QEvent *event)
+    {
+        if (event->type() != QEvent::InputMethod || !watched->isWindowType()) {
+            return false;
+        }
+        QInputMethodEvent *ime = static_cast<QInputMethodEvent *>(event);
+        QByteArray preedit = ime->preeditString().toUtf8();
+        QByteArray commit = ime->commitString().toUtf8();
+        return hookInputMethodEvent(preedit.data(), preedit.size(), commit.data(), commit.size(),
+                                    ime->replacementStart(), ime->replacementLength());
+    }
+};
+
+void inputMethodFilterEvents()
+{
+    qGuiApp->installEventFilter(new InputMethodFilter);
+}
+
+void *currentThread()
+{
+    return QThread::currentThread();
+}
+
+void *appThread()
+{
+    return QCoreApplication::instance()->thread();
+}
+
+QQmlEngine_ *newEngine(QObject_ *parent)
+{
+    return new QQmlEngine(reinterpret_cast<QObject *>(parent));
+}
+
+QQmlContext_ *engineRootContext(QQmlEngine_ *engine)
+{
+    return reinterpret_cast<QQmlEngine *>(engine)->rootContext();
+}
+
+void engineSetContextForObject(QQmlEngine_ *engine, QObject_ *object)
+{
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    QObject *qobject = reinterpret_cast<QObject *>(object);
+
+    QQmlEngine::setContextForObject(qobject, qengine->rootContext());
+}
+
+void engineSetOwnershipCPP(QQmlEngine_ *engine, QObject_ *object)
+{
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    QObject *qobject = reinterpret_cast<QObject *>(object);
+
+    qengine->setObjectOwnership(qobject, QQmlEngine::CppOwnership);
+}
+
+void engineSetOwnershipJS(QQmlEngine_ *engine, QObject_ *object)
+{
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    QObject *qobject = reinterpret_cast<QObject *>(object);
+
+    qengine->setObjectOwnership(qobject, QQmlEngine::JavaScriptOwnership);
+}
+
+static QAtomicInt objectsAlive;
+static QAtomicInt componentsAlive;
+
+// trackAlive increments counter until obj is destroyed.
+static void trackAlive(QObject *obj, QAtomicInt *counter)
+{
+    counter->ref();
+    QObject::connect(obj, &QObject::destroyed, [=]() { counter->deref(); });
+}
+
+int statsObjectsAlive()
+{
+    return objectsAlive.load();
+}
+
+int statsComponentsAlive()
+{
+    return componentsAlive.load();
+}
+
+QQmlComponent_ *newComponent(QQmlEngine_ *engine, QObject_ *parent)
+{
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    //QObject *qparent = reinterpret_cast<QObject *>(parent);
+    QQmlComponent *qcomponent = new QQmlComponent(qengine);
+    // Qt 5.2.0 returns NULL on qmlEngine(qcomponent) without this.
+    QQmlEngine::setContextForObject(qcomponent, qengine->rootContext());
+    trackAlive(qcomponent, &componentsAlive);
+    return qcomponent;
+}
+
+void engineCompileComponent(QQmlEngine_ *engine, const char *path, int pathLen, int keep, void *compile)
+{
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    QUrl url = QUrl::fromLocalFile(QString::fromUtf8(path, pathLen));
+    QQmlComponent *component = new QQmlComponent(qengine, url, QQmlComponent::Asynchronous, qengine);
+    std::function<void()> finish = [=]() {
+        QByteArray message;
+        if (component->isError()) {
+            message = component->errorString().trimmed().toUtf8();
+        }
+        component->disconnect();
+        if (keep && message.isEmpty()) {
+            QQmlEngine::setContextForObject(component, qengine->rootContext());
+            trackAlive(component, &componentsAlive);
+            hookComponentCompiled(compile, component, 0, 0);
+        } else {
+            hookComponentCompiled(compile, 0, (char *)message.constData(), message.size());
+            component->deleteLater();
+        }
+    };
+    if (!component->isLoading()) {
+        finish();
+        return;
+    }
+    QObject::connect(component, &QQmlComponent::statusChanged, [=](QQmlComponent::Status status) {
+        if (status != QQmlComponent::Loading) {
+            finish();
+        }
+    });
+}
+
+char *applicationDiskCachePath()
+{
+    return local_qstrdup(QStandardPaths::writableLocation(QStandardPaths::CacheLocation) + "/qmlcache");
+}
+
+class GoImageProvider : public QQuickImageProvider {
+
+    // TODO Destroy this when engine is destroyed.
+
+    public:
+
+    GoImageProvider(void *imageFunc) : QQuickImageProvider(QQmlImageProviderBase::Image), imageFunc(imageFunc) {};
+
+    virtual QImage requestImage(const QString &id, QSize *size, const QSize &requestedSize)
+    {
+        QByteArray ba = id.toUtf8();
+        int width = 0, height = 0;
+        if (requestedSize.isValid()) {
+            width = requestedSize.width();
+            height = requestedSize.height();
+        }
+        QImage *image = reinterpret_cast<QImage *>(hookRequestImage(imageFunc, (char*)ba.constData(), ba.size(), width, height));
+        *size = image->size();
+        if (requestedSize.isValid() && requestedSize != *size) {
+            *image = image->scaled(requestedSize, Qt::KeepAspectRatio);
+        }
+        return *image;
+    };
+
+    private:
+
+    void *imageFunc;
+};
+
+void engineAddImageProvider(QQmlEngine_ *engine, QString_ *providerId, void *imageFunc)
+{
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    QString *qproviderId = reinterpret_cast<QString *>(providerId);
+
+    qengine->addImageProvider(*qproviderId, new GoImageProvider(imageFunc));
+}
+
+#if QT_VERSION >= QT_VERSION_CHECK(5, 6, 0)
+class GoImageResponse : public QQuickImageResponse {
+
+    public:
+
+    GoImageResponse(const QSize &requestedSize) : requestedSize(requestedSize) {};
+
+    virtual QQuickTextureFactory *textureFactory() const
+    {
+        return QQuickTextureFactory::textureFactoryForImage(image);
+    }
+
+    virtual QString errorString() const
+    {
+        return error;
+    }
+
+    virtual void cancel()
+    {
+        hookImageResponseCancel(this);
+    }
+
+    QSize requestedSize;
+    QImage image;
+    QString error;
+};
+
+class GoAsyncImageProvider : public QQuickAsyncImageProvider {
+
+    public:
+
+    GoAsyncImageProvider(void *imageFunc) : imageFunc(imageFunc) {};
+
+    virtual QQuickImageResponse *requestImageResponse(const QString &id, const QSize &requestedSize)
+    {
+        GoImageResponse *response = new GoImageResponse(requestedSize);
+        QByteArray ba = id.toUtf8();
+        int width = 0, height = 0;
+        if (requestedSize.isValid()) {
+            width = requestedSize.width();
+            height = requestedSize.height();
+        }
+        hookRequestImageResponse(imageFunc, response, (char*)ba.constData(), ba.size(), width, height);
+        return response;
+    }
+
+    private:
+
+    void *imageFunc;
+};
+#endif
+
+error *engineAddAsyncImageProvider(QQmlEngine_ *engine, QString_ *providerId, void *imageFunc)
+{
+#if QT_VERSION >= QT_VERSION_CHECK(5, 6, 0)
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    QString *qproviderId = reinterpret_cast<QString *>(providerId);
+
+    qengine->addImageProvider(*qproviderId, new GoAsyncImageProvider(imageFunc));
+    return 0;
+#else
+    Q_UNUSED(engine);
+    Q_UNUSED(providerId);
+    Q_UNUSED(imageFunc);
+    return errorf("asynchronous image providers require Qt 5.6 or later");
+#endif
+}
+
+// imageResponseFinish completes the response with image, or with the
+// error message if image is null. It may be called from any thread, and
+// takes ownership of image.
+void imageResponseFinish(void *response, QImage_ *image, const char *error, int errorLen)
+{
+#if QT_VERSION >= QT_VERSION_CHECK(5, 6, 0)
+    GoImageResponse *qresponse = reinterpret_cast<GoImageResponse *>(response);
+    if (image) {
+        QImage *qimage = reinterpret_cast<QImage *>(image);
+        qresponse->image = *qimage;
+        delete qimage;
+        const QSize &requestedSize = qresponse->requestedSize;
+        if (requestedSize.isValid() && requestedSize != qresponse->image.size()) {
+            qresponse->image = qresponse->image.scaled(requestedSize, Qt::KeepAspectRatio, Qt::SmoothTransformation);
+        }
+    } else {
+        qresponse->error = QString::fromUtf8(error, errorLen);
+    }
+    QMetaObject::invokeMethod(qresponse, "finished", Qt::QueuedConnection);
+#else
+    Q_UNUSED(response);
+    Q_UNUSED(image);
+    Q_UNUSED(error);
+    Q_UNUSED(errorLen);
+#endif
+}
+
+class ScreenCapture : public QObject
+{
+public:
+    ScreenCapture(void *capture, WId window) : capture(capture), window(window), region(0, 0, -1, -1)
+    {
+        QObject::connect(&timer, &QTimer::timeout, [=]() {
+            grab();
+        });
+    }
+
+    void grab()
+    {
+        QScreen *screen = QGuiApplication::primaryScreen();
+        if (!screen) {
+            return;
+        }
+        frame = screen->grabWindow(window, region.x(), region.y(), region.width(), region.height()).toImage();
+        hookScreenCaptured(capture);
+    }
+
+    void *capture;
+    WId window;
+    QRect region;
+    QTimer timer;
+    QImage frame;
+};
+
+class ScreenCaptureImageProvider : public QQuickImageProvider {
+
+    public:
+
+    ScreenCaptureImageProvider(ScreenCapture *capture) : QQuickImageProvider(QQmlImageProviderBase::Image), capture(capture) {};
+
+    virtual QImage requestImage(const QString &id, QSize *size, const QSize &requestedSize)
+    {
+        Q_UNUSED(id);
+        QImage image;
+        if (capture) {
+            image = capture->frame;
+        }
+        *size = image.size();
+        if (requestedSize.isValid() && requestedSize != *size) {
+            image = image.scaled(requestedSize, Qt::KeepAspectRatio);
+        }
+        return image;
+    };
+
+    private:
+
+    QPointer<ScreenCapture> capture;
+};
+
+void engineAddScreenCaptureProvider(QQmlEngine_ *engine, QString_ *providerId, ScreenCapture_ *capture)
+{
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    QString *qproviderId = reinterpret_cast<QString *>(providerId);
+
+    qengine->addImageProvider(*qproviderId, new ScreenCaptureImageProvider(reinterpret_cast<ScreenCapture *>(capture)));
+}
+
+void engineSetUiLanguage(QQmlEngine_ *engine, const char *language)
+{
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+#if QT_VERSION >= QT_VERSION_CHECK(5, 15, 0)
+    qengine->setUiLanguage(QString::fromUtf8(language));
+#elif QT_VERSION >= QT_VERSION_CHECK(5, 10, 0)
+    qengine->retranslate();
+#endif
+}
+
+void componentSetData(QQmlComponent_ *component, const char *data, int dataLen, const char *url, int urlLen)
+{
+    QByteArray qdata(data, dataLen);
+    QByteArray qurl(url, urlLen);
+    QString qsurl = QString::fromUtf8(qurl);
+    reinterpret_cast<QQmlComponent *>(component)->setData(qdata, qsurl);
+}
+
+void engineCollectGarbage(QQmlEngine_ *engine)
+{
+    reinterpret_cast<QQmlEngine *>(engine)->collectGarbage();
+}
+
+int engineJSHeapSize(QQmlEngine_ *engine, int64_t *size)
+{
+#if QT_VERSION >= QT_VERSION_CHECK(5, 12, 0)
+    QV4::MemoryManager *mm = reinterpret_cast<QQmlEngine *>(engine)->handle()->memoryManager;
+    *size = mm->getUsedMem() + mm->getLargeItemsMem();
+    return 1;
+#else
+    Q_UNUSED(engine);
+    *size = 0;
+    return 0;
+#endif
+}
+
+// IdleCollector runs the garbage collector of engine once no user
+// input was received for the given interval.
+class IdleCollector : public QObject
+{
+public:
+    IdleCollector(QQmlEngine *engine, int msecs) : QObject(engine)
+    {
+        timer.setSingleShot(true);
+        timer.setInterval(msecs);
+        QObject::connect(&timer, &QTimer::timeout, [=]() { engine->collectGarbage(); });
+        qApp->installEventFilter(this);
+        timer.start();
+    }
+
+protected:
+    bool eventFilter(QObject *obj, QEvent *event)
+    {
+        switch (event->type()) {
+        case QEvent::KeyPress:
+        case QEvent::MouseButtonPress:
+        case QEvent::MouseMove:
+        case QEvent::Wheel:
+        case QEvent::TouchBegin:
+        case QEvent::TouchUpdate:
+            timer.start();
+            break;
+        default:
+            break;
+        }
+        return QObject::eventFilter(obj, event);
+    }
+
+private:
+    QTimer timer;
+};
+
+QObject_ *newIdleCollector(QQmlEngine_ *engine, int msecs)
+{
+    return new IdleCollector(reinterpret_cast<QQmlEngine *>(engine), msecs);
+}
+
+char *engineStackTrace(QQmlEngine_ *engine)
+{
+    QJSValue stack = reinterpret_cast<QQmlEngine *>(engine)->evaluate("new Error().stack");
+    return local_qstrdup(stack.toString());
+}
+
+char *componentErrorString(QQmlComponent_ *component)
+{
+    QQmlComponent *qcomponent = reinterpret_cast<QQmlComponent *>(component);
+    if (qcomponent->isReady()) {
+        return NULL;
+    }
+    if (qcomponent->isError()) {
+        QByteArray ba = qcomponent->errorString().toUtf8();
+        return local_strdup(ba.constData());
+    }
+    return local_strdup("component is not ready (why!?)");
+}
+
+QObject_ *componentCreate(QQmlComponent_ *component, QQmlContext_ *context)
+{
+    QQmlComponent *qcomponent = reinterpret_cast<QQmlComponent *>(component);
+    QQmlContext *qcontext = reinterpret_cast<QQmlContext *>(context);
+
+    if (!qcontext) {
+        qcontext = qmlContext(qcomponent);
+    }
+    QObject *qobject = qcomponent->create(qcontext);
+    // Objects created by Go code are released by Go code, so make that
+    // explicit. Otherwise the JavaScript engine would take ownership of
+    // the object when a Go method handed it over to QML.
+    if (qobject) {
+        QQmlEngine::setObjectOwnership(qobject, QQmlEngine::CppOwnership);
+        trackAlive(qobject, &objectsAlive);
+    }
+    return qobject;
+}
+
+QQuickWindow_ *componentCreateWindow(QQmlComponent_ *component, QQmlContext_ *context)
+{
+    QQmlComponent *qcomponent = reinterpret_cast<QQmlComponent *>(component);
+    QQmlContext *qcontext = reinterpret_cast<QQmlContext *>(context);
+
+    if (!qcontext) {
+        qcontext = qmlContext(qcomponent);
+    }
+    QObject *obj = qcomponent->create(qcontext);
+    if (!objectIsWindow(obj)) {
+        QQuickView *view = new QQuickView(qmlEngine(qcomponent), 0);
+        view->setContent(qcomponent->url(), qcomponent, obj);
+        view->setResizeMode(QQuickView::SizeRootObjectToView);
+        obj = view;
+    }
+    if (obj) {
+        QQmlEngine::setObjectOwnership(obj, QQmlEngine::CppOwnership);
+        trackAlive(obj, &objectsAlive);
+    }
+    return obj;
+}
+
+// Workaround for bug https://bugs.launchpad.net/bugs/1179716
+struct DoShowWindow : public QQuickWindow {
+    void show() {
+        QQuickWindow::show();
+        QResizeEvent resize(size(), size());
+        resizeEvent(&resize);
+    }
+};
+
+void windowShow(QQuickWindow_ *win)
+{
+    reinterpret_cast<DoShowWindow *>(win)->show();
+}
+
+void windowHide(QQuickWindow_ *win)
+{
+    reinterpret_cast<QQuickWindow *>(win)->hide();
+}
+
+void windowConnectHidden(QQuickWindow_ *win)
+{
+    QQuickWindow *qwin = reinterpret_cast<QQuickWindow *>(win);
+    QObject::connect(qwin, &QWindow::visibleChanged, [=](bool visible){
+        if (!visible) {
+            hookWindowHidden(win);
+        }
+    });
+}
+
+int windowStartSystemMove(QQuickWindow_ *win)
+{
+#if QT_VERSION >= QT_VERSION_CHECK(5, 15, 0)
+    return reinterpret_cast<QQuickWindow *>(win)->startSystemMove() ? 1 : 0;
+#else
+    return 0;
+#endif
+}
+
+int windowStartSystemResize(QQuickWindow_ *win, int edges)
+{
+#if QT_VERSION >= QT_VERSION_CHECK(5, 15, 0)
+    return reinterpret_cast<QQuickWindow *>(win)->startSystemResize(Qt::Edges(edges)) ? 1 : 0;
+#else
+    return 0;
+#endif
+}
+
+error *windowSetFullScreenOn(QQuickWindow_ *win, int screen)
+{
+    QQuickWindow *qwin = reinterpret_cast<QQuickWindow *>(win);
+    QList<QScreen *> screens = QGuiApplication::screens();
+    if (screen < 0 || screen >= screens.size()) {
+        return errorf("screen %d does not exist", screen);
+    }
+    qwin->setScreen(screens[screen]);
+    qwin->setGeometry(screens[screen]->geometry());
+    qwin->showFullScreen();
+    return 0;
+}
+
+// The window state before entering kiosk mode is saved in these
+// dynamic properties so that it may be restored afterwards.
+static const char *kioskFlagsProperty = "_qml_kiosk_flags";
+static const char *kioskGeometryProperty = "_qml_kiosk_geometry";
+static const char *kioskVisibilityProperty = "_qml_kiosk_visibility";
+
+error *windowEnterKiosk(QQuickWindow_ *win, int screen)
+{
+    QQuickWindow *qwin = reinterpret_cast<QQuickWindow *>(win);
+    if (!qwin->property(kioskFlagsProperty).isValid()) {
+        qwin->setProperty(kioskFlagsProperty, (int)qwin->flags());
+        qwin->setProperty(kioskGeometryProperty, qwin->geometry());
+        qwin->setProperty(kioskVisibilityProperty, (int)qwin->visibility());
+        QGuiApplication::setOverrideCursor(QCursor(Qt::BlankCursor));
+    }
+    qwin->setFlags(qwin->flags() | Qt::FramelessWindowHint | Qt::WindowStaysOnTopHint);
+    error *err = windowSetFullScreenOn(win, screen);
+    if (err) {
+        windowExitKiosk(win);
+        return err;
+    }
+    qwin->requestActivate();
+    qwin->setKeyboardGrabEnabled(true);
+    return 0;
+}
+
+void windowExitKiosk(QQuickWindow_ *win)
+{
+    QQuickWindow *qwin = reinterpret_cast<QQuickWindow *>(win);
+    QVariant flags = qwin->property(kioskFlagsProperty);
+    if (!flags.isValid()) {
+        return;
+    }
+    qwin->setKeyboardGrabEnabled(false);
+    QGuiApplication::restoreOverrideCursor();
+    qwin->setFlags(Qt::WindowFlags(flags.toInt()));
+    qwin->setVisibility(QWindow::Visibility(qwin->property(kioskVisibilityProperty).toInt()));
+    qwin->setGeometry(qwin->property(kioskGeometryProperty).toRect());
+    qwin->setProperty(kioskFlagsProperty, QVariant());
+    qwin->setProperty(kioskGeometryProperty, QVariant());
+    qwin->setProperty(kioskVisibilityProperty, QVariant());
+}
+
+QTimer_ *windowStartRecording(QQuickWindow_ *win, int interval, void *recorder)
+{
+    QQuickWindow *qwin = reinterpret_cast<QQuickWindow *>(win);
+    QTimer *timer = new QTimer();
+    timer->setTimerType(Qt::PreciseTimer);
+    QObject::connect(qwin, &QObject::destroyed, timer, &QTimer::stop);
+    QObject::connect(timer, &QTimer::timeout, [=]() {
+        QImage *image = new QImage(qwin->grabWindow());
+        if (image->format() != QImage::Format_ARGB32) {
+            *image = image->convertToFormat(QImage::Format_ARGB32);
+        }
+        hookWindowFrame(recorder, image);
+    });
+    timer->start(interval);
+    return timer;
+}
+
+void windowStopRecording(QTimer_ *timer)
+{
+    delete reinterpret_cast<QTimer *>(timer);
+}
+
+class FrameTimingEvent : public QEvent
+{
+public:
+    FrameTimingEvent(qint64 sync, qint64 render, qint64 swap, qint64 interval)
+        : QEvent(eventType()), sync(sync), render(render), swap(swap), interval(interval) {}
+
+    static QEvent::Type eventType()
+    {
+        static int type = QEvent::registerEventType();
+        return QEvent::Type(type);
+    }
+
+    qint64 sync, render, swap, interval;
+};
+
+// FrameTimer measures the phases of every frame rendered for a window.
+// The window signals are emitted in the render thread, so the timings
+// are posted as events to be reported from the GUI thread.
+class FrameTimer : public QObject
+{
+public:
+    FrameTimer(QQuickWindow *win, void *func) : win(win), func(func), syncStart(0), syncEnd(0), renderStart(0), renderEnd(0), lastSwap(0)
+    {
+        clock.start();
+        connect(win, &QQuickWindow::beforeSynchronizing, this, [=]() { syncStart = clock.nsecsElapsed(); }, Qt::DirectConnection);
+        connect(win, &QQuickWindow::afterSynchronizing, this, [=]() { syncEnd = clock.nsecsElapsed(); }, Qt::DirectConnection);
+        connect(win, &QQuickWindow::beforeRendering, this, [=]() { renderStart = clock.nsecsElapsed(); }, Qt::DirectConnection);
+        connect(win, &QQuickWindow::afterRendering, this, [=]() { renderEnd = clock.nsecsElapsed(); }, Qt::DirectConnection);
+        connect(win, &QQuickWindow::frameSwapped, this, [=]() {
+            qint64 now = clock.nsecsElapsed();
+            qint64 interval = lastSwap ? now - lastSwap : 0;
+            lastSwap = now;
+            QCoreApplication::postEvent(this, new FrameTimingEvent(syncEnd - syncStart, renderEnd - renderStart, now - renderEnd, interval));
+        }, Qt::DirectConnection);
+    }
+
+    virtual bool event(QEvent *e)
+    {
+        if (e->type() != FrameTimingEvent::eventType()) {
+            return QObject::event(e);
+        }
+        FrameTimingEvent *timing = static_cast<FrameTimingEvent *>(e);
+        // Frames whose synchronization and rendering took longer than
+        // the refresh period of the screen missed the display refresh.
+        int missed = 0;
+        if (win && win->screen() && win->screen()->refreshRate() > 0) {
+            double period = 1e9 / win->screen()->refreshRate();
+            missed = int((timing->sync + timing->render) / period);
+        }
+        hookFrameTiming(func, timing->sync, timing->render, timing->swap, timing->interval, missed);
+        return true;
+    }
+
+private:
+    QPointer<QQuickWindow> win;
+    void *func;
+    QElapsedTimer clock;
+    qint64 syncStart, syncEnd, renderStart, renderEnd, lastSwap;
+};
+
+QObject_ *windowStartFrameTiming(QQuickWindow_ *win, void *func)
+{
+    return new FrameTimer(reinterpret_cast<QQuickWindow *>(win), func);
+}
+
+void windowStopFrameTiming(QObject_ *timer)
+{
+    delete reinterpret_cast<FrameTimer *>(timer);
+}
+
+void windowReleaseResources(QQuickWindow_ *win)
+{
+    reinterpret_cast<QQuickWindow *>(win)->releaseResources();
+}
+
+void windowSetPersistentResources(QQuickWindow_ *win, int persistent)
+{
+    QQuickWindow *qwin = reinterpret_cast<QQuickWindow *>(win);
+    qwin->setPersistentSceneGraph(persistent);
+#if QT_VERSION >= QT_VERSION_CHECK(6, 0, 0)
+    qwin->setPersistentGraphics(persistent);
+#else
+    qwin->setPersistentOpenGLContext(persistent);
+#endif
+}
+
+static qint64 textureBytes(const QSize &size)
+{
+    return qint64(size.width()) * size.height() * 4;
+}
+
+static void itemTextureMemory(QQuickItem *item, qreal dpr, QSet<QString> &seen, qint64 *total)
+{
+    if (item->inherits("QQuickImageBase")) {
+        // Images with the same source and size share the texture.
+        QSize size = item->property("sourceSize").toSize();
+        QString key = QString("%1@%2x%3").arg(item->property("source").toUrl().toString()).arg(size.width()).arg(size.height());
+        if (!seen.contains(key)) {
+            seen.insert(key);
+            *total += textureBytes(size);
+        }
+    }
+    QSize itemSize = QSizeF(item->width() * dpr, item->height() * dpr).toSize();
+    if (item->inherits("QQuickShaderEffectSource")) {
+        QSize size = item->property("textureSize").toSize();
+        *total += textureBytes(size.isEmpty() ? itemSize : size);
+    }
+    if (QQmlProperty(item, "layer.enabled").read().toBool()) {
+        QSize size = QQmlProperty(item, "layer.textureSize").read().toSize();
+        *total += textureBytes(size.isEmpty() ? itemSize : size);
+    }
+    foreach (QQuickItem *child, item->childItems()) {
+        itemTextureMemory(child, dpr, seen, total);
+    }
+}
+
+void applicationReleaseResources()
+{
+    foreach (QWindow *win, QGuiApplication::topLevelWindows()) {
+        QQuickWindow *qwin = qobject_cast<QQuickWindow *>(win);
+        if (qwin) {
+            qwin->releaseResources();
+        }
+    }
+}
+
+int64_t windowTextureMemory(QQuickWindow_ *win)
+{
+    QQuickWindow *qwin = reinterpret_cast<QQuickWindow *>(win);
+    QSet<QString> seen;
+    qint64 total = 0;
+    itemTextureMemory(qwin->contentItem(), qwin->devicePixelRatio(), seen, &total);
+    return total;
+}
+
+QObject_ *windowRootObject(QQuickWindow_ *win)
+{
+    if (objectIsView(win)) {
+        return reinterpret_cast<QQuickView *>(win)->rootObject();
+    }
+    return win;
+}
+
+QImage_ *windowGrabWindow(QQuickWindow_ *win)
+{
+    QQuickWindow *qwin = reinterpret_cast<QQuickWindow *>(win);
+    QImage *image = new QImage;
+    *image = qwin->grabWindow().convertToFormat(QImage::Format_ARGB32_Premultiplied);
+    return image;
+}
+
+QImage_ *newImage(int width, int height)
+{
+    return new QImage(width, height, QImage::Format_ARGB32_Premultiplied);
+}
+
+void delImage(QImage_ *image)
+{
+    delete reinterpret_cast<QImage *>(image);
+}
+
+void imageSize(QImage_ *image, int *width, int *height)
+{
+    QImage *qimage = reinterpret_cast<QImage *>(image);
+    *width = qimage->width();
+    *height = qimage->height();
+}
+
+unsigned char *imageBits(QImage_ *image)
 {
-    qApp->exec();
+    QImage *qimage = reinterpret_cast<QImage *>(image);
+    return qimage->bits();
 }
 
-void applicationFlushAll()
+const unsigned char *imageConstBits(QImage_ *image)
 {
-    qApp->processEvents();
+    QImage *qimage = reinterpret_cast<QImage *>(image);
+    return qimage->constBits();
 }
 
-void *currentThread()
+QFileSystemWatcher_ *newFileWatcher(void *watcher)
 {
-    return QThread::currentThread();
+    QFileSystemWatcher *fw = new QFileSystemWatcher();
+    QObject::connect(fw, &QFileSystemWatcher::fileChanged, [=](const QString &path) {
+        // Editors often save by replacing the file, which drops the watch.
+        if (!fw->files().contains(path) && QFile::exists(path)) {
+            fw->addPath(path);
+        }
+        QByteArray ba = path.toUtf8();
+        hookFileChanged(watcher, ba.data(), ba.size());
+    });
+    QObject::connect(fw, &QFileSystemWatcher::directoryChanged, [=](const QString &path) {
+        QByteArray ba = path.toUtf8();
+        hookFileChanged(watcher, ba.data(), ba.size());
+    });
+    return fw;
 }
 
-void *appThread()
+void delFileWatcher(QFileSystemWatcher_ *fw)
 {
-    return QCoreApplication::instance()->thread();
+    delete reinterpret_cast<QFileSystemWatcher *>(fw);
 }
 
-QQmlEngine_ *newEngine(QObject_ *parent)
+int fileWatcherAdd(QFileSystemWatcher_ *fw, const char *path)
 {
-    return new QQmlEngine(reinterpret_cast<QObject *>(parent));
+    return reinterpret_cast<QFileSystemWatcher *>(fw)->addPath(QString::fromUtf8(path)) ? 1 : 0;
 }
 
-QQmlContext_ *engineRootContext(QQmlEngine_ *engine)
+void fileWatcherRemove(QFileSystemWatcher_ *fw, const char *path)
 {
-    return reinterpret_cast<QQmlEngine *>(engine)->rootContext();
+    reinterpret_cast<QFileSystemWatcher *>(fw)->removePath(QString::fromUtf8(path));
 }
 
-void engineSetContextForObject(QQmlEngine_ *engine, QObject_ *object)
+char *fileWatcherPaths(QFileSystemWatcher_ *fw)
 {
-    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
-    QObject *qobject = reinterpret_cast<QObject *>(object);
-
-    QQmlEngine::setContextForObject(qobject, qengine->rootContext());
+    QFileSystemWatcher *qfw = reinterpret_cast<QFileSystemWatcher *>(fw);
+    QStringList paths = qfw->files() + qfw->directories();
+    return local_qstrdup(paths.join('\n'));
 }
 
-void engineSetOwnershipCPP(QQmlEngine_ *engine, QObject_ *object)
+QTextDocument_ *objectTextDocument(QObject_ *object)
 {
-    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
     QObject *qobject = reinterpret_cast<QObject *>(object);
-
-    qengine->setObjectOwnership(qobject, QQmlEngine::CppOwnership);
+    QQuickTextDocument *qdoc = qobject_cast<QQuickTextDocument *>(qobject->property("textDocument").value<QObject *>());
+    if (!qdoc) {
+        return 0;
+    }
+    return qdoc->textDocument();
 }
 
-void engineSetOwnershipJS(QQmlEngine_ *engine, QObject_ *object)
+void textDocumentConnect(QTextDocument_ *doc)
 {
-    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
-    QObject *qobject = reinterpret_cast<QObject *>(object);
+    QTextDocument *qdoc = reinterpret_cast<QTextDocument *>(doc);
+    QObject::connect(qdoc, &QTextDocument::contentsChange, [=](int position, int removed, int added) {
+        hookTextDocumentChanged(doc, position, removed, added);
+    });
+    QObject::connect(qdoc, &QObject::destroyed, [=]() {
+        hookTextDocumentDestroyed(doc);
+    });
+}
 
-    qengine->setObjectOwnership(qobject, QQmlEngine::JavaScriptOwnership);
+char *textDocumentText(QTextDocument_ *doc)
+{
+    return local_qstrdup(reinterpret_cast<QTextDocument *>(doc)->toPlainText());
 }
 
-QQmlComponent_ *newComponent(QQmlEngine_ *engine, QObject_ *parent)
+int textDocumentLength(QTextDocument_ *doc)
 {
-    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
-    //QObject *qparent = reinterpret_cast<QObject *>(parent);
-    QQmlComponent *qcomponent = new QQmlComponent(qengine);
-    // Qt 5.2.0 returns NULL on qmlEngine(qcomponent) without this.
-    QQmlEngine::setContextForObject(qcomponent, qengine->rootContext());
-    return qcomponent;
+    // characterCount includes the final paragraph separator.
+    return reinterpret_cast<QTextDocument *>(doc)->characterCount() - 1;
 }
 
-class GoImageProvider : public QQuickImageProvider {
+int textDocumentBlockCount(QTextDocument_ *doc)
+{
+    return reinterpret_cast<QTextDocument *>(doc)->blockCount();
+}
 
-    // TODO Destroy this when engine is destroyed.
+char *textDocumentBlockText(QTextDocument_ *doc, int block, int *position)
+{
+    QTextBlock qblock = reinterpret_cast<QTextDocument *>(doc)->findBlockByNumber(block);
+    *position = qblock.position();
+    return local_qstrdup(qblock.text());
+}
 
-    public:
+QTextCursor_ *newTextCursor(QTextDocument_ *doc)
+{
+    return new QTextCursor(reinterpret_cast<QTextDocument *>(doc));
+}
 
-    GoImageProvider(void *imageFunc) : QQuickImageProvider(QQmlImageProviderBase::Image), imageFunc(imageFunc) {};
+void delTextCursor(QTextCursor_ *cursor)
+{
+    delete reinterpret_cast<QTextCursor *>(cursor);
+}
 
-    virtual QImage requestImage(const QString &id, QSize *size, const QSize &requestedSize)
-    {
-        QByteArray ba = id.toUtf8();
-        int width = 0, height = 0;
-        if (requestedSize.isValid()) {
-            width = requestedSize.width();
-            height = requestedSize.height();
-        }
-        QImage *image = reinterpret_cast<QImage *>(hookRequestImage(imageFunc, (char*)ba.constData(), ba.size(), width, height));
-        *size = image->size();
-        if (requestedSize.isValid() && requestedSize != *size) {
-            *image = image->scaled(requestedSize, Qt::KeepAspectRatio);
-        }
-        return *image;
-    };
+int textCursorPosition(QTextCursor_ *cursor)
+{
+    return reinterpret_cast<QTextCursor *>(cursor)->position();
+}
 
-    private:
+int textCursorAnchor(QTextCursor_ *cursor)
+{
+    return reinterpret_cast<QTextCursor *>(cursor)->anchor();
+}
 
-    void *imageFunc;
-};
+void textCursorSetPosition(QTextCursor_ *cursor, int position, int keepAnchor)
+{
+    QTextCursor::MoveMode mode = keepAnchor ? QTextCursor::KeepAnchor : QTextCursor::MoveAnchor;
+    reinterpret_cast<QTextCursor *>(cursor)->setPosition(position, mode);
+}
 
-void engineAddImageProvider(QQmlEngine_ *engine, QString_ *providerId, void *imageFunc)
+void textCursorInsertText(QTextCursor_ *cursor, const char *text, int textLen)
 {
-    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
-    QString *qproviderId = reinterpret_cast<QString *>(providerId);
+    reinterpret_cast<QTextCursor *>(cursor)->insertText(QString::fromUtf8(text, textLen));
+}
 
-    qengine->addImageProvider(*qproviderId, new GoImageProvider(imageFunc));
+void textCursorRemoveSelectedText(QTextCursor_ *cursor)
+{
+    reinterpret_cast<QTextCursor *>(cursor)->removeSelectedText();
 }
 
-void componentSetData(QQmlComponent_ *component, const char *data, int dataLen, const char *url, int urlLen)
+char *textCursorSelectedText(QTextCursor_ *cursor)
 {
-    QByteArray qdata(data, dataLen);
-    QByteArray qurl(url, urlLen);
-    QString qsurl = QString::fromUtf8(qurl);
-    reinterpret_cast<QQmlComponent *>(component)->setData(qdata, qsurl);
+    QString text = reinterpret_cast<QTextCursor *>(cursor)->selectedText();
+    text.replace(QChar::ParagraphSeparator, '\n');
+    return local_qstrdup(text);
 }
 
-char *componentErrorString(QQmlComponent_ *component)
+static QTextCharFormat textCharFormat(TextFormat *format)
 {
-    QQmlComponent *qcomponent = reinterpret_cast<QQmlComponent *>(component);
-    if (qcomponent->isReady()) {
-        return NULL;
+    QTextCharFormat qformat;
+    if (format->foreground) {
+        qformat.setForeground(QColor::fromRgba(format->foreground));
     }
-    if (qcomponent->isError()) {
-        QByteArray ba = qcomponent->errorString().toUtf8();
-        return local_strdup(ba.constData());
+    if (format->background) {
+        qformat.setBackground(QColor::fromRgba(format->background));
     }
-    return local_strdup("component is not ready (why!?)");
+    if (format->bold) {
+        qformat.setFontWeight(QFont::Bold);
+    }
+    if (format->italic) {
+        qformat.setFontItalic(true);
+    }
+    if (format->underline) {
+        qformat.setFontUnderline(true);
+    }
+    return qformat;
 }
 
-QObject_ *componentCreate(QQmlComponent_ *component, QQmlContext_ *context)
+void textCursorMergeFormat(QTextCursor_ *cursor, TextFormat *format)
 {
-    QQmlComponent *qcomponent = reinterpret_cast<QQmlComponent *>(component);
-    QQmlContext *qcontext = reinterpret_cast<QQmlContext *>(context);
+    reinterpret_cast<QTextCursor *>(cursor)->mergeCharFormat(textCharFormat(format));
+}
 
-    if (!qcontext) {
-        qcontext = qmlContext(qcomponent);
-    }
-    return qcomponent->create(qcontext);
+void textCursorClearFormat(QTextCursor_ *cursor)
+{
+    reinterpret_cast<QTextCursor *>(cursor)->setCharFormat(QTextCharFormat());
 }
 
-QQuickWindow_ *componentCreateWindow(QQmlComponent_ *component, QQmlContext_ *context)
+void textCursorBeginEditBlock(QTextCursor_ *cursor)
 {
-    QQmlComponent *qcomponent = reinterpret_cast<QQmlComponent *>(component);
-    QQmlContext *qcontext = reinterpret_cast<QQmlContext *>(context);
+    reinterpret_cast<QTextCursor *>(cursor)->beginEditBlock();
+}
 
-    if (!qcontext) {
-        qcontext = qmlContext(qcomponent);
-    }
-    QObject *obj = qcomponent->create(qcontext);
-    if (!objectIsWindow(obj)) {
-        QQuickView *view = new QQuickView(qmlEngine(qcomponent), 0);
-        view->setContent(qcomponent->url(), qcomponent, obj);
-        view->setResizeMode(QQuickView::SizeRootObjectToView);
-        obj = view;
-    }
-    return obj;
+void textCursorEndEditBlock(QTextCursor_ *cursor)
+{
+    reinterpret_cast<QTextCursor *>(cursor)->endEditBlock();
 }
 
-// Workaround for bug https://bugs.launchpad.net/bugs/1179716
-struct DoShowWindow : public QQuickWindow {
-    void show() {
-        QQuickWindow::show();
-        QResizeEvent resize(size(), size());
-        resizeEvent(&resize);
+class GoHighlighter : public QSyntaxHighlighter
+{
+public:
+    GoHighlighter(QTextDocument *doc) : QSyntaxHighlighter(doc) {};
+
+    void applyFormat(int start, int length, const QTextCharFormat &format)
+    {
+        setFormat(start, length, format);
+    }
+
+protected:
+    void highlightBlock(const QString &text)
+    {
+        QByteArray ba = text.toUtf8();
+        setCurrentBlockState(hookHighlightBlock(document(), this, ba.data(), ba.size(), previousBlockState()));
     }
 };
 
-void windowShow(QQuickWindow_ *win)
+QSyntaxHighlighter_ *newHighlighter(QTextDocument_ *doc)
 {
-    reinterpret_cast<DoShowWindow *>(win)->show();
+    return new GoHighlighter(reinterpret_cast<QTextDocument *>(doc));
 }
 
-void windowHide(QQuickWindow_ *win)
+void delHighlighter(QSyntaxHighlighter_ *highlighter)
 {
-    reinterpret_cast<QQuickWindow *>(win)->hide();
+    delete reinterpret_cast<GoHighlighter *>(highlighter);
 }
 
-void windowConnectHidden(QQuickWindow_ *win)
+void highlighterSetFormat(QSyntaxHighlighter_ *highlighter, int start, int length, TextFormat *format)
 {
-    QQuickWindow *qwin = reinterpret_cast<QQuickWindow *>(win);
-    QObject::connect(qwin, &QWindow::visibleChanged, [=](bool visible){
-        if (!visible) {
-            hookWindowHidden(win);
-        }
-    });
+    reinterpret_cast<GoHighlighter *>(highlighter)->applyFormat(start, length, textCharFormat(format));
 }
 
-QObject_ *windowRootObject(QQuickWindow_ *win)
+void highlighterRehighlight(QSyntaxHighlighter_ *highlighter)
 {
-    if (objectIsView(win)) {
-        return reinterpret_cast<QQuickView *>(win)->rootObject();
-    }
-    return win;
+    reinterpret_cast<GoHighlighter *>(highlighter)->rehighlight();
 }
 
-QImage_ *windowGrabWindow(QQuickWindow_ *win)
+ScreenCapture_ *newScreenCapture(void *capture, uintptr_t window)
 {
-    QQuickWindow *qwin = reinterpret_cast<QQuickWindow *>(win);
-    QImage *image = new QImage;
-    *image = qwin->grabWindow().convertToFormat(QImage::Format_ARGB32_Premultiplied);
-    return image;
+    return new ScreenCapture(capture, (WId)window);
 }
 
-QImage_ *newImage(int width, int height)
+void delScreenCapture(ScreenCapture_ *capture)
 {
-    return new QImage(width, height, QImage::Format_ARGB32_Premultiplied);
+    delete reinterpret_cast<ScreenCapture *>(capture);
 }
 
-void delImage(QImage_ *image)
+void screenCaptureSetRegion(ScreenCapture_ *capture, int x, int y, int width, int height)
 {
-    delete reinterpret_cast<QImage *>(image);
+    reinterpret_cast<ScreenCapture *>(capture)->region = QRect(x, y, width, height);
 }
 
-void imageSize(QImage_ *image, int *width, int *height)
+void screenCaptureStart(ScreenCapture_ *capture, int interval)
 {
-    QImage *qimage = reinterpret_cast<QImage *>(image);
-    *width = qimage->width();
-    *height = qimage->height();
+    ScreenCapture *qcapture = reinterpret_cast<ScreenCapture *>(capture);
+    qcapture->timer.start(interval);
+    qcapture->grab();
 }
 
-unsigned char *imageBits(QImage_ *image)
+void screenCaptureStop(ScreenCapture_ *capture)
 {
-    QImage *qimage = reinterpret_cast<QImage *>(image);
-    return qimage->bits();
+    reinterpret_cast<ScreenCapture *>(capture)->timer.stop();
 }
 
-const unsigned char *imageConstBits(QImage_ *image)
+QImage_ *screenCaptureImage(ScreenCapture_ *capture)
 {
-    QImage *qimage = reinterpret_cast<QImage *>(image);
-    return qimage->constBits();
+    QImage *image = new QImage(reinterpret_cast<ScreenCapture *>(capture)->frame);
+    if (image->format() != QImage::Format_ARGB32) {
+        *image = image->convertToFormat(QImage::Format_ARGB32);
+    }
+    return image;
+}
+
+QQmlContext_ *contextSpawn(QQmlContext_ *context)
+{
+    QQmlContext *qcontext = reinterpret_cast<QQmlContext *>(context);
+    return new QQmlContext(qcontext, qcontext);
 }
 
 void contextSetObject(QQmlContext_ *context, QObject_ *value)
@@ -333,9 +1537,26 @@ const char *objectTypeName(QObject_ *object)
     return reinterpret_cast<QObject *>(object)->metaObject()->className();
 }
 
+// objectPathProperty resolves a dotted property path such as "anchors.margins"
+// or "Accessible.name", which address grouped and attached properties.
+static QQmlProperty objectPathProperty(QObject *qobject, const char *path)
+{
+    return QQmlProperty(qobject, QString::fromUtf8(path), qmlContext(qobject));
+}
+
 int objectGetProperty(QObject_ *object, const char *name, DataValue *result)
 {
     QObject *qobject = reinterpret_cast<QObject *>(object);
+
+    if (strchr(name, '.')) {
+        QQmlProperty prop = objectPathProperty(qobject, name);
+        if (!prop.isValid()) {
+            return 0;
+        }
+        QVariant var = prop.read();
+        packDataValue(&var, result);
+        return 1;
+    }
     
     QVariant var = qobject->property(name);
     packDataValue(&var, result);
@@ -347,7 +1568,7 @@ int objectGetProperty(QObject_ *object, const char *name, DataValue *result)
     return 1;
 }
 
-void objectSetProperty(QObject_ *object, const char *name, DataValue *value)
+error *objectSetProperty(QObject_ *object, const char *name, DataValue *value)
 {
     QObject *qobject = reinterpret_cast<QObject *>(object);
     QVariant var;
@@ -362,7 +1583,192 @@ void objectSetProperty(QObject_ *object, const char *name, DataValue *value)
         }
     }
 
-    qobject->setProperty(name, var);
+    if (strchr(name, '.')) {
+        QQmlProperty prop = objectPathProperty(qobject, name);
+        if (!prop.isValid()) {
+            return errorf("object does not have a \"%s\" property", name);
+        }
+        if (!prop.write(var)) {
+            return errorf("cannot set property \"%s\"", name);
+        }
+        return 0;
+    }
+
+    qobject->setProperty(name, var);
+    return 0;
+}
+
+// shortcutProperty returns the name of the property holding the key
+// sequence of object, if it's a Shortcut or an Action.
+static const char *shortcutProperty(QObject *object)
+{
+    const QMetaObject *mo = object->metaObject();
+    if (object->inherits("QQuickShortcut") && mo->indexOfProperty("sequence") >= 0) {
+        return "sequence";
+    }
+    if (object->inherits("QQuickAction") && mo->indexOfProperty("shortcut") >= 0) {
+        return "shortcut";
+    }
+    return 0;
+}
+
+void objectFindShortcuts(QObject_ *object, DataValue *result)
+{
+    QObject *qobject = reinterpret_cast<QObject *>(object);
+    QList<QObject *> objects = qobject->findChildren<QObject *>();
+    objects.prepend(qobject);
+    QVariantList shortcuts;
+    foreach (QObject *obj, objects) {
+        if (shortcutProperty(obj)) {
+            shortcuts.append(QVariant::fromValue(obj));
+        }
+    }
+    QVariant var(shortcuts);
+    packDataValue(&var, result);
+}
+
+char *shortcutSequence(QObject_ *object)
+{
+    QObject *qobject = reinterpret_cast<QObject *>(object);
+    QVariant var = qobject->property(shortcutProperty(qobject));
+    QKeySequence seq;
+    if (var.userType() == QMetaType::QKeySequence) {
+        seq = var.value<QKeySequence>();
+    } else if (var.userType() == QMetaType::Int) {
+        seq = QKeySequence(QKeySequence::StandardKey(var.toInt()));
+    } else {
+        seq = QKeySequence(var.toString());
+    }
+    return local_qstrdup(seq.toString(QKeySequence::PortableText));
+}
+
+void shortcutSetSequence(QObject_ *object, const char *seq, int seqLen)
+{
+    QObject *qobject = reinterpret_cast<QObject *>(object);
+    qobject->setProperty(shortcutProperty(qobject), QString::fromUtf8(seq, seqLen));
+}
+
+error *objectBind(QQmlEngine_ *engine, QObject_ *object, const char *name, int nameLen, DataValue *evaluator)
+{
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    QObject *qobject = reinterpret_cast<QObject *>(object);
+    QString qname = QString::fromUtf8(name, nameLen);
+
+    QQmlProperty prop(qobject, qname);
+    if (!prop.isValid() || !prop.isWritable()) {
+        return errorf("object does not have a writable \"%s\" property", qname.toUtf8().constData());
+    }
+
+    QVariant var;
+    unpackDataValue(evaluator, &var);
+
+    // Qt.binding must be assigned from within a QML context, so the
+    // function doing it is created inside the context of the object.
+    QQmlContext *context = QQmlEngine::contextForObject(qobject);
+    if (!context) {
+        context = qengine->rootContext();
+    }
+    QQmlExpression expr(context, qobject,
+        "(function(target, name, evaluator) {"
+        "    target[name] = Qt.binding(function() {"
+        "        evaluator.revision;"
+        "        for (var i = 0; i < evaluator.depCount(); i++) {"
+        "            evaluator.dep(i)[evaluator.depProperty(i)];"
+        "        }"
+        "        return evaluator.evaluate();"
+        "    });"
+        "})");
+    QJSValue fn = expr.evaluate().value<QJSValue>();
+    if (expr.hasError()) {
+        return errorf("%s", expr.error().toString().toUtf8().constData());
+    }
+    QJSValue result = fn.call(QJSValueList() << qengine->toScriptValue(qobject) << qname << qengine->toScriptValue(var));
+    if (result.isError()) {
+        return errorf("%s", result.toString().toUtf8().constData());
+    }
+    return 0;
+}
+
+QObject_ *objectAnimate(QObject_ *object, const char *name, int nameLen, DataValue *from, DataValue *to, int msecs, int easing)
+{
+    QObject *qobject = reinterpret_cast<QObject *>(object);
+    QByteArray qname(name, nameLen);
+    int index = qobject->metaObject()->indexOfProperty(qname.constData());
+    if (index < 0) {
+        return 0;
+    }
+    int propType = qobject->metaObject()->property(index).userType();
+
+    QPropertyAnimation *anim = new QPropertyAnimation(qobject, qname, qobject);
+    QVariant var;
+    if (from->dataType != DTInvalid) {
+        unpackDataValue(from, &var);
+        var.convert(propType);
+        anim->setStartValue(var);
+    }
+    unpackDataValue(to, &var);
+    var.convert(propType);
+    anim->setEndValue(var);
+    anim->setDuration(msecs);
+    anim->setEasingCurve(QEasingCurve::Type(easing));
+    anim->start();
+    return anim;
+}
+
+void animationRetarget(QObject_ *animation, DataValue *to)
+{
+    QPropertyAnimation *anim = reinterpret_cast<QPropertyAnimation *>(animation);
+    int propType = anim->targetObject()->property(anim->propertyName().constData()).userType();
+    // Without a start value, the animation starts from the current
+    // property value, so retargeting a running animation is smooth.
+    anim->stop();
+    QVariant var;
+    unpackDataValue(to, &var);
+    var.convert(propType);
+    anim->setEndValue(var);
+    anim->start();
+}
+
+class FakeAnimationDriver : public QAnimationDriver
+{
+public:
+    FakeAnimationDriver() : elapsedTime(0) {}
+
+    qint64 elapsed() const { return elapsedTime; }
+
+    void advanceBy(qint64 msecs)
+    {
+        // Advance in frame-sized steps, so repeating timers fire once per
+        // interval and animations observe the intermediate frames.
+        while (msecs > 0) {
+            qint64 step = qMin(msecs, qint64(16));
+            elapsedTime += step;
+            msecs -= step;
+            advance();
+        }
+    }
+
+private:
+    qint64 elapsedTime;
+};
+
+static FakeAnimationDriver *fakeAnimationDriver = 0;
+
+void useFakeClock(int enabled)
+{
+    if (!fakeAnimationDriver) {
+        fakeAnimationDriver = new FakeAnimationDriver();
+    }
+    if (enabled) {
+        fakeAnimationDriver->install();
+    } else {
+        fakeAnimationDriver->uninstall();
+    }
+}
+
+void advanceFakeClock(int64_t msecs)
+{
+    fakeAnimationDriver->advanceBy(msecs);
 }
 
 error *objectInvoke(QObject_ *object, const char *method, int methodLen, DataValue *resultdv, DataValue *paramsdv, int paramsLen)
@@ -435,12 +1841,38 @@ void objectSetParent(QObject_ *object, QObject_ *parent)
     qobject->setParent(qparent);
 }
 
+void objectSetName(QObject_ *object, const char *name, int nameLen)
+{
+    QObject *qobject = reinterpret_cast<QObject *>(object);
+    qobject->setObjectName(QString::fromUtf8(name, nameLen));
+}
+
 error *objectConnect(QObject_ *object, const char *signal, int signalLen, QQmlEngine_ *engine, void *func, int argsLen)
 {
     QObject *qobject = reinterpret_cast<QObject *>(object);
     QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
     QByteArray qsignal(signal, signalLen);
 
+    if (qsignal.contains('.')) {
+        // Signals of attached objects, such as "Accessible.pressAction", are
+        // resolved via their handler property ("Accessible.onPressAction").
+        int dot = qsignal.lastIndexOf('.');
+        QByteArray handler = qsignal.left(dot+1) + "on" + qsignal.mid(dot+1, 1).toUpper() + qsignal.mid(dot+2);
+        QQmlProperty prop = objectPathProperty(qobject, handler.constData());
+        if (!prop.isSignalProperty()) {
+            return errorf("object does not expose a \"%s\" signal", qsignal.constData());
+        }
+        QMetaMethod method = prop.method();
+        if (method.parameterCount() < argsLen) {
+            return errorf("signal \"%s\" has too few parameters for provided function", qsignal.constData());
+        }
+        QObject *target = prop.object();
+        Connector *connector = new Connector(target, method, qengine, func, argsLen);
+        const QMetaObject *connmeta = connector->metaObject();
+        QObject::connect(target, method, connector, connmeta->method(connmeta->methodOffset()));
+        return 0;
+    }
+
     const QMetaObject *meta = qobject->metaObject();
     // Walk backwards so descendants have priority.
     for (int i = meta->methodCount()-1; i >= 0; i--) {
@@ -497,6 +1929,281 @@ error *objectGoAddr(QObject_ *object, GoAddr **addr)
     return errorf("QML object is not backed by a Go value");
 }
 
+error *seriesAppend(QObject_ *series, void *xs, void *ys, int len, int isFloat32, int replace)
+{
+    // XY series in QtCharts take all the points at once when the Qt
+    // Charts module is linked in.
+    if (seriesSetPoints(series, xs, ys, len, isFloat32, replace)) {
+        return 0;
+    }
+
+    // Otherwise points are appended one at a time. XY series in QtCharts
+    // take reals, while series implemented in QML itself take variants.
+    QObject *qseries = reinterpret_cast<QObject *>(series);
+    const QMetaObject *meta = qseries->metaObject();
+    bool variant = false;
+    int index = meta->indexOfMethod("append(qreal,qreal)");
+    if (index == -1) {
+        index = meta->indexOfMethod("append(double,double)");
+    }
+    if (index == -1) {
+        index = meta->indexOfMethod("append(QVariant,QVariant)");
+        variant = true;
+    }
+    if (index == -1) {
+        return errorf("object does not expose an append(x, y) method");
+    }
+    QMetaMethod append = meta->method(index);
+
+    if (replace && !QMetaObject::invokeMethod(qseries, "clear", Qt::DirectConnection)) {
+        return errorf("object does not expose a clear() method");
+    }
+    for (int i = 0; i < len; i++) {
+        qreal x, y;
+        if (isFloat32) {
+            x = reinterpret_cast<float *>(xs)[i];
+            y = reinterpret_cast<float *>(ys)[i];
+        } else {
+            x = reinterpret_cast<double *>(xs)[i];
+            y = reinterpret_cast<double *>(ys)[i];
+        }
+        bool ok;
+        if (variant) {
+            QVariant vx(x), vy(y);
+            ok = append.invoke(qseries, Qt::DirectConnection, Q_ARG(QVariant, vx), Q_ARG(QVariant, vy));
+        } else {
+            ok = append.invoke(qseries, Qt::DirectConnection, Q_ARG(qreal, x), Q_ARG(qreal, y));
+        }
+        if (!ok) {
+            return errorf("failed to append point %d to series", i);
+        }
+    }
+    return 0;
+}
+
+static QLocale localeFor(const char *locale)
+{
+    if (*locale) {
+        return QLocale(QString::fromUtf8(locale));
+    }
+    return QLocale();
+}
+
+char *localeName(const char *locale)
+{
+    return local_qstrdup(localeFor(locale).name());
+}
+
+void localeSetDefault(const char *locale)
+{
+    QLocale::setDefault(localeFor(locale));
+    QEvent event(QEvent::LocaleChange);
+    QCoreApplication::sendEvent(qApp, &event);
+}
+
+char *localeFormatFloat(const char *locale, double value, char format, int precision)
+{
+    return local_qstrdup(localeFor(locale).toString(value, format, precision));
+}
+
+char *localeFormatInt(const char *locale, int64_t value)
+{
+    return local_qstrdup(localeFor(locale).toString((qlonglong)value));
+}
+
+char *localeFormatCurrency(const char *locale, double value, const char *symbol)
+{
+    return local_qstrdup(localeFor(locale).toCurrencyString(value, QString::fromUtf8(symbol)));
+}
+
+static QColor unpackColor(unsigned int argb)
+{
+    return QColor::fromRgba(argb);
+}
+
+static unsigned int packColor(const QColor &color)
+{
+    return color.rgba();
+}
+
+// The factors are handled as done by Qt.darker and Qt.lighter in QML.
+unsigned int colorDarker(unsigned int argb, double factor)
+{
+    return packColor(unpackColor(argb).darker(int(qRound(factor * 100.0))));
+}
+
+unsigned int colorLighter(unsigned int argb, double factor)
+{
+    return packColor(unpackColor(argb).lighter(int(qRound(factor * 100.0))));
+}
+
+unsigned int colorAlpha(unsigned int argb, double alpha)
+{
+    QColor color = unpackColor(argb);
+    color.setAlphaF(qBound(0.0, alpha, 1.0));
+    return packColor(color);
+}
+
+unsigned int colorHSLA(double h, double s, double l, double a)
+{
+    return packColor(QColor::fromHslF(qBound(0.0, h, 1.0), qBound(0.0, s, 1.0), qBound(0.0, l, 1.0), qBound(0.0, a, 1.0)));
+}
+
+// colorTint blends tint over base as done by Qt.tint in QML.
+unsigned int colorTint(unsigned int base, unsigned int tint)
+{
+    QColor qbase = unpackColor(base);
+    QColor qtint = unpackColor(tint);
+    qreal a = qtint.alphaF();
+    qreal inv_a = 1.0 - a;
+    qreal r = qtint.redF() * a + qbase.redF() * inv_a;
+    qreal g = qtint.greenF() * a + qbase.greenF() * inv_a;
+    qreal b = qtint.blueF() * a + qbase.blueF() * inv_a;
+    return packColor(QColor::fromRgbF(r, g, b, a + inv_a * qbase.alphaF()));
+}
+
+QObject_ *newPropertyMap(QQmlEngine_ *engine)
+{
+    QQmlPropertyMap *map = new QQmlPropertyMap(reinterpret_cast<QQmlEngine *>(engine));
+    QQmlEngine::setObjectOwnership(map, QQmlEngine::CppOwnership);
+    return map;
+}
+
+void propertyMapInsert(QObject_ *map, const char *key, int keyLen, DataValue *value)
+{
+    QQmlPropertyMap *qmap = reinterpret_cast<QQmlPropertyMap *>(map);
+    QVariant var;
+    unpackDataValue(value, &var);
+    qmap->insert(QString::fromUtf8(key, keyLen), var);
+}
+
+void propertyMapClear(QObject_ *map, const char *key, int keyLen)
+{
+    QQmlPropertyMap *qmap = reinterpret_cast<QQmlPropertyMap *>(map);
+    qmap->clear(QString::fromUtf8(key, keyLen));
+}
+
+// localeFormatDecimal formats the plain decimal number in digits, such as
+// "-1234.50", without converting it into a double so no precision is lost.
+char *localeFormatDecimal(const char *locale, const char *digits, int currency, const char *symbol)
+{
+    QLocale qlocale = localeFor(locale);
+    QString localDigits[10];
+    for (int i = 0; i < 10; i++) {
+        localDigits[i] = qlocale.toString(i);
+    }
+    bool negative = *digits == '-';
+    if (negative) {
+        digits++;
+    }
+    const char *point = strchr(digits, '.');
+    int intLen = point ? point - digits : strlen(digits);
+    bool group = !(qlocale.numberOptions() & QLocale::OmitGroupSeparator);
+
+    QString number;
+    for (int i = 0; i < intLen; i++) {
+        if (group && i > 0 && (intLen - i) % 3 == 0) {
+            number += qlocale.groupSeparator();
+        }
+        number += localDigits[digits[i] - '0'];
+    }
+    if (point && point[1]) {
+        number += qlocale.decimalPoint();
+        for (const char *p = point + 1; *p; p++) {
+            number += localDigits[*p - '0'];
+        }
+    }
+
+    if (currency) {
+        // Let the locale place the symbol and sign around a unit amount,
+        // and then replace the unit with the formatted number.
+        QString pattern = qlocale.toCurrencyString(qlonglong(negative ? -1 : 1), QString::fromUtf8(symbol));
+        return local_qstrdup(pattern.replace(localDigits[1], number));
+    }
+    if (negative) {
+        number.prepend(qlocale.negativeSign());
+    }
+    return local_qstrdup(number);
+}
+
+// localeParseDecimal turns a decimal number formatted according to the
+// locale back into the plain form, dropping group separators and any
+// currency symbol.
+char *localeParseDecimal(const char *locale, const char *str)
+{
+    QLocale qlocale = localeFor(locale);
+    QString s = QString::fromUtf8(str);
+    QString decimalPoint = qlocale.decimalPoint();
+    QString negativeSign = qlocale.negativeSign();
+    QString result;
+    for (int i = 0; i < s.size(); i++) {
+        QChar ch = s.at(i);
+        if (ch.isDigit()) {
+            result += QChar('0' + ch.digitValue());
+        } else if (s.mid(i).startsWith(decimalPoint)) {
+            result += '.';
+        } else if (s.mid(i).startsWith(negativeSign) || ch == '-' || ch == '(') {
+            if (!result.startsWith('-')) {
+                result.prepend('-');
+            }
+        }
+    }
+    return local_qstrdup(result);
+}
+
+// The part parameter selects the date and time (0), only the date (1),
+// or only the time (2).
+char *localeFormatDateTime(const char *locale, int64_t msecs, int offset, int format, int part)
+{
+    QLocale qlocale = localeFor(locale);
+    QDateTime dt = QDateTime::fromMSecsSinceEpoch(msecs, Qt::OffsetFromUTC, offset);
+    QLocale::FormatType qformat = (QLocale::FormatType)format;
+    switch (part) {
+    case 1:
+        return local_qstrdup(qlocale.toString(dt.date(), qformat));
+    case 2:
+        return local_qstrdup(qlocale.toString(dt.time(), qformat));
+    }
+    return local_qstrdup(qlocale.toString(dt, qformat));
+}
+
+int localeParseFloat(const char *locale, const char *str, double *result)
+{
+    bool ok;
+    *result = localeFor(locale).toDouble(QString::fromUtf8(str), &ok);
+    return ok ? 1 : 0;
+}
+
+int localeParseInt(const char *locale, const char *str, int64_t *result)
+{
+    bool ok;
+    *result = localeFor(locale).toLongLong(QString::fromUtf8(str), &ok);
+    return ok ? 1 : 0;
+}
+
+int localeParseDateTime(const char *locale, const char *str, int format, int part, int64_t *msecs)
+{
+    QLocale qlocale = localeFor(locale);
+    QLocale::FormatType qformat = (QLocale::FormatType)format;
+    QDateTime dt;
+    switch (part) {
+    case 1:
+        dt = QDateTime(qlocale.toDate(QString::fromUtf8(str), qformat));
+        break;
+    case 2:
+        dt = QDateTime(QDate(1970, 1, 1), qlocale.toTime(QString::fromUtf8(str), qformat));
+        break;
+    default:
+        dt = qlocale.toDateTime(QString::fromUtf8(str), qformat);
+        break;
+    }
+    if (!dt.isValid()) {
+        return 0;
+    }
+    *msecs = dt.toMSecsSinceEpoch();
+    return 1;
+}
+
 QString_ *newString(const char *data, int len)
 {
     // This will copy data only once.
@@ -529,6 +2236,15 @@ void goValueActivate(GoValue_ *value, GoTypeInfo *typeInfo, int addrOffset)
     // TODO Return an error; probably an unexported field.
 }
 
+void goValueActivateAll(GoValue_ *value, GoTypeInfo *typeInfo)
+{
+    GoMemberInfo *fieldInfo = typeInfo->fields;
+    for (int i = 0; i < typeInfo->fieldsLen; i++) {
+        reinterpret_cast<GoValue *>(value)->activate(fieldInfo->metaIndex);
+        fieldInfo++;
+    }
+}
+
 void unpackDataValue(DataValue *value, QVariant_ *var)
 {
     QVariant *qvar = reinterpret_cast<QVariant *>(var);
@@ -536,6 +2252,9 @@ void unpackDataValue(DataValue *value, QVariant_ *var)
     case DTString:
         *qvar = QString::fromUtf8(*(char **)value->data, value->len);
         break;
+    case DTByteArray:
+        *qvar = QByteArray(*(char **)value->data, value->len);
+        break;
     case DTBool:
         *qvar = bool(*(char *)(value->data) != 0);
         break;
@@ -554,10 +2273,30 @@ void unpackDataValue(DataValue *value, QVariant_ *var)
     case DTColor:
         *qvar = QColor::fromRgba(*(QRgb*)(value->data));
         break;
+    case DTDateTime:
+        {
+            qint64 msecs = *(qint64*)(value->data);
+            if (value->len == DateTimeUTC) {
+                *qvar = QDateTime::fromMSecsSinceEpoch(msecs, Qt::UTC);
+            } else if (value->len == DateTimeLocal) {
+                *qvar = QDateTime::fromMSecsSinceEpoch(msecs, Qt::LocalTime);
+            } else {
+                *qvar = QDateTime::fromMSecsSinceEpoch(msecs, Qt::OffsetFromUTC, value->len);
+            }
+        }
+        break;
     case DTVariantList:
         *qvar = **(QVariantList**)(value->data);
         delete *(QVariantList**)(value->data);
         break;
+    case DTVariant:
+        *qvar = **(QVariant**)(value->data);
+        delete *(QVariant**)(value->data);
+        break;
+    case DTJSValue:
+        qvar->setValue(**(QJSValue**)(value->data));
+        delete *(QJSValue**)(value->data);
+        break;
     case DTObject:
         qvar->setValue(*(QObject**)(value->data));
         break;
@@ -573,6 +2312,140 @@ void unpackDataValue(DataValue *value, QVariant_ *var)
     }
 }
 
+struct JSPromise {
+    QQmlEngine *engine;
+    QJSValue promise;
+    QJSValue resolve;
+    QJSValue reject;
+};
+
+JSPromise_ *newJSPromise(QQmlEngine_ *engine)
+{
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    QJSValue result = qengine->evaluate(
+        "(function() {"
+        "    var resolve, reject;"
+        "    var promise = new Promise(function(res, rej) { resolve = res; reject = rej });"
+        "    return {promise: promise, resolve: resolve, reject: function(msg) { reject(new Error(msg)) }};"
+        "})()");
+    if (result.isError()) {
+        panicf("cannot create JavaScript promise (requires Qt 5.12 or later): %s", result.toString().toUtf8().constData());
+    }
+    JSPromise *promise = new JSPromise;
+    promise->engine = qengine;
+    promise->promise = result.property("promise");
+    promise->resolve = result.property("resolve");
+    promise->reject = result.property("reject");
+    return promise;
+}
+
+QJSValue_ *jsPromiseValue(JSPromise_ *promise)
+{
+    return new QJSValue(reinterpret_cast<JSPromise *>(promise)->promise);
+}
+
+void jsPromiseSettle(JSPromise_ *promise, int reject, DataValue *value)
+{
+    JSPromise *jspromise = reinterpret_cast<JSPromise *>(promise);
+    QVariant var;
+    unpackDataValue(value, &var);
+    QJSValueList args;
+    args << jspromise->engine->toScriptValue(var);
+    if (reject) {
+        jspromise->reject.call(args);
+    } else {
+        jspromise->resolve.call(args);
+    }
+    delete jspromise;
+}
+
+QJSValue_ *jsValueCopy(QJSValue_ *value)
+{
+    return new QJSValue(*reinterpret_cast<QJSValue *>(value));
+}
+
+void delJSValue(QJSValue_ *value)
+{
+    delete reinterpret_cast<QJSValue *>(value);
+}
+
+error *jsValueCall(QQmlEngine_ *engine, QJSValue_ *value, DataValue *resultdv, DataValue *paramsdv, int paramsLen)
+{
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    QJSValue *fn = reinterpret_cast<QJSValue *>(value);
+
+    QJSValueList args;
+    QVariant param;
+    for (int i = 0; i < paramsLen; i++) {
+        unpackDataValue(&paramsdv[i], &param);
+        args << qengine->toScriptValue(param);
+    }
+    QJSValue result = fn->call(args);
+    if (result.isError()) {
+        return errorf("%s", result.toString().toUtf8().constData());
+    }
+    QVariant var = result.toVariant();
+    packDataValue(&var, resultdv);
+    return 0;
+}
+
+// GoStream forwards the values received from a Go channel to QML
+// through its element(value) signal, and emits finished() once the
+// channel is closed. There's no moc for it, so the meta object with
+// the signals is built at runtime.
+class GoStream : public QObject
+{
+public:
+    const QMetaObject *metaObject() const
+    {
+        static QMetaObject *mo = 0;
+        if (!mo) {
+            QMetaObjectBuilder mob;
+            mob.setSuperClass(&QObject::staticMetaObject);
+            mob.setClassName("GoStream");
+            QMetaMethodBuilder element = mob.addSignal("element(QVariant)");
+            element.setParameterNames(QList<QByteArray>() << "value");
+            mob.addSignal("finished()");
+            mo = mob.toMetaObject();
+        }
+        return mo;
+    }
+
+    int qt_metacall(QMetaObject::Call c, int id, void **a)
+    {
+        id = QObject::qt_metacall(c, id, a);
+        if (id >= 0 && c == QMetaObject::InvokeMetaMethod) {
+            QMetaObject::activate(this, metaObject(), id, a);
+        }
+        return -1;
+    }
+};
+
+QObject_ *newGoStream()
+{
+    GoStream *stream = new GoStream;
+    // Keep the stream alive while values are flowing, even if the
+    // JavaScript logic holds no references to it.
+    QQmlEngine::setObjectOwnership(stream, QQmlEngine::CppOwnership);
+    return stream;
+}
+
+void goStreamEmit(QObject_ *stream, DataValue *value)
+{
+    GoStream *qstream = reinterpret_cast<GoStream *>(stream);
+    QVariant var;
+    unpackDataValue(value, &var);
+    void *args[] = {0, &var};
+    QMetaObject::activate(qstream, qstream->metaObject(), 0, args);
+}
+
+void goStreamFinish(QObject_ *stream)
+{
+    GoStream *qstream = reinterpret_cast<GoStream *>(stream);
+    QMetaObject::activate(qstream, qstream->metaObject(), 1, 0);
+    QQmlEngine::setObjectOwnership(qstream, QQmlEngine::JavaScriptOwnership);
+}
+
 void packDataValue(QVariant_ *var, DataValue *value)
 {
     QVariant *qvar = reinterpret_cast<QVariant *>(var);
@@ -596,6 +2469,16 @@ void packDataValue(QVariant_ *var, DataValue *value)
             value->len = ba.size();
             break;
         }
+    case QMetaType::QByteArray:
+        {
+            value->dataType = DTByteArray;
+            QByteArray ba = qvar->toByteArray();
+            char *data = (char *) malloc(ba.size());
+            memcpy(data, ba.constData(), ba.size());
+            *(char**)(value->data) = data;
+            value->len = ba.size();
+            break;
+        }
     case QMetaType::Bool:
         value->dataType = DTBool;
         *(qint8*)(value->data) = (qint8)qvar->toInt();
@@ -620,6 +2503,31 @@ void packDataValue(QVariant_ *var, DataValue *value)
         value->dataType = DTColor;
         *(unsigned int*)(value->data) = qvar->value<QColor>().rgba();
         break;
+    case QMetaType::QDate:
+        *qvar = QDateTime(qvar->toDate());
+        // fallthrough
+    case QMetaType::QDateTime:
+        {
+            QDateTime dt = qvar->toDateTime();
+            if (!dt.isValid()) {
+                value->dataType = DTInvalid;
+                break;
+            }
+            value->dataType = DTDateTime;
+            *(qint64*)(value->data) = dt.toMSecsSinceEpoch();
+            switch (dt.timeSpec()) {
+            case Qt::UTC:
+                value->len = DateTimeUTC;
+                break;
+            case Qt::LocalTime:
+                value->len = DateTimeLocal;
+                break;
+            default:
+                value->len = dt.offsetFromUtc();
+                break;
+            }
+        }
+        break;
     case QMetaType::QVariantList:
         {
             QVariantList varlist = qvar->toList();
@@ -633,7 +2541,35 @@ void packDataValue(QVariant_ *var, DataValue *value)
             *(DataValue**)(value->data) = dvlist;
         }
         break;
+    case QMetaType::QVariantMap:
+        {
+            // Keys and values are interleaved.
+            QVariantMap varmap = qvar->toMap();
+            int len = varmap.size();
+            DataValue *dvlist = (DataValue *) malloc(sizeof(DataValue) * len * 2);
+            int i = 0;
+            for (QVariantMap::const_iterator it = varmap.constBegin(); it != varmap.constEnd(); ++it, i += 2) {
+                QVariant key(it.key());
+                packDataValue(&key, &dvlist[i]);
+                packDataValue((void*)&it.value(), &dvlist[i+1]);
+            }
+            value->dataType = DTValueMap;
+            value->len = len;
+            *(DataValue**)(value->data) = dvlist;
+        }
+        break;
     default:
+        if (qvar->userType() == qMetaTypeId<QJSValue>()) {
+            QJSValue jsvalue = qvar->value<QJSValue>();
+            if (jsvalue.isCallable()) {
+                value->dataType = DTJSValue;
+                *(QJSValue **)(value->data) = new QJSValue(jsvalue);
+            } else {
+                QVariant var = jsvalue.toVariant();
+                packDataValue(&var, value);
+            }
+            break;
+        }
         if (qvar->type() == (int)QMetaType::QObjectStar || qvar->canConvert<QObject *>()) {
             QObject *qobject = qvar->value<QObject *>();
             GoValue *govalue = dynamic_cast<GoValue *>(qobject);
@@ -695,6 +2631,57 @@ QVariantList_ *newVariantList(DataValue *list, int len)
     return vlist;
 }
 
+QVariant_ *newVariantMap(DataValue *pairs, int len)
+{
+    QVariantMap vmap;
+    for (int i = 0; i < len; i++) {
+        QVariant key, value;
+        unpackDataValue(&pairs[i*2], &key);
+        unpackDataValue(&pairs[i*2+1], &value);
+        vmap.insert(key.toString(), value);
+    }
+    return new QVariant(vmap);
+}
+
+QVariant_ *newVariantFromJSON(const char *data, int len)
+{
+    // Wrap the value in an array, as documents must hold an object
+    // or an array.
+    QByteArray json = "[" + QByteArray(data, len) + "]";
+    return new QVariant(QJsonDocument::fromJson(json).array().at(0).toVariant());
+}
+
+QVariantList_ *newVariantListFromArray(DataType elemType, void *data, int *offsets, int len)
+{
+    QVariantList *vlist = new QVariantList();
+    vlist->reserve(len);
+    for (int i = 0; i < len; i++) {
+        switch (elemType) {
+        case DTString:
+            vlist->append(QString::fromUtf8(reinterpret_cast<char *>(data) + offsets[i], offsets[i+1] - offsets[i]));
+            break;
+        case DTBool:
+            vlist->append(reinterpret_cast<char *>(data)[i] != 0);
+            break;
+        case DTInt64:
+            vlist->append(reinterpret_cast<qint64 *>(data)[i]);
+            break;
+        case DTInt32:
+            vlist->append(reinterpret_cast<qint32 *>(data)[i]);
+            break;
+        case DTFloat64:
+            vlist->append(reinterpret_cast<double *>(data)[i]);
+            break;
+        case DTFloat32:
+            vlist->append(reinterpret_cast<float *>(data)[i]);
+            break;
+        default:
+            panicf("unsupported array element type: %d", elemType);
+        }
+    }
+    return vlist;
+}
+
 QObject *listPropertyAt(QQmlListProperty<QObject> *list, int i)
 {
     return reinterpret_cast<QObject *>(hookListPropertyAt(list->dummy1, list->data, i));
@@ -730,10 +2717,53 @@ QQmlListProperty_ *newListProperty(QQmlEngine_ *engine, GoAddr *addr)
 void internalLogHandler(QtMsgType severity, const QMessageLogContext &context, const QString &text)
 {
     QByteArray textba = text.toUtf8();
-    LogMessage message = {severity, textba.constData(), textba.size(), context.file, (int)strlen(context.file), context.line};
+    // Release builds of Qt do not record the file nor the category.
+    const char *file = context.file ? context.file : "";
+    const char *category = context.category ? context.category : "default";
+    LogMessage message = {severity, textba.constData(), textba.size(), file, (int)strlen(file), context.line, category, (int)strlen(category)};
     hookLogHandler(&message);
 }
 
+void setLogFilterRules(const char *rules, int rulesLen)
+{
+    QLoggingCategory::setFilterRules(QString::fromUtf8(rules, rulesLen));
+}
+
+void *newLogCategory(const char *name)
+{
+    // The category keeps the name pointer, so it's never released.
+    return new QLoggingCategory(local_strdup(name));
+}
+
+int logCategoryEnabled(void *category, int severity)
+{
+    return reinterpret_cast<QLoggingCategory *>(category)->isEnabled(QtMsgType(severity));
+}
+
+void logCategoryOutput(void *category, int severity, const char *text, int textLen)
+{
+    QLoggingCategory *qcategory = reinterpret_cast<QLoggingCategory *>(category);
+    if (!qcategory->isEnabled(QtMsgType(severity))) {
+        return;
+    }
+    QByteArray textba(text, textLen);
+    QMessageLogger logger(0, 0, 0, qcategory->categoryName());
+    switch (severity) {
+    case QtDebugMsg:
+        logger.debug("%s", textba.constData());
+        break;
+    case QtInfoMsg:
+        logger.info("%s", textba.constData());
+        break;
+    case QtWarningMsg:
+        logger.warning("%s", textba.constData());
+        break;
+    default:
+        logger.critical("%s", textba.constData());
+        break;
+    }
+}
+
 void installLogHandler()
 {
     qInstallMessageHandler(internalLogHandler);
@@ -17,6 +17,8 @@ typedef void QApplication_;
 typedef void QMetaObject_;
 typedef void QObject_;
 typedef void QVariant_;
+typedef void QJSValue_;
+typedef void JSPromise_;
 typedef void QVariantList_;
 typedef void QString_;
 typedef void QQmlEngine_;
@@ -27,6 +29,18 @@ typedef void QQuickWindow_;
 typedef void QQuickView_;
 typedef void QMessageLogContext_;
 typedef void QImage_;
+typedef void QVideoProbe_;
+typedef void QAudioOutput_;
+typedef void QAudioInput_;
+typedef void QGeoPositionInfoSource_;
+typedef void QSensor_;
+typedef void QFileSystemWatcher_;
+typedef void QTextDocument_;
+typedef void QTextCursor_;
+typedef void QSyntaxHighlighter_;
+typedef void ScreenCapture_;
+typedef void QTimer_;
+typedef void QSoundEffect_;
 typedef void GoValue_;
 typedef void GoAddr;
 typedef void GoTypeSpec_;
@@ -35,6 +49,37 @@ typedef char error;
 error *errorf(const char *format, ...);
 void panicf(const char *format, ...);
 
+typedef struct {
+    unsigned int foreground; // ARGB, or zero to leave it unchanged.
+    unsigned int background; // ARGB, or zero to leave it unchanged.
+    int bold;
+    int italic;
+    int underline;
+} TextFormat;
+
+typedef struct {
+    unsigned char *planes[3]; // Y, Cb, and Cr samples.
+    int strides[3];           // Bytes between rows of each plane.
+    int steps[3];             // Bytes between samples in a row of each plane.
+    int width;
+    int height;
+    int subsample;            // 420 or 422, as in image.YCbCrSubsampleRatio.
+} YCbCrFrame;
+
+typedef struct {
+    int id;
+    int state;
+    double x;
+    double y;
+    double pressure;
+} TouchPoint;
+
+typedef enum {
+    AppStateFocusWindow = 1,
+    AppStateClipboard   = 2,
+    AppStateScreens     = 3
+} AppStateChange;
+
 typedef enum {
     DTUnknown = 0, // Has an unsupported type.
     DTInvalid = 1, // Does not exist or similar.
@@ -46,12 +91,17 @@ typedef enum {
     DTFloat64 = 14,
     DTFloat32 = 15,
     DTColor   = 16,
+    DTDateTime = 17, // See DateTimeZone for the meaning of len.
+    DTByteArray = 18,
 
     DTGoAddr       = 100,
     DTObject       = 101,
     DTValueList    = 102,
     DTVariantList  = 103,
     DTListProperty = 104,
+    DTValueMap     = 105,
+    DTJSValue      = 106,
+    DTVariant      = 107,
 
     // Used in type information, not in an actual data value.
     DTAny     = 201, // Can hold any of the above types.
@@ -64,8 +114,16 @@ typedef struct {
     int len;
 } DataValue;
 
+// DTDateTime values hold the milliseconds since the epoch in data, and
+// in len either the offset from UTC in seconds or one of these markers,
+// which are out of the range of valid offsets.
+typedef enum {
+    DateTimeUTC   = 1 << 30,
+    DateTimeLocal = (1 << 30) + 1
+} DateTimeZone;
+
 typedef struct {
-    char *memberName; // points to memberNames
+    char *memberName; // interned; shared with other types
     DataType memberType;
     int reflectIndex;
     int reflectChangedIndex;
@@ -85,7 +143,6 @@ typedef struct {
     int fieldsLen;
     int methodsLen;
     int membersLen;
-    char *memberNames;
 
     QMetaObject_ *metaObject;
 } GoTypeInfo;
@@ -97,11 +154,51 @@ typedef struct {
     const char *file;
     int fileLen;
     int line;
+    const char *category;
+    int categoryLen;
 } LogMessage;
 
 void newGuiApplication();
+void applicationEnableDebugger(int port);
+const char *applicationQtVersion();
+char *applicationObjectTree();
 void applicationExec();
 void applicationFlushAll();
+void applicationConnectState();
+int applicationState();
+QObject_ *applicationFocusWindow();
+char *applicationClipboardText();
+void applicationConnectAppState();
+int applicationColorScheme();
+void applicationConnectColorScheme();
+void applicationSetColorScheme(int scheme);
+void applicationSetFont(const char *family, double pointSize);
+
+int screenCount();
+char *screenInfo(int index, int *x, int *y, int *width, int *height, double *pixelRatio, int *primary);
+
+void gamepadConnect();
+int gamepadList(int *devices, int devicesLen);
+int gamepadConfigureButton(int deviceId, int button);
+int gamepadConfigureAxis(int deviceId, int axis);
+void gamepadResetConfiguration(int deviceId);
+
+error *svgRender(const char *data, int dataLen, int width, int height, QImage_ **image);
+
+error *itemHandleTouch(QObject_ *item, void *tracker);
+error *itemMapToItem(QObject_ *item, QObject_ *other, double *x, double *y);
+error *itemMapToGlobal(QObject_ *item, double *x, double *y);
+error *itemSetLayer(QObject_ *item, int enabled, int smooth, int mipmap, int textureWidth, int textureHeight, QObject_ *effect, const char *samplerName);
+error *itemLayer(QObject_ *item, int *enabled, int *smooth, int *mipmap, int *textureWidth, int *textureHeight, QObject_ **effect, char **samplerName);
+QObject_ *windowContentItem(QQuickWindow_ *win);
+QObject_ *windowOverlay(QQuickWindow_ *win);
+void windowItemsAt(QQuickWindow_ *win, double x, double y, DataValue *result);
+
+void inputPanelSetVisible(int visible);
+int inputPanelVisible();
+void inputPanelRect(int *x, int *y, int *width, int *height);
+void inputPanelConnect();
+void inputMethodFilterEvents();
 
 void idleTimerInit(int *hookWaiting);
 void idleTimerStart();
@@ -111,22 +208,42 @@ void *appThread();
 
 QQmlEngine_ *newEngine(QObject_ *parent);
 QQmlContext_ *engineRootContext(QQmlEngine_ *engine);
+void engineSetHTTPPrefix(QQmlEngine_ *engine, const char *prefix, int prefixLen, int enabled);
+void engineInterceptUrls(QQmlEngine_ *engine);
+void httpReplyFinish(void *reply, int status, const char *headers, int headersLen, const char *data, int dataLen);
+void httpReplyFail(void *reply, const char *message, int messageLen);
 void engineSetOwnershipCPP(QQmlEngine_ *engine, QObject_ *object);
 void engineSetOwnershipJS(QQmlEngine_ *engine, QObject_ *object);
 void engineSetContextForObject(QQmlEngine_ *engine, QObject_ *object);
+void engineCompileComponent(QQmlEngine_ *engine, const char *path, int pathLen, int keep, void *compile);
+char *applicationDiskCachePath();
 void engineAddImageProvider(QQmlEngine_ *engine, QString_ *providerId, void *imageFunc);
+error *engineAddAsyncImageProvider(QQmlEngine_ *engine, QString_ *providerId, void *imageFunc);
+void imageResponseFinish(void *response, QImage_ *image, const char *error, int errorLen);
+void engineSetUiLanguage(QQmlEngine_ *engine, const char *language);
+void engineAddScreenCaptureProvider(QQmlEngine_ *engine, QString_ *providerId, ScreenCapture_ *capture);
 
 void contextGetProperty(QQmlContext_ *context, QString_ *name, DataValue *value);
 void contextSetProperty(QQmlContext_ *context, QString_ *name, DataValue *value);
 void contextSetObject(QQmlContext_ *context, QObject_ *value);
+QQmlContext_ *contextSpawn(QQmlContext_ *context);
 
 void delObject(QObject_ *object);
 void delObjectLater(QObject_ *object);
 const char *objectTypeName(QObject_ *object);
 int objectGetProperty(QObject_ *object, const char *name, DataValue *result);
-void objectSetProperty(QObject_ *object, const char *name, DataValue *value);
+error *objectSetProperty(QObject_ *object, const char *name, DataValue *value);
 void objectSetParent(QObject_ *object, QObject_ *parent);
+void objectSetName(QObject_ *object, const char *name, int nameLen);
 error *objectInvoke(QObject_ *object, const char *method, int methodLen, DataValue *result, DataValue *params, int paramsLen);
+error *objectBind(QQmlEngine_ *engine, QObject_ *object, const char *name, int nameLen, DataValue *evaluator);
+void objectFindShortcuts(QObject_ *object, DataValue *result);
+char *shortcutSequence(QObject_ *object);
+void shortcutSetSequence(QObject_ *object, const char *seq, int seqLen);
+QObject_ *objectAnimate(QObject_ *object, const char *name, int nameLen, DataValue *from, DataValue *to, int msecs, int easing);
+void animationRetarget(QObject_ *animation, DataValue *to);
+void useFakeClock(int enabled);
+void advanceFakeClock(int64_t msecs);
 void objectFindChild(QObject_ *object, QString_ *name, DataValue *result);
 QQmlContext_ *objectContext(QObject_ *object);
 int objectIsComponent(QObject_ *object);
@@ -135,8 +252,49 @@ int objectIsView(QObject_ *object);
 error *objectConnect(QObject_ *object, const char *signal, int signalLen, QQmlEngine_ *engine, void *func, int argsLen);
 error *objectGoAddr(QObject_ *object, GoAddr **addr);
 
+error *dbusRegisterObject(int bus, const char *service, const char *path, QObject_ *object);
+void dbusUnregisterObject(int bus, const char *path);
+unsigned int dbusInhibitScreenSaver(const char *app, const char *reason);
+void dbusUninhibitScreenSaver(unsigned int cookie);
+int dbusInhibitSleep(const char *app, const char *reason);
+void dbusConnectPowerEvents();
+void dbusUpdateLauncherEntry(double progress, int badge);
+
+void androidRequestPermissions(char **permissions, int permissionsLen, void *func);
+error *androidStartActivity(const char *action, const char *data, const char *type, char **extras, int extrasLen, int requestCode, void *func);
+void androidSetSystemBarStyle(int bar, unsigned int argb, int darkIcons);
+void androidHapticFeedback(int effect);
+void androidVibrate(int64_t msecs);
+void androidKeepScreenOn(int on);
+
+void iosObserveMemoryWarnings();
+void iosShare(const char *text, const char *url);
+void iosPickDocuments(const char *types, int multiple, void *func);
+void iosSetStatusBarStyle(int darkIcons);
+void iosHapticFeedback(int effect);
+void iosVibrate();
+void iosSetIdleTimerDisabled(int disabled);
+void iosSetBadgeNumber(int count);
+
+void darwinNoteRecentDocument(const char *path, int pathLen);
+void darwinClearRecentDocuments();
+void darwinUpdateDockTile(double progress, int badge);
+
+void windowsAddRecentDocument(const unsigned short *path);
+void windowsSetTaskbarProgress(double progress);
+
+error *seriesAppend(QObject_ *series, void *xs, void *ys, int len, int isFloat32, int replace);
+int seriesSetPoints(QObject_ *series, void *xs, void *ys, int len, int isFloat32, int replace);
+
+int statsObjectsAlive();
+int statsComponentsAlive();
+
 QQmlComponent_ *newComponent(QQmlEngine_ *engine, QObject_ *parent);
 void componentSetData(QQmlComponent_ *component, const char *data, int dataLen, const char *url, int urlLen);
+char *engineStackTrace(QQmlEngine_ *engine);
+void engineCollectGarbage(QQmlEngine_ *engine);
+int engineJSHeapSize(QQmlEngine_ *engine, int64_t *size);
+QObject_ *newIdleCollector(QQmlEngine_ *engine, int msecs);
 char *componentErrorString(QQmlComponent_ *component);
 QObject_ *componentCreate(QQmlComponent_ *component, QQmlContext_ *context);
 QQuickWindow_ *componentCreateWindow(QQmlComponent_ *component, QQmlContext_ *context);
@@ -144,6 +302,19 @@ QQuickWindow_ *componentCreateWindow(QQmlComponent_ *component, QQmlContext_ *co
 void windowShow(QQuickWindow_ *win);
 void windowHide(QQuickWindow_ *win);
 void windowConnectHidden(QQuickWindow_ *win);
+int windowStartSystemMove(QQuickWindow_ *win);
+int windowStartSystemResize(QQuickWindow_ *win, int edges);
+error *windowSetFullScreenOn(QQuickWindow_ *win, int screen);
+error *windowEnterKiosk(QQuickWindow_ *win, int screen);
+void windowExitKiosk(QQuickWindow_ *win);
+QTimer_ *windowStartRecording(QQuickWindow_ *win, int interval, void *recorder);
+void windowStopRecording(QTimer_ *timer);
+void windowReleaseResources(QQuickWindow_ *win);
+void applicationReleaseResources();
+void windowSetPersistentResources(QQuickWindow_ *win, int persistent);
+int64_t windowTextureMemory(QQuickWindow_ *win);
+QObject_ *windowStartFrameTiming(QQuickWindow_ *win, void *func);
+void windowStopFrameTiming(QObject_ *timer);
 QObject_ *windowRootObject(QQuickWindow_ *win);
 QImage_ *windowGrabWindow(QQuickWindow_ *win);
 
@@ -153,16 +324,116 @@ void imageSize(QImage_ *image, int *width, int *height);
 unsigned char *imageBits(QImage_ *image);
 const unsigned char *imageConstBits(QImage_ *image);
 
+QVideoProbe_ *newVideoProbe(QObject_ *source, void *frameFunc);
+void delVideoProbe(QVideoProbe_ *probe);
+
+QAudioOutput_ *newAudioOutput(int sampleRate, int channelCount, int sampleSize, void *stream);
+void delAudioOutput(QAudioOutput_ *output);
+QAudioInput_ *newAudioInput(int sampleRate, int channelCount, int sampleSize, void *stream);
+void delAudioInput(QAudioInput_ *input);
+
+QGeoPositionInfoSource_ *newPositionSource(void *func);
+void delPositionSource(QGeoPositionInfoSource_ *source);
+void positionSourceStart(QGeoPositionInfoSource_ *source, int interval);
+void positionSourceStop(QGeoPositionInfoSource_ *source);
+
+QSensor_ *newSensor(const char *type, void *func);
+void delSensor(QSensor_ *sensor);
+int sensorStart(QSensor_ *sensor);
+void sensorStop(QSensor_ *sensor);
+
+QSoundEffect_ *newSoundEffect(const char *url);
+void delSoundEffect(QSoundEffect_ *effect);
+void soundEffectPlay(QSoundEffect_ *effect);
+void soundEffectStop(QSoundEffect_ *effect);
+void soundEffectSetVolume(QSoundEffect_ *effect, double volume);
+void soundEffectSetLoops(QSoundEffect_ *effect, int loops);
+
+QFileSystemWatcher_ *newFileWatcher(void *watcher);
+void delFileWatcher(QFileSystemWatcher_ *fw);
+int fileWatcherAdd(QFileSystemWatcher_ *fw, const char *path);
+void fileWatcherRemove(QFileSystemWatcher_ *fw, const char *path);
+char *fileWatcherPaths(QFileSystemWatcher_ *fw);
+
+QTextDocument_ *objectTextDocument(QObject_ *object);
+void textDocumentConnect(QTextDocument_ *doc);
+char *textDocumentText(QTextDocument_ *doc);
+int textDocumentLength(QTextDocument_ *doc);
+int textDocumentBlockCount(QTextDocument_ *doc);
+char *textDocumentBlockText(QTextDocument_ *doc, int block, int *position);
+QTextCursor_ *newTextCursor(QTextDocument_ *doc);
+void delTextCursor(QTextCursor_ *cursor);
+int textCursorPosition(QTextCursor_ *cursor);
+int textCursorAnchor(QTextCursor_ *cursor);
+void textCursorSetPosition(QTextCursor_ *cursor, int position, int keepAnchor);
+void textCursorInsertText(QTextCursor_ *cursor, const char *text, int textLen);
+void textCursorRemoveSelectedText(QTextCursor_ *cursor);
+char *textCursorSelectedText(QTextCursor_ *cursor);
+void textCursorMergeFormat(QTextCursor_ *cursor, TextFormat *format);
+void textCursorClearFormat(QTextCursor_ *cursor);
+void textCursorBeginEditBlock(QTextCursor_ *cursor);
+void textCursorEndEditBlock(QTextCursor_ *cursor);
+
+QSyntaxHighlighter_ *newHighlighter(QTextDocument_ *doc);
+void delHighlighter(QSyntaxHighlighter_ *highlighter);
+void highlighterSetFormat(QSyntaxHighlighter_ *highlighter, int start, int length, TextFormat *format);
+void highlighterRehighlight(QSyntaxHighlighter_ *highlighter);
+
+ScreenCapture_ *newScreenCapture(void *capture, uintptr_t window);
+void delScreenCapture(ScreenCapture_ *capture);
+void screenCaptureSetRegion(ScreenCapture_ *capture, int x, int y, int width, int height);
+void screenCaptureStart(ScreenCapture_ *capture, int interval);
+void screenCaptureStop(ScreenCapture_ *capture);
+QImage_ *screenCaptureImage(ScreenCapture_ *capture);
+
+char *localeName(const char *locale);
+void localeSetDefault(const char *locale);
+char *localeFormatFloat(const char *locale, double value, char format, int precision);
+char *localeFormatInt(const char *locale, int64_t value);
+char *localeFormatCurrency(const char *locale, double value, const char *symbol);
+unsigned int colorDarker(unsigned int argb, double factor);
+unsigned int colorLighter(unsigned int argb, double factor);
+unsigned int colorAlpha(unsigned int argb, double alpha);
+unsigned int colorHSLA(double h, double s, double l, double a);
+unsigned int colorTint(unsigned int base, unsigned int tint);
+
+QObject_ *newPropertyMap(QQmlEngine_ *engine);
+void propertyMapInsert(QObject_ *map, const char *key, int keyLen, DataValue *value);
+void propertyMapClear(QObject_ *map, const char *key, int keyLen);
+
+char *localeFormatDecimal(const char *locale, const char *digits, int currency, const char *symbol);
+char *localeParseDecimal(const char *locale, const char *str);
+char *localeFormatDateTime(const char *locale, int64_t msecs, int offset, int format, int part);
+int localeParseFloat(const char *locale, const char *str, double *result);
+int localeParseInt(const char *locale, const char *str, int64_t *result);
+int localeParseDateTime(const char *locale, const char *str, int format, int part, int64_t *msecs);
+
 QString_ *newString(const char *data, int len);
 void delString(QString_ *s);
 
 GoValue_ *newGoValue(GoAddr *addr, GoTypeInfo *typeInfo, QObject_ *parent);
 void goValueActivate(GoValue_ *value, GoTypeInfo *typeInfo, int addrOffset);
+void goValueActivateAll(GoValue_ *value, GoTypeInfo *typeInfo);
+
+JSPromise_ *newJSPromise(QQmlEngine_ *engine);
+QJSValue_ *jsPromiseValue(JSPromise_ *promise);
+void jsPromiseSettle(JSPromise_ *promise, int reject, DataValue *value);
+
+QJSValue_ *jsValueCopy(QJSValue_ *value);
+void delJSValue(QJSValue_ *value);
+error *jsValueCall(QQmlEngine_ *engine, QJSValue_ *value, DataValue *result, DataValue *params, int paramsLen);
+
+QObject_ *newGoStream();
+void goStreamEmit(QObject_ *stream, DataValue *value);
+void goStreamFinish(QObject_ *stream);
 
 void packDataValue(QVariant_ *var, DataValue *result);
 void unpackDataValue(DataValue *value, QVariant_ *result);
 
 QVariantList_ *newVariantList(DataValue *list, int len);
+QVariant_ *newVariantMap(DataValue *pairs, int len);
+QVariant_ *newVariantFromJSON(const char *data, int len);
+QVariantList_ *newVariantListFromArray(DataType elemType, void *data, int *offsets, int len);
 
 QQmlListProperty_ *newListProperty(QQmlEngine_ *engine, GoAddr *addr);
 
@@ -170,6 +441,10 @@ int registerType(char *location, int major, int minor, char *name, GoTypeInfo *t
 int registerSingleton(char *location, int major, int minor, char *name, GoTypeInfo *typeInfo, GoTypeSpec_ *spec);
 
 void installLogHandler();
+void setLogFilterRules(const char *rules, int rulesLen);
+void *newLogCategory(const char *name);
+int logCategoryEnabled(void *category, int severity);
+void logCategoryOutput(void *category, int severity, const char *text, int textLen);
 
 void hookIdleTimer();
 void hookLogHandler(LogMessage *message);
@@ -178,8 +453,42 @@ void hookGoValueWriteField(QQmlEngine_ *engine, GoAddr *addr, int memberIndex, i
 void hookGoValueCallMethod(QQmlEngine_ *engine, GoAddr *addr, int memberIndex, DataValue *result);
 void hookGoValueDestroyed(QQmlEngine_ *engine, GoAddr *addr);
 QImage_ *hookRequestImage(void *imageFunc, char *id, int idLen, int width, int height);
+void hookRequestImageResponse(void *imageFunc, void *response, char *id, int idLen, int width, int height);
+void hookImageResponseCancel(void *response);
 GoAddr *hookGoValueTypeNew(GoValue_ *value, GoTypeSpec_ *spec);
+void hookGoValueTypeCreated(GoValue_ *value, GoAddr *addr);
 void hookWindowHidden(QObject_ *addr);
+void hookVideoFrame(void *frameFunc, QImage_ *image);
+void hookVideoFrameYCbCr(void *frameFunc, YCbCrFrame *frame);
+int hookAudioRead(void *stream, char *data, int maxLen);
+int hookAudioWrite(void *stream, char *data, int len);
+void hookPositionUpdated(void *func, double latitude, double longitude, double altitude, int64_t timestamp);
+void hookSensorReading(void *func, double *values, int valuesLen);
+void hookAndroidPermissions(void *func, char *granted, int grantedLen);
+void hookAndroidActivityResult(void *func, int resultCode, char *data, int dataLen);
+void hookApplicationStateChanged(int state);
+void hookAppStateChanged(int what);
+void hookColorSchemeChanged(int scheme);
+void hookFileChanged(void *watcher, char *path, int pathLen);
+void hookTextDocumentChanged(QTextDocument_ *doc, int position, int removed, int added);
+void hookTextDocumentDestroyed(QTextDocument_ *doc);
+void hookScreenCaptured(void *capture);
+void hookPowerEvent(int event);
+void hookTouchEvent(void *tracker, TouchPoint *points, int pointsLen);
+void hookGamepadConnected(int deviceId, int connected);
+void hookGamepadAxis(int deviceId, int axis, double value);
+void hookGamepadButton(int deviceId, int button, double value, int pressed);
+void hookWindowFrame(void *recorder, QImage_ *image);
+void hookComponentCompiled(void *compile, QQmlComponent_ *component, char *error, int errorLen);
+void hookFrameTiming(void *func, int64_t sync, int64_t render, int64_t swap, int64_t interval, int missed);
+int hookHighlightBlock(QTextDocument_ *doc, QSyntaxHighlighter_ *highlighter, char *text, int textLen, int previousState);
+void hookInputPanelChanged();
+int hookInputMethodEvent(char *preedit, int preeditLen, char *commit, int commitLen, int replaceStart, int replaceLen);
+void hookMemoryWarning();
+void hookDocumentsPicked(void *func, char *paths, int pathsLen);
+void hookHTTPRequest(QQmlEngine_ *engine, void *reply, char *prefix, int prefixLen, char *method, int methodLen, char *url, int urlLen, char *headers, int headersLen, char *body, int bodyLen);
+int hookSandboxAllows(QQmlEngine_ *engine, char *url, int urlLen, int xhr);
+int hookLintAllows(QQmlEngine_ *engine, char *url, int urlLen);
 void hookSignalCall(QQmlEngine_ *engine, void *func, DataValue *params);
 void hookSignalDisconnect(void *func);
 void hookPanic(char *message);
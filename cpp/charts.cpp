@@ -0,0 +1,42 @@
+#include <QtCharts/QXYSeries>
+
+#include "capi.h"
+
+#ifdef QT_CHARTS_USE_NAMESPACE
+QT_CHARTS_USE_NAMESPACE
+#endif
+
+int seriesSetPoints(QObject_ *series, void *xs, void *ys, int len, int isFloat32, int replace)
+{
+    QXYSeries *xy = qobject_cast<QXYSeries *>(reinterpret_cast<QObject *>(series));
+    if (!xy) {
+        return 0;
+    }
+    QVector<QPointF> points;
+    points.reserve(len);
+    if (isFloat32) {
+        const float *fxs = reinterpret_cast<float *>(xs);
+        const float *fys = reinterpret_cast<float *>(ys);
+        for (int i = 0; i < len; i++) {
+            points.append(QPointF(fxs[i], fys[i]));
+        }
+    } else {
+        const double *dxs = reinterpret_cast<double *>(xs);
+        const double *dys = reinterpret_cast<double *>(ys);
+        for (int i = 0; i < len; i++) {
+            points.append(QPointF(dxs[i], dys[i]));
+        }
+    }
+    if (replace) {
+        xy->replace(points);
+    } else {
+#if QT_VERSION >= QT_VERSION_CHECK(6, 0, 0)
+        xy->append(points);
+#else
+        xy->append(points.toList());
+#endif
+    }
+    return 1;
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
@@ -0,0 +1,12 @@
+#include "capi.h"
+
+// Series are updated point by point via their append method unless the
+// package is built with the qmlcharts tag, which links against the Qt
+// Charts module.
+
+int seriesSetPoints(QObject_ *series, void *xs, void *ys, int len, int isFloat32, int replace)
+{
+    return 0;
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
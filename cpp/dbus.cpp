@@ -0,0 +1,40 @@
+#include <QtDBus/QDBusConnection>
+#include <QtDBus/QDBusError>
+
+#include "capi.h"
+
+static QDBusConnection dbusConnection(int bus)
+{
+    if (bus == 1) {
+        return QDBusConnection::systemBus();
+    }
+    return QDBusConnection::sessionBus();
+}
+
+error *dbusRegisterObject(int bus, const char *service, const char *path, QObject_ *object)
+{
+    QDBusConnection conn = dbusConnection(bus);
+    if (!conn.isConnected()) {
+        QByteArray ba = conn.lastError().message().toUtf8();
+        return errorf("cannot connect to D-Bus: %s", ba.constData());
+    }
+    if (*service && !conn.registerService(QString::fromUtf8(service))) {
+        QByteArray ba = conn.lastError().message().toUtf8();
+        return errorf("cannot register D-Bus service %s: %s", service, ba.constData());
+    }
+
+    // Methods of Go values are not slots, so invokables must be exported too.
+    QObject *qobject = reinterpret_cast<QObject *>(object);
+    QDBusConnection::RegisterOptions options = QDBusConnection::ExportAllContents | QDBusConnection::ExportAllInvokables;
+    if (!conn.registerObject(QString::fromUtf8(path), qobject, options)) {
+        return errorf("cannot register D-Bus object at path %s", path);
+    }
+    return 0;
+}
+
+void dbusUnregisterObject(int bus, const char *path)
+{
+    dbusConnection(bus).unregisterObject(QString::fromUtf8(path));
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
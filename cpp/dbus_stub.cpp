@@ -0,0 +1,15 @@
+#include "capi.h"
+
+// Publishing Go values on D-Bus is unsupported unless the package is
+// built with the qmldbus tag.
+
+error *dbusRegisterObject(int bus, const char *service, const char *path, QObject_ *object)
+{
+    return errorf("D-Bus support requires building with the qmldbus tag");
+}
+
+void dbusUnregisterObject(int bus, const char *path)
+{
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
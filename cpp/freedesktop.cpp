@@ -0,0 +1,113 @@
+#include <QtDBus/QDBusConnection>
+#include <QtDBus/QDBusMessage>
+#include <QtDBus/QDBusReply>
+#include <QtDBus/QDBusUnixFileDescriptor>
+#include <QTimer>
+#include <QCoreApplication>
+#include <QGuiApplication>
+
+#include <unistd.h>
+
+#include "capi.h"
+
+// The freedesktop.org services used for power and launcher integration
+// on Linux are reached over D-Bus whether or not the package is built
+// with the qmldbus tag, which only covers publishing Go values.
+
+unsigned int dbusInhibitScreenSaver(const char *app, const char *reason)
+{
+    QDBusMessage msg = QDBusMessage::createMethodCall("org.freedesktop.ScreenSaver", "/org/freedesktop/ScreenSaver",
+                                                      "org.freedesktop.ScreenSaver", "Inhibit");
+    msg << QString::fromUtf8(app) << QString::fromUtf8(reason);
+    QDBusReply<unsigned int> reply = QDBusConnection::sessionBus().call(msg);
+    return reply.isValid() ? reply.value() : 0;
+}
+
+void dbusUninhibitScreenSaver(unsigned int cookie)
+{
+    QDBusMessage msg = QDBusMessage::createMethodCall("org.freedesktop.ScreenSaver", "/org/freedesktop/ScreenSaver",
+                                                      "org.freedesktop.ScreenSaver", "UnInhibit");
+    msg << cookie;
+    QDBusConnection::sessionBus().call(msg, QDBus::NoBlock);
+}
+
+int dbusInhibitSleep(const char *app, const char *reason)
+{
+    QDBusMessage msg = QDBusMessage::createMethodCall("org.freedesktop.login1", "/org/freedesktop/login1",
+                                                      "org.freedesktop.login1.Manager", "Inhibit");
+    msg << QString("sleep:idle") << QString::fromUtf8(app) << QString::fromUtf8(reason) << QString("block");
+    QDBusReply<QDBusUnixFileDescriptor> reply = QDBusConnection::systemBus().call(msg);
+    if (!reply.isValid() || !reply.value().isValid()) {
+        return -1;
+    }
+    // The lock is held while the descriptor is open.
+    return dup(reply.value().fileDescriptor());
+}
+
+static bool dbusBoolProperty(QDBusConnection conn, const char *service, const char *path, const char *iface, const char *name)
+{
+    QDBusMessage msg = QDBusMessage::createMethodCall(service, path, "org.freedesktop.DBus.Properties", "Get");
+    msg << QString(iface) << QString(name);
+    QDBusReply<QVariant> reply = conn.call(msg);
+    return reply.isValid() && reply.value().toBool();
+}
+
+// QtDBus only delivers signals to slots, so the signals are connected
+// to the start slot of a zero-interval timer, and the new state is read
+// when the timer fires.
+static QTimer *dbusSignalTimer(const char *service, const char *path, const char *iface, const char *name, QDBusConnection conn)
+{
+    QTimer *timer = new QTimer(qApp);
+    timer->setSingleShot(true);
+    timer->setInterval(0);
+    conn.connect(service, path, iface, name, timer, SLOT(start()));
+    return timer;
+}
+
+void dbusConnectPowerEvents()
+{
+    QDBusConnection system = QDBusConnection::systemBus();
+    QTimer *sleepTimer = dbusSignalTimer("org.freedesktop.login1", "/org/freedesktop/login1",
+                                         "org.freedesktop.login1.Manager", "PrepareForSleep", system);
+    QObject::connect(sleepTimer, &QTimer::timeout, [=]() {
+        bool sleeping = dbusBoolProperty(system, "org.freedesktop.login1", "/org/freedesktop/login1",
+                                         "org.freedesktop.login1.Manager", "PreparingForSleep");
+        hookPowerEvent(sleeping ? 0 : 1);
+    });
+
+    QDBusConnection session = QDBusConnection::sessionBus();
+    QTimer *lockTimer = dbusSignalTimer("org.freedesktop.ScreenSaver", "/org/freedesktop/ScreenSaver",
+                                        "org.freedesktop.ScreenSaver", "ActiveChanged", session);
+    QObject::connect(lockTimer, &QTimer::timeout, [=]() {
+        QDBusMessage msg = QDBusMessage::createMethodCall("org.freedesktop.ScreenSaver", "/org/freedesktop/ScreenSaver",
+                                                          "org.freedesktop.ScreenSaver", "GetActive");
+        QDBusReply<bool> reply = session.call(msg);
+        hookPowerEvent(reply.isValid() && reply.value() ? 2 : 3);
+    });
+}
+
+void dbusUpdateLauncherEntry(double progress, int badge)
+{
+    QString desktop = QGuiApplication::applicationName();
+#if QT_VERSION >= QT_VERSION_CHECK(5, 7, 0)
+    if (!QGuiApplication::desktopFileName().isEmpty()) {
+        desktop = QGuiApplication::desktopFileName();
+    }
+#endif
+    if (!desktop.endsWith(".desktop")) {
+        desktop += ".desktop";
+    }
+
+    QVariantMap props;
+    props["progress"] = progress < 0 ? 0.0 : progress;
+    props["progress-visible"] = progress >= 0;
+    props["count"] = qint64(badge);
+    props["count-visible"] = badge > 0;
+
+    QDBusMessage msg = QDBusMessage::createSignal("/com/canonical/unity/launcherentry/" + QString::number(qHash(desktop)),
+                                                  "com.canonical.Unity.LauncherEntry", "Update");
+    msg << "application://" + desktop << props;
+    QDBusConnection::sessionBus().send(msg);
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
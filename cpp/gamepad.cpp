@@ -0,0 +1,49 @@
+#include <QtGamepad/QGamepadManager>
+
+#include "capi.h"
+
+void gamepadConnect()
+{
+    QGamepadManager *manager = QGamepadManager::instance();
+    QObject::connect(manager, &QGamepadManager::gamepadConnected, [=](int deviceId) {
+        hookGamepadConnected(deviceId, 1);
+    });
+    QObject::connect(manager, &QGamepadManager::gamepadDisconnected, [=](int deviceId) {
+        hookGamepadConnected(deviceId, 0);
+    });
+    QObject::connect(manager, &QGamepadManager::gamepadAxisEvent, [=](int deviceId, QGamepadManager::GamepadAxis axis, double value) {
+        hookGamepadAxis(deviceId, axis, value);
+    });
+    QObject::connect(manager, &QGamepadManager::gamepadButtonPressEvent, [=](int deviceId, QGamepadManager::GamepadButton button, double value) {
+        hookGamepadButton(deviceId, button, value, 1);
+    });
+    QObject::connect(manager, &QGamepadManager::gamepadButtonReleaseEvent, [=](int deviceId, QGamepadManager::GamepadButton button) {
+        hookGamepadButton(deviceId, button, 0, 0);
+    });
+}
+
+int gamepadList(int *devices, int devicesLen)
+{
+    QList<int> connected = QGamepadManager::instance()->connectedGamepads();
+    for (int i = 0; i < connected.size() && i < devicesLen; i++) {
+        devices[i] = connected[i];
+    }
+    return connected.size();
+}
+
+int gamepadConfigureButton(int deviceId, int button)
+{
+    return QGamepadManager::instance()->configureButton(deviceId, QGamepadManager::GamepadButton(button));
+}
+
+int gamepadConfigureAxis(int deviceId, int axis)
+{
+    return QGamepadManager::instance()->configureAxis(deviceId, QGamepadManager::GamepadAxis(axis));
+}
+
+void gamepadResetConfiguration(int deviceId)
+{
+    QGamepadManager::instance()->resetConfiguration(deviceId);
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
@@ -0,0 +1,29 @@
+#include "capi.h"
+
+// Gamepads are unsupported unless the package is built with the
+// qmlgamepad tag, which links against the Qt Gamepad module.
+
+void gamepadConnect()
+{
+}
+
+int gamepadList(int *devices, int devicesLen)
+{
+    return 0;
+}
+
+int gamepadConfigureButton(int deviceId, int button)
+{
+    return 0;
+}
+
+int gamepadConfigureAxis(int deviceId, int axis)
+{
+    return 0;
+}
+
+void gamepadResetConfiguration(int deviceId)
+{
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
@@ -9,7 +9,10 @@ class GoValueType : public GoValue
 public:
 
     GoValueType()
-        : GoValue(hookGoValueTypeNew(this, typeSpec), typeInfo, 0) {};
+        : GoValue(hookGoValueTypeNew(this, typeSpec), typeInfo, 0)
+    {
+        hookGoValueTypeCreated(this, addr);
+    };
 
     static void init(GoTypeInfo *info, GoTypeSpec_ *spec)
     {
@@ -0,0 +1,217 @@
+#include <QImage>
+#include <QMediaObject>
+#include <QVideoProbe>
+#include <QAudioOutput>
+#include <QAudioInput>
+#include <QSoundEffect>
+
+#include <climits>
+
+#include "capi.h"
+
+static void setPlane(YCbCrFrame *yuv, int i, uchar *plane, int stride, int step)
+{
+    yuv->planes[i] = plane;
+    yuv->strides[i] = stride;
+    yuv->steps[i] = step;
+}
+
+// videoFrameYCbCr describes in yuv the samples of the mapped frame, and
+// returns whether the frame holds them in a supported YUV format.
+static bool videoFrameYCbCr(QVideoFrame &frame, YCbCrFrame *yuv)
+{
+    yuv->width = frame.width();
+    yuv->height = frame.height();
+    switch (frame.pixelFormat()) {
+    case QVideoFrame::Format_YUV420P:
+    case QVideoFrame::Format_YV12: {
+        if (frame.planeCount() < 3) {
+            return false;
+        }
+        int cb = frame.pixelFormat() == QVideoFrame::Format_YUV420P ? 1 : 2;
+        int cr = 3 - cb;
+        setPlane(yuv, 0, frame.bits(0), frame.bytesPerLine(0), 1);
+        setPlane(yuv, 1, frame.bits(cb), frame.bytesPerLine(cb), 1);
+        setPlane(yuv, 2, frame.bits(cr), frame.bytesPerLine(cr), 1);
+        yuv->subsample = 420;
+        return true;
+    }
+    case QVideoFrame::Format_NV12:
+    case QVideoFrame::Format_NV21: {
+        if (frame.planeCount() < 2) {
+            return false;
+        }
+        // Chroma samples are interleaved in the second plane.
+        int cb = frame.pixelFormat() == QVideoFrame::Format_NV12 ? 0 : 1;
+        setPlane(yuv, 0, frame.bits(0), frame.bytesPerLine(0), 1);
+        setPlane(yuv, 1, frame.bits(1) + cb, frame.bytesPerLine(1), 2);
+        setPlane(yuv, 2, frame.bits(1) + 1 - cb, frame.bytesPerLine(1), 2);
+        yuv->subsample = 420;
+        return true;
+    }
+    case QVideoFrame::Format_UYVY:
+        // Packed as U0 Y0 V0 Y1.
+        setPlane(yuv, 0, frame.bits() + 1, frame.bytesPerLine(), 2);
+        setPlane(yuv, 1, frame.bits(), frame.bytesPerLine(), 4);
+        setPlane(yuv, 2, frame.bits() + 2, frame.bytesPerLine(), 4);
+        yuv->subsample = 422;
+        return true;
+    case QVideoFrame::Format_YUYV:
+        // Packed as Y0 U0 Y1 V0.
+        setPlane(yuv, 0, frame.bits(), frame.bytesPerLine(), 2);
+        setPlane(yuv, 1, frame.bits() + 1, frame.bytesPerLine(), 4);
+        setPlane(yuv, 2, frame.bits() + 3, frame.bytesPerLine(), 4);
+        yuv->subsample = 422;
+        return true;
+    default:
+        return false;
+    }
+}
+
+QVideoProbe_ *newVideoProbe(QObject_ *source, void *frameFunc)
+{
+    QObject *qsource = reinterpret_cast<QObject *>(source);
+
+    // QML media elements hold the real media object in a property.
+    QMediaObject *media = qobject_cast<QMediaObject *>(qsource->property("mediaObject").value<QObject *>());
+    if (!media) {
+        media = qobject_cast<QMediaObject *>(qsource);
+    }
+    if (!media) {
+        return 0;
+    }
+
+    QVideoProbe *probe = new QVideoProbe();
+    if (!probe->setSource(media)) {
+        delete probe;
+        return 0;
+    }
+    QObject::connect(probe, &QVideoProbe::videoFrameProbed, [=](const QVideoFrame &frame) {
+        QVideoFrame qframe(frame);
+        if (!qframe.map(QAbstractVideoBuffer::ReadOnly)) {
+            return;
+        }
+        QImage::Format format = QVideoFrame::imageFormatFromPixelFormat(qframe.pixelFormat());
+        if (format != QImage::Format_Invalid) {
+            QImage image(qframe.bits(), qframe.width(), qframe.height(), qframe.bytesPerLine(), format);
+            QImage converted = image.convertToFormat(QImage::Format_ARGB32_Premultiplied);
+            qframe.unmap();
+            hookVideoFrame(frameFunc, &converted);
+            return;
+        }
+        // Cameras mostly produce YUV frames, which QImage can't hold.
+        // The samples are copied by the hook before the frame is unmapped.
+        YCbCrFrame yuv;
+        if (videoFrameYCbCr(qframe, &yuv)) {
+            hookVideoFrameYCbCr(frameFunc, &yuv);
+        }
+        qframe.unmap();
+    });
+    return probe;
+}
+
+void delVideoProbe(QVideoProbe_ *probe)
+{
+    delete reinterpret_cast<QVideoProbe *>(probe);
+}
+
+class GoAudioDevice : public QIODevice {
+
+    public:
+
+    GoAudioDevice(void *stream, QObject *parent) : QIODevice(parent), stream(stream) {};
+
+    protected:
+
+    virtual qint64 readData(char *data, qint64 maxLen)
+    {
+        return hookAudioRead(stream, data, (int)qMin(maxLen, (qint64)INT_MAX));
+    };
+
+    virtual qint64 writeData(const char *data, qint64 len)
+    {
+        return hookAudioWrite(stream, (char *)data, (int)qMin(len, (qint64)INT_MAX));
+    };
+
+    private:
+
+    void *stream;
+};
+
+static QAudioFormat audioFormat(int sampleRate, int channelCount, int sampleSize)
+{
+    QAudioFormat format;
+    format.setSampleRate(sampleRate);
+    format.setChannelCount(channelCount);
+    format.setSampleSize(sampleSize);
+    format.setCodec("audio/pcm");
+    format.setByteOrder(QAudioFormat::LittleEndian);
+    format.setSampleType(sampleSize == 8 ? QAudioFormat::UnSignedInt : QAudioFormat::SignedInt);
+    return format;
+}
+
+QAudioOutput_ *newAudioOutput(int sampleRate, int channelCount, int sampleSize, void *stream)
+{
+    QAudioOutput *output = new QAudioOutput(audioFormat(sampleRate, channelCount, sampleSize));
+    GoAudioDevice *device = new GoAudioDevice(stream, output);
+    device->open(QIODevice::ReadOnly);
+    output->start(device);
+    return output;
+}
+
+void delAudioOutput(QAudioOutput_ *output)
+{
+    QAudioOutput *qoutput = reinterpret_cast<QAudioOutput *>(output);
+    qoutput->stop();
+    delete qoutput;
+}
+
+QAudioInput_ *newAudioInput(int sampleRate, int channelCount, int sampleSize, void *stream)
+{
+    QAudioInput *input = new QAudioInput(audioFormat(sampleRate, channelCount, sampleSize));
+    GoAudioDevice *device = new GoAudioDevice(stream, input);
+    device->open(QIODevice::WriteOnly);
+    input->start(device);
+    return input;
+}
+
+void delAudioInput(QAudioInput_ *input)
+{
+    QAudioInput *qinput = reinterpret_cast<QAudioInput *>(input);
+    qinput->stop();
+    delete qinput;
+}
+
+QSoundEffect_ *newSoundEffect(const char *url)
+{
+    QSoundEffect *effect = new QSoundEffect();
+    effect->setSource(QUrl(QString::fromUtf8(url)));
+    return effect;
+}
+
+void delSoundEffect(QSoundEffect_ *effect)
+{
+    delete reinterpret_cast<QSoundEffect *>(effect);
+}
+
+void soundEffectPlay(QSoundEffect_ *effect)
+{
+    reinterpret_cast<QSoundEffect *>(effect)->play();
+}
+
+void soundEffectStop(QSoundEffect_ *effect)
+{
+    reinterpret_cast<QSoundEffect *>(effect)->stop();
+}
+
+void soundEffectSetVolume(QSoundEffect_ *effect, double volume)
+{
+    reinterpret_cast<QSoundEffect *>(effect)->setVolume(volume);
+}
+
+void soundEffectSetLoops(QSoundEffect_ *effect, int loops)
+{
+    reinterpret_cast<QSoundEffect *>(effect)->setLoopCount(loops);
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
@@ -0,0 +1,59 @@
+#include "capi.h"
+
+// Video probing, audio streams, and sound effects are unsupported unless
+// the package is built with the qmlmultimedia tag, which links against
+// the Qt Multimedia module.
+
+QVideoProbe_ *newVideoProbe(QObject_ *source, void *frameFunc)
+{
+    return 0;
+}
+
+void delVideoProbe(QVideoProbe_ *probe)
+{
+}
+
+QAudioOutput_ *newAudioOutput(int sampleRate, int channelCount, int sampleSize, void *stream)
+{
+    return 0;
+}
+
+void delAudioOutput(QAudioOutput_ *output)
+{
+}
+
+QAudioInput_ *newAudioInput(int sampleRate, int channelCount, int sampleSize, void *stream)
+{
+    return 0;
+}
+
+void delAudioInput(QAudioInput_ *input)
+{
+}
+
+QSoundEffect_ *newSoundEffect(const char *url)
+{
+    return 0;
+}
+
+void delSoundEffect(QSoundEffect_ *effect)
+{
+}
+
+void soundEffectPlay(QSoundEffect_ *effect)
+{
+}
+
+void soundEffectStop(QSoundEffect_ *effect)
+{
+}
+
+void soundEffectSetVolume(QSoundEffect_ *effect, double volume)
+{
+}
+
+void soundEffectSetLoops(QSoundEffect_ *effect, int loops)
+{
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
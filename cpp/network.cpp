@@ -0,0 +1,343 @@
+#include <QtQml>
+#include <QNetworkAccessManager>
+#include <QNetworkReply>
+#include <QMutex>
+#include <QDir>
+#include <QLibraryInfo>
+
+#include <functional>
+
+#include "capi.h"
+
+// GoNetworkReply is the reply for a request handled by a Go http.Client.
+// Go delivers the response from an arbitrary goroutine, so live replies
+// are tracked in goNetworkReplies to prevent delivering to a reply that
+// was already deleted.
+class GoNetworkReply;
+static QMutex goNetworkRepliesMutex;
+static QSet<GoNetworkReply *> goNetworkReplies;
+
+// GoNetworkReplyEvent runs a function within the thread of the reply it
+// is posted to. Unlike queued functor calls, posted events are supported
+// by every Qt 5 release, and Qt drops the events pending for a reply that
+// is deleted.
+class GoNetworkReplyEvent : public QEvent
+{
+public:
+    static const QEvent::Type eventType;
+    std::function<void()> run;
+
+    GoNetworkReplyEvent(std::function<void()> run) : QEvent(eventType), run(run) {}
+};
+
+const QEvent::Type GoNetworkReplyEvent::eventType = static_cast<QEvent::Type>(QEvent::registerEventType());
+
+static void postToReply(QObject *reply, std::function<void()> run)
+{
+    QCoreApplication::postEvent(reply, new GoNetworkReplyEvent(run));
+}
+
+class GoNetworkReply : public QNetworkReply
+{
+    QByteArray content;
+    qint64 offset;
+
+public:
+    GoNetworkReply(QNetworkAccessManager::Operation op, const QNetworkRequest &req, QObject *parent)
+        : QNetworkReply(parent), offset(0)
+    {
+        setOperation(op);
+        setRequest(req);
+        setUrl(req.url());
+        QMutexLocker locker(&goNetworkRepliesMutex);
+        goNetworkReplies.insert(this);
+    }
+
+    ~GoNetworkReply()
+    {
+        QMutexLocker locker(&goNetworkRepliesMutex);
+        goNetworkReplies.remove(this);
+    }
+
+    void abort()
+    {
+        if (isFinished()) {
+            return;
+        }
+        fail(OperationCanceledError, "Operation canceled");
+    }
+
+    qint64 bytesAvailable() const
+    {
+        return content.size() - offset + QNetworkReply::bytesAvailable();
+    }
+
+    bool isSequential() const
+    {
+        return true;
+    }
+
+    void deliver(int status, const QByteArray &headers, const QByteArray &data)
+    {
+        if (isFinished()) {
+            return;
+        }
+        setAttribute(QNetworkRequest::HttpStatusCodeAttribute, status);
+        foreach (const QByteArray &line, headers.split('\n')) {
+            int colon = line.indexOf(':');
+            if (colon > 0) {
+                setRawHeader(line.left(colon).trimmed(), line.mid(colon+1).trimmed());
+            }
+        }
+        content = data;
+        setHeader(QNetworkRequest::ContentLengthHeader, content.size());
+        open(ReadOnly | Unbuffered);
+        emit metaDataChanged();
+        emit downloadProgress(content.size(), content.size());
+        emit readyRead();
+        setFinished(true);
+        emit finished();
+    }
+
+    void fail(NetworkError code, const QString &message)
+    {
+        setError(code, message);
+        open(ReadOnly | Unbuffered);
+#if QT_VERSION >= QT_VERSION_CHECK(5, 15, 0)
+        emit errorOccurred(code);
+#else
+        emit error(code);
+#endif
+        setFinished(true);
+        emit finished();
+    }
+
+protected:
+    bool event(QEvent *e)
+    {
+        if (e->type() == GoNetworkReplyEvent::eventType) {
+            static_cast<GoNetworkReplyEvent *>(e)->run();
+            return true;
+        }
+        return QNetworkReply::event(e);
+    }
+
+    qint64 readData(char *data, qint64 maxSize)
+    {
+        qint64 n = qMin(maxSize, content.size() - offset);
+        if (n <= 0) {
+            return -1;
+        }
+        memcpy(data, content.constData() + offset, n);
+        offset += n;
+        return n;
+    }
+};
+
+// GoNetworkAccessManager is created once for each thread loading content
+// for the engine. The one owned by the engine itself serves the requests
+// made from the main thread, including all the ones made via
+// XMLHttpRequest, which is how the sandbox tells them apart.
+class GoNetworkAccessManager : public QNetworkAccessManager
+{
+    QQmlEngine *engine;
+    QMutex *mutex;
+    QStringList *prefixes;
+    int xhr;
+
+public:
+    GoNetworkAccessManager(QQmlEngine *engine, QMutex *mutex, QStringList *prefixes, QObject *parent)
+        : QNetworkAccessManager(parent), engine(engine), mutex(mutex), prefixes(prefixes), xhr(parent == engine) {}
+
+protected:
+    QNetworkReply *createRequest(Operation op, const QNetworkRequest &req, QIODevice *outgoingData)
+    {
+        QString url = req.url().toString();
+        QByteArray urlData = url.toUtf8();
+        if (req.url().scheme() == "sandbox" || !hookSandboxAllows(engine, (char *)urlData.constData(), urlData.size(), xhr)) {
+            // The url interceptor redirects denied URLs to the sandbox scheme.
+            QString denied = req.url().scheme() == "sandbox" ? req.url().path() : url;
+            GoNetworkReply *reply = new GoNetworkReply(op, req, this);
+            postToReply(reply, [=]() {
+                reply->fail(QNetworkReply::ContentAccessDenied, "access to " + denied + " denied by the sandbox");
+            });
+            return reply;
+        }
+        if (req.url().scheme() == "lint") {
+            // The url interceptor redirects rejected documents to the lint scheme.
+            QString rejected = req.url().path();
+            GoNetworkReply *reply = new GoNetworkReply(op, req, this);
+            postToReply(reply, [=]() {
+                reply->fail(QNetworkReply::ContentAccessDenied, rejected + " rejected by the lint hook");
+            });
+            return reply;
+        }
+
+        QByteArray prefix;
+        {
+            QMutexLocker locker(mutex);
+            foreach (const QString &p, *prefixes) {
+                if (url.startsWith(p)) {
+                    prefix = p.toUtf8();
+                    break;
+                }
+            }
+        }
+        if (prefix.isNull()) {
+            return QNetworkAccessManager::createRequest(op, req, outgoingData);
+        }
+
+        QByteArray method;
+        switch (op) {
+        case HeadOperation: method = "HEAD"; break;
+        case GetOperation: method = "GET"; break;
+        case PutOperation: method = "PUT"; break;
+        case PostOperation: method = "POST"; break;
+        case DeleteOperation: method = "DELETE"; break;
+        default: method = req.attribute(QNetworkRequest::CustomVerbAttribute).toByteArray(); break;
+        }
+        QByteArray headers;
+        foreach (const QByteArray &name, req.rawHeaderList()) {
+            headers.append(name).append(": ").append(req.rawHeader(name)).append('\n');
+        }
+        QByteArray body;
+        if (outgoingData) {
+            body = outgoingData->readAll();
+        }
+
+        GoNetworkReply *reply = new GoNetworkReply(op, req, this);
+        hookHTTPRequest(engine, reply,
+                        (char *)prefix.constData(), prefix.size(),
+                        (char *)method.constData(), method.size(),
+                        (char *)urlData.constData(), urlData.size(),
+                        (char *)headers.constData(), headers.size(),
+                        (char *)body.constData(), body.size());
+        return reply;
+    }
+};
+
+// GoNetworkAccessManagerFactory is called from the threads that load
+// content for the engine, so the prefixes must be guarded.
+class GoNetworkAccessManagerFactory : public QQmlNetworkAccessManagerFactory
+{
+    QQmlEngine *engine;
+
+public:
+    QMutex mutex;
+    QStringList prefixes;
+
+    GoNetworkAccessManagerFactory(QQmlEngine *engine) : engine(engine) {}
+
+    QNetworkAccessManager *create(QObject *parent)
+    {
+        return new GoNetworkAccessManager(engine, &mutex, &prefixes, parent);
+    }
+};
+
+static GoNetworkAccessManagerFactory *engineNetworkFactory(QQmlEngine *qengine)
+{
+    GoNetworkAccessManagerFactory *factory = dynamic_cast<GoNetworkAccessManagerFactory *>(qengine->networkAccessManagerFactory());
+    if (!factory) {
+        factory = new GoNetworkAccessManagerFactory(qengine);
+        qengine->setNetworkAccessManagerFactory(factory);
+        QObject::connect(qengine, &QObject::destroyed, [=]() { delete factory; });
+    }
+    return factory;
+}
+
+void engineSetHTTPPrefix(QQmlEngine_ *engine, const char *prefix, int prefixLen, int enabled)
+{
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    GoNetworkAccessManagerFactory *factory = engineNetworkFactory(qengine);
+    QString qprefix = QString::fromUtf8(prefix, prefixLen);
+    QMutexLocker locker(&factory->mutex);
+    factory->prefixes.removeAll(qprefix);
+    if (enabled) {
+        factory->prefixes.append(qprefix);
+    }
+}
+
+// GoUrlInterceptor enforces the sandbox of an engine on every URL its
+// content refers to, including the documents loaded by QML itself, which
+// do not go through the network access manager when local, and runs the
+// lint hook of the engine on the local documents and scripts. Denied URLs
+// are redirected to the sandbox scheme, and rejected documents to the lint
+// scheme, which the network access manager fails with a proper message.
+// It's called from the threads that load content for the engine.
+class GoUrlInterceptor : public QQmlAbstractUrlInterceptor
+{
+    QQmlEngine *engine;
+
+public:
+    GoUrlInterceptor(QQmlEngine *engine) : engine(engine) {}
+
+    QUrl intercept(const QUrl &url, DataType type)
+    {
+        if (url.isLocalFile() && isModuleFile(QDir::cleanPath(url.toLocalFile()))) {
+            return url;
+        }
+        QByteArray urlData = url.toString().toUtf8();
+        if (!hookSandboxAllows(engine, (char *)urlData.constData(), urlData.size(), 0)) {
+            return QUrl("sandbox:" + url.toString());
+        }
+        if ((type == QmlFile || type == JavaScriptFile) && !hookLintAllows(engine, (char *)urlData.constData(), urlData.size())) {
+            return QUrl("lint:" + url.toString());
+        }
+        return url;
+    }
+
+private:
+    // isModuleFile returns whether path is within the directories holding
+    // the installed QML modules. Only the directory where Qt installs them
+    // and the ones explicitly listed in the environment are considered,
+    // rather than all the import paths of the engine, which include the
+    // directory of the application itself. They are looked up on every
+    // call so that changes made to the environment are respected.
+    static bool isModuleFile(const QString &path)
+    {
+        QStringList modulePaths;
+        modulePaths.append(QLibraryInfo::location(QLibraryInfo::Qml2ImportsPath));
+        modulePaths.append(QString::fromLocal8Bit(qgetenv("QML2_IMPORT_PATH")).split(QDir::listSeparator(), QString::SkipEmptyParts));
+        foreach (const QString &modulePath, modulePaths) {
+            if (path.startsWith(QDir::cleanPath(modulePath) + "/")) {
+                return true;
+            }
+        }
+        return false;
+    }
+};
+
+void engineInterceptUrls(QQmlEngine_ *engine)
+{
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    engineNetworkFactory(qengine);
+    if (!qengine->urlInterceptor()) {
+        GoUrlInterceptor *interceptor = new GoUrlInterceptor(qengine);
+        qengine->setUrlInterceptor(interceptor);
+        QObject::connect(qengine, &QObject::destroyed, [=]() { delete interceptor; });
+    }
+}
+
+void httpReplyFinish(void *reply, int status, const char *headers, int headersLen, const char *data, int dataLen)
+{
+    GoNetworkReply *qreply = reinterpret_cast<GoNetworkReply *>(reply);
+    QByteArray qheaders(headers, headersLen);
+    QByteArray qdata(data, dataLen);
+    QMutexLocker locker(&goNetworkRepliesMutex);
+    if (goNetworkReplies.contains(qreply)) {
+        // Pending calls are dropped if the reply is deleted first.
+        postToReply(qreply, [=]() { qreply->deliver(status, qheaders, qdata); });
+    }
+}
+
+void httpReplyFail(void *reply, const char *message, int messageLen)
+{
+    GoNetworkReply *qreply = reinterpret_cast<GoNetworkReply *>(reply);
+    QString qmessage = QString::fromUtf8(message, messageLen);
+    QMutexLocker locker(&goNetworkRepliesMutex);
+    if (goNetworkReplies.contains(qreply)) {
+        postToReply(qreply, [=]() { qreply->fail(QNetworkReply::UnknownNetworkError, qmessage); });
+    }
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
@@ -0,0 +1,35 @@
+#include <QGeoPositionInfoSource>
+
+#include "capi.h"
+
+QGeoPositionInfoSource_ *newPositionSource(void *func)
+{
+    QGeoPositionInfoSource *source = QGeoPositionInfoSource::createDefaultSource(0);
+    if (!source) {
+        return 0;
+    }
+    QObject::connect(source, &QGeoPositionInfoSource::positionUpdated, [=](const QGeoPositionInfo &info) {
+        QGeoCoordinate coord = info.coordinate();
+        hookPositionUpdated(func, coord.latitude(), coord.longitude(), coord.altitude(), info.timestamp().toMSecsSinceEpoch());
+    });
+    return source;
+}
+
+void delPositionSource(QGeoPositionInfoSource_ *source)
+{
+    delete reinterpret_cast<QGeoPositionInfoSource *>(source);
+}
+
+void positionSourceStart(QGeoPositionInfoSource_ *source, int interval)
+{
+    QGeoPositionInfoSource *qsource = reinterpret_cast<QGeoPositionInfoSource *>(source);
+    qsource->setUpdateInterval(interval);
+    qsource->startUpdates();
+}
+
+void positionSourceStop(QGeoPositionInfoSource_ *source)
+{
+    reinterpret_cast<QGeoPositionInfoSource *>(source)->stopUpdates();
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
@@ -0,0 +1,23 @@
+#include "capi.h"
+
+// Positioning is unsupported unless the package is built with the
+// qmlpositioning tag, which links against the Qt Positioning module.
+
+QGeoPositionInfoSource_ *newPositionSource(void *func)
+{
+    return 0;
+}
+
+void delPositionSource(QGeoPositionInfoSource_ *source)
+{
+}
+
+void positionSourceStart(QGeoPositionInfoSource_ *source, int interval)
+{
+}
+
+void positionSourceStop(QGeoPositionInfoSource_ *source)
+{
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
@@ -0,0 +1,40 @@
+#include <QSensor>
+#include <QVarLengthArray>
+
+#include "capi.h"
+
+QSensor_ *newSensor(const char *type, void *func)
+{
+    QSensor *sensor = new QSensor(QByteArray(type));
+    if (!sensor->connectToBackend()) {
+        delete sensor;
+        return 0;
+    }
+    QObject::connect(sensor, &QSensor::readingChanged, [=]() {
+        QSensorReading *reading = sensor->reading();
+        int len = reading->valueCount();
+        QVarLengthArray<double, 8> values(len);
+        for (int i = 0; i < len; i++) {
+            values[i] = reading->value(i).toDouble();
+        }
+        hookSensorReading(func, values.data(), len);
+    });
+    return sensor;
+}
+
+void delSensor(QSensor_ *sensor)
+{
+    delete reinterpret_cast<QSensor *>(sensor);
+}
+
+int sensorStart(QSensor_ *sensor)
+{
+    return reinterpret_cast<QSensor *>(sensor)->start() ? 1 : 0;
+}
+
+void sensorStop(QSensor_ *sensor)
+{
+    reinterpret_cast<QSensor *>(sensor)->stop();
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
@@ -0,0 +1,24 @@
+#include "capi.h"
+
+// Sensors are unsupported unless the package is built with the
+// qmlsensors tag, which links against the Qt Sensors module.
+
+QSensor_ *newSensor(const char *type, void *func)
+{
+    return 0;
+}
+
+void delSensor(QSensor_ *sensor)
+{
+}
+
+int sensorStart(QSensor_ *sensor)
+{
+    return 0;
+}
+
+void sensorStop(QSensor_ *sensor)
+{
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
@@ -0,0 +1,41 @@
+#include <QtSvg/QSvgRenderer>
+#include <QPainter>
+
+#include "capi.h"
+
+error *svgRender(const char *data, int dataLen, int width, int height, QImage_ **image)
+{
+    QSvgRenderer renderer(QByteArray::fromRawData(data, dataLen));
+    if (!renderer.isValid()) {
+        return errorf("invalid SVG document");
+    }
+    QSize size = renderer.defaultSize();
+    if (width > 0 && height > 0) {
+        if (size.isEmpty()) {
+            size = QSize(width, height);
+        } else {
+            size.scale(width, height, Qt::KeepAspectRatio);
+        }
+    } else if (width > 0 || height > 0) {
+        if (size.isEmpty()) {
+            return errorf("SVG document has no default size; both width and height must be provided");
+        }
+        if (width > 0) {
+            size = QSize(width, qMax(1, size.height() * width / size.width()));
+        } else {
+            size = QSize(qMax(1, size.width() * height / size.height()), height);
+        }
+    } else if (size.isEmpty()) {
+        return errorf("SVG document has no default size; a size must be provided");
+    }
+
+    QImage *qimage = new QImage(size, QImage::Format_ARGB32_Premultiplied);
+    qimage->fill(Qt::transparent);
+    QPainter painter(qimage);
+    renderer.render(&painter);
+    painter.end();
+    *image = qimage;
+    return 0;
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
@@ -0,0 +1,11 @@
+#include "capi.h"
+
+// SVG documents are unsupported unless the package is built with the
+// qmlsvg tag, which links against the Qt SVG module.
+
+error *svgRender(const char *data, int dataLen, int width, int height, QImage_ **image)
+{
+    return errorf("SVG rendering requires building with the qmlsvg tag");
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
@@ -0,0 +1,38 @@
+#include <windows.h>
+#include <shlobj.h>
+#include <shobjidl.h>
+
+#include <QGuiApplication>
+#include <QWindow>
+
+#include "capi.h"
+
+void windowsAddRecentDocument(const unsigned short *path)
+{
+    SHAddToRecentDocs(SHARD_PATHW, path);
+}
+
+void windowsSetTaskbarProgress(double progress)
+{
+    ITaskbarList3 *taskbar = 0;
+    if (FAILED(CoCreateInstance(CLSID_TaskbarList, 0, CLSCTX_INPROC_SERVER, IID_ITaskbarList3, (void **)&taskbar))) {
+        return;
+    }
+    if (SUCCEEDED(taskbar->HrInit())) {
+        foreach (QWindow *win, QGuiApplication::topLevelWindows()) {
+            if (!win->isVisible()) {
+                continue;
+            }
+            HWND hwnd = reinterpret_cast<HWND>(win->winId());
+            if (progress < 0) {
+                taskbar->SetProgressState(hwnd, TBPF_NOPROGRESS);
+            } else {
+                taskbar->SetProgressState(hwnd, TBPF_NORMAL);
+                taskbar->SetProgressValue(hwnd, ULONGLONG(progress * 1000), 1000);
+            }
+        }
+    }
+    taskbar->Release();
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
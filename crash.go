@@ -0,0 +1,150 @@
+package qml
+
+// #include <stdlib.h>
+//
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// CrashReport holds diagnostics collected when the application is
+// about to terminate due to a fatal Qt error or a Go panic in the
+// main GUI thread.
+type CrashReport struct {
+	Time   time.Time
+	Reason string // The fatal Qt message or the Go panic value.
+
+	GoStack    string // Stack of the goroutine that crashed.
+	QMLStack   string // JavaScript backtrace of each engine, if available.
+	ObjectTree string // Objects under every window, if available.
+
+	QtVersion string // Version of the Qt libraries in use.
+	GoVersion string // Version of the Go runtime in use.
+
+	// File holds the path of the file the report was written to,
+	// or is empty if the report could not be written.
+	File string
+}
+
+// String returns the report in the format written to disk.
+func (r *CrashReport) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Time: %s\n", r.Time.Format(time.RFC3339))
+	fmt.Fprintf(&buf, "Reason: %s\n", r.Reason)
+	fmt.Fprintf(&buf, "Qt version: %s\n", r.QtVersion)
+	fmt.Fprintf(&buf, "Go version: %s\n", r.GoVersion)
+	for _, section := range []struct{ name, text string }{
+		{"Go stack", r.GoStack},
+		{"QML stack", r.QMLStack},
+		{"Object tree", r.ObjectTree},
+	} {
+		if section.text != "" {
+			fmt.Fprintf(&buf, "\n%s:\n\n%s\n", section.name, section.text)
+		}
+	}
+	return buf.String()
+}
+
+var crashHandler struct {
+	sync.Mutex
+	dir string
+	f   func(report *CrashReport)
+}
+
+// HandleCrashes arranges for a diagnostic report to be written into dir
+// when the application is about to terminate due to a fatal Qt error or
+// due to a Go panic in the main GUI thread, such as one in a method called
+// from QML or in a signal handler. The report includes the QML backtrace,
+// the tree of objects under every window, and the Qt and Go versions in
+// use, so that crash reports from the field are actionable.
+//
+// If f is not nil, it is called with the report after it is written,
+// and may for example offer the user to submit it. The application then
+// exits with status 2.
+//
+// Providing an empty dir disables crash handling, restoring the default
+// behavior of Qt and Go.
+func HandleCrashes(dir string, f func(report *CrashReport)) {
+	crashHandler.Lock()
+	crashHandler.dir = dir
+	crashHandler.f = f
+	crashHandler.Unlock()
+}
+
+func crashHandling() bool {
+	crashHandler.Lock()
+	defer crashHandler.Unlock()
+	return crashHandler.dir != ""
+}
+
+// handleGuiPanic is deferred by the GUI loop to report Go panics that
+// reached it unrecovered.
+func handleGuiPanic() {
+	if !crashHandling() {
+		return
+	}
+	if v := recover(); v != nil {
+		crash(fmt.Sprintf("panic: %v", v), debug.Stack())
+	}
+}
+
+// crash writes the crash report and terminates the application.
+func crash(reason string, stack []byte) {
+	crashHandler.Lock()
+	dir, f := crashHandler.dir, crashHandler.f
+	crashHandler.Unlock()
+
+	report := &CrashReport{
+		Time:      time.Now(),
+		Reason:    reason,
+		GoStack:   string(stack),
+		QtVersion: C.GoString(C.applicationQtVersion()),
+		GoVersion: runtime.Version(),
+	}
+
+	// Qt objects may only be inspected safely from the GUI thread.
+	if onGuiThread() {
+		var buf bytes.Buffer
+		for _, engine := range engines {
+			if !engine.destroyed {
+				fmt.Fprintf(&buf, "Engine %p:\n%s\n", engine.addr, cstringResult(C.engineStackTrace(engine.addr)))
+			}
+		}
+		report.QMLStack = buf.String()
+		report.ObjectTree = cstringResult(C.applicationObjectTree())
+	}
+
+	name := filepath.Join(dir, "crash-"+report.Time.Format("20060102-150405")+".txt")
+	if err := os.MkdirAll(dir, 0755); err == nil {
+		if err := ioutil.WriteFile(name, []byte(report.String()), 0644); err == nil {
+			report.File = name
+		}
+	}
+	if report.File == "" {
+		os.Stderr.WriteString(report.String())
+	}
+
+	if f != nil {
+		f(report)
+	}
+	os.Exit(2)
+}
+
+// crashFatalMessage reports a fatal Qt message, which is followed by
+// the process being aborted.
+func crashFatalMessage(text string) {
+	if crashHandling() {
+		crash(text, debug.Stack())
+	}
+}
@@ -0,0 +1,44 @@
+// +build darwin,!ios
+
+#import <AppKit/AppKit.h>
+
+#include "capi.h"
+
+void darwinNoteRecentDocument(const char *path, int pathLen)
+{
+    NSString *str = [[NSString alloc] initWithBytes:path length:pathLen encoding:NSUTF8StringEncoding];
+    [[NSDocumentController sharedDocumentController] noteNewRecentDocumentURL:[NSURL fileURLWithPath:str]];
+    [str release];
+}
+
+void darwinClearRecentDocuments()
+{
+    [[NSDocumentController sharedDocumentController] clearRecentDocuments:nil];
+}
+
+static NSImageView *dockView;
+static NSProgressIndicator *dockProgress;
+
+void darwinUpdateDockTile(double progress, int badge)
+{
+    NSDockTile *tile = [NSApp dockTile];
+    if (progress < 0) {
+        [tile setContentView:nil];
+    } else {
+        if (!dockView) {
+            NSSize size = [tile size];
+            dockView = [[NSImageView alloc] initWithFrame:NSMakeRect(0, 0, size.width, size.height)];
+            [dockView setImage:[NSApp applicationIconImage]];
+            dockProgress = [[NSProgressIndicator alloc] initWithFrame:NSMakeRect(0, 0, size.width, 16)];
+            [dockProgress setStyle:NSProgressIndicatorBarStyle];
+            [dockProgress setIndeterminate:NO];
+            [dockProgress setMinValue:0];
+            [dockProgress setMaxValue:1];
+            [dockView addSubview:dockProgress];
+        }
+        [dockProgress setDoubleValue:progress];
+        [tile setContentView:dockView];
+    }
+    [tile setBadgeLabel:(badge > 0 ? [NSString stringWithFormat:@"%d", badge] : nil)];
+    [tile display];
+}
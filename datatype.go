@@ -6,11 +6,17 @@ import "C"
 
 import (
 	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding"
 	"fmt"
 	"image/color"
 	"reflect"
 	"strings"
+	"sync/atomic"
+	"time"
 	"unicode"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -24,6 +30,7 @@ var (
 	nilCharPtr = (*C.char)(nilPtr)
 
 	typeString   = reflect.TypeOf("")
+	typeBytes    = reflect.TypeOf([]byte(nil))
 	typeBool     = reflect.TypeOf(false)
 	typeInt      = reflect.TypeOf(int(0))
 	typeInt64    = reflect.TypeOf(int64(0))
@@ -32,7 +39,10 @@ var (
 	typeFloat32  = reflect.TypeOf(float32(0))
 	typeIface    = reflect.TypeOf(new(interface{})).Elem()
 	typeRGBA     = reflect.TypeOf(color.RGBA{})
+	typeColor    = reflect.TypeOf(new(color.Color)).Elem()
+	typeTime     = reflect.TypeOf(time.Time{})
 	typeObjSlice = reflect.TypeOf([]Object(nil))
+	typeContext  = reflect.TypeOf(new(context.Context)).Elem()
 )
 
 func init() {
@@ -57,6 +67,7 @@ func init() {
 // This must be run from the main GUI thread due to the cases where
 // calling wrapGoValue is necessary.
 func packDataValue(value interface{}, dvalue *C.DataValue, engine *Engine, owner valueOwner) {
+	assertGui("packDataValue")
 	datap := unsafe.Pointer(&dvalue.data)
 	if value == nil {
 		dvalue.dataType = C.DTInvalid
@@ -68,11 +79,16 @@ func packDataValue(value interface{}, dvalue *C.DataValue, engine *Engine, owner
 		cstr, cstrlen := unsafeStringData(value)
 		*(**C.char)(datap) = cstr
 		dvalue.len = cstrlen
+	case []byte:
+		dvalue.dataType = C.DTByteArray
+		cdata, cdatalen := unsafeBytesData(value)
+		*(**C.char)(datap) = cdata
+		dvalue.len = cdatalen
 	case bool:
 		dvalue.dataType = C.DTBool
 		*(*bool)(datap) = value
 	case int:
-		if value > 1<<31-1 {
+		if value > 1<<31-1 || value < -1<<31 {
 			dvalue.dataType = C.DTInt64
 			*(*int64)(datap) = int64(value)
 		} else {
@@ -98,20 +114,196 @@ func packDataValue(value interface{}, dvalue *C.DataValue, engine *Engine, owner
 		// TODO Prevent value from being garbage collected improperly.
 		dvalue.dataType = C.DTListProperty
 		*(*unsafe.Pointer)(datap) = C.newListProperty(engine.addr, unsafe.Pointer(value))
+	case *Promise:
+		dvalue.dataType = C.DTJSValue
+		*(*unsafe.Pointer)(datap) = value.jsValue(engine)
+	case *Func:
+		if value.addr == nilPtr {
+			panic("function was released")
+		}
+		dvalue.dataType = C.DTJSValue
+		*(*unsafe.Pointer)(datap) = C.jsValueCopy(value.addr)
 	case color.RGBA:
 		dvalue.dataType = C.DTColor
-		*(*uint32)(datap) = uint32(value.A)<<24 | uint32(value.R)<<16 | uint32(value.G)<<8 | uint32(value.B)
+		*(*uint32)(datap) = packColor(value)
+	case time.Time:
+		packTime(value, dvalue)
+	case []string, []bool, []int, []int64, []int32, []float64, []float32:
+		dvalue.dataType = C.DTVariantList
+		*(*unsafe.Pointer)(datap) = packArray(value)
 	default:
-		dvalue.dataType = C.DTObject
 		if obj, ok := value.(Object); ok {
+			dvalue.dataType = C.DTObject
 			*(*unsafe.Pointer)(datap) = obj.Common().addr
-		} else {
-			*(*unsafe.Pointer)(datap) = wrapGoValue(engine, value, owner)
+		} else if dec, ok := value.(decimalValue); ok {
+			packDataValue(formatDecimal(dec, decimalFormat{precision: -1}), dvalue, engine, jsOwner)
+		} else if valuer, ok := value.(driver.Valuer); ok {
+			packNullable(valuer, dvalue, engine)
+		} else if c, ok := value.(color.Color); ok && reflect.TypeOf(value).Kind() != reflect.Ptr {
+			dvalue.dataType = C.DTColor
+			*(*uint32)(datap) = packColor(c)
+		} else if marshaler, ok := value.(encoding.TextMarshaler); ok {
+			packText(marshaler, dvalue, engine)
+		} else if !packConverted(value, dvalue, engine) {
+			if v := reflect.ValueOf(value); v.Kind() == reflect.Slice {
+				dvalue.dataType = C.DTVariantList
+				*(*unsafe.Pointer)(datap) = packList(v, engine, owner)
+			} else if v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String {
+				dvalue.dataType = C.DTVariant
+				*(*unsafe.Pointer)(datap) = packMap(v, engine, owner)
+			} else {
+				dvalue.dataType = C.DTObject
+				*(*unsafe.Pointer)(datap) = wrapGoValue(engine, value, owner)
+			}
 		}
 	}
 }
 
-// TODO Handle byte slices.
+// packsByValue returns whether struct values of type t are packed as
+// plain QML values, such as colors and dates, rather than wrapped by
+// address as objects.
+func packsByValue(t reflect.Type) bool {
+	return t == typeTime || t.Implements(typeColor)
+}
+
+// packTime packs t as a QDateTime, which QML logic sees as a JavaScript
+// Date. Times in UTC and in the local time zone are handed over as such,
+// and times in any other location at their offset from UTC at that time.
+// The zero time is handed over as null, and precision below milliseconds
+// is lost.
+func packTime(t time.Time, dvalue *C.DataValue) {
+	if t.IsZero() {
+		dvalue.dataType = C.DTInvalid
+		return
+	}
+	dvalue.dataType = C.DTDateTime
+	*(*int64)(unsafe.Pointer(&dvalue.data)) = t.Unix()*1000 + int64(t.Nanosecond()/1e6)
+	switch t.Location() {
+	case time.UTC:
+		dvalue.len = C.DateTimeUTC
+	case time.Local:
+		dvalue.len = C.DateTimeLocal
+	default:
+		_, offset := t.Zone()
+		dvalue.len = C.int(offset)
+	}
+}
+
+// unpackTime unpacks the QDateTime in dvalue, in the time zone it
+// was in.
+func unpackTime(dvalue *C.DataValue) time.Time {
+	msecs := *(*int64)(unsafe.Pointer(&dvalue.data))
+	t := time.Unix(msecs/1000, msecs%1000*1e6)
+	switch dvalue.len {
+	case C.DateTimeUTC:
+		return t.UTC()
+	case C.DateTimeLocal:
+		return t.Local()
+	}
+	return t.In(time.FixedZone("", int(dvalue.len)))
+}
+
+// packList packs the elements of the slice v into a new QVariantList,
+// so that QML logic may iterate over them as with a JavaScript array.
+// Elements are packed as any other value, so nested slices become nested
+// lists, while struct elements are handed over by address so that QML
+// logic observes and changes the elements in the slice itself.
+func packList(v reflect.Value, engine *Engine, owner valueOwner) unsafe.Pointer {
+	n := v.Len()
+	if n == 0 {
+		return C.newVariantList(nil, 0)
+	}
+	dvlist := make([]C.DataValue, n)
+	for i := 0; i < n; i++ {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Struct && !packsByValue(elem.Type()) {
+			elem = elem.Addr()
+		}
+		packDataValue(elem.Interface(), &dvlist[i], engine, owner)
+	}
+	return C.newVariantList(&dvlist[0], C.int(n))
+}
+
+// packMap packs the entries of the string-keyed map v into a new QVariant
+// holding a QVariantMap, which QML logic sees as a JavaScript object.
+// Values are packed as any other value, so nested maps become nested
+// objects.
+func packMap(v reflect.Value, engine *Engine, owner valueOwner) unsafe.Pointer {
+	n := v.Len()
+	if n == 0 {
+		return C.newVariantMap(nil, 0)
+	}
+	dvpairs := make([]C.DataValue, 0, n*2)
+	iter := v.MapRange()
+	for iter.Next() {
+		dvpairs = append(dvpairs, C.DataValue{}, C.DataValue{})
+		packDataValue(iter.Key().String(), &dvpairs[len(dvpairs)-2], engine, owner)
+		packDataValue(iter.Value().Interface(), &dvpairs[len(dvpairs)-1], engine, owner)
+	}
+	return C.newVariantMap(&dvpairs[0], C.int(n))
+}
+
+// packArray packs a slice of simple values into a new QVariantList.
+// The whole slice is handed over at once, so that large slices do not
+// require a cgo call per element.
+func packArray(value interface{}) unsafe.Pointer {
+	var elemType C.DataType
+	var data unsafe.Pointer
+	var offsets *C.int
+	var n int
+	switch value := value.(type) {
+	case []string:
+		// Strings are concatenated into a single buffer, and
+		// offsets[i] and offsets[i+1] delimit the string at i.
+		size := 0
+		for _, s := range value {
+			size += len(s)
+		}
+		buf := make([]byte, 0, size)
+		offs := make([]C.int, len(value)+1)
+		for i, s := range value {
+			buf = append(buf, s...)
+			offs[i+1] = C.int(len(buf))
+		}
+		elemType, n, offsets = C.DTString, len(value), &offs[0]
+		if size > 0 {
+			data = unsafe.Pointer(&buf[0])
+		}
+	case []bool:
+		elemType, n = C.DTBool, len(value)
+		if n > 0 {
+			data = unsafe.Pointer(&value[0])
+		}
+	case []int:
+		elemType, n = intDT, len(value)
+		if n > 0 {
+			data = unsafe.Pointer(&value[0])
+		}
+	case []int64:
+		elemType, n = C.DTInt64, len(value)
+		if n > 0 {
+			data = unsafe.Pointer(&value[0])
+		}
+	case []int32:
+		elemType, n = C.DTInt32, len(value)
+		if n > 0 {
+			data = unsafe.Pointer(&value[0])
+		}
+	case []float64:
+		elemType, n = C.DTFloat64, len(value)
+		if n > 0 {
+			data = unsafe.Pointer(&value[0])
+		}
+	case []float32:
+		elemType, n = C.DTFloat32, len(value)
+		if n > 0 {
+			data = unsafe.Pointer(&value[0])
+		}
+	default:
+		panic(fmt.Sprintf("cannot pack array of type %T", value))
+	}
+	return C.newVariantListFromArray(elemType, data, offsets, C.int(n))
+}
 
 // unpackDataValue converts a value shipped by C++ into a native Go value.
 //
@@ -126,6 +318,10 @@ func unpackDataValue(dvalue *C.DataValue, engine *Engine) interface{} {
 		// can we get rid of this allocation somehow?
 		C.free(unsafe.Pointer(*(**C.char)(datap)))
 		return s
+	case C.DTByteArray:
+		b := C.GoBytes(*(*unsafe.Pointer)(datap), dvalue.len)
+		C.free(*(*unsafe.Pointer)(datap))
+		return b
 	case C.DTBool:
 		return *(*bool)(datap)
 	case C.DTInt64:
@@ -137,8 +333,9 @@ func unpackDataValue(dvalue *C.DataValue, engine *Engine) interface{} {
 	case C.DTFloat32:
 		return *(*float32)(datap)
 	case C.DTColor:
-		var c uint32 = *(*uint32)(datap)
-		return color.RGBA{byte(c >> 16), byte(c >> 8), byte(c), byte(c >> 24)}
+		return unpackColor(*(*C.uint)(datap))
+	case C.DTDateTime:
+		return unpackTime(dvalue)
 	case C.DTGoAddr:
 		return (*(**valueFold)(datap)).gvalue
 	case C.DTInvalid:
@@ -161,6 +358,24 @@ func unpackDataValue(dvalue *C.DataValue, engine *Engine) interface{} {
 		}
 		C.free(*(*unsafe.Pointer)(datap))
 		return &List{result}
+	case C.DTValueMap:
+		var dvlist []C.DataValue
+		var dvlisth = (*reflect.SliceHeader)(unsafe.Pointer(&dvlist))
+		dvlisth.Data = uintptr(*(*unsafe.Pointer)(datap))
+		dvlisth.Len = int(dvalue.len) * 2
+		dvlisth.Cap = int(dvalue.len) * 2
+		result := make(map[string]interface{}, int(dvalue.len))
+		for i := 0; i < len(dvlist); i += 2 {
+			key, _ := unpackDataValue(&dvlist[i], engine).(string)
+			result[key] = unpackDataValue(&dvlist[i+1], engine)
+		}
+		C.free(*(*unsafe.Pointer)(datap))
+		return result
+	case C.DTJSValue:
+		return &Func{
+			engine: engine,
+			addr:   *(*unsafe.Pointer)(datap),
+		}
 	}
 	panic(fmt.Sprintf("unsupported data type: %d", dvalue.dataType))
 }
@@ -171,6 +386,8 @@ func dataTypeOf(typ reflect.Type) C.DataType {
 	switch typ {
 	case typeString:
 		return C.DTString
+	case typeBytes:
+		return C.DTByteArray
 	case typeBool:
 		return C.DTBool
 	case typeInt:
@@ -187,6 +404,8 @@ func dataTypeOf(typ reflect.Type) C.DataType {
 		return C.DTAny
 	case typeRGBA:
 		return C.DTColor
+	case typeTime:
+		return C.DTDateTime
 	case typeObjSlice:
 		return C.DTListProperty
 	}
@@ -196,9 +415,48 @@ func dataTypeOf(typ reflect.Type) C.DataType {
 var typeInfoSize = C.size_t(unsafe.Sizeof(C.GoTypeInfo{}))
 var memberInfoSize = C.size_t(unsafe.Sizeof(C.GoMemberInfo{}))
 
+// typeInfoCache holds the type information of the Go types handed to
+// QML logic. It is shared by all engines, and released along with the
+// last of them by releaseTypeInfo.
 var typeInfoCache = make(map[reflect.Type]*C.GoTypeInfo)
 
+// typeInfoPinned holds the types registered via RegisterTypes, which QML
+// refers to for the life of the process, so their type information is
+// never released.
+var typeInfoPinned = make(map[reflect.Type]bool)
+
+// The number of types in typeInfoCache and of C allocations held by it
+// and by internedNames, atomically updated so statistics may be taken
+// outside the GUI thread.
+var typeInfoTypes, typeInfoAllocs int32
+
+// internedNames holds the C strings for member names and signatures,
+// shared by all types since many of them have members with the same
+// names, such as "name" or "value". The strings are never released.
+var internedNames = make(map[string]*C.char)
+
+// internName returns the interned C string for name.
+// It must be called within the main GUI thread.
+func internName(name string) *C.char {
+	assertGui("internName")
+	cname, ok := internedNames[name]
+	if !ok {
+		cname = C.CString(name)
+		internedNames[name] = cname
+		atomic.AddInt32(&typeInfoAllocs, 1)
+	}
+	return cname
+}
+
+// memberName returns the QML name for the Go field or method name,
+// which has the first letter lowercased.
+func memberName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
+
 func typeInfo(v interface{}) *C.GoTypeInfo {
+	assertGui("typeInfo")
 	vt := reflect.TypeOf(v)
 	for vt.Kind() == reflect.Ptr {
 		vt = vt.Elem()
@@ -209,10 +467,6 @@ func typeInfo(v interface{}) *C.GoTypeInfo {
 		return typeInfo
 	}
 
-	typeInfo = (*C.GoTypeInfo)(C.malloc(typeInfoSize))
-	typeInfo.typeName = C.CString(vt.Name())
-	typeInfo.metaObject = nilPtr
-
 	var onChanged map[string]int
 
 	// TODO Only do that if it's a struct?
@@ -222,47 +476,13 @@ func typeInfo(v interface{}) *C.GoTypeInfo {
 	prvField := 0
 	numMethod := vtptr.NumMethod()
 
-	// struct { FooBar T; Baz T } => "fooBar\0baz\0"
-	namesLen := 0
 	for i := 0; i < numField; i++ {
-		field := vt.Field(i)
-		if field.PkgPath != "" {
+		if vt.Field(i).PkgPath != "" {
 			prvField++ // not exported
-			continue
 		}
-		namesLen += len(field.Name) + 1
-	}
-	for i := 0; i < numMethod; i++ {
-		namesLen += len(vtptr.Method(i).Name) + 1
-	}
-	names := make([]byte, 0, namesLen)
-	for i := 0; i < numField; i++ {
-		field := vt.Field(i)
-		if field.PkgPath != "" {
-			continue // not exported
-		}
-		name := field.Name
-		for i, rune := range name {
-			if i == 0 {
-				names = append(names, string(unicode.ToLower(rune))...)
-			} else {
-				names = append(names, name[i:]...)
-				break
-			}
-		}
-		names = append(names, 0)
 	}
 	for i := 0; i < numMethod; i++ {
 		name := vtptr.Method(i).Name
-		for i, rune := range name {
-			if i == 0 {
-				names = append(names, string(unicode.ToLower(rune))...)
-			} else {
-				names = append(names, name[i:]...)
-				break
-			}
-		}
-		names = append(names, 0)
 
 		// Track "On*Changed" notification methods.
 		if len(name) > 9 && name[0] == 'O' && name[1] == 'n' && strings.HasSuffix(name, "Changed") {
@@ -272,30 +492,32 @@ func typeInfo(v interface{}) *C.GoTypeInfo {
 			onChanged[name[2:len(name)-7]] = i
 		}
 	}
-	if len(names) != namesLen {
-		panic("pre-allocated buffer size was wrong")
-	}
-	typeInfo.memberNames = C.CString(string(names))
 
-	// Assemble information on members.
+	// The type information, its members, and its name are allocated
+	// from a single block starting at the type information, so freeing
+	// it releases all of them. Member names and signatures are interned.
 	membersLen := numField - prvField + numMethod
+	typeName := vt.Name()
+	arena := newTypeArena(uintptr(typeInfoSize) + uintptr(memberInfoSize)*uintptr(membersLen) + uintptr(len(typeName)) + 1)
+	typeInfo = (*C.GoTypeInfo)(arena.alloc(uintptr(typeInfoSize)))
+	typeInfo.typeName = arena.cstring(typeName)
+	typeInfo.metaObject = nilPtr
+
+	// Assemble information on members.
 	membersi := uintptr(0)
-	mnamesi := uintptr(0)
-	members := uintptr(C.malloc(memberInfoSize * C.size_t(membersLen)))
-	mnames := uintptr(unsafe.Pointer(typeInfo.memberNames))
+	members := uintptr(arena.alloc(uintptr(memberInfoSize) * uintptr(membersLen)))
 	for i := 0; i < numField; i++ {
 		field := vt.Field(i)
 		if field.PkgPath != "" {
 			continue // not exported
 		}
 		memberInfo := (*C.GoMemberInfo)(unsafe.Pointer(members + uintptr(memberInfoSize)*membersi))
-		memberInfo.memberName = (*C.char)(unsafe.Pointer(mnames + mnamesi))
+		memberInfo.memberName = internName(memberName(field.Name))
 		memberInfo.memberType = dataTypeOf(field.Type)
 		memberInfo.reflectIndex = C.int(i)
 		memberInfo.reflectChangedIndex = -1
 		memberInfo.addrOffset = C.int(field.Offset)
 		membersi += 1
-		mnamesi += uintptr(len(field.Name)) + 1
 		if methodIndex, ok := onChanged[field.Name]; ok {
 			memberInfo.reflectChangedIndex = C.int(methodIndex)
 		}
@@ -303,21 +525,25 @@ func typeInfo(v interface{}) *C.GoTypeInfo {
 	for i := 0; i < numMethod; i++ {
 		method := vtptr.Method(i)
 		memberInfo := (*C.GoMemberInfo)(unsafe.Pointer(members + uintptr(memberInfoSize)*membersi))
-		memberInfo.memberName = (*C.char)(unsafe.Pointer(mnames + mnamesi))
+		memberInfo.memberName = internName(memberName(method.Name))
 		memberInfo.memberType = C.DTMethod
 		memberInfo.reflectIndex = C.int(i)
 		memberInfo.reflectChangedIndex = -1
 		memberInfo.addrOffset = 0
 		signature, result := methodQtSignature(method)
-		// TODO The signature data might be embedded in the same array as the member names.
-		memberInfo.methodSignature = C.CString(signature)
-		memberInfo.resultSignature = C.CString(result)
+		memberInfo.methodSignature = internName(signature)
+		memberInfo.resultSignature = internName(result)
 		// TODO Sort out methods with a variable number of arguments.
 		// It's called while bound, so drop the receiver.
 		memberInfo.numIn = C.int(method.Type.NumIn() - 1)
 		memberInfo.numOut = C.int(method.Type.NumOut())
+		if takesContext(method.Type, 1) {
+			// The context is provided on the Go side, and the
+			// operation handle is always returned.
+			memberInfo.numIn--
+			memberInfo.numOut = 1
+		}
 		membersi += 1
-		mnamesi += uintptr(len(method.Name)) + 1
 	}
 	typeInfo.members = (*C.GoMemberInfo)(unsafe.Pointer(members))
 	typeInfo.membersLen = C.int(membersLen)
@@ -330,17 +556,83 @@ func typeInfo(v interface{}) *C.GoTypeInfo {
 	if int(membersi) != membersLen {
 		panic("used more space than allocated for member names")
 	}
-	if int(mnamesi) != namesLen {
-		panic("allocated buffer doesn't match used space")
-	}
 	if typeInfo.fieldsLen+typeInfo.methodsLen != typeInfo.membersLen {
 		panic("lengths are inconsistent")
 	}
 
 	typeInfoCache[vt] = typeInfo
+	atomic.AddInt32(&typeInfoTypes, 1)
+	atomic.AddInt32(&typeInfoAllocs, 1)
 	return typeInfo
 }
 
+// pinTypeInfo prevents the type information of the type of v from being
+// released, and returns it.
+func pinTypeInfo(v interface{}) *C.GoTypeInfo {
+	info := typeInfo(v)
+	vt := reflect.TypeOf(v)
+	for vt.Kind() == reflect.Ptr {
+		vt = vt.Elem()
+	}
+	typeInfoPinned[vt] = true
+	return info
+}
+
+// releaseTypeInfo frees the type information cached for the types that
+// are not pinned, along with the meta objects built from it. It must be
+// called once the last engine is released, as the values wrapped by the
+// engines refer to that information while alive.
+func releaseTypeInfo() {
+	assertGui("releaseTypeInfo")
+	for vt, typeInfo := range typeInfoCache {
+		if typeInfoPinned[vt] {
+			continue
+		}
+		C.free(unsafe.Pointer(typeInfo.metaObject))
+		// The arena block starts at the type information itself.
+		C.free(unsafe.Pointer(typeInfo))
+		delete(typeInfoCache, vt)
+		atomic.AddInt32(&typeInfoTypes, -1)
+		atomic.AddInt32(&typeInfoAllocs, -1)
+	}
+}
+
+// typeArena allocates the C structures describing a type from a single
+// block of memory, so they are laid out together and released at once
+// by releaseTypeInfo freeing the block.
+type typeArena struct {
+	block unsafe.Pointer
+	used  uintptr
+	size  uintptr
+}
+
+// newTypeArena returns an arena able to hold size bytes, plus any
+// padding needed to align its allocations.
+func newTypeArena(size uintptr) *typeArena {
+	size += 3 * uintptr(ptrSize) // Padding for the type info, members, and name.
+	return &typeArena{block: C.malloc(C.size_t(size)), size: size}
+}
+
+// alloc returns size bytes from the arena, aligned to a pointer size.
+func (a *typeArena) alloc(size uintptr) unsafe.Pointer {
+	align := uintptr(ptrSize)
+	a.used = (a.used + align - 1) &^ (align - 1)
+	if a.used+size > a.size {
+		panic("type arena is too small")
+	}
+	p := unsafe.Pointer(uintptr(a.block) + a.used)
+	a.used += size
+	return p
+}
+
+// cstring returns a copy of s as a C string allocated from the arena.
+func (a *typeArena) cstring(s string) *C.char {
+	p := a.alloc(uintptr(len(s)) + 1)
+	buf := cbytes((*C.char)(p), C.int(len(s)+1))
+	buf[copy(buf, s)] = 0
+	return (*C.char)(p)
+}
+
 func methodQtSignature(method reflect.Method) (signature, result string) {
 	var buf bytes.Buffer
 	for i, rune := range method.Name {
@@ -352,9 +644,13 @@ func methodQtSignature(method reflect.Method) (signature, result string) {
 		}
 	}
 	buf.WriteByte('(')
+	first := 1
+	if takesContext(method.Type, 1) {
+		first = 2
+	}
 	n := method.Type.NumIn()
-	for i := 1; i < n; i++ {
-		if i > 1 {
+	for i := first; i < n; i++ {
+		if i > first {
 			buf.WriteByte(',')
 		}
 		buf.WriteString("QVariant")
@@ -362,6 +658,10 @@ func methodQtSignature(method reflect.Method) (signature, result string) {
 	buf.WriteByte(')')
 	signature = buf.String()
 
+	if first == 2 {
+		result = "QVariant"
+		return
+	}
 	switch method.Type.NumOut() {
 	case 0:
 		// keep it as ""
@@ -0,0 +1,64 @@
+package qml
+
+// Qt D-Bus is linked on Linux for the power and launcher integration,
+// while publishing Go values requires the qmldbus build tag.
+
+// #cgo pkg-config: Qt5DBus
+//
+// #include <stdlib.h>
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"unsafe"
+)
+
+// DBusBus identifies a D-Bus message bus.
+type DBusBus int
+
+const (
+	SessionBus DBusBus = iota
+	SystemBus
+)
+
+// PublishDBus publishes value on the D-Bus message bus under the provided
+// object path, and acquires the service name on the bus if it is not empty.
+//
+// The value is exposed with the same metadata used to make it available to
+// QML code: exported fields become D-Bus properties, methods become D-Bus
+// methods, and the change notifications triggered by the Changed function
+// are emitted as D-Bus signals. This makes it convenient to implement
+// desktop integration interfaces such as MPRIS in Go.
+//
+// The engine will hold a reference to the provided value, so it will
+// not be garbage collected until the engine is destroyed.
+//
+// PublishDBus is only available on Linux, and only works when the package
+// is built with the qmldbus build tag, which requires the Qt D-Bus module.
+// Otherwise it always returns an error.
+func (e *Engine) PublishDBus(bus DBusBus, service, path string, value interface{}) error {
+	cservice := C.CString(service)
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cservice))
+	defer C.free(unsafe.Pointer(cpath))
+	var cerr *C.error
+	gui(func() {
+		cvalue := wrapGoValue(e, value, cppOwner)
+		cerr = C.dbusRegisterObject(C.int(bus), cservice, cpath, cvalue)
+	})
+	if cerr != nil {
+		return cerror(cerr)
+	}
+	return nil
+}
+
+// UnpublishDBus removes the object published under path from the
+// D-Bus message bus.
+func (e *Engine) UnpublishDBus(bus DBusBus, path string) {
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	gui(func() {
+		C.dbusUnregisterObject(C.int(bus), cpath)
+	})
+}
@@ -0,0 +1,106 @@
+package qml
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// decimalValue is implemented by decimal number types such as the one in
+// github.com/shopspring/decimal. Values of these types are handed to QML
+// logic as strings formatted according to the default locale, rather than
+// as numbers, since converting them into float64 would lose precision.
+//
+// When read from a struct field, the formatting may be tuned with a tag
+// such as:
+//
+//     Price decimal.Decimal `qml:"precision=2,currency"`
+//
+// The precision option defines the number of digits after the decimal
+// point, and the currency option formats the value as an amount of money
+// in the currency of the locale. Fields implementing encoding.TextUnmarshaler
+// may be assigned from QML logic either a string formatted according to
+// the locale, or a number.
+type decimalValue interface {
+	StringFixed(places int32) string
+	String() string
+}
+
+// decimalFormat holds the formatting options defined in a field tag.
+type decimalFormat struct {
+	precision int
+	currency  bool
+}
+
+// parseDecimalTag parses the decimal formatting options in the qml
+// key of tag.
+func parseDecimalTag(tag reflect.StructTag) decimalFormat {
+	format := decimalFormat{precision: -1}
+	for _, option := range strings.Split(tag.Get("qml"), ",") {
+		switch {
+		case option == "currency":
+			format.currency = true
+		case strings.HasPrefix(option, "precision="):
+			precision, err := strconv.Atoi(option[len("precision="):])
+			if err != nil || precision < 0 {
+				panic(fmt.Sprintf("invalid precision in qml tag: %q", tag.Get("qml")))
+			}
+			format.precision = precision
+		}
+	}
+	return format
+}
+
+// formatDecimal formats value according to the default locale.
+func formatDecimal(value decimalValue, format decimalFormat) string {
+	var digits string
+	if format.precision >= 0 {
+		digits = value.StringFixed(int32(format.precision))
+	} else {
+		digits = value.String()
+	}
+	locale := &Locale{}
+	if format.currency {
+		return locale.FormatDecimalCurrency(digits, "")
+	}
+	return locale.FormatDecimal(digits)
+}
+
+// scanDecimal sets to, which must be a decimal value implementing
+// encoding.TextUnmarshaler when addressed, from a string formatted
+// according to the default locale or from a number, and returns whether
+// it did so. It panics if from cannot be parsed.
+func scanDecimal(to reflect.Value, from interface{}) bool {
+	if !to.CanAddr() {
+		return false
+	}
+	unmarshaler, ok := to.Addr().Interface().(encoding.TextUnmarshaler)
+	if _, isDecimal := to.Addr().Interface().(decimalValue); !ok || !isDecimal {
+		return false
+	}
+	var digits string
+	switch from := from.(type) {
+	case string:
+		var err error
+		digits, err = (&Locale{}).ParseDecimal(from)
+		if err != nil {
+			panic(err.Error())
+		}
+	case int:
+		digits = strconv.Itoa(from)
+	case int64:
+		digits = strconv.FormatInt(from, 10)
+	case float64:
+		digits = strconv.FormatFloat(from, 'f', -1, 64)
+	case float32:
+		digits = strconv.FormatFloat(float64(from), 'f', -1, 32)
+	default:
+		return false
+	}
+	if err := unmarshaler.UnmarshalText([]byte(digits)); err != nil {
+		panic(fmt.Sprintf("cannot unmarshal %q into %s value: %v", digits, to.Type(), err))
+	}
+	return true
+}
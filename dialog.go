@@ -0,0 +1,123 @@
+package qml
+
+import (
+	"fmt"
+)
+
+// DialogResult holds the choice made by the user in a dialog.
+type DialogResult struct {
+	// Accepted is whether the dialog was accepted, such as by
+	// clicking its Ok button, rather than rejected or closed.
+	Accepted bool
+
+	// Code is the result property of the dialog when it was closed,
+	// which is 1 if accepted, 0 if rejected, or the value provided
+	// to the done method of the dialog.
+	Code int
+}
+
+// DialogFuture holds the eventual result of a dialog shown with
+// ShowDialog.
+type DialogFuture struct {
+	dialog *Popup
+	done   chan struct{}
+	result DialogResult
+	then   []func(result DialogResult)
+}
+
+// ShowDialog instantiates component, which must hold a Qt Quick
+// Controls 2 Dialog, with parent as its visual parent and with the
+// properties in props set, and opens it as a modal dialog unless props
+// defines otherwise. The dialog is destroyed once closed, and the choice
+// of the user is available from the returned future. For example:
+//
+//     result := qml.ShowDialog(confirm, win.ContentItem(), map[string]interface{}{
+//             "title": "Delete all files?",
+//     }).Wait()
+//     if result.Accepted {
+//             ...
+//     }
+//
+// ShowDialog panics if props holds properties that the dialog does not have.
+func ShowDialog(component, parent Object, props map[string]interface{}) *DialogFuture {
+	f := &DialogFuture{done: make(chan struct{})}
+	gui(func() {
+		f.dialog = NewPopup(component.Create(nil))
+		f.dialog.Set("parent", parent)
+		f.dialog.Set("modal", true)
+		for name, value := range props {
+			if err := f.dialog.Set(name, value); err != nil {
+				f.dialog.Destroy()
+				panic(fmt.Sprintf("cannot set dialog property %q: %v", name, err))
+			}
+		}
+		f.dialog.On("accepted", func() { f.result.Accepted = true })
+		f.dialog.OnClosed(f.closed)
+		f.dialog.Open()
+	})
+	return f
+}
+
+func (f *DialogFuture) closed() {
+	select {
+	case <-f.done:
+		return
+	default:
+	}
+	f.result.Code = f.dialog.Int("result")
+	f.dialog.Destroy()
+	for _, fn := range f.then {
+		fn(f.result)
+	}
+	f.then = nil
+	close(f.done)
+}
+
+// Dialog returns the dialog shown.
+func (f *DialogFuture) Dialog() *Popup {
+	return f.dialog
+}
+
+// Wait waits until the dialog is closed and returns the choice of the user.
+//
+// Wait must not be called from the GUI thread, as it waits for the GUI
+// thread to make progress. Logic running in the GUI thread may instead
+// use Then.
+func (f *DialogFuture) Wait() DialogResult {
+	if onGuiThread() {
+		panic("DialogFuture.Wait must not be called from the GUI thread")
+	}
+	<-f.done
+	return f.result
+}
+
+// Done returns a channel that is closed once the dialog is closed,
+// after which Wait returns immediately.
+func (f *DialogFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Then arranges for fn to be called with the choice of the user once the
+// dialog is closed, or immediately if it's closed already. As with all
+// signal handlers, fn is run within the main GUI thread.
+func (f *DialogFuture) Then(fn func(result DialogResult)) {
+	gui(func() {
+		select {
+		case <-f.done:
+			fn(f.result)
+		default:
+			f.then = append(f.then, fn)
+		}
+	})
+}
+
+// Close closes the dialog as if rejected by the user, if it's still open.
+func (f *DialogFuture) Close() {
+	gui(func() {
+		select {
+		case <-f.done:
+		default:
+			f.dialog.Call("reject")
+		}
+	})
+}
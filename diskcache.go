@@ -0,0 +1,76 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"os"
+)
+
+// DiskCache holds the settings of the caches where Qt stores compiled
+// QML and JavaScript code, and compiled shaders, so that they are not
+// compiled again when the application restarts.
+type DiskCache struct {
+	// Disabled disables caching of compiled QML and JavaScript code.
+	Disabled bool
+
+	// DisableShaders disables caching of compiled shaders.
+	DisableShaders bool
+
+	// Path, if not empty, is the directory where compiled QML and
+	// JavaScript code is cached, in place of a directory within the
+	// user cache location. It's honored by Qt 6 and later.
+	Path string
+}
+
+// SetDiskCache changes the settings of the disk caches. The settings
+// apply to the whole process, and must be defined before any engine
+// is created.
+func SetDiskCache(cache DiskCache) {
+	setEnvFlag("QML_DISABLE_DISK_CACHE", cache.Disabled)
+	setEnvFlag("QT_DISABLE_SHADER_DISK_CACHE", cache.DisableShaders)
+	if cache.Path != "" {
+		os.Setenv("QML_DISK_CACHE_PATH", cache.Path)
+	} else {
+		os.Unsetenv("QML_DISK_CACHE_PATH")
+	}
+}
+
+func setEnvFlag(name string, enabled bool) {
+	if enabled {
+		os.Setenv(name, "1")
+	} else {
+		os.Unsetenv(name)
+	}
+}
+
+// DiskCachePath returns the directory where compiled QML and JavaScript
+// code is cached.
+func DiskCachePath() string {
+	if path := os.Getenv("QML_DISK_CACHE_PATH"); path != "" {
+		return path
+	}
+	var cpath *C.char
+	gui(func() {
+		cpath = C.applicationDiskCachePath()
+	})
+	return cstringResult(cpath)
+}
+
+// PrewarmCache compiles the QML files at the provided paths, and the
+// files they depend on, ahead of their first use, so that the compiled
+// code is stored in the disk cache and components load quickly later,
+// even on slow storage. Compilation happens in the background, while the
+// GUI thread keeps running, and PrewarmCache returns once it's done,
+// with an error describing all files that failed to compile, if any.
+//
+// PrewarmCache must not be called from the GUI thread, as it waits for
+// the GUI thread to make progress.
+func (e *Engine) PrewarmCache(paths ...string) error {
+	if onGuiThread() {
+		panic("PrewarmCache must not be called from the GUI thread")
+	}
+	_, err := e.compileFiles(paths, false)
+	return err
+}
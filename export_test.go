@@ -0,0 +1,13 @@
+package qml
+
+// Internals exposed to the tests in package qml_test.
+var (
+	AssertGui = assertGui
+	Gui       = gui
+)
+
+// AudioStreams returns the number of audio streams not yet stopped.
+func AudioStreams() (n int) {
+	gui(func() { n = len(audioStreams) })
+	return n
+}
@@ -0,0 +1,81 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"fmt"
+)
+
+// FactoryFunc creates a new object for QML logic, given the name of the
+// requested type and the properties provided by QML for the new object.
+// The result may be a Go value, which is made available to QML as usual,
+// or a QML object such as one created with Common.Create.
+type FactoryFunc func(typeName string, props map[string]interface{}) (interface{}, error)
+
+// Factory is the Go value behind the Factory QML element registered
+// by RegisterFactory.
+type Factory struct {
+	// Error holds the error returned by the factory function on the
+	// last failed call to Create, or is empty if it succeeded.
+	Error string
+
+	f FactoryFunc
+}
+
+// RegisterFactory registers a Factory element for use by QML code, which
+// creates objects of types chosen at runtime by calling f. This allows
+// plugin systems to have QML logic instantiate implementation objects
+// that are only known to Go code. The element is available under the
+// provided location and major.minor version numbers, as with RegisterTypes.
+//
+// For example, after registering a factory under "GoExtensions" 1.0:
+//
+//     import GoExtensions 1.0
+//
+//     Item {
+//         Factory { id: factory }
+//         Component.onCompleted: {
+//             var player = factory.create("AudioPlayer", {volume: 0.5})
+//             if (!player) console.log(factory.error)
+//         }
+//     }
+//
+// The returned objects are owned by QML logic, and are destroyed by the
+// JavaScript garbage collector once unreferenced, unless they have a parent.
+func RegisterFactory(location string, major, minor int, f FactoryFunc) {
+	RegisterTypes(location, major, minor, []TypeSpec{{
+		Name: "Factory",
+		New:  func() interface{} { return &Factory{f: f} },
+	}})
+}
+
+// Create calls the factory function with the provided type name and
+// properties, and returns the new object, or nil on errors. The props
+// parameter must be nil or a map[string]interface{}. Create is meant
+// to be called by QML logic.
+func (factory *Factory) Create(typeName string, props interface{}) interface{} {
+	propsMap, _ := props.(map[string]interface{})
+	if propsMap == nil {
+		propsMap = make(map[string]interface{})
+	}
+	result, err := factory.f(typeName, propsMap)
+	if err == nil && result == nil {
+		err = fmt.Errorf("factory returned no object for type %s", typeName)
+	}
+	if err != nil {
+		factory.Error = err.Error()
+		Changed(factory, &factory.Error)
+		return nil
+	}
+	if factory.Error != "" {
+		factory.Error = ""
+		Changed(factory, &factory.Error)
+	}
+	if obj, ok := result.(Object); ok && obj.Common().engine != nil {
+		common := obj.Common()
+		C.engineSetOwnershipJS(common.engine.addr, common.addr)
+	}
+	return result
+}
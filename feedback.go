@@ -0,0 +1,110 @@
+package qml
+
+// #include <stdlib.h>
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"time"
+	"unsafe"
+)
+
+// HapticEffect identifies a kind of haptic feedback.
+type HapticEffect int
+
+const (
+	HapticLight     HapticEffect = iota // A light tap, such as for a key press.
+	HapticMedium                        // A medium tap, such as for a button press.
+	HapticHeavy                         // A strong tap, such as for a long press.
+	HapticSelection                     // A subtle tick, such as for a picker value change.
+)
+
+// HapticFeedback produces the provided haptic effect on the device.
+// It does nothing on platforms without haptic feedback support, which
+// currently includes all platforms but Android and iOS.
+func HapticFeedback(effect HapticEffect) {
+	gui(func() {
+		hapticFeedback(effect)
+	})
+}
+
+// Vibrate vibrates the device for the provided duration. On Android this
+// requires the android.permission.VIBRATE permission. On iOS the duration
+// is defined by the system. On other platforms Vibrate does nothing.
+func Vibrate(d time.Duration) {
+	gui(func() {
+		vibrate(d)
+	})
+}
+
+// SoundEffect plays short uncompressed sounds with low latency, such as
+// the clicks and beeps used as user interface feedback. Sound effects are
+// silent unless the package is built with the qmlmultimedia build tag.
+type SoundEffect struct {
+	addr unsafe.Pointer
+}
+
+var soundEffects = make(map[*SoundEffect]bool)
+
+// InfiniteLoops makes a sound effect loop until stopped. See SetLoops.
+const InfiniteLoops = -2
+
+// NewSoundEffect returns a sound effect that plays the WAV file at the
+// provided URL, such as "file:///path/to/click.wav" or "qrc:/click.wav".
+// The file is loaded in the background, so the sound effect should be
+// created in advance of being played.
+//
+// The Destroy method must be called to release the sound effect.
+func NewSoundEffect(url string) *SoundEffect {
+	curl := C.CString(url)
+	defer C.free(unsafe.Pointer(curl))
+	effect := &SoundEffect{}
+	gui(func() {
+		effect.addr = C.newSoundEffect(curl)
+		soundEffects[effect] = true
+	})
+	return effect
+}
+
+// Play starts playing the sound effect from the beginning.
+func (effect *SoundEffect) Play() {
+	gui(func() {
+		C.soundEffectPlay(effect.addr)
+	})
+}
+
+// Stop stops playing the sound effect.
+func (effect *SoundEffect) Stop() {
+	gui(func() {
+		C.soundEffectStop(effect.addr)
+	})
+}
+
+// SetVolume changes the playback volume, ranging from 0.0 to 1.0.
+func (effect *SoundEffect) SetVolume(volume float64) {
+	gui(func() {
+		C.soundEffectSetVolume(effect.addr, C.double(volume))
+	})
+}
+
+// SetLoops changes how many times the sound effect is played each time
+// Play is called, or makes it loop until stopped if loops is InfiniteLoops.
+func (effect *SoundEffect) SetLoops(loops int) {
+	gui(func() {
+		C.soundEffectSetLoops(effect.addr, C.int(loops))
+	})
+}
+
+// Destroy stops the sound effect and releases it.
+//
+// It is safe to call Destroy more than once.
+func (effect *SoundEffect) Destroy() {
+	gui(func() {
+		if soundEffects[effect] {
+			C.delSoundEffect(effect.addr)
+			effect.addr = nilPtr
+			delete(soundEffects, effect)
+		}
+	})
+}
@@ -0,0 +1,11 @@
+// +build !android,!ios
+
+package qml
+
+import (
+	"time"
+)
+
+func hapticFeedback(effect HapticEffect) {}
+
+func vibrate(d time.Duration) {}
@@ -0,0 +1,237 @@
+package qml
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Form keeps the fields of a Go struct synchronized with the input
+// items of a QML form, created with BindForm.
+type Form struct {
+	// Dirty is whether any field differs from the values the form was
+	// last loaded with or marked clean at.
+	Dirty bool
+
+	value    reflect.Value
+	fields   []*formField
+	loading  bool
+	validate []func(field string, value interface{}) error
+	changed  []func(field string)
+}
+
+type formField struct {
+	name     string // Go field name.
+	item     Object
+	property string
+	value    reflect.Value
+	clean    interface{}
+	err      error
+}
+
+// BindForm binds the fields of the struct pointed to by ptr to the input
+// items within obj, and loads the current field values into the items.
+// From then on, editing an item assigns the new value to the respective
+// field, while Go logic that changes the struct calls Load to update the
+// items.
+//
+// Each exported field is bound to the descendant item of obj with an
+// objectName matching the QML name of the field, such as "email" for
+// an Email field, if there is one. The property of the item holding the
+// value is "checked" for bool fields, as in CheckBox and Switch, "value"
+// for numeric fields, as in SpinBox and Slider, and "text" for all other
+// fields, as in TextField and TextArea. Either may be defined explicitly
+// in the qml field tag. For example:
+//
+//     type Account struct {
+//             Name    string
+//             Country string `qml:"form=countryBox,property=editText"`
+//             Notes   string `qml:"form=-"`
+//     }
+//
+// An error is returned if an item named explicitly in a field tag is
+// not found.
+func BindForm(obj Object, ptr interface{}) (*Form, error) {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("BindForm must be given a pointer to a struct, got %T", ptr))
+	}
+	f := &Form{value: v.Elem()}
+	t := f.value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sfield := t.Field(i)
+		if sfield.PkgPath != "" {
+			continue // not exported
+		}
+		name, property, explicit := parseFormTag(sfield)
+		if name == "-" {
+			continue
+		}
+		item, ok := obj.Common().findByName(name)
+		if !ok {
+			if explicit {
+				return nil, fmt.Errorf("cannot find form item %q for field %s", name, sfield.Name)
+			}
+			continue
+		}
+		f.fields = append(f.fields, &formField{
+			name:     sfield.Name,
+			item:     item,
+			property: property,
+			value:    f.value.Field(i),
+		})
+	}
+	gui(func() {
+		f.load()
+		for _, field := range f.fields {
+			field := field
+			field.item.On(field.property+"Changed", func() { f.edited(field) })
+		}
+	})
+	return f, nil
+}
+
+// parseFormTag returns the item name and property bound to field, and
+// whether the item name was defined explicitly.
+func parseFormTag(field reflect.StructField) (name, property string, explicit bool) {
+	name = memberName(field.Name)
+	switch field.Type.Kind() {
+	case reflect.Bool:
+		property = "checked"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		property = "value"
+	default:
+		property = "text"
+	}
+	for _, option := range strings.Split(field.Tag.Get("qml"), ",") {
+		switch {
+		case strings.HasPrefix(option, "form="):
+			name = option[len("form="):]
+			explicit = true
+		case strings.HasPrefix(option, "property="):
+			property = option[len("property="):]
+		}
+	}
+	return name, property, explicit
+}
+
+// Load updates the form items with the current values of the struct
+// fields, and marks the form as clean.
+func (f *Form) Load() {
+	gui(f.load)
+}
+
+func (f *Form) load() {
+	f.loading = true
+	defer func() { f.loading = false }()
+	for _, field := range f.fields {
+		if err := field.item.Set(field.property, field.value.Interface()); err != nil {
+			panic(fmt.Sprintf("cannot load field %s into form: %v", field.name, err))
+		}
+		field.err = nil
+	}
+	f.markClean()
+}
+
+// MarkClean marks the current field values as clean, such as when they
+// are saved.
+func (f *Form) MarkClean() {
+	gui(f.markClean)
+}
+
+func (f *Form) markClean() {
+	for _, field := range f.fields {
+		field.clean = field.value.Interface()
+	}
+	f.updateDirty()
+}
+
+// Revert restores the field values the form was last loaded with or
+// marked clean at, and loads them into the form items.
+func (f *Form) Revert() {
+	gui(func() {
+		for _, field := range f.fields {
+			if field.clean == nil {
+				field.value.Set(reflect.Zero(field.value.Type()))
+			} else {
+				field.value.Set(reflect.ValueOf(field.clean))
+			}
+		}
+		f.load()
+	})
+}
+
+// OnValidate arranges for fn to be called with the field name and the
+// new value whenever a form item is edited, before the value is assigned
+// to the field. If fn returns an error, the field is left unchanged, and
+// the error is returned by the Error method until the field is
+// successfully edited or loaded again.
+// As with all signal handlers, fn is run within the main GUI thread.
+func (f *Form) OnValidate(fn func(field string, value interface{}) error) {
+	gui(func() {
+		f.validate = append(f.validate, fn)
+	})
+}
+
+// OnChange arranges for fn to be called with the field name whenever
+// a field is changed by editing its form item.
+// As with all signal handlers, fn is run within the main GUI thread.
+func (f *Form) OnChange(fn func(field string)) {
+	gui(func() {
+		f.changed = append(f.changed, fn)
+	})
+}
+
+// Error returns the error that prevented the last edit of the form item
+// bound to the named field from being assigned to it, if any.
+func (f *Form) Error(field string) error {
+	var err error
+	gui(func() {
+		for _, ff := range f.fields {
+			if ff.name == field {
+				err = ff.err
+			}
+		}
+	})
+	return err
+}
+
+// edited assigns the value of the form item bound to field after it
+// was edited.
+func (f *Form) edited(field *formField) {
+	if f.loading {
+		return
+	}
+	value := field.item.Property(field.property)
+	field.err = nil
+	for _, fn := range f.validate {
+		if err := fn(field.name, value); err != nil {
+			field.err = err
+			return
+		}
+	}
+	if err := assignValue(field.value, value); err != nil {
+		field.err = err
+		return
+	}
+	f.updateDirty()
+	for _, fn := range f.changed {
+		fn(field.name)
+	}
+}
+
+func (f *Form) updateDirty() {
+	dirty := false
+	for _, field := range f.fields {
+		if !reflect.DeepEqual(field.clean, field.value.Interface()) {
+			dirty = true
+			break
+		}
+	}
+	if f.Dirty != dirty {
+		f.Dirty = dirty
+		Changed(f, &f.Dirty)
+	}
+}
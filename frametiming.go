@@ -0,0 +1,82 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"time"
+	"unsafe"
+)
+
+// FrameTiming holds the time spent in the phases of rendering a frame
+// of a window, similar to what is logged with QSG_RENDER_TIMING set.
+type FrameTiming struct {
+	// Sync is the time spent synchronizing the scene graph with the
+	// state of the items, during which the GUI thread is blocked.
+	Sync time.Duration
+
+	// Render is the time spent issuing the rendering commands.
+	Render time.Duration
+
+	// Swap is the time spent presenting the frame, which includes
+	// waiting for the vertical refresh of the display.
+	Swap time.Duration
+
+	// Interval is the time since the previous frame was presented,
+	// or zero for the first frame observed.
+	Interval time.Duration
+
+	// Missed is the number of display refreshes missed because
+	// synchronizing and rendering the frame took longer than the
+	// refresh period of the screen.
+	Missed int
+}
+
+type frameTimer struct {
+	addr unsafe.Pointer
+	f    func(timing FrameTiming)
+}
+
+var frameTimers = make(map[*frameTimer]bool)
+
+// OnFrameTiming arranges for f to be called with the timing of every
+// frame rendered for the window, so that the application may monitor
+// its own responsiveness in production and adapt, such as by reducing
+// model updates while frames are being missed. Frames are only rendered
+// when the window content changes. As with all signal handlers, f is run
+// within the main GUI thread, after the frame is presented.
+//
+// The returned function stops the timing, and is safe to call more than
+// once.
+func (win *Window) OnFrameTiming(f func(timing FrameTiming)) (stop func()) {
+	timer := &frameTimer{f: f}
+	gui(func() {
+		frameTimers[timer] = true
+		timer.addr = C.windowStartFrameTiming(win.addr, unsafe.Pointer(timer))
+	})
+	return func() {
+		gui(func() {
+			if timer.addr != nilPtr {
+				C.windowStopFrameTiming(timer.addr)
+				timer.addr = nilPtr
+				delete(frameTimers, timer)
+			}
+		})
+	}
+}
+
+//export hookFrameTiming
+func hookFrameTiming(timerp unsafe.Pointer, sync, render, swap, interval C.int64_t, missed C.int) {
+	timer := (*frameTimer)(timerp)
+	if timer.addr == nilPtr {
+		return
+	}
+	timer.f(FrameTiming{
+		Sync:     time.Duration(sync),
+		Render:   time.Duration(render),
+		Swap:     time.Duration(swap),
+		Interval: time.Duration(interval),
+		Missed:   int(missed),
+	})
+}
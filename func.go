@@ -0,0 +1,70 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"unsafe"
+)
+
+// Func holds a JavaScript function received from QML logic. When a
+// method of a Go value is called by QML with a function as one of its
+// arguments, the parameter may be declared as *qml.Func so that the
+// function may be called back later. For example:
+//
+//     func (b *Backend) Fetch(url string, done *qml.Func) {
+//         go func() {
+//             data := fetch(url)
+//             done.Call(data)
+//             done.Release()
+//         }()
+//     }
+//
+// and in QML:
+//
+//     backend.fetch(url, function(data) { ... })
+//
+// A Func may also be handed back to QML logic, in which case it is
+// seen as the original JavaScript function.
+type Func struct {
+	engine *Engine
+	addr   unsafe.Pointer
+}
+
+// Call calls the JavaScript function with the provided parameters
+// and returns its result. The function is run within the main GUI
+// thread, so Call may be used from any goroutine.
+// Call panics if the function throws an error or if f was released.
+func (f *Func) Call(params ...interface{}) interface{} {
+	if len(params) > len(dataValueArray) {
+		panic("too many parameters")
+	}
+	var result C.DataValue
+	var cerr *C.error
+	gui(func() {
+		if f.addr == nilPtr {
+			panic("function was released")
+		}
+		for i, param := range params {
+			packDataValue(param, &dataValueArray[i], f.engine, jsOwner)
+		}
+		cerr = C.jsValueCall(f.engine.addr, f.addr, &result, &dataValueArray[0], C.int(len(params)))
+	})
+	cmust(cerr)
+	return unpackDataValue(&result, f.engine)
+}
+
+// Release releases the reference to the JavaScript function held by f,
+// allowing it to be garbage collected by the JavaScript engine.
+// The function must not be called after it is released.
+//
+// It is safe to call Release more than once.
+func (f *Func) Release() {
+	gui(func() {
+		if f.addr != nilPtr {
+			C.delJSValue(f.addr)
+			f.addr = nilPtr
+		}
+	})
+}
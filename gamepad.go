@@ -0,0 +1,153 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+// GamepadAxis identifies an analog stick axis of a gamepad.
+// Axis values range from -1.0 to 1.0.
+type GamepadAxis int
+
+const (
+	AxisLeftX GamepadAxis = iota
+	AxisLeftY
+	AxisRightX
+	AxisRightY
+)
+
+// GamepadButton identifies a button of a gamepad, named after the
+// layout of common controllers.
+type GamepadButton int
+
+const (
+	ButtonA GamepadButton = iota
+	ButtonB
+	ButtonX
+	ButtonY
+	ButtonL1
+	ButtonR1
+	ButtonL2
+	ButtonR2
+	ButtonSelect
+	ButtonStart
+	ButtonL3
+	ButtonR3
+	ButtonUp
+	ButtonDown
+	ButtonRight
+	ButtonLeft
+	ButtonCenter
+	ButtonGuide
+)
+
+// GamepadHandler holds the functions called when gamepad events happen.
+// Nil functions are ignored. All functions are run within the main GUI
+// thread, as the events are delivered by the Qt event loop.
+type GamepadHandler struct {
+	// Connected is called when a gamepad is connected or disconnected.
+	Connected func(device int, connected bool)
+
+	// Axis is called when an axis of a gamepad moves.
+	Axis func(device int, axis GamepadAxis, value float64)
+
+	// Button is called when a button of a gamepad is pressed or released.
+	// For analog buttons such as triggers, value ranges from 0.0 to 1.0,
+	// and is called repeatedly as the button is pressed further.
+	Button func(device int, button GamepadButton, value float64, pressed bool)
+}
+
+var gamepadHandlers []*GamepadHandler
+
+// HandleGamepads arranges for the functions in h to be called when
+// gamepad events happen.
+//
+// Gamepads are only supported when the package is built with the
+// qmlgamepad build tag, which requires the Qt Gamepad module:
+//
+//     go build -tags qmlgamepad
+//
+// Otherwise gamepad events never happen, Gamepads returns no devices,
+// and configuring gamepads is unsupported.
+func HandleGamepads(h GamepadHandler) {
+	gui(func() {
+		if gamepadHandlers == nil {
+			C.gamepadConnect()
+		}
+		gamepadHandlers = append(gamepadHandlers, &h)
+	})
+}
+
+// Gamepads returns the identifiers of the gamepads currently connected.
+func Gamepads() []int {
+	var devices []int
+	gui(func() {
+		var cdevices [16]C.int
+		n := int(C.gamepadList(&cdevices[0], C.int(len(cdevices))))
+		if n > len(cdevices) {
+			n = len(cdevices)
+		}
+		devices = make([]int, n)
+		for i := range devices {
+			devices[i] = int(cdevices[i])
+		}
+	})
+	return devices
+}
+
+// ConfigureGamepadButton maps the next button pressed on the device to
+// button, for controllers whose buttons are not recognized correctly.
+// The mapping is persisted by the platform backend where supported.
+// ConfigureGamepadButton returns false if the backend does not support
+// configuring buttons.
+func ConfigureGamepadButton(device int, button GamepadButton) bool {
+	var ok C.int
+	gui(func() {
+		ok = C.gamepadConfigureButton(C.int(device), C.int(button))
+	})
+	return ok != 0
+}
+
+// ConfigureGamepadAxis maps the next axis moved on the device to axis.
+// See ConfigureGamepadButton.
+func ConfigureGamepadAxis(device int, axis GamepadAxis) bool {
+	var ok C.int
+	gui(func() {
+		ok = C.gamepadConfigureAxis(C.int(device), C.int(axis))
+	})
+	return ok != 0
+}
+
+// ResetGamepadConfiguration drops the button and axis mappings
+// configured for the device.
+func ResetGamepadConfiguration(device int) {
+	gui(func() {
+		C.gamepadResetConfiguration(C.int(device))
+	})
+}
+
+//export hookGamepadConnected
+func hookGamepadConnected(device, connected C.int) {
+	for _, h := range gamepadHandlers {
+		if h.Connected != nil {
+			h.Connected(int(device), connected != 0)
+		}
+	}
+}
+
+//export hookGamepadAxis
+func hookGamepadAxis(device, axis C.int, value C.double) {
+	for _, h := range gamepadHandlers {
+		if h.Axis != nil {
+			h.Axis(int(device), GamepadAxis(axis), float64(value))
+		}
+	}
+}
+
+//export hookGamepadButton
+func hookGamepadButton(device, button C.int, value C.double, pressed C.int) {
+	for _, h := range gamepadHandlers {
+		if h.Button != nil {
+			h.Button(int(device), GamepadButton(button), float64(value), pressed != 0)
+		}
+	}
+}
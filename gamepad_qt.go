@@ -0,0 +1,7 @@
+// +build qmlgamepad
+
+package qml
+
+// #cgo pkg-config: Qt5Gamepad
+//
+import "C"
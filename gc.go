@@ -0,0 +1,67 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// CollectGarbage runs the JavaScript garbage collector of the engine,
+// releasing the memory held by unreferenced JavaScript values and
+// destroying the unreferenced objects owned by JavaScript.
+//
+// Long-running applications may call it in response to memory pressure,
+// such as when notified by OnMemoryWarning on iOS, or when idle.
+// See CollectGarbageWhenIdle.
+func (e *Engine) CollectGarbage() {
+	e.assertValid()
+	gui(func() {
+		C.engineCollectGarbage(e.addr)
+	})
+}
+
+// CollectGarbageWhenIdle arranges for the JavaScript garbage collector of
+// the engine to run once the application has received no user input for
+// the idle duration, and again after every subsequent period of activity
+// followed by the same idle duration. That's useful for long-running
+// applications such as kiosks, so that collection pauses are not noticed.
+//
+// Collection when idle continues until the returned stop function is
+// called or the engine is destroyed. Calling stop more than once has no
+// further effect.
+func (e *Engine) CollectGarbageWhenIdle(idle time.Duration) (stop func()) {
+	e.assertValid()
+	var collector unsafe.Pointer
+	gui(func() {
+		collector = C.newIdleCollector(e.addr, C.int(idle/time.Millisecond))
+	})
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			gui(func() {
+				// The collector is a child of the engine, and
+				// dies with it.
+				if !e.destroyed {
+					C.delObject(collector)
+				}
+			})
+		})
+	}
+}
+
+// JSHeapSize returns the number of bytes in use by the JavaScript heap
+// of the engine. The ok result is false if the Qt version in use does
+// not expose that information.
+func (e *Engine) JSHeapSize() (size int64, ok bool) {
+	e.assertValid()
+	gui(func() {
+		var csize C.int64_t
+		ok = C.engineJSHeapSize(e.addr, &csize) != 0
+		size = int64(csize)
+	})
+	return size, ok
+}
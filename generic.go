@@ -0,0 +1,229 @@
+//go:build go1.18
+// +build go1.18
+
+package qml
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ObjectOf offers typed access to an object backed by a Go value of
+// type T, such as one created by QML for a type registered with
+// RegisterTypes, or one handed to QML via Context.SetVar. For example:
+//
+//     person := qml.TypedObject[*Person](root.Object("person"))
+//     fmt.Println(person.Value().Name)
+//     qml.SetField(person, &person.Value().Name, "Bob")
+//
+type ObjectOf[T any] struct {
+	Object
+}
+
+// TypedObject returns obj as an ObjectOf[T].
+func TypedObject[T any](obj Object) ObjectOf[T] {
+	return ObjectOf[T]{obj}
+}
+
+// Value returns the Go value backing the object.
+//
+// It is a runtime error to call Value on objects that are not backed
+// by a Go value of type T.
+func (o ObjectOf[T]) Value() T {
+	value := o.Interface()
+	if v, ok := value.(T); ok {
+		return v
+	}
+	var zero T
+	panic(fmt.Sprintf("object holds a %T value rather than %T", value, zero))
+}
+
+// SetField sets the field pointed to by fieldAddr, which must be a field
+// of the Go value backing obj, and notifies QML bindings of the change.
+// The field is set within the main GUI thread, so QML never observes the
+// change partially applied.
+func SetField[T, F any](obj ObjectOf[T], fieldAddr *F, value F) {
+	gui(func() {
+		*fieldAddr = value
+	})
+	Changed(obj.Value(), fieldAddr)
+}
+
+// OnChanged arranges for f to be called with the new value of the named
+// property of obj whenever it changes. The value is converted into F if
+// necessary, so an int property may be observed as an int64 or a float64.
+// As with all signal handlers, f is run within the main GUI thread.
+func OnChanged[F any](obj Object, property string, f func(value F)) {
+	obj.On(property+"Changed", func() {
+		f(convertTo[F](obj.Property(property)))
+	})
+}
+
+// Optional holds a value of type T that may be null, such as a column
+// that is nullable in a database. Fields and method results of type
+// Optional[T] are seen by QML logic as the value itself when Valid is
+// true, and as null otherwise. Assigning null to such a field from QML
+// logic makes it invalid.
+//
+// Optional implements the database/sql Scanner and driver Valuer
+// interfaces, so it may also be used directly with that package.
+type Optional[T any] struct {
+	V     T
+	Valid bool
+}
+
+// Some returns a valid Optional holding value.
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{V: value, Valid: true}
+}
+
+// Value returns the value held by o, or nil if o is not valid.
+func (o Optional[T]) Value() (driver.Value, error) {
+	if !o.Valid {
+		return nil, nil
+	}
+	return o.V, nil
+}
+
+// Scan sets o to value, converted into T if necessary, or makes o
+// invalid if value is nil.
+func (o *Optional[T]) Scan(value interface{}) error {
+	if value == nil {
+		*o = Optional[T]{}
+		return nil
+	}
+	v, ok := tryConvertTo[T](value)
+	if !ok {
+		return fmt.Errorf("cannot convert %T value to %T", value, o.V)
+	}
+	*o = Some(v)
+	return nil
+}
+
+// convertTo returns value as an F, converting it if necessary.
+func convertTo[F any](value interface{}) F {
+	v, ok := tryConvertTo[F](value)
+	if !ok {
+		panic(fmt.Sprintf("cannot convert %T value to %T", value, v))
+	}
+	return v
+}
+
+// tryConvertTo returns value as an F, converting it if necessary, and
+// whether it could do so. A nil value is returned as the zero F.
+func tryConvertTo[F any](value interface{}) (F, bool) {
+	if v, ok := value.(F); ok {
+		return v, true
+	}
+	var zero F
+	if value == nil {
+		return zero, true
+	}
+	ft := reflect.TypeOf(&zero).Elem()
+	if v := reflect.ValueOf(value); v.Type().ConvertibleTo(ft) {
+		return v.Convert(ft).Interface().(F), true
+	}
+	return zero, false
+}
+
+// Stream holds the latest value of a live feed, such as a sensor
+// reading, for display by QML logic. The producer sends values from any
+// goroutine at its own pace, while they are delivered to QML at most
+// once per interval, so that only the latest value matters and the
+// GUI thread is never flooded. For example:
+//
+//     type Dashboard struct {
+//         Temperature *qml.Stream[float64]
+//     }
+//
+//     dashboard := &Dashboard{Temperature: qml.NewStream[float64](0)}
+//     context.SetVar("dashboard", dashboard)
+//     go func() {
+//         for reading := range sensor {
+//             dashboard.Temperature.Send(reading)
+//         }
+//     }()
+//
+// and in QML:
+//
+//     Text { text: dashboard.temperature.value.toFixed(1) }
+//
+// The producer observes back-pressure via the result of Send and via
+// Pending, and may slow down or skip expensive work accordingly.
+// The exported fields are updated within the GUI thread, and must not
+// be modified directly.
+type Stream[T any] struct {
+	Value   T   // Latest value delivered.
+	Dropped int // Number of values replaced by newer ones before being delivered.
+
+	mu        sync.Mutex
+	interval  time.Duration
+	pending   T
+	ready     bool // Whether pending holds a value awaiting delivery.
+	scheduled bool
+	last      time.Time
+	dropped   int
+}
+
+// NewStream returns a new stream delivering values at most once per
+// interval, or about once per frame if interval is zero.
+func NewStream[T any](interval time.Duration) *Stream[T] {
+	if interval <= 0 {
+		interval = 16 * time.Millisecond
+	}
+	return &Stream[T]{interval: interval}
+}
+
+// Send sends value to be delivered to QML logic, replacing any value
+// still awaiting delivery. Send never blocks, and returns false if a
+// value was dropped in favor of the new one.
+func (s *Stream[T]) Send(value T) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delivered := !s.ready
+	if s.ready {
+		s.dropped++
+	}
+	s.pending = value
+	s.ready = true
+	if !s.scheduled {
+		s.scheduled = true
+		wait := s.interval - time.Since(s.last)
+		if wait < 0 {
+			wait = 0
+		}
+		time.AfterFunc(wait, s.deliver)
+	}
+	return delivered
+}
+
+// Pending returns whether a value sent is still awaiting delivery.
+func (s *Stream[T]) Pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ready
+}
+
+func (s *Stream[T]) deliver() {
+	gui(func() {
+		s.mu.Lock()
+		value := s.pending
+		var zero T
+		s.pending = zero
+		s.ready = false
+		s.scheduled = false
+		s.last = time.Now()
+		dropped := s.dropped
+		s.mu.Unlock()
+
+		s.Value = value
+		Changed(s, &s.Value)
+		if s.Dropped != dropped {
+			s.Dropped = dropped
+			Changed(s, &s.Dropped)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+//go:build go1.18
+// +build go1.18
+
+package qml_test
+
+import (
+	"time"
+
+	"github.com/niemeyer/qml"
+	. "launchpad.net/gocheck"
+)
+
+func (s *S) TestObjectOf(c *C) {
+	value := &TestType{StringValue: "<before>"}
+	s.context.SetVar("value", value)
+
+	component, err := s.engine.LoadString("file.qml", `
+		import QtQuick 2.0
+		Item {
+			property var held: value
+			property string text: value.stringValue
+			property int number: 42
+		}
+	`)
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	obj := qml.TypedObject[*TestType](root.Object("held"))
+	c.Assert(obj.Value(), Equals, value)
+
+	var texts []string
+	var numbers []float64
+	qml.OnChanged(root, "text", func(text string) { texts = append(texts, text) })
+	qml.OnChanged(root, "number", func(number float64) { numbers = append(numbers, number) })
+
+	qml.SetField(obj, &value.StringValue, "<after>")
+	c.Assert(root.String("text"), Equals, "<after>")
+	c.Assert(texts, DeepEquals, []string{"<after>"})
+
+	root.Set("number", 7)
+	c.Assert(numbers, DeepEquals, []float64{7})
+
+	c.Assert(func() { qml.TypedObject[*qml.Common](root.Object("held")).Value() }, PanicMatches, `object holds a \*qml_test.TestType value rather than \*qml.Common`)
+}
+
+type testOptional struct {
+	Score qml.Optional[float64]
+}
+
+func (s *S) TestOptional(c *C) {
+	value := &testOptional{Score: qml.Some(1.5)}
+	s.context.SetVar("value", value)
+
+	component, err := s.engine.LoadString("file.qml", `
+		import QtQuick 2.0
+		Item {
+			property var score: value.score
+			function clear() { value.score = null; return value.score === null }
+			function set() { value.score = 2; return value.score }
+		}
+	`)
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	c.Assert(root.Property("score"), Equals, 1.5)
+	c.Assert(root.Call("clear"), Equals, true)
+	c.Assert(value.Score.Valid, Equals, false)
+	c.Assert(root.Call("set"), Equals, 2.0)
+	c.Assert(value.Score, Equals, qml.Some(2.0))
+}
+
+type testSensor struct {
+	Reading *qml.Stream[float64]
+}
+
+func (s *S) TestStream(c *C) {
+	sensor := &testSensor{Reading: qml.NewStream[float64](50 * time.Millisecond)}
+	s.context.SetVar("sensor", sensor)
+
+	component, err := s.engine.LoadString("file.qml", `
+		import QtQuick 2.0
+		Item {
+			property real reading: sensor.reading.value
+			property int dropped: sensor.reading.dropped
+		}
+	`)
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	c.Assert(sensor.Reading.Send(1), Equals, true)
+	c.Assert(sensor.Reading.Pending(), Equals, true)
+	c.Assert(sensor.Reading.Send(2), Equals, false)
+	c.Assert(sensor.Reading.Send(3), Equals, false)
+
+	for i := 0; i < 100 && root.Float64("reading") != 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(root.Float64("reading"), Equals, 3.0)
+	c.Assert(sensor.Reading.Pending(), Equals, false)
+	c.Assert(root.Int("dropped"), Equals, 2)
+}
@@ -0,0 +1,25 @@
+// +build race qmlguicheck
+
+package qml
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// assertGui panics with the stack of the calling goroutine if it is not
+// running within the main GUI thread, so that unsynchronized use of state
+// owned by the GUI thread fails at once rather than corrupting memory at
+// random. The assertion is only enabled when building with the race
+// detector or with the qmlguicheck build tag, as it is run in hot paths:
+//
+//     go test -tags qmlguicheck
+//
+func assertGui(funcName string) {
+	if atomic.LoadUintptr(&guiLoopRef) == 0 || onGuiThread() {
+		// The GUI loop was not yet started, so there's nothing to race with.
+		return
+	}
+	panic(fmt.Sprintf("%s must be run within the main GUI thread; called from:\n%s", funcName, debug.Stack()))
+}
@@ -0,0 +1,7 @@
+// +build !race,!qmlguicheck
+
+package qml
+
+// assertGui is enabled when building with the race detector or with the
+// qmlguicheck build tag. See guicheck.go.
+func assertGui(funcName string) {}
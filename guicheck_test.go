@@ -0,0 +1,15 @@
+// +build qmlguicheck
+
+package qml_test
+
+import (
+	"github.com/niemeyer/qml"
+	. "launchpad.net/gocheck"
+)
+
+func (s *S) TestAssertGui(c *C) {
+	c.Assert(func() { qml.AssertGui("packDataValue") }, PanicMatches,
+		"(?s)packDataValue must be run within the main GUI thread; called from:\n.*")
+
+	qml.Gui(func() { qml.AssertGui("packDataValue") })
+}
@@ -0,0 +1,126 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Highlighter is implemented by values that compute the formatting
+// of a TextDocument block by block, such as the syntax highlighting
+// of a code editor. See TextDocument.SetHighlighter.
+type Highlighter interface {
+	// HighlightBlock returns the formatting for the text of a single
+	// block, with the ranges expressed as byte offsets into text.
+	HighlightBlock(text string) []FormatRange
+}
+
+// BlockStateHighlighter is implemented by highlighters that need to
+// carry state from one block to the next, such as when highlighting
+// comments or strings spanning several lines.
+type BlockStateHighlighter interface {
+	Highlighter
+
+	// HighlightBlockState is called instead of HighlightBlock with the
+	// state returned for the previous block, or -1 for the first block.
+	// When the state returned for a block changes, the following block
+	// is highlighted again.
+	HighlightBlockState(text string, previousState int) (ranges []FormatRange, state int)
+}
+
+// FormatRange holds the formatting for a span of text within a block.
+type FormatRange struct {
+	Start  int
+	Length int
+	Format TextFormat
+}
+
+// SetHighlighter arranges for h to compute the formatting of every block
+// of the document, as the document is loaded and whenever a block is
+// edited. Only the blocks affected by an edit are highlighted again, so
+// h must derive the formatting from the block text and state alone.
+// The formatting is only used for display and is not part of the
+// document content. Setting h to nil removes the current highlighter
+// and its formatting.
+//
+// The highlighter runs within the main GUI thread.
+func (doc *TextDocument) SetHighlighter(h Highlighter) {
+	gui(func() {
+		doc.assertValid()
+		if doc.highlighterAddr != nilPtr {
+			C.delHighlighter(doc.highlighterAddr)
+			doc.highlighterAddr = nilPtr
+		}
+		doc.highlighter = h
+		if h != nil {
+			doc.highlighterAddr = C.newHighlighter(doc.addr)
+			C.highlighterRehighlight(doc.highlighterAddr)
+		}
+	})
+}
+
+// Rehighlight highlights the whole document again, which is necessary
+// when the highlighter rules change without the text being edited.
+func (doc *TextDocument) Rehighlight() {
+	gui(func() {
+		if doc.highlighterAddr != nilPtr {
+			C.highlighterRehighlight(doc.highlighterAddr)
+		}
+	})
+}
+
+//export hookHighlightBlock
+func hookHighlightBlock(docp, highlighterp unsafe.Pointer, ctext *C.char, ctextLen C.int, previousState C.int) C.int {
+	doc := textDocuments[docp]
+	if doc == nil || doc.highlighterAddr != highlighterp {
+		return previousState
+	}
+	text := C.GoStringN(ctext, ctextLen)
+	var ranges []FormatRange
+	state := -1
+	if sh, ok := doc.highlighter.(BlockStateHighlighter); ok {
+		ranges, state = sh.HighlightBlockState(text, int(previousState))
+	} else {
+		ranges = doc.highlighter.HighlightBlock(text)
+	}
+	offsets := utf16Offsets(text)
+	var cformat C.TextFormat
+	for i := range ranges {
+		r := &ranges[i]
+		start, end := r.Start, r.Start+r.Length
+		if start < 0 || end > len(text) || start >= end {
+			continue
+		}
+		if offsets != nil {
+			start, end = offsets[start], offsets[end]
+		}
+		packTextFormat(&r.Format, &cformat)
+		C.highlighterSetFormat(highlighterp, C.int(start), C.int(end-start), &cformat)
+	}
+	return C.int(state)
+}
+
+// utf16Offsets returns the UTF-16 offset for every byte offset in text,
+// or nil if they are all the same.
+func utf16Offsets(text string) []int {
+	if utf8.RuneCountInString(text) == len(text) {
+		return nil
+	}
+	offsets := make([]int, len(text)+1)
+	n := 0
+	for i, r := range text {
+		for j := i; j < len(text) && (j == i || !utf8.RuneStart(text[j])); j++ {
+			offsets[j] = n
+		}
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	offsets[len(text)] = n
+	return offsets
+}
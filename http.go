@@ -0,0 +1,118 @@
+package qml
+
+// The network access manager that routes requests to Go is also the one
+// enforcing the sandbox of the engine and linting the documents that QML
+// loads, so the Qt Network module is always required. It's a dependency
+// of the Qt QML module itself.
+
+// #cgo pkg-config: Qt5Network
+//
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+type httpRoute struct {
+	engine unsafe.Pointer
+	prefix string
+}
+
+// httpClients is accessed from the threads loading content for the
+// engines, so it's guarded by its own mutex instead of relying on
+// the main GUI thread.
+var httpClients = struct {
+	sync.Mutex
+	m map[httpRoute]*http.Client
+}{m: make(map[httpRoute]*http.Client)}
+
+// SetHTTPClient arranges for all network requests made by the engine
+// for URLs starting with prefix to be performed by client rather than
+// by the Qt network stack. This covers QML documents, images, and
+// XMLHttpRequest calls alike, and enables using the cookies, proxies,
+// client certificates, and transports configured in client.
+// For example:
+//
+//     engine.SetHTTPClient("https://intranet.example.com/", client)
+//
+// A nil client removes the prefix. SetHTTPClient must be called before
+// the engine loads any content from the network, as the network
+// configuration of the engine cannot be changed afterwards.
+func (e *Engine) SetHTTPClient(prefix string, client *http.Client) {
+	route := httpRoute{e.addr, prefix}
+	httpClients.Lock()
+	if client == nil {
+		delete(httpClients.m, route)
+	} else {
+		httpClients.m[route] = client
+	}
+	httpClients.Unlock()
+	cprefix, cprefixLen := unsafeStringData(prefix)
+	gui(func() {
+		C.engineSetHTTPPrefix(e.addr, cprefix, cprefixLen, cbool(client != nil))
+	})
+}
+
+//export hookHTTPRequest
+func hookHTTPRequest(enginep, reply unsafe.Pointer, cprefix *C.char, cprefixLen C.int, cmethod *C.char, cmethodLen C.int, curl *C.char, curlLen C.int, cheaders *C.char, cheadersLen C.int, cbody *C.char, cbodyLen C.int) {
+	prefix := C.GoStringN(cprefix, cprefixLen)
+	httpClients.Lock()
+	client := httpClients.m[httpRoute{enginep, prefix}]
+	httpClients.Unlock()
+
+	method := C.GoStringN(cmethod, cmethodLen)
+	url := C.GoStringN(curl, curlLen)
+	headers := C.GoStringN(cheaders, cheadersLen)
+	body := C.GoBytes(unsafe.Pointer(cbody), cbodyLen)
+	go func() {
+		if client == nil {
+			// The prefix was removed after the request was made.
+			client = http.DefaultClient
+		}
+		req, err := http.NewRequest(method, url, bytes.NewReader(body))
+		if err != nil {
+			httpReplyFail(reply, err)
+			return
+		}
+		for _, line := range strings.Split(headers, "\n") {
+			if i := strings.Index(line, ": "); i > 0 {
+				req.Header.Add(line[:i], line[i+2:])
+			}
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			httpReplyFail(reply, err)
+			return
+		}
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			httpReplyFail(reply, err)
+			return
+		}
+		var rheaders bytes.Buffer
+		for name, values := range resp.Header {
+			for _, value := range values {
+				rheaders.WriteString(name)
+				rheaders.WriteString(": ")
+				rheaders.WriteString(value)
+				rheaders.WriteByte('\n')
+			}
+		}
+		cheaders, cheadersLen := unsafeBytesData(rheaders.Bytes())
+		cdata, cdataLen := unsafeBytesData(data)
+		C.httpReplyFinish(reply, C.int(resp.StatusCode), cheaders, cheadersLen, cdata, cdataLen)
+	}()
+}
+
+func httpReplyFail(reply unsafe.Pointer, err error) {
+	cmsg, cmsgLen := unsafeStringData(err.Error())
+	C.httpReplyFail(reply, cmsg, cmsgLen)
+}
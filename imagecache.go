@@ -0,0 +1,202 @@
+package qml
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"image"
+	"sync"
+)
+
+// ImageKey identifies an image held by an ImageCache.
+type ImageKey struct {
+	Provider string // The name the loading function was wrapped with.
+	Id       string // The requested image identifier.
+	Width    int    // The requested width, or zero if none was requested.
+	Height   int    // The requested height, or zero if none was requested.
+}
+
+// ImageCache holds images loaded by image providers in memory, up to a
+// budget, so that views rebinding the same "image:" URLs over and over,
+// such as grids recycling their delegates while scrolling, do not have
+// the same images rendered in Go repeatedly. When the budget is exceeded,
+// the least recently used images are evicted first.
+//
+// Loading functions are wrapped with Provider or AsyncProvider, and the
+// result registered with the engine as usual:
+//
+//     cache := qml.NewImageCache(64 << 20)
+//     engine.AddAsyncImageProvider("thumbs", cache.AsyncProvider("thumbs", loadThumb))
+//
+// An ImageCache may be shared by several providers and engines, and is
+// safe for concurrent use.
+type ImageCache struct {
+	mu      sync.Mutex
+	budget  int64
+	size    int64
+	lru     *list.List
+	entries map[ImageKey]*list.Element
+	loaders map[string]ImageFunc
+	evicted []func(key ImageKey, img image.Image)
+}
+
+type imageCacheEntry struct {
+	key  ImageKey
+	img  image.Image
+	size int64
+}
+
+// NewImageCache returns a new image cache holding at most budget bytes
+// worth of decoded images, accounted as four bytes per pixel.
+func NewImageCache(budget int64) *ImageCache {
+	return &ImageCache{
+		budget:  budget,
+		lru:     list.New(),
+		entries: make(map[ImageKey]*list.Element),
+		loaders: make(map[string]ImageFunc),
+	}
+}
+
+// Provider returns a function suitable for Engine.AddImageProvider that
+// serves images from the cache when available, and otherwise loads them
+// with f and caches the result. The name identifies the images loaded
+// by f in the cache, and is usually the provider identifier.
+func (c *ImageCache) Provider(name string, f func(imgId string, width, height int) image.Image) func(imgId string, width, height int) image.Image {
+	loader := func(ctx context.Context, imgId string, width, height int) (image.Image, error) {
+		return f(imgId, width, height), nil
+	}
+	c.setLoader(name, loader)
+	return func(imgId string, width, height int) image.Image {
+		img, _ := c.load(context.Background(), ImageKey{name, imgId, width, height}, loader)
+		return img
+	}
+}
+
+// AsyncProvider returns a function suitable for Engine.AddAsyncImageProvider
+// that serves images from the cache when available, and otherwise loads
+// them with f and caches the result. Errors are not cached. The name
+// identifies the images loaded by f in the cache, and is usually the
+// provider identifier.
+func (c *ImageCache) AsyncProvider(name string, f ImageFunc) ImageFunc {
+	c.setLoader(name, f)
+	return func(ctx context.Context, imgId string, width, height int) (image.Image, error) {
+		return c.load(ctx, ImageKey{name, imgId, width, height}, f)
+	}
+}
+
+func (c *ImageCache) setLoader(name string, f ImageFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.loaders[name]; ok {
+		panic(fmt.Sprintf("image cache already has a provider named %q", name))
+	}
+	c.loaders[name] = f
+}
+
+// Prewarm loads in the background the images with the provided keys
+// that are not yet in the cache, so that they are readily available
+// once requested. The provider in each key must have been previously
+// wrapped with Provider or AsyncProvider.
+func (c *ImageCache) Prewarm(keys ...ImageKey) {
+	for _, key := range keys {
+		c.mu.Lock()
+		f, ok := c.loaders[key.Provider]
+		_, cached := c.entries[key]
+		c.mu.Unlock()
+		if !ok {
+			panic(fmt.Sprintf("image cache has no provider named %q", key.Provider))
+		}
+		if !cached {
+			go c.load(context.Background(), key, f)
+		}
+	}
+}
+
+// OnEvict arranges for f to be called whenever an image is evicted from
+// the cache to stay within its budget, or is removed via Remove or Purge.
+// The function is called from whatever goroutine caused the eviction,
+// and must not use the cache.
+func (c *ImageCache) OnEvict(f func(key ImageKey, img image.Image)) {
+	c.mu.Lock()
+	c.evicted = append(c.evicted, f)
+	c.mu.Unlock()
+}
+
+// Get returns the cached image with the provided key, if any.
+func (c *ImageCache) Get(key ImageKey) (img image.Image, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		return elem.Value.(*imageCacheEntry).img, true
+	}
+	return nil, false
+}
+
+// Remove removes from the cache the image with the provided key, so it
+// is loaded again when next requested.
+func (c *ImageCache) Remove(key ImageKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.evict(elem)
+	}
+}
+
+// Purge removes all images from the cache.
+func (c *ImageCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.evict(c.lru.Back())
+	}
+}
+
+// Size returns the number of bytes worth of images currently held by
+// the cache, accounted as four bytes per pixel.
+func (c *ImageCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+func (c *ImageCache) load(ctx context.Context, key ImageKey, f ImageFunc) (image.Image, error) {
+	if img, ok := c.Get(key); ok {
+		return img, nil
+	}
+	img, err := f(ctx, key.Id, key.Width, key.Height)
+	if err != nil || img == nil {
+		return img, err
+	}
+	c.add(key, img)
+	return img, nil
+}
+
+func (c *ImageCache) add(key ImageKey, img image.Image) {
+	bounds := img.Bounds()
+	entry := &imageCacheEntry{key, img, int64(bounds.Dx()) * int64(bounds.Dy()) * 4}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		// Loaded concurrently by another request.
+		c.lru.MoveToFront(elem)
+		return
+	}
+	if entry.size > c.budget {
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.size += entry.size
+	for c.size > c.budget {
+		c.evict(c.lru.Back())
+	}
+}
+
+func (c *ImageCache) evict(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*imageCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+	for _, f := range c.evicted {
+		f(entry.key, entry.img)
+	}
+}
@@ -0,0 +1,256 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"unsafe"
+)
+
+// ImageFunc loads the image with the given identifier for an
+// asynchronous image provider. See Engine.AddAsyncImageProvider.
+type ImageFunc func(ctx context.Context, imgId string, width, height int) (image.Image, error)
+
+// asyncImageFuncs holds the functions of asynchronous image providers,
+// which are referenced by C++ for as long as the engine exists.
+var asyncImageFuncs = struct {
+	sync.Mutex
+	m map[*ImageFunc]bool
+}{m: make(map[*ImageFunc]bool)}
+
+// imageResponses holds the functions cancelling the context of image
+// requests that are still being served, by response. Requests are made
+// from the threads loading images in the background, so access is
+// guarded by a mutex rather than done in the GUI thread.
+var imageResponses = struct {
+	sync.Mutex
+	m map[unsafe.Pointer]context.CancelFunc
+}{m: make(map[unsafe.Pointer]context.CancelFunc)}
+
+// AddAsyncImageProvider registers f to be called when an image is requested
+// by QML code with the specified provider identifier, as AddImageProvider
+// does, except that f is run on its own goroutine, so that slow loading and
+// decoding never holds back the GUI thread or other images. The context
+// provided to f is cancelled when the image is no longer needed, such as
+// when the requesting item is destroyed while scrolling quickly past it,
+// and f should then return as soon as possible. If f returns an error, the
+// image fails to load, and the error is reported by the requesting item.
+//
+// The returned image is scaled to the requested size, if any, while
+// keeping its aspect ratio. It is a runtime error to register the same
+// provider identifier multiple times.
+//
+// Asynchronous image providers require Qt 5.6 or later.
+func (e *Engine) AddAsyncImageProvider(prvId string, f ImageFunc) {
+	if _, ok := e.imageProviders[prvId]; ok {
+		panic(fmt.Sprintf("engine already has an image provider with id %q", prvId))
+	}
+	e.imageProviders[prvId] = nil
+	asyncImageFuncs.Lock()
+	asyncImageFuncs.m[&f] = true
+	asyncImageFuncs.Unlock()
+	cprvId, cprvIdLen := unsafeStringData(prvId)
+	gui(func() {
+		qprvId := C.newString(cprvId, cprvIdLen)
+		defer C.delString(qprvId)
+		cmust(C.engineAddAsyncImageProvider(e.addr, qprvId, unsafe.Pointer(&f)))
+	})
+}
+
+//export hookRequestImageResponse
+func hookRequestImageResponse(imageFunc, response unsafe.Pointer, cid *C.char, cidLen, cwidth, cheight C.int) {
+	f := *(*ImageFunc)(imageFunc)
+	id := C.GoStringN(cid, cidLen)
+	width := int(cwidth)
+	height := int(cheight)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	imageResponses.Lock()
+	imageResponses.m[response] = cancel
+	imageResponses.Unlock()
+
+	go func() {
+		img, err := f(ctx, id, width, height)
+		imageResponses.Lock()
+		delete(imageResponses.m, response)
+		imageResponses.Unlock()
+		cancel()
+		if err == nil && img == nil {
+			err = fmt.Errorf("image provider returned no image for %q", id)
+		}
+		if err != nil {
+			cerr, cerrLen := unsafeStringData(err.Error())
+			C.imageResponseFinish(response, nilPtr, cerr, cerrLen)
+			return
+		}
+		C.imageResponseFinish(response, newCImage(img), nilCharPtr, 0)
+	}()
+}
+
+//export hookImageResponseCancel
+func hookImageResponseCancel(response unsafe.Pointer) {
+	imageResponses.Lock()
+	cancel := imageResponses.m[response]
+	imageResponses.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// ImageFiles returns an ImageFunc for AddAsyncImageProvider that loads
+// image files from dir with DecodeImage, so that photos are presented
+// upright. The requested image identifier is the path of the file
+// relative to dir, and may not refer to files outside of it.
+func ImageFiles(dir string) ImageFunc {
+	return func(ctx context.Context, imgId string, width, height int) (image.Image, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(path.Clean("/"+imgId))))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return DecodeImage(data)
+	}
+}
+
+// DecodeImage decodes an image in any format registered with the image
+// package, and rotates or flips it as defined by its EXIF orientation tag,
+// if any, so that photos taken with the camera held sideways are presented
+// upright.
+func DecodeImage(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return orientImage(img, exifOrientation(data)), nil
+}
+
+// exifOrientation returns the orientation tag in the EXIF data of the
+// JPEG image in data, or 1, meaning the image is upright, if there's
+// no such tag.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			i++ // Fill byte.
+			continue
+		case marker >= 0xD0 && marker <= 0xD8 || marker == 0x01:
+			i += 2 // Markers without a payload.
+			continue
+		case marker == 0xDA || marker == 0xD9:
+			return 1 // Image data starts; metadata comes before it.
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		if marker == 0xE1 {
+			if orientation := tiffOrientation(data[i+4 : i+2+size]); orientation != 0 {
+				return orientation
+			}
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+// tiffOrientation returns the orientation tag in the first image file
+// directory of the EXIF segment seg, or 0 if there's no such tag.
+func tiffOrientation(seg []byte) int {
+	if len(seg) < 14 || string(seg[:6]) != "Exif\x00\x00" {
+		return 0
+	}
+	tiff := seg[6:]
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 0 || ifd+2 > len(tiff) {
+		return 0
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// orientImage returns img transformed as defined by the EXIF orientation
+// tag value, so that it's presented upright.
+func orientImage(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Flipped horizontally.
+				dx, dy = w-1-x, y
+			case 3: // Rotated by 180 degrees.
+				dx, dy = w-1-x, h-1-y
+			case 4: // Flipped vertically.
+				dx, dy = x, h-1-y
+			case 5: // Transposed.
+				dx, dy = y, x
+			case 6: // Must be rotated clockwise.
+				dx, dy = h-1-y, x
+			case 7: // Transversed.
+				dx, dy = h-1-y, w-1-x
+			case 8: // Must be rotated counterclockwise.
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,156 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"image"
+	"os"
+)
+
+// ShowInputPanel requests the input panel, such as the virtual keyboard,
+// to be shown for the item that currently has the input focus.
+func ShowInputPanel() {
+	gui(func() {
+		C.inputPanelSetVisible(1)
+	})
+}
+
+// HideInputPanel requests the input panel to be hidden.
+func HideInputPanel() {
+	gui(func() {
+		C.inputPanelSetVisible(0)
+	})
+}
+
+// InputPanelVisible returns whether the input panel is currently visible.
+func InputPanelVisible() bool {
+	var visible C.int
+	gui(func() {
+		visible = C.inputPanelVisible()
+	})
+	return visible != 0
+}
+
+// InputPanelRect returns the area covered by the input panel, in the
+// coordinates of the window holding the focused item. The rectangle is
+// empty while the panel is hidden or when the platform does not report it.
+func InputPanelRect() image.Rectangle {
+	var x, y, width, height C.int
+	gui(func() {
+		C.inputPanelRect(&x, &y, &width, &height)
+	})
+	return image.Rect(int(x), int(y), int(x+width), int(y+height))
+}
+
+var inputPanelHandlers []func(visible bool, rect image.Rectangle)
+
+// OnInputPanelChanged arranges for f to be called whenever the input
+// panel is shown, hidden, or resized, so the user interface may be
+// rearranged to keep the focused item in view. As with signal handlers,
+// f is run within the main GUI thread.
+func OnInputPanelChanged(f func(visible bool, rect image.Rectangle)) {
+	gui(func() {
+		if inputPanelHandlers == nil {
+			C.inputPanelConnect()
+		}
+		inputPanelHandlers = append(inputPanelHandlers, f)
+	})
+}
+
+//export hookInputPanelChanged
+func hookInputPanelChanged() {
+	visible := InputPanelVisible()
+	rect := InputPanelRect()
+	for _, f := range inputPanelHandlers {
+		f(visible, rect)
+	}
+}
+
+// InputMethodEvent holds the text composition state sent by the input
+// method, such as a virtual keyboard or a platform IME, to the focused item.
+type InputMethodEvent struct {
+	// Preedit holds the text being composed, not yet committed.
+	Preedit string
+
+	// Commit holds the text to be inserted into the focused item.
+	Commit string
+
+	// ReplaceStart and ReplaceLength, when ReplaceLength is not zero,
+	// hold the range of existing text, relative to the cursor, that
+	// must be replaced by Commit.
+	ReplaceStart  int
+	ReplaceLength int
+}
+
+var inputMethodHandlers []func(event *InputMethodEvent) bool
+
+// OnInputMethodEvent arranges for f to be called with every event sent
+// by the input method before it reaches the focused item. If f returns
+// true the event is consumed and the item never sees it, which allows
+// custom input flows such as validating or transforming the text typed
+// on a virtual keyboard. As with signal handlers, f is run within the
+// main GUI thread.
+func OnInputMethodEvent(f func(event *InputMethodEvent) bool) {
+	gui(func() {
+		if inputMethodHandlers == nil {
+			C.inputMethodFilterEvents()
+		}
+		inputMethodHandlers = append(inputMethodHandlers, f)
+	})
+}
+
+//export hookInputMethodEvent
+func hookInputMethodEvent(cpreedit *C.char, cpreeditLen C.int, ccommit *C.char, ccommitLen C.int, replaceStart, replaceLen C.int) C.int {
+	event := &InputMethodEvent{
+		Preedit:       C.GoStringN(cpreedit, cpreeditLen),
+		Commit:        C.GoStringN(ccommit, ccommitLen),
+		ReplaceStart:  int(replaceStart),
+		ReplaceLength: int(replaceLen),
+	}
+	for _, f := range inputMethodHandlers {
+		if f(event) {
+			return 1
+		}
+	}
+	return 0
+}
+
+// VirtualKeyboard holds the configuration of the Qt Virtual Keyboard.
+type VirtualKeyboard struct {
+	// Style is the name of the keyboard style, such as "default" or
+	// "retro", or the name of a custom style installed under one of
+	// the paths in the QML import path.
+	Style string
+
+	// LayoutPath is the directory holding custom keyboard layouts.
+	LayoutPath string
+
+	// DisableDesktop prevents the keyboard from being shown in its own
+	// top-level window when no InputPanel is declared in the QML scene.
+	DisableDesktop bool
+}
+
+// EnableVirtualKeyboard selects the Qt Virtual Keyboard as the input
+// method of the application, with the provided configuration.
+// It must be called before qml.Init, since the input method is
+// loaded when the application starts.
+//
+// The keyboard shows up automatically when an editable item gains the
+// input focus, and may also be controlled with ShowInputPanel and
+// HideInputPanel. In kiosk and embedded deployments the keyboard is
+// usually placed explicitly in the scene with an InputPanel element
+// from the QtQuick.VirtualKeyboard module.
+func EnableVirtualKeyboard(config VirtualKeyboard) {
+	os.Setenv("QT_IM_MODULE", "qtvirtualkeyboard")
+	if config.Style != "" {
+		os.Setenv("QT_VIRTUALKEYBOARD_STYLE", config.Style)
+	}
+	if config.LayoutPath != "" {
+		os.Setenv("QT_VIRTUALKEYBOARD_LAYOUT_PATH", config.LayoutPath)
+	}
+	if config.DisableDesktop {
+		os.Setenv("QT_VIRTUALKEYBOARD_DESKTOP_DISABLE", "1")
+	}
+}
@@ -0,0 +1,120 @@
+// +build ios
+
+package qml
+
+// #cgo LDFLAGS: -framework UIKit -framework Foundation -framework AudioToolbox
+//
+// #include <stdlib.h>
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"strings"
+	"time"
+	"unsafe"
+)
+
+var memoryWarningHandlers []func()
+
+// OnMemoryWarning arranges for f to be called when iOS warns the
+// application that memory is running low. Caches should be released
+// in response, or the application may be terminated.
+// As with signal handlers, f is run within the main GUI thread.
+//
+// OnMemoryWarning is only available on iOS.
+func OnMemoryWarning(f func()) {
+	gui(func() {
+		if memoryWarningHandlers == nil {
+			C.iosObserveMemoryWarnings()
+		}
+		memoryWarningHandlers = append(memoryWarningHandlers, f)
+	})
+}
+
+//export hookMemoryWarning
+func hookMemoryWarning() {
+	for _, f := range memoryWarningHandlers {
+		f()
+	}
+}
+
+// Share presents the native iOS share sheet offering the provided text
+// and URL to other applications. Either of them may be empty.
+//
+// Share is only available on iOS.
+func Share(text, url string) {
+	ctext := C.CString(text)
+	curl := C.CString(url)
+	defer C.free(unsafe.Pointer(ctext))
+	defer C.free(unsafe.Pointer(curl))
+	gui(func() {
+		C.iosShare(ctext, curl)
+	})
+}
+
+var pickDocumentsFuncs = make(map[unsafe.Pointer]bool)
+
+// PickDocuments presents the native iOS document picker restricted to
+// the provided uniform type identifiers (such as "public.image"), and
+// calls f with the local paths of the documents the user picked, or with
+// no paths if the picker was cancelled. As with signal handlers, f is run
+// within the main GUI thread.
+//
+// PickDocuments is only available on iOS.
+func PickDocuments(types []string, multiple bool, f func(paths []string)) {
+	ctypes := C.CString(strings.Join(types, "\n"))
+	defer C.free(unsafe.Pointer(ctypes))
+	cmultiple := C.int(0)
+	if multiple {
+		cmultiple = 1
+	}
+	gui(func() {
+		funcp := unsafe.Pointer(&f)
+		pickDocumentsFuncs[funcp] = true
+		C.iosPickDocuments(ctypes, cmultiple, funcp)
+	})
+}
+
+//export hookDocumentsPicked
+func hookDocumentsPicked(funcp unsafe.Pointer, cpaths *C.char, cpathsLen C.int) {
+	delete(pickDocumentsFuncs, funcp)
+	f := *(*func(paths []string))(funcp)
+	var paths []string
+	if cpathsLen > 0 {
+		paths = strings.Split(C.GoStringN(cpaths, cpathsLen), "\n")
+	}
+	f(paths)
+}
+
+func setSystemBarStyle(bar SystemBar, argb uint32, darkIcons bool) {
+	if bar == StatusBar {
+		cdark := C.int(0)
+		if darkIcons {
+			cdark = 1
+		}
+		C.iosSetStatusBarStyle(cdark)
+	}
+}
+
+func hapticFeedback(effect HapticEffect) {
+	C.iosHapticFeedback(C.int(effect))
+}
+
+func vibrate(d time.Duration) {
+	// iOS does not support custom durations.
+	C.iosVibrate()
+}
+
+func inhibitSleep(reason string) (release func()) {
+	keepAwake(true, func(on bool) { C.iosSetIdleTimerDisabled(cbool(on)) })
+	return func() {
+		keepAwake(false, func(on bool) { C.iosSetIdleTimerDisabled(cbool(on)) })
+	}
+}
+
+func connectPowerEvents() {}
+
+func updateTaskbar(progress float64, badge int) {
+	C.iosSetBadgeNumber(C.int(badge))
+}
@@ -0,0 +1,123 @@
+// +build ios
+
+#import <UIKit/UIKit.h>
+#import <AudioToolbox/AudioToolbox.h>
+
+#include "capi.h"
+
+static UIViewController *rootViewController()
+{
+    return [[[UIApplication sharedApplication] keyWindow] rootViewController];
+}
+
+void iosObserveMemoryWarnings()
+{
+    [[NSNotificationCenter defaultCenter] addObserverForName:UIApplicationDidReceiveMemoryWarningNotification
+                                                      object:nil
+                                                       queue:[NSOperationQueue mainQueue]
+                                                  usingBlock:^(NSNotification *note) {
+        hookMemoryWarning();
+    }];
+}
+
+void iosShare(const char *text, const char *url)
+{
+    NSMutableArray *items = [NSMutableArray array];
+    if (*text) {
+        [items addObject:[NSString stringWithUTF8String:text]];
+    }
+    if (*url) {
+        [items addObject:[NSURL URLWithString:[NSString stringWithUTF8String:url]]];
+    }
+    UIActivityViewController *controller = [[UIActivityViewController alloc] initWithActivityItems:items applicationActivities:nil];
+    [rootViewController() presentViewController:controller animated:YES completion:nil];
+    [controller release];
+}
+
+@interface GoDocumentPickerDelegate : NSObject <UIDocumentPickerDelegate> {
+    void *func;
+}
+- (id)initWithFunc:(void *)f;
+@end
+
+@implementation GoDocumentPickerDelegate
+
+- (id)initWithFunc:(void *)f
+{
+    if ((self = [super init])) {
+        func = f;
+    }
+    return self;
+}
+
+- (void)documentPicker:(UIDocumentPickerViewController *)controller didPickDocumentsAtURLs:(NSArray *)urls
+{
+    NSMutableArray *paths = [NSMutableArray array];
+    for (NSURL *url in urls) {
+        [paths addObject:[url path]];
+    }
+    const char *joined = [[paths componentsJoinedByString:@"\n"] UTF8String];
+    hookDocumentsPicked(func, (char *)joined, (int)strlen(joined));
+    [self release];
+}
+
+- (void)documentPickerWasCancelled:(UIDocumentPickerViewController *)controller
+{
+    hookDocumentsPicked(func, "", 0);
+    [self release];
+}
+
+@end
+
+void iosPickDocuments(const char *types, int multiple, void *func)
+{
+    NSArray *utis = [[NSString stringWithUTF8String:types] componentsSeparatedByString:@"\n"];
+    UIDocumentPickerViewController *picker = [[UIDocumentPickerViewController alloc] initWithDocumentTypes:utis inMode:UIDocumentPickerModeImport];
+    picker.allowsMultipleSelection = multiple ? YES : NO;
+
+    // The picker holds a weak reference to its delegate, which releases itself once done.
+    picker.delegate = [[GoDocumentPickerDelegate alloc] initWithFunc:func];
+    [rootViewController() presentViewController:picker animated:YES completion:nil];
+    [picker release];
+}
+
+void iosSetStatusBarStyle(int darkIcons)
+{
+    // Requires UIViewControllerBasedStatusBarAppearance set to NO in Info.plist.
+    UIStatusBarStyle style = darkIcons ? UIStatusBarStyleDefault : UIStatusBarStyleLightContent;
+    [[UIApplication sharedApplication] setStatusBarStyle:style animated:YES];
+}
+
+void iosHapticFeedback(int effect)
+{
+    if (effect == 3) {
+        UISelectionFeedbackGenerator *generator = [[UISelectionFeedbackGenerator alloc] init];
+        [generator selectionChanged];
+        [generator release];
+        return;
+    }
+    UIImpactFeedbackStyle styles[] = {UIImpactFeedbackStyleLight, UIImpactFeedbackStyleMedium, UIImpactFeedbackStyleHeavy};
+    if (effect < 0 || effect > 2) {
+        return;
+    }
+    UIImpactFeedbackGenerator *generator = [[UIImpactFeedbackGenerator alloc] initWithStyle:styles[effect]];
+    [generator impactOccurred];
+    [generator release];
+}
+
+void iosVibrate()
+{
+    AudioServicesPlaySystemSound(kSystemSoundID_Vibrate);
+}
+
+void iosSetIdleTimerDisabled(int disabled)
+{
+    [[UIApplication sharedApplication] setIdleTimerDisabled:(disabled ? YES : NO)];
+}
+
+void iosSetBadgeNumber(int count)
+{
+    [[UIApplication sharedApplication] setApplicationIconBadgeNumber:count];
+}
+
+// vim:ts=4:sw=4:et
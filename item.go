@@ -0,0 +1,197 @@
+package qml
+
+// #include <stdlib.h>
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"unsafe"
+)
+
+// MapToItem converts the point at x, y in the coordinate system of the
+// object, which must be a visual item, into the coordinate system of the
+// other item. If other is nil, the point is converted into the coordinate
+// system of the window scene.
+// MapToItem panics if either object is not a visual item.
+func (obj *Common) MapToItem(other Object, x, y float64) (float64, float64) {
+	cx, cy := C.double(x), C.double(y)
+	otheraddr := nilPtr
+	if other != nil {
+		otheraddr = other.Common().addr
+	}
+	var cerr *C.error
+	gui(func() {
+		cerr = C.itemMapToItem(obj.addr, otheraddr, &cx, &cy)
+	})
+	cmust(cerr)
+	return float64(cx), float64(cy)
+}
+
+// MapToGlobal converts the point at x, y in the coordinate system of the
+// object, which must be a visual item, into global screen coordinates.
+// MapToGlobal panics if the object is not a visual item.
+func (obj *Common) MapToGlobal(x, y float64) (float64, float64) {
+	cx, cy := C.double(x), C.double(y)
+	var cerr *C.error
+	gui(func() {
+		cerr = C.itemMapToGlobal(obj.addr, &cx, &cy)
+	})
+	cmust(cerr)
+	return float64(cx), float64(cy)
+}
+
+// ItemAt returns the topmost visible item in the window under the point
+// at x, y in window coordinates for which match returns true, or nil if
+// there is no such item. If match is nil, the topmost item is returned.
+// For example, to find the button under the mouse cursor:
+//
+//     button := win.ItemAt(x, y, func(item qml.Object) bool {
+//         return strings.HasPrefix(item.TypeName(), "Button")
+//     })
+//
+// Items with children are considered to be below them.
+func (win *Window) ItemAt(x, y float64, match func(item Object) bool) Object {
+	var dvalue C.DataValue
+	gui(func() {
+		C.windowItemsAt(win.addr, C.double(x), C.double(y), &dvalue)
+	})
+	list := unpackDataValue(&dvalue, win.engine).(*List)
+	for _, item := range list.data {
+		obj := item.(Object)
+		if match == nil || match(obj) {
+			return obj
+		}
+	}
+	return nil
+}
+
+// ForceActiveFocus gives the active focus to the object, which must be
+// a visual item, and makes it the focused item within all of its focus
+// scopes, so that it receives keyboard input.
+func (obj *Common) ForceActiveFocus() {
+	obj.Call("forceActiveFocus")
+}
+
+// HasActiveFocus returns whether the object is a visual item that
+// currently has the active focus.
+func (obj *Common) HasActiveFocus() bool {
+	return obj.Bool("activeFocus")
+}
+
+// NextItemInFocusChain returns the item that receives the focus after
+// the object, which must be a visual item, when the user moves the focus
+// forward, such as with the tab key. If forward is false, the previous
+// item in the chain is returned instead.
+func (obj *Common) NextItemInFocusChain(forward bool) Object {
+	return nonNilObject(obj.Call("nextItemInFocusChain", forward))
+}
+
+// ActiveFocusItem returns the item in the window that currently has
+// the active focus, or nil if there is none.
+func (win *Window) ActiveFocusItem() Object {
+	return nonNilObject(win.Property("activeFocusItem"))
+}
+
+// OnActiveFocusItemChanged arranges for f to be called with the item
+// that has the active focus whenever it changes. The item is nil if no
+// item has the active focus. As with all signal handlers, f is run
+// within the main GUI thread.
+func (win *Window) OnActiveFocusItemChanged(f func(item Object)) {
+	win.On("activeFocusItemChanged", func() { f(win.ActiveFocusItem()) })
+}
+
+// nonNilObject returns value as an Object, or nil if it is not an object
+// or a null object reference.
+func nonNilObject(value interface{}) Object {
+	obj, ok := value.(Object)
+	if !ok || obj.Common().addr == nilPtr {
+		return nil
+	}
+	return obj
+}
+
+// Layer holds the layering settings of a visual item. When a layer is
+// enabled, the item and its children are rendered into an offscreen
+// texture that is reused while they don't change, which speeds up
+// rendering of complex static subtrees at the cost of memory.
+//
+// See the documentation of the layer properties of Item for details.
+type Layer struct {
+	Enabled bool
+
+	// Smooth and Mipmap define how the texture is sampled when it's
+	// scaled, at some rendering cost.
+	Smooth bool
+	Mipmap bool
+
+	// TextureWidth and TextureHeight define the size of the texture.
+	// If zero, the texture has the size of the item.
+	TextureWidth  int
+	TextureHeight int
+
+	// Effect, if not nil, is a component loaded with the engine, such
+	// as a ShaderEffect, which is instantiated to render the layer
+	// texture in place of the item.
+	Effect Object
+
+	// SamplerName is the name of the Effect property receiving the
+	// layer texture. If empty, "source" is used.
+	SamplerName string
+}
+
+// SetLayer changes the layering settings of the object, which must be a
+// visual item. All settings are applied at once, so that the layer
+// texture is not rendered with partial settings.
+// SetLayer panics if the object is not a visual item.
+func (obj *Common) SetLayer(layer Layer) {
+	effect := nilPtr
+	if layer.Effect != nil {
+		effect = layer.Effect.Common().addr
+	}
+	if layer.SamplerName == "" {
+		layer.SamplerName = "source"
+	}
+	csamplerName := C.CString(layer.SamplerName)
+	defer C.free(unsafe.Pointer(csamplerName))
+	var cerr *C.error
+	gui(func() {
+		cerr = C.itemSetLayer(obj.addr, cbool(layer.Enabled), cbool(layer.Smooth), cbool(layer.Mipmap),
+			C.int(layer.TextureWidth), C.int(layer.TextureHeight), effect, csamplerName)
+	})
+	cmust(cerr)
+}
+
+// Layer returns the layering settings of the object, which must be a
+// visual item. Layer panics if the object is not a visual item.
+func (obj *Common) Layer() Layer {
+	var enabled, smooth, mipmap, textureWidth, textureHeight C.int
+	var effect unsafe.Pointer
+	var csamplerName *C.char
+	var cerr *C.error
+	gui(func() {
+		cerr = C.itemLayer(obj.addr, &enabled, &smooth, &mipmap, &textureWidth, &textureHeight, &effect, &csamplerName)
+	})
+	cmust(cerr)
+	layer := Layer{
+		Enabled:       enabled != 0,
+		Smooth:        smooth != 0,
+		Mipmap:        mipmap != 0,
+		TextureWidth:  int(textureWidth),
+		TextureHeight: int(textureHeight),
+		SamplerName:   cstringResult(csamplerName),
+	}
+	if effect != nilPtr {
+		layer.Effect = &Common{engine: obj.engine, addr: effect}
+	}
+	return layer
+}
+
+// SetLayerEnabled enables or disables the layer of the object, which
+// must be a visual item, leaving its other layering settings unchanged.
+// SetLayerEnabled panics if the object is not a visual item.
+func (obj *Common) SetLayerEnabled(enabled bool) {
+	if err := obj.Set("layer.enabled", enabled); err != nil {
+		panic("object is not a visual item")
+	}
+}
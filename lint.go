@@ -0,0 +1,136 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// Issue is a problem found in QML content by a lint hook.
+// Line and Column are 1-based, and zero if unknown.
+type Issue struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+// lintHooks is accessed from the threads loading content for the
+// engines, so it's guarded by its own mutex instead of relying on
+// the main GUI thread.
+var lintHooks = struct {
+	sync.Mutex
+	m map[unsafe.Pointer]func(file string, src []byte) []Issue
+}{m: make(map[unsafe.Pointer]func(file string, src []byte) []Issue)}
+
+// lintLog reports the issues found in the documents and scripts that
+// QML itself loads, as the errors it reports for them are not detailed.
+var lintLog = NewLogCategory("qml.lint")
+
+// SetLintHook arranges for hook to be called with the location and the
+// content of every component the engine loads via Load, LoadFile,
+// LoadString, Preload, or PrewarmCache, before the content is handed to
+// QML. If hook reports any issues, the component is not loaded, and the
+// error returned describes all the issues reported, one per line, in
+// the same format used for QML errors. For example:
+//
+//     engine.SetLintHook(func(file string, src []byte) []qml.Issue {
+//         if bytes.Contains(src, []byte("Qt.openUrlExternally")) {
+//             return []qml.Issue{{Message: "external URLs must be opened via the Go backend"}}
+//         }
+//         return nil
+//     })
+//
+// This enables projects to enforce their own style or security rules on
+// QML content at runtime, such as in development builds. The local QML
+// documents and scripts that the loaded content imports, or loads by
+// itself such as via a Loader, are seen by hook as well, when QML loads
+// them. If hook reports any issues for those, QML fails to load them as
+// if they could not be read, and the issues are logged as warnings under
+// the "qml.lint" logging category. The installed QML modules are not
+// seen by hook.
+//
+// The hook may be called from threads loading content for the engine,
+// and must be safe for concurrent use. It may also be called more than
+// once for the same content, such as for files handed to Preload, which
+// are linted before and while QML loads them. A nil hook disables
+// linting.
+func (e *Engine) SetLintHook(hook func(file string, src []byte) []Issue) {
+	lintHooks.Lock()
+	if hook == nil {
+		delete(lintHooks.m, e.addr)
+	} else {
+		lintHooks.m[e.addr] = hook
+	}
+	lintHooks.Unlock()
+	if hook != nil {
+		gui(func() {
+			C.engineInterceptUrls(e.addr)
+		})
+	}
+}
+
+// lint runs the lint hook of the engine, if any, on the content of the
+// component at location, and returns an error describing the issues
+// reported, if any.
+func lint(enginep unsafe.Pointer, location string, data []byte) error {
+	lintHooks.Lock()
+	hook := lintHooks.m[enginep]
+	lintHooks.Unlock()
+	if hook == nil {
+		return nil
+	}
+	issues := hook(location, data)
+	if len(issues) == 0 {
+		return nil
+	}
+	lines := make([]string, len(issues))
+	for i, issue := range issues {
+		switch {
+		case issue.Line > 0 && issue.Column > 0:
+			lines[i] = fmt.Sprintf("%s:%d:%d: %s", location, issue.Line, issue.Column, issue.Message)
+		case issue.Line > 0:
+			lines[i] = fmt.Sprintf("%s:%d: %s", location, issue.Line, issue.Message)
+		default:
+			lines[i] = fmt.Sprintf("%s: %s", location, issue.Message)
+		}
+	}
+	return errors.New(strings.Join(lines, "\n"))
+}
+
+// lintFile runs the lint hook of the engine, if any, on the content of
+// the QML file at path.
+func lintFile(enginep unsafe.Pointer, path string) error {
+	lintHooks.Lock()
+	hook := lintHooks.m[enginep]
+	lintHooks.Unlock()
+	if hook == nil {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		// Reported by the compilation itself.
+		return nil
+	}
+	return lint(enginep, path, data)
+}
+
+//export hookLintAllows
+func hookLintAllows(enginep unsafe.Pointer, curl *C.char, curlLen C.int) C.int {
+	u, err := url.Parse(C.GoStringN(curl, curlLen))
+	if err != nil || u.Scheme != "file" {
+		return 1
+	}
+	if err := lintFile(enginep, u.Path); err != nil {
+		lintLog.Warningf("%v", err)
+		return 0
+	}
+	return 1
+}
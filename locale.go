@@ -0,0 +1,242 @@
+package qml
+
+// #include <stdlib.h>
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// Locale formats and parses numbers, currencies, and dates according to
+// the conventions of a language and country, exactly as QML code does
+// with Qt.locale(), Number.toLocaleString, and Qt.formatDateTime.
+type Locale struct {
+	name string
+}
+
+// LocaleFormat selects the length of formatted dates and times.
+type LocaleFormat int
+
+const (
+	LongFormat LocaleFormat = iota
+	ShortFormat
+	NarrowFormat
+)
+
+// DefaultLocale returns the locale currently used by default by the
+// application, which is the system locale unless changed by
+// SetDefaultLocale.
+func DefaultLocale() *Locale {
+	return &Locale{name: localeName("")}
+}
+
+// NewLocale returns the locale with the provided name, in the
+// "language_country" form such as "en_US" or "pt_BR".
+func NewLocale(name string) *Locale {
+	return &Locale{name: localeName(name)}
+}
+
+// SetDefaultLocale changes the locale used by default by the application,
+// and notifies the running QML engines so that bindings depending on the
+// user interface language (Qt.uiLanguage, qsTr) are reevaluated.
+func SetDefaultLocale(name string) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	gui(func() {
+		C.localeSetDefault(cname)
+		for _, engine := range engines {
+			if !engine.destroyed {
+				C.engineSetUiLanguage(engine.addr, cname)
+			}
+		}
+	})
+}
+
+func localeName(name string) string {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	return cstringResult(C.localeName(cname))
+}
+
+// cstringResult returns a copy of the C string allocated by the C++
+// side, and releases it.
+func cstringResult(cstr *C.char) string {
+	defer C.free(unsafe.Pointer(cstr))
+	return C.GoString(cstr)
+}
+
+// Name returns the locale name in the "language_country" form.
+func (l *Locale) Name() string {
+	return l.name
+}
+
+// FormatFloat formats f according to the locale. The format and
+// precision parameters have the same meaning as in strconv.FormatFloat,
+// and format must be one of 'e', 'E', 'f', 'g', or 'G'.
+func (l *Locale) FormatFloat(f float64, format byte, precision int) string {
+	cname := C.CString(l.name)
+	defer C.free(unsafe.Pointer(cname))
+	return cstringResult(C.localeFormatFloat(cname, C.double(f), C.char(format), C.int(precision)))
+}
+
+// FormatInt formats i according to the locale, including any
+// digit group separators.
+func (l *Locale) FormatInt(i int64) string {
+	cname := C.CString(l.name)
+	defer C.free(unsafe.Pointer(cname))
+	return cstringResult(C.localeFormatInt(cname, C.int64_t(i)))
+}
+
+// FormatCurrency formats value as an amount of money according to the
+// locale. If symbol is empty, the currency symbol of the locale is used.
+func (l *Locale) FormatCurrency(value float64, symbol string) string {
+	cname := C.CString(l.name)
+	csymbol := C.CString(symbol)
+	defer C.free(unsafe.Pointer(cname))
+	defer C.free(unsafe.Pointer(csymbol))
+	return cstringResult(C.localeFormatCurrency(cname, C.double(value), csymbol))
+}
+
+// FormatDecimal formats the decimal number in digits, in the plain form
+// such as "-1234.50", according to the locale, including any digit group
+// separators. Unlike FormatFloat, no precision is lost, so it is suitable
+// for monetary amounts held in decimal types. Values of decimal types
+// such as the one in github.com/shopspring/decimal are formatted this way
+// when handed to QML logic.
+func (l *Locale) FormatDecimal(digits string) string {
+	return l.formatDecimal(digits, false, "")
+}
+
+// FormatDecimalCurrency formats the decimal number in digits as an
+// amount of money according to the locale, as done by FormatDecimal.
+// If symbol is empty, the currency symbol of the locale is used.
+func (l *Locale) FormatDecimalCurrency(digits string, symbol string) string {
+	return l.formatDecimal(digits, true, symbol)
+}
+
+func (l *Locale) formatDecimal(digits string, currency bool, symbol string) string {
+	if !plainDecimal(digits) {
+		panic(fmt.Sprintf("invalid decimal number: %q", digits))
+	}
+	cname := C.CString(l.name)
+	cdigits := C.CString(digits)
+	csymbol := C.CString(symbol)
+	defer C.free(unsafe.Pointer(cname))
+	defer C.free(unsafe.Pointer(cdigits))
+	defer C.free(unsafe.Pointer(csymbol))
+	return cstringResult(C.localeFormatDecimal(cname, cdigits, cbool(currency), csymbol))
+}
+
+// ParseDecimal parses a decimal number formatted according to the
+// locale, possibly as an amount of money, and returns it in the plain
+// form such as "-1234.50".
+func (l *Locale) ParseDecimal(s string) (string, error) {
+	cname := C.CString(l.name)
+	cs := C.CString(s)
+	defer C.free(unsafe.Pointer(cname))
+	defer C.free(unsafe.Pointer(cs))
+	digits := cstringResult(C.localeParseDecimal(cname, cs))
+	if !plainDecimal(digits) {
+		return "", fmt.Errorf("cannot parse %q as a decimal number in locale %s", s, l.name)
+	}
+	return digits, nil
+}
+
+// plainDecimal returns whether s is a decimal number in the plain form,
+// with an optional leading minus sign and an optional decimal point.
+func plainDecimal(s string) bool {
+	if strings.HasPrefix(s, "-") {
+		s = s[1:]
+	}
+	if i := strings.Index(s, "."); i >= 0 {
+		s = s[:i] + s[i+1:]
+	}
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatDateTime formats the date and time of t according to the
+// locale, in the time zone of t.
+func (l *Locale) FormatDateTime(t time.Time, format LocaleFormat) string {
+	return l.formatTime(t, format, 0)
+}
+
+// FormatDate formats the date of t according to the locale.
+func (l *Locale) FormatDate(t time.Time, format LocaleFormat) string {
+	return l.formatTime(t, format, 1)
+}
+
+// FormatTime formats the time of day of t according to the locale.
+func (l *Locale) FormatTime(t time.Time, format LocaleFormat) string {
+	return l.formatTime(t, format, 2)
+}
+
+func (l *Locale) formatTime(t time.Time, format LocaleFormat, part int) string {
+	cname := C.CString(l.name)
+	defer C.free(unsafe.Pointer(cname))
+	_, offset := t.Zone()
+	msecs := t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond)
+	return cstringResult(C.localeFormatDateTime(cname, C.int64_t(msecs), C.int(offset), C.int(format), C.int(part)))
+}
+
+// ParseFloat parses a number formatted according to the locale.
+func (l *Locale) ParseFloat(s string) (float64, error) {
+	cname := C.CString(l.name)
+	cs := C.CString(s)
+	defer C.free(unsafe.Pointer(cname))
+	defer C.free(unsafe.Pointer(cs))
+	var result C.double
+	if C.localeParseFloat(cname, cs, &result) == 0 {
+		return 0, fmt.Errorf("cannot parse %q as a number in locale %s", s, l.name)
+	}
+	return float64(result), nil
+}
+
+// ParseInt parses an integer formatted according to the locale.
+func (l *Locale) ParseInt(s string) (int64, error) {
+	cname := C.CString(l.name)
+	cs := C.CString(s)
+	defer C.free(unsafe.Pointer(cname))
+	defer C.free(unsafe.Pointer(cs))
+	var result C.int64_t
+	if C.localeParseInt(cname, cs, &result) == 0 {
+		return 0, fmt.Errorf("cannot parse %q as an integer in locale %s", s, l.name)
+	}
+	return int64(result), nil
+}
+
+// ParseDateTime parses a date and time formatted according to the
+// locale, interpreting it in the local time zone.
+func (l *Locale) ParseDateTime(s string, format LocaleFormat) (time.Time, error) {
+	return l.parseTime(s, format, 0)
+}
+
+// ParseDate parses a date formatted according to the locale,
+// interpreting it in the local time zone.
+func (l *Locale) ParseDate(s string, format LocaleFormat) (time.Time, error) {
+	return l.parseTime(s, format, 1)
+}
+
+func (l *Locale) parseTime(s string, format LocaleFormat, part int) (time.Time, error) {
+	cname := C.CString(l.name)
+	cs := C.CString(s)
+	defer C.free(unsafe.Pointer(cname))
+	defer C.free(unsafe.Pointer(cs))
+	var msecs C.int64_t
+	if C.localeParseDateTime(cname, cs, C.int(format), C.int(part), &msecs) == 0 {
+		return time.Time{}, fmt.Errorf("cannot parse %q as a date in locale %s", s, l.name)
+	}
+	return time.Unix(int64(msecs)/1000, int64(msecs)%1000*int64(time.Millisecond)), nil
+}
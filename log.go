@@ -65,6 +65,10 @@ type LogMessage interface {
 	File() string
 	Line() int
 
+	// Category returns the name of the logging category the message
+	// was logged under, such as "qt.qml.binding", or "default" if none.
+	Category() string
+
 	String() string // returns "file:line: text"
 
 	privateMarker()
@@ -77,6 +81,7 @@ const (
 	LogWarning
 	LogCritical
 	LogFatal
+	LogInfo
 )
 
 var logHandler QmlLogger = defaultLogger{}
@@ -96,7 +101,14 @@ func init() {
 //export hookLogHandler
 func hookLogHandler(cmsg *C.LogMessage) {
 	msg := logMessage{c: cmsg}
-	logHandler.QmlOutput(&msg)
+	if f := logCategoryHandler(msg.Category()); f != nil {
+		f(&msg)
+	} else {
+		logHandler.QmlOutput(&msg)
+	}
+	if msg.Severity() == LogFatal {
+		crashFatalMessage(msg.String())
+	}
 	msg.invalid = true
 }
 
@@ -145,7 +157,12 @@ func (m *logMessage) File() string {
 
 func (m *logMessage) Text() string {
 	m.assertValid()
-	return C.GoStringN(m.c.text, m.c.line)
+	return C.GoStringN(m.c.text, m.c.textLen)
+}
+
+func (m *logMessage) Category() string {
+	m.assertValid()
+	return C.GoStringN(m.c.category, m.c.categoryLen)
 }
 
 func (*logMessage) privateMarker() {}
@@ -0,0 +1,144 @@
+package qml
+
+// #include <stdlib.h>
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+var logCategories struct {
+	sync.Mutex
+	rules    []string // Filter rules in the order they apply.
+	handlers []logCategoryRoute
+}
+
+type logCategoryRoute struct {
+	pattern string
+	f       func(msg LogMessage)
+}
+
+// EnableLogCategory enables or disables the messages logged under the
+// logging categories matching pattern, as done with the QT_LOGGING_RULES
+// environment variable. Patterns may start or end with a "*" wildcard, and
+// may have a ".debug", ".info", ".warning", or ".critical" suffix to affect
+// a single severity. For example, this logs the evaluation of bindings:
+//
+//     qml.EnableLogCategory("qt.qml.binding", true)
+//
+// Later calls take precedence over earlier ones for the categories matched.
+func EnableLogCategory(pattern string, enabled bool) {
+	logCategories.Lock()
+	rule := fmt.Sprintf("%s=%t", pattern, enabled)
+	for i, r := range logCategories.rules {
+		if strings.HasPrefix(r, pattern+"=") {
+			logCategories.rules = append(logCategories.rules[:i], logCategories.rules[i+1:]...)
+			break
+		}
+	}
+	logCategories.rules = append(logCategories.rules, rule)
+	rules := strings.Join(logCategories.rules, "\n")
+	logCategories.Unlock()
+	crules, crulesLen := unsafeStringData(rules)
+	gui(func() {
+		C.setLogFilterRules(crules, crulesLen)
+	})
+}
+
+// HandleLogCategory arranges for messages logged under the categories
+// matching pattern to be handed to f rather than to the logger set with
+// SetLogger. Patterns have the syntax of path.Match, so "qt.qml.*" matches
+// all categories under "qt.qml". When several patterns match a category,
+// the handler registered last is used.
+//
+// As with QmlLogger, f may be called from any thread, and msg must not be
+// used after f returns.
+func HandleLogCategory(pattern string, f func(msg LogMessage)) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic(fmt.Sprintf("invalid log category pattern %q", pattern))
+	}
+	logCategories.Lock()
+	logCategories.handlers = append(logCategories.handlers, logCategoryRoute{pattern, f})
+	logCategories.Unlock()
+}
+
+// logCategoryHandler returns the handler for messages logged under
+// category, or nil if they go to the logger.
+func logCategoryHandler(category string) func(msg LogMessage) {
+	logCategories.Lock()
+	defer logCategories.Unlock()
+	for i := len(logCategories.handlers) - 1; i >= 0; i-- {
+		route := logCategories.handlers[i]
+		if ok, _ := path.Match(route.pattern, category); ok {
+			return route.f
+		}
+	}
+	return nil
+}
+
+// LogCategory is a logging category for messages logged by Go logic,
+// which are handled as those logged by Qt and by QML logic under the
+// same category, and appear tagged with it in Qt tooling.
+type LogCategory struct {
+	name string
+	addr unsafe.Pointer
+}
+
+// NewLogCategory returns the logging category with the provided name,
+// such as "app.network". Logging categories are never released, so they
+// are usually held in global variables:
+//
+//     var netLog = qml.NewLogCategory("app.network")
+//
+// Debug messages are enabled by default, and may be disabled with
+// EnableLogCategory.
+func NewLogCategory(name string) *LogCategory {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	return &LogCategory{name: name, addr: C.newLogCategory(cname)}
+}
+
+// Name returns the name of the category.
+func (c *LogCategory) Name() string {
+	return c.name
+}
+
+// Enabled returns whether messages with the provided severity are
+// currently enabled for the category.
+func (c *LogCategory) Enabled(severity LogSeverity) bool {
+	return C.logCategoryEnabled(c.addr, C.int(severity)) != 0
+}
+
+// Debugf logs a debug message under the category if enabled.
+func (c *LogCategory) Debugf(format string, args ...interface{}) {
+	c.output(LogDebug, format, args)
+}
+
+// Infof logs an informational message under the category if enabled.
+func (c *LogCategory) Infof(format string, args ...interface{}) {
+	c.output(LogInfo, format, args)
+}
+
+// Warningf logs a warning message under the category if enabled.
+func (c *LogCategory) Warningf(format string, args ...interface{}) {
+	c.output(LogWarning, format, args)
+}
+
+// Criticalf logs a critical message under the category if enabled.
+func (c *LogCategory) Criticalf(format string, args ...interface{}) {
+	c.output(LogCritical, format, args)
+}
+
+func (c *LogCategory) output(severity LogSeverity, format string, args []interface{}) {
+	if !c.Enabled(severity) {
+		return
+	}
+	ctext, ctextLen := unsafeStringData(fmt.Sprintf(format, args...))
+	C.logCategoryOutput(c.addr, C.int(severity), ctext, ctextLen)
+}
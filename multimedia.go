@@ -0,0 +1,248 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"errors"
+	"image"
+	"time"
+	"unsafe"
+)
+
+// MediaPlayer offers typed access to a QML MediaPlayer or Audio element
+// from the QtMultimedia module, so that playback logic may be driven
+// entirely from Go code.
+//
+// For example:
+//
+//     player := qml.NewMediaPlayer(root.ObjectByName("player"))
+//     player.SetSource("file:///tmp/song.ogg")
+//     player.Play()
+//
+type MediaPlayer struct {
+	Object
+}
+
+// NewMediaPlayer returns a MediaPlayer that controls obj, which must be
+// a QML MediaPlayer or Audio element.
+func NewMediaPlayer(obj Object) *MediaPlayer {
+	return &MediaPlayer{obj}
+}
+
+// MediaState holds the playback state of a media element.
+type MediaState int
+
+const (
+	MediaStopped MediaState = iota
+	MediaPlaying
+	MediaPaused
+)
+
+// MediaStatus holds the loading and buffering status of a media element.
+type MediaStatus int
+
+const (
+	MediaUnknownStatus MediaStatus = iota
+	MediaNoMedia
+	MediaLoading
+	MediaLoaded
+	MediaStalled
+	MediaBuffering
+	MediaBuffered
+	MediaEndOfMedia
+	MediaInvalid
+)
+
+// Play starts or resumes playback of the current source.
+func (p *MediaPlayer) Play() {
+	p.Call("play")
+}
+
+// Pause pauses playback at the current position.
+func (p *MediaPlayer) Pause() {
+	p.Call("pause")
+}
+
+// Stop stops playback and resets the position to the beginning.
+func (p *MediaPlayer) Stop() {
+	p.Call("stop")
+}
+
+// Seek moves the playback position to the provided offset.
+func (p *MediaPlayer) Seek(offset time.Duration) {
+	p.Call("seek", int(offset/time.Millisecond))
+}
+
+// SetSource changes the URL of the media being played.
+func (p *MediaPlayer) SetSource(url string) {
+	p.Set("source", url)
+}
+
+// SetVolume changes the playback volume, ranging from 0.0 to 1.0.
+func (p *MediaPlayer) SetVolume(volume float64) {
+	p.Set("volume", volume)
+}
+
+// Volume returns the playback volume, ranging from 0.0 to 1.0.
+func (p *MediaPlayer) Volume() float64 {
+	return p.Float64("volume")
+}
+
+// SetMuted changes whether the audio output is muted.
+func (p *MediaPlayer) SetMuted(muted bool) {
+	p.Set("muted", muted)
+}
+
+// Position returns the current playback position.
+func (p *MediaPlayer) Position() time.Duration {
+	return time.Duration(p.Int64("position")) * time.Millisecond
+}
+
+// Duration returns the duration of the current media, or zero if unknown.
+func (p *MediaPlayer) Duration() time.Duration {
+	return time.Duration(p.Int64("duration")) * time.Millisecond
+}
+
+// State returns the current playback state.
+func (p *MediaPlayer) State() MediaState {
+	return MediaState(p.Int("playbackState"))
+}
+
+// Status returns the current loading and buffering status.
+func (p *MediaPlayer) Status() MediaStatus {
+	return MediaStatus(p.Int("status"))
+}
+
+// BufferProgress returns how much of the data buffer is currently
+// filled, ranging from 0.0 to 1.0.
+func (p *MediaPlayer) BufferProgress() float64 {
+	return p.Float64("bufferProgress")
+}
+
+// OnPositionChanged arranges for f to be called with the new playback
+// position whenever it changes. As with all signal handlers, f is run
+// within the main GUI thread.
+func (p *MediaPlayer) OnPositionChanged(f func(position time.Duration)) {
+	p.On("positionChanged", func() { f(p.Position()) })
+}
+
+// OnStateChanged arranges for f to be called with the new playback
+// state whenever it changes.
+func (p *MediaPlayer) OnStateChanged(f func(state MediaState)) {
+	p.On("playbackStateChanged", func() { f(p.State()) })
+}
+
+// OnStatusChanged arranges for f to be called with the new media status
+// whenever it changes. This includes buffering transitions.
+func (p *MediaPlayer) OnStatusChanged(f func(status MediaStatus)) {
+	p.On("statusChanged", func() { f(p.Status()) })
+}
+
+// OnBufferProgressChanged arranges for f to be called with the new
+// buffer fill level whenever it changes.
+func (p *MediaPlayer) OnBufferProgressChanged(f func(progress float64)) {
+	p.On("bufferProgressChanged", func() { f(p.BufferProgress()) })
+}
+
+// VideoProbe delivers the frames flowing through a QML media element,
+// such as a Camera or a MediaPlayer, to a Go function. The frames are
+// still rendered normally by any VideoOutput showing the same source.
+// Video probing requires building the package with the qmlmultimedia
+// build tag, which links against the Qt Multimedia module.
+type VideoProbe struct {
+	addr unsafe.Pointer
+	f    func(frame image.Image)
+}
+
+var videoProbes = make(map[*VideoProbe]bool)
+
+// NewVideoProbe arranges for f to be called with every video frame
+// produced by source, which must be a QML Camera or MediaPlayer element
+// or another object holding a media object in its mediaObject property.
+//
+// Each frame is copied into a newly allocated image, so f may hold on to
+// it after returning. Frames in the YUV formats commonly produced by
+// cameras are delivered as *image.YCbCr values with their samples as
+// they are, so the luma needed by tasks such as barcode scanning is
+// readily available in the Y plane. Frames in other formats that cannot
+// be converted into an image are dropped.
+//
+// The Stop method must be called to release the probe.
+func NewVideoProbe(source Object, f func(frame image.Image)) (*VideoProbe, error) {
+	probe := &VideoProbe{f: f}
+	gui(func() {
+		probe.addr = C.newVideoProbe(source.Common().addr, unsafe.Pointer(&probe.f))
+		if probe.addr != nilPtr {
+			videoProbes[probe] = true
+		}
+	})
+	if probe.addr == nilPtr {
+		return nil, errors.New("source does not support video frame probing")
+	}
+	return probe, nil
+}
+
+// Stop interrupts the delivery of frames and releases the probe.
+//
+// It is safe to call Stop more than once.
+func (probe *VideoProbe) Stop() {
+	gui(func() {
+		if probe.addr != nilPtr {
+			C.delVideoProbe(probe.addr)
+			probe.addr = nilPtr
+			delete(videoProbes, probe)
+		}
+	})
+}
+
+//export hookVideoFrame
+func hookVideoFrame(frameFunc unsafe.Pointer, cimage unsafe.Pointer) {
+	f := *(*func(frame image.Image))(frameFunc)
+	f(unpackImage(cimage))
+}
+
+//export hookVideoFrameYCbCr
+func hookVideoFrameYCbCr(frameFunc unsafe.Pointer, cframe *C.YCbCrFrame) {
+	f := *(*func(frame image.Image))(frameFunc)
+	f(unpackYCbCr(cframe))
+}
+
+// unpackYCbCr copies the samples of the YUV frame described by cframe
+// into a new image.
+func unpackYCbCr(cframe *C.YCbCrFrame) *image.YCbCr {
+	width, height := int(cframe.width), int(cframe.height)
+	ratio := image.YCbCrSubsampleRatio420
+	cheight := (height + 1) / 2
+	if cframe.subsample == 422 {
+		ratio = image.YCbCrSubsampleRatio422
+		cheight = height
+	}
+	cwidth := (width + 1) / 2
+	img := image.NewYCbCr(image.Rect(0, 0, width, height), ratio)
+	copyPlane(img.Y, img.YStride, width, height, cframe, 0)
+	copyPlane(img.Cb, img.CStride, cwidth, cheight, cframe, 1)
+	copyPlane(img.Cr, img.CStride, cwidth, cheight, cframe, 2)
+	return img
+}
+
+// copyPlane copies the width x height samples of plane i of cframe into dst.
+func copyPlane(dst []byte, dstStride, width, height int, cframe *C.YCbCrFrame, i int) {
+	if width == 0 {
+		return
+	}
+	plane := uintptr(unsafe.Pointer(cframe.planes[i]))
+	stride, step := int(cframe.strides[i]), int(cframe.steps[i])
+	for y := 0; y < height; y++ {
+		src := cbytes((*C.char)(unsafe.Pointer(plane+uintptr(y*stride))), C.int((width-1)*step+1))
+		row := dst[y*dstStride : y*dstStride+width]
+		if step == 1 {
+			copy(row, src)
+			continue
+		}
+		for x := range row {
+			row[x] = src[x*step]
+		}
+	}
+}
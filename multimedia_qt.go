@@ -0,0 +1,7 @@
+// +build qmlmultimedia
+
+package qml
+
+// #cgo pkg-config: Qt5Multimedia
+//
+import "C"
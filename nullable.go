@@ -0,0 +1,45 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+)
+
+var (
+	typeValuer  = reflect.TypeOf(new(driver.Valuer)).Elem()
+	typeScanner = reflect.TypeOf(new(sql.Scanner)).Elem()
+)
+
+// packNullable packs the value held by a nullable value such as a
+// sql.NullString or an Optional into dvalue, so that values that are
+// not valid are seen by QML logic as null.
+func packNullable(value driver.Valuer, dvalue *C.DataValue, engine *Engine) {
+	v, err := value.Value()
+	if err != nil {
+		panic(fmt.Sprintf("cannot obtain value of %T: %v", value, err))
+	}
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	}
+	packDataValue(v, dvalue, engine, jsOwner)
+}
+
+// scanNullable sets to, which must implement sql.Scanner when addressed,
+// by scanning from into it, and returns whether it did so. A nil from
+// value, as obtained when QML logic assigns null, makes to invalid.
+// It panics if to cannot scan the value.
+func scanNullable(to reflect.Value, from interface{}) bool {
+	if !to.CanAddr() || !to.Addr().Type().Implements(typeScanner) {
+		return false
+	}
+	if err := to.Addr().Interface().(sql.Scanner).Scan(from); err != nil {
+		panic(fmt.Sprintf("cannot assign %#v to %s value: %v", from, to.Type(), err))
+	}
+	return true
+}
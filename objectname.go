@@ -0,0 +1,60 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+var (
+	objectNamer  func(value interface{}) string
+	typeCounters = make(map[reflect.Type]int)
+)
+
+// SetObjectNamer arranges for namer to be called with every Go value
+// handed to QML logic, or created by QML logic via a registered type,
+// and for the objectName of the object wrapping the value to be set to
+// the name returned, so that runtime inspectors such as GammaRay and
+// crash report object trees identify Go values rather than showing
+// anonymous wrappers. Empty names are not set, and names set by QML
+// logic take precedence. A nil namer disables the naming.
+//
+// TypeCounterName may be used as the namer for names based on the type:
+//
+//     qml.SetObjectNamer(qml.TypeCounterName)
+//
+// The namer is run within the main GUI thread.
+func SetObjectNamer(namer func(value interface{}) string) {
+	gui(func() {
+		objectNamer = namer
+	})
+}
+
+// TypeCounterName returns a name for value made of its type name and a
+// counter of values of that type named so far, such as "Person_3".
+// It is meant to be used with SetObjectNamer, and must be run within the
+// main GUI thread.
+func TypeCounterName(value interface{}) string {
+	t := reflect.TypeOf(value)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	typeCounters[t]++
+	return fmt.Sprintf("%s_%d", t.Name(), typeCounters[t])
+}
+
+// nameObject sets the objectName of the object wrapping gvalue, if a
+// namer is set.
+func nameObject(cvalue unsafe.Pointer, gvalue interface{}) {
+	if objectNamer == nil {
+		return
+	}
+	if name := objectNamer(gvalue); name != "" {
+		cname, cnameLen := unsafeStringData(name)
+		C.objectSetName(cvalue, cname, cnameLen)
+	}
+}
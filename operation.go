@@ -0,0 +1,59 @@
+package qml
+
+import (
+	"context"
+	"reflect"
+)
+
+// operation is the handle handed to QML when it calls a method of a
+// Go value that takes a context.Context as its first parameter. The
+// context is provided by the package rather than by the QML logic, and
+// is cancelled when the handle's cancel method is called. For example:
+//
+//     func (b *Backend) Search(ctx context.Context, query string) <-chan string {
+//         results := make(chan string)
+//         go func() {
+//             defer close(results)
+//             for _, match := range search(query) {
+//                 select {
+//                 case results <- match:
+//                 case <-ctx.Done():
+//                     return
+//                 }
+//             }
+//         }()
+//         return results
+//     }
+//
+// and in QML:
+//
+//     var op = backend.search(query)
+//     op.result.element.connect(function(match) { ... })
+//     ...
+//     op.cancel()
+//
+// The result of the method, if any, is available in the result property
+// of the handle. Such methods must have at most one result.
+type operation struct {
+	Result    interface{}
+	Cancelled bool
+
+	cancel context.CancelFunc
+}
+
+// Cancel cancels the context provided to the method call.
+// It is safe to call Cancel more than once.
+func (op *operation) Cancel() {
+	op.cancel()
+	if !op.Cancelled {
+		op.Cancelled = true
+		Changed(op, &op.Cancelled)
+	}
+}
+
+// takesContext returns whether the method with type methodt takes a
+// context.Context as its first parameter, after skipping the first
+// skip parameters (the receiver, for unbound methods).
+func takesContext(methodt reflect.Type, skip int) bool {
+	return methodt.NumIn() > skip && methodt.In(skip) == typeContext
+}
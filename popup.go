@@ -0,0 +1,129 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"errors"
+	"time"
+)
+
+// Popup offers typed access to a Qt Quick Controls 2 Popup or any of its
+// subtypes, such as Menu, Dialog, Drawer or ToolTip, so that transient
+// user interface may be triggered by Go logic.
+//
+// For example:
+//
+//     menu := qml.NewPopup(root.ObjectByName("contextMenu"))
+//     menu.OpenAt(item, x, y)
+//
+type Popup struct {
+	Object
+}
+
+// NewPopup returns a Popup that controls obj, which must be a Qt Quick
+// Controls 2 Popup or one of its subtypes.
+func NewPopup(obj Object) *Popup {
+	return &Popup{obj}
+}
+
+// Open opens the popup at its current position.
+func (p *Popup) Open() {
+	p.Call("open")
+}
+
+// OpenAt opens the popup with the provided visual item as its parent,
+// at the point x, y in the coordinate system of the parent.
+func (p *Popup) OpenAt(parent Object, x, y float64) {
+	gui(func() {
+		p.Set("parent", parent)
+		p.Set("x", x)
+		p.Set("y", y)
+		p.Call("open")
+	})
+}
+
+// Close closes the popup.
+func (p *Popup) Close() {
+	p.Call("close")
+}
+
+// Opened returns whether the popup is currently visible.
+func (p *Popup) Opened() bool {
+	return p.Bool("visible")
+}
+
+// OnOpened arranges for f to be called whenever the popup is opened.
+// As with all signal handlers, f is run within the main GUI thread.
+func (p *Popup) OnOpened(f func()) {
+	p.On("opened", f)
+}
+
+// OnClosed arranges for f to be called whenever the popup is closed.
+// As with all signal handlers, f is run within the main GUI thread.
+func (p *Popup) OnClosed(f func()) {
+	p.On("closed", f)
+}
+
+const toolTipQML = `
+import QtQuick.Controls 2.0
+ToolTip {}
+`
+
+// ShowToolTip shows a tool tip with text at the point x, y in the
+// coordinate system of item, which must be a visual item. The tool tip
+// is hidden after timeout, or only once closed if timeout is zero, and
+// is destroyed once hidden. Showing tool tips requires Qt Quick
+// Controls 2 to be available.
+func ShowToolTip(item Object, text string, x, y float64, timeout time.Duration) (*Popup, error) {
+	engine := item.Common().engine
+	component, err := engine.LoadString("tooltip.qml", toolTipQML)
+	if err != nil {
+		return nil, err
+	}
+	tip := NewPopup(component.Create(nil))
+	gui(func() {
+		tip.Set("text", text)
+		if timeout > 0 {
+			tip.Set("timeout", int(timeout/time.Millisecond))
+		}
+		tip.OnClosed(tip.Destroy)
+		tip.OpenAt(item, x, y)
+	})
+	return tip, nil
+}
+
+const overlayPopupQML = `
+import QtQuick.Controls 2.0
+Popup {}
+`
+
+// Overlay returns the Qt Quick Controls 2 overlay layer of the window,
+// where popups are displayed above all other items. Items parented to
+// the overlay are also displayed above all other items. Accessing the
+// overlay requires Qt Quick Controls 2 to be available.
+func (win *Window) Overlay() (Object, error) {
+	addr := nilPtr
+	gui(func() {
+		addr = C.windowOverlay(win.addr)
+	})
+	if addr == nilPtr {
+		// The overlay is only created once a popup is placed in
+		// the window, so place a dummy one.
+		component, err := win.engine.LoadString("overlay.qml", overlayPopupQML)
+		if err != nil {
+			return nil, err
+		}
+		popup := component.Create(nil)
+		gui(func() {
+			popup.Set("parent", win.ContentItem())
+			addr = C.windowOverlay(win.addr)
+		})
+		popup.Destroy()
+	}
+	if addr == nilPtr {
+		return nil, errors.New("window has no overlay")
+	}
+	return &Common{engine: win.engine, addr: addr}, nil
+}
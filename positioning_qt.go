@@ -0,0 +1,7 @@
+// +build qmlpositioning
+
+package qml
+
+// #cgo pkg-config: Qt5Positioning
+//
+import "C"
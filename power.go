@@ -0,0 +1,90 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"sync"
+)
+
+// PowerEvent identifies a change in the power or session state of the system.
+type PowerEvent int
+
+const (
+	SystemSuspending PowerEvent = iota // The system is about to suspend.
+	SystemResumed                      // The system resumed from suspension.
+	SessionLocked                      // The user session was locked.
+	SessionUnlocked                    // The user session was unlocked.
+)
+
+// InhibitSleep prevents the display from being turned off or locked
+// and the system from being suspended due to user inactivity, until the
+// returned release function is called. The reason is shown to the user
+// by platforms that list active inhibitions. Calling release more than
+// once has no further effect.
+//
+// Multiple inhibitions may be active at once, and the system may only
+// sleep once all of them have been released.
+//
+// On Linux the freedesktop.org screen saver and logind services are used
+// over D-Bus. On Android and iOS the screen is kept on while the application
+// is in the foreground. On other platforms InhibitSleep does nothing.
+func InhibitSleep(reason string) (release func()) {
+	var platformRelease func()
+	gui(func() {
+		platformRelease = inhibitSleep(reason)
+	})
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			gui(platformRelease)
+		})
+	}
+}
+
+var keepAwakeCount int
+
+// keepAwake counts active inhibitions on platforms that only offer a
+// single on/off switch, and calls set whenever the switch must change.
+// It must be run within the main GUI thread.
+func keepAwake(on bool, set func(on bool)) {
+	assertGui("keepAwake")
+	if on {
+		keepAwakeCount++
+		if keepAwakeCount == 1 {
+			set(true)
+		}
+	} else {
+		keepAwakeCount--
+		if keepAwakeCount == 0 {
+			set(false)
+		}
+	}
+}
+
+var powerEventHandlers []func(event PowerEvent)
+
+// OnPowerEvent arranges for f to be called when the system is about to
+// suspend or has resumed, and when the user session is locked or
+// unlocked, so that applications may pause and resume their work.
+// As with signal handlers, f is run within the main GUI thread.
+//
+// Power events are currently only reported on Linux, via logind and
+// the freedesktop.org screen saver service. On mobile platforms use
+// OnApplicationStateChanged instead.
+func OnPowerEvent(f func(event PowerEvent)) {
+	gui(func() {
+		if powerEventHandlers == nil {
+			connectPowerEvents()
+		}
+		powerEventHandlers = append(powerEventHandlers, f)
+	})
+}
+
+//export hookPowerEvent
+func hookPowerEvent(event C.int) {
+	for _, f := range powerEventHandlers {
+		f(PowerEvent(event))
+	}
+}
@@ -0,0 +1,36 @@
+// +build !android
+
+package qml
+
+// #include <stdlib.h>
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+func inhibitSleep(reason string) (release func()) {
+	capp := C.CString(filepath.Base(os.Args[0]))
+	creason := C.CString(reason)
+	defer C.free(unsafe.Pointer(capp))
+	defer C.free(unsafe.Pointer(creason))
+	cookie := C.dbusInhibitScreenSaver(capp, creason)
+	fd := int(C.dbusInhibitSleep(capp, creason))
+	return func() {
+		if cookie != 0 {
+			C.dbusUninhibitScreenSaver(cookie)
+		}
+		if fd >= 0 {
+			syscall.Close(fd)
+		}
+	}
+}
+
+func connectPowerEvents() {
+	C.dbusConnectPowerEvents()
+}
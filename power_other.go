@@ -0,0 +1,9 @@
+// +build !linux,!android,!ios
+
+package qml
+
+func inhibitSleep(reason string) (release func()) {
+	return func() {}
+}
+
+func connectPowerEvents() {}
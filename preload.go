@@ -0,0 +1,234 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+type componentCompile struct {
+	path    string
+	results chan componentCompiled
+}
+
+type componentCompiled struct {
+	path string
+	addr unsafe.Pointer
+	err  error
+}
+
+var componentCompiles = make(map[*componentCompile]bool)
+
+// compileFiles compiles the QML files at the provided paths in the
+// background and waits until they are all done. If keep is true, the
+// compiled components are returned by absolute path. Otherwise they
+// are released once compiled.
+func (e *Engine) compileFiles(paths []string, keep bool) (map[string]unsafe.Pointer, error) {
+	abspaths := make([]string, len(paths))
+	for i, path := range paths {
+		abspath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		abspaths[i] = abspath
+	}
+	var errs []string
+	var lintedPaths []string
+	for _, path := range abspaths {
+		if err := lintFile(e.addr, path); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			lintedPaths = append(lintedPaths, path)
+		}
+	}
+	results := make(chan componentCompiled, len(lintedPaths))
+	gui(func() {
+		for _, path := range lintedPaths {
+			compile := &componentCompile{path: path, results: results}
+			componentCompiles[compile] = true
+			cpath, cpathLen := unsafeStringData(path)
+			C.engineCompileComponent(e.addr, cpath, cpathLen, cbool(keep), unsafe.Pointer(compile))
+		}
+	})
+	var compiled map[string]unsafe.Pointer
+	if keep {
+		compiled = make(map[string]unsafe.Pointer)
+	}
+	for range lintedPaths {
+		result := <-results
+		if result.err != nil {
+			errs = append(errs, result.err.Error())
+		} else if keep {
+			compiled[result.path] = result.addr
+		}
+	}
+	if len(errs) > 0 {
+		return compiled, errors.New(strings.Join(errs, "\n"))
+	}
+	return compiled, nil
+}
+
+//export hookComponentCompiled
+func hookComponentCompiled(compilep unsafe.Pointer, component unsafe.Pointer, cerror *C.char, cerrorLen C.int) {
+	compile := (*componentCompile)(compilep)
+	delete(componentCompiles, compile)
+	result := componentCompiled{path: compile.path, addr: component}
+	if cerrorLen > 0 {
+		result.err = errors.New(C.GoStringN(cerror, cerrorLen))
+	}
+	compile.results <- result
+}
+
+// Preload compiles the QML files at the provided paths, and the files
+// they depend on, on background threads where supported, and keeps the
+// resulting components in the engine. Later calls to LoadFile with any
+// of these paths return the preloaded component at once, rather than
+// compiling the file again. Preload returns once all files are compiled,
+// with an error describing all files that failed to compile, if any.
+// Files that compiled successfully are preloaded even then.
+//
+// Preload must not be called from the GUI thread, as it waits for
+// the GUI thread to make progress.
+func (e *Engine) Preload(paths ...string) error {
+	if onGuiThread() {
+		panic("Preload must not be called from the GUI thread")
+	}
+	e.assertValid()
+	compiled, err := e.compileFiles(paths, true)
+	gui(func() {
+		if e.preloaded == nil {
+			e.preloaded = make(map[string]Object)
+		}
+		for path, addr := range compiled {
+			e.preloaded[path] = &Common{addr: addr, engine: e}
+		}
+	})
+	return err
+}
+
+// preloadedComponent returns the component preloaded from path, if any.
+func (e *Engine) preloadedComponent(path string) Object {
+	abspath, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	var component Object
+	gui(func() {
+		component = e.preloaded[abspath]
+	})
+	return component
+}
+
+// Pool keeps instances of a component created ahead of time, so that
+// views which are instantiated often, such as delegates and pages in
+// navigation-heavy applications, are ready for immediate use.
+//
+// Instances are created one at a time on the GUI thread, so that event
+// processing continues in between, and the pool is refilled in the
+// background whenever an instance is taken from it.
+type Pool struct {
+	component Object
+	ctx       *Context
+	size      int
+
+	mu      sync.Mutex
+	ready   []Object
+	filling bool
+	closed  bool
+}
+
+// NewPool returns a pool holding up to size instances of component,
+// created under the ctx context. If ctx is nil, instances run under
+// the same context as the component. The pool starts filling up
+// immediately.
+func NewPool(component Object, ctx *Context, size int) *Pool {
+	if size < 1 {
+		panic("pool size must be positive")
+	}
+	p := &Pool{component: component, ctx: ctx, size: size}
+	p.refill()
+	return p
+}
+
+// Get returns an instance of the pool component. If no instance is
+// ready, a new one is created at once. The returned instance is owned
+// by the caller, and the pool is refilled in the background.
+func (p *Pool) Get() Object {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		panic("pool is closed")
+	}
+	var obj Object
+	if n := len(p.ready); n > 0 {
+		obj = p.ready[n-1]
+		p.ready[n-1] = nil
+		p.ready = p.ready[:n-1]
+	}
+	p.mu.Unlock()
+	if obj == nil {
+		obj = p.component.Create(p.ctx)
+	}
+	p.refill()
+	return obj
+}
+
+// Len returns the number of instances ready in the pool.
+func (p *Pool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.ready)
+}
+
+// Close destroys the instances ready in the pool and stops refilling it.
+// Instances previously obtained via Get are not affected.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	ready := p.ready
+	p.ready = nil
+	p.closed = true
+	p.mu.Unlock()
+	for _, obj := range ready {
+		obj.Destroy()
+	}
+}
+
+func (p *Pool) refill() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.filling || p.closed || len(p.ready) >= p.size {
+		return
+	}
+	p.filling = true
+	go p.fill()
+}
+
+func (p *Pool) fill() {
+	for {
+		p.mu.Lock()
+		if p.closed || len(p.ready) >= p.size {
+			p.filling = false
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+
+		obj := p.component.Create(p.ctx)
+
+		p.mu.Lock()
+		if p.closed {
+			p.filling = false
+			p.mu.Unlock()
+			obj.Destroy()
+			return
+		}
+		p.ready = append(p.ready, obj)
+		p.mu.Unlock()
+	}
+}
@@ -0,0 +1,104 @@
+package qml
+
+import (
+	"context"
+)
+
+// Progress holds the progress of a background operation in a form that
+// may be bound directly by QML logic, such as a progress bar. Progress
+// may be updated from any goroutine, and its fields must not be modified
+// directly. Progress values must be created with NewProgress.
+// For example:
+//
+//     progress := qml.NewProgress()
+//     context.SetVar("progress", progress)
+//     go func() {
+//         for i, file := range files {
+//             if progress.Context().Err() != nil {
+//                 return
+//             }
+//             progress.Set(float64(i), float64(len(files)))
+//             progress.SetMessage("Copying " + file)
+//             copyFile(file)
+//         }
+//         progress.Set(1, 1)
+//     }()
+//
+// and in QML:
+//
+//     ProgressBar { value: progress.fraction }
+//     Text { text: progress.message }
+//     Button { text: "Cancel"; onClicked: progress.cancel() }
+//
+type Progress struct {
+	Value     float64 // Amount of work done.
+	Total     float64 // Total amount of work, or zero if unknown.
+	Fraction  float64 // Value/Total, ranging from 0.0 to 1.0, or zero if Total is unknown.
+	Message   string  // Description of the work being done.
+	Cancelled bool    // Whether the operation was cancelled.
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewProgress returns a new Progress with no work done.
+func NewProgress() *Progress {
+	p := &Progress{}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	return p
+}
+
+// Set changes the amount of work done and the total amount of work.
+func (p *Progress) Set(value, total float64) {
+	gui(func() {
+		p.Value = value
+		p.Total = total
+		p.Fraction = 0
+		if total > 0 {
+			p.Fraction = value / total
+			if p.Fraction > 1 {
+				p.Fraction = 1
+			}
+		}
+		Changed(p, &p.Value)
+		Changed(p, &p.Total)
+		Changed(p, &p.Fraction)
+	})
+}
+
+// Add adds delta to the amount of work done.
+func (p *Progress) Add(delta float64) {
+	gui(func() {
+		p.Set(p.Value+delta, p.Total)
+	})
+}
+
+// SetMessage changes the description of the work being done.
+func (p *Progress) SetMessage(message string) {
+	gui(func() {
+		p.Message = message
+		Changed(p, &p.Message)
+	})
+}
+
+// Cancel requests the cancellation of the operation. The context
+// returned by Context is cancelled, and the Cancelled field is set.
+// Cancel is usually called by QML logic, such as when a cancel button
+// is clicked.
+//
+// It is safe to call Cancel more than once.
+func (p *Progress) Cancel() {
+	p.cancel()
+	gui(func() {
+		if !p.Cancelled {
+			p.Cancelled = true
+			Changed(p, &p.Cancelled)
+		}
+	})
+}
+
+// Context returns a context that is cancelled when Cancel is called.
+// It is safe to use the context from any goroutine.
+func (p *Progress) Context() context.Context {
+	return p.ctx
+}
@@ -0,0 +1,117 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// Promise holds the eventual result of an asynchronous operation.
+// When returned from a method of a Go value called by QML logic, it
+// is handed to QML as a JavaScript Promise that settles when Resolve
+// or Reject is called. For example:
+//
+//     func (b *Backend) FetchData(url string) *qml.Promise {
+//         promise := qml.NewPromise()
+//         go func() {
+//             data, err := fetch(url)
+//             if err != nil {
+//                 promise.Reject(err)
+//             } else {
+//                 promise.Resolve(data)
+//             }
+//         }()
+//         return promise
+//     }
+//
+// and in QML:
+//
+//     backend.fetchData(url).then(function(data) { ... })
+//
+// JavaScript promises require Qt 5.12 or later.
+type Promise struct {
+	mu       sync.Mutex
+	settled  bool
+	rejected bool
+	value    interface{}
+	engine   *Engine
+	addr     unsafe.Pointer
+}
+
+// NewPromise returns a new unsettled promise.
+func NewPromise() *Promise {
+	return &Promise{}
+}
+
+// Resolve settles the promise successfully with the provided value,
+// which is converted as any other value handed to QML logic.
+// Only the first call to Resolve or Reject has an effect.
+// Resolve may be called from any goroutine.
+func (p *Promise) Resolve(value interface{}) {
+	p.settle(false, value)
+}
+
+// Reject settles the promise with an error. QML logic observes a
+// JavaScript Error with the error message.
+// Only the first call to Resolve or Reject has an effect.
+// Reject may be called from any goroutine.
+func (p *Promise) Reject(err error) {
+	p.settle(true, err.Error())
+}
+
+func (p *Promise) settle(rejected bool, value interface{}) {
+	p.mu.Lock()
+	if p.settled {
+		p.mu.Unlock()
+		return
+	}
+	p.settled = true
+	p.rejected = rejected
+	p.value = value
+	p.mu.Unlock()
+	gui(func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.addr != nilPtr {
+			p.deliver()
+		}
+	})
+}
+
+// deliver settles the JavaScript promise and releases it. If p is
+// handed to QML again afterwards, a new JavaScript promise is created
+// and settled with the same result. deliver must be run from the main
+// GUI thread with p.mu held.
+func (p *Promise) deliver() {
+	var dvalue C.DataValue
+	packDataValue(p.value, &dvalue, p.engine, jsOwner)
+	reject := C.int(0)
+	if p.rejected {
+		reject = 1
+	}
+	C.jsPromiseSettle(p.addr, reject, &dvalue)
+	p.addr = nilPtr
+	delete(promises, p)
+}
+
+var promises = make(map[*Promise]bool)
+
+// jsValue returns the JavaScript promise for p in engine, creating it
+// if necessary. It must be run from the main GUI thread.
+func (p *Promise) jsValue(engine *Engine) unsafe.Pointer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.addr == nilPtr {
+		p.engine = engine
+		p.addr = C.newJSPromise(engine.addr)
+		promises[p] = true
+	}
+	value := C.jsPromiseValue(p.addr)
+	if p.settled {
+		p.deliver()
+	}
+	return value
+}
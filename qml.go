@@ -22,6 +22,7 @@ import "C"
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
@@ -30,6 +31,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -38,7 +40,35 @@ import (
 
 // InitOptions holds options to initialize the qml package.
 type InitOptions struct {
-	// Reserved for coming options.
+	// DebugPort, if non-zero, starts the QML debugging service listening
+	// on the given TCP port, so that tools such as Qt Creator may attach
+	// to the application to debug and profile its QML logic.
+	DebugPort int
+
+	// SoftwareRender renders scenes in software rather than via OpenGL.
+	// It's useful to work around broken graphics drivers.
+	SoftwareRender bool
+
+	// ScaleFactor, if non-zero, scales the whole user interface by the
+	// given factor, on top of any scaling done for high DPI screens.
+	ScaleFactor float64
+}
+
+// RegisterFlags defines in fs the flags that set the respective options,
+// so that support scenarios may be diagnosed and worked around without
+// every application handling the same switches. For example:
+//
+//     var options qml.InitOptions
+//     options.RegisterFlags(flag.CommandLine)
+//     flag.Parse()
+//     qml.Init(&options)
+//
+// The flags defined are --qml-debug=<port>, --software-render, and
+// --scale-factor=<factor>.
+func (options *InitOptions) RegisterFlags(fs *flag.FlagSet) {
+	fs.IntVar(&options.DebugPort, "qml-debug", options.DebugPort, "start the QML debugging service on the given `port`")
+	fs.BoolVar(&options.SoftwareRender, "software-render", options.SoftwareRender, "render scenes in software rather than via OpenGL")
+	fs.Float64Var(&options.ScaleFactor, "scale-factor", options.ScaleFactor, "scale the user interface by the given `factor`")
 }
 
 var initialized int32
@@ -53,9 +83,22 @@ func Init(options *InitOptions) {
 	if !atomic.CompareAndSwapInt32(&initialized, 0, 1) {
 		panic("qml.Init called more than once")
 	}
+	if options == nil {
+		options = &InitOptions{}
+	}
+
+	// Qt reads those before the application is created.
+	if options.SoftwareRender {
+		os.Setenv("QT_QUICK_BACKEND", "software")
+		os.Setenv("QMLSCENE_DEVICE", "softwarecontext")
+		os.Setenv("QT_OPENGL", "software")
+	}
+	if options.ScaleFactor > 0 {
+		os.Setenv("QT_SCALE_FACTOR", strconv.FormatFloat(options.ScaleFactor, 'g', -1, 64))
+	}
 
 	guiLoopReady.Lock()
-	go guiLoop()
+	go guiLoop(options)
 	guiLoopReady.Lock()
 }
 
@@ -65,7 +108,12 @@ type Engine struct {
 	values    map[interface{}]*valueFold
 	destroyed bool
 
+	conversion ConversionPolicy
+
 	imageProviders map[string]*func(providerId string, width, height int) image.Image
+
+	preloaded map[string]Object
+	store     *Store
 }
 
 var engines = make(map[unsafe.Pointer]*Engine)
@@ -103,7 +151,7 @@ func (e *Engine) Destroy() {
 				e.destroyed = true
 				C.delObjectLater(e.addr)
 				if len(e.values) == 0 {
-					delete(engines, e.addr)
+					releaseEngine(e)
 				} else {
 					// The engine reference keeps those values alive.
 					// The last value destroyed will clear it.
@@ -114,6 +162,16 @@ func (e *Engine) Destroy() {
 	}
 }
 
+// releaseEngine forgets the destroyed engine e once none of its values
+// are alive, and releases the cached type information along with the
+// last engine.
+func releaseEngine(e *Engine) {
+	delete(engines, e.addr)
+	if len(engines) == 0 {
+		releaseTypeInfo()
+	}
+}
+
 // Load loads a new component with the provided location and with the
 // content read from r. The location informs the resource name for
 // logged messages, and its path is used to locate any other resources
@@ -126,6 +184,9 @@ func (e *Engine) Load(location string, r io.Reader) (Object, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := lint(e.addr, location, data); err != nil {
+		return nil, err
+	}
 	if colon, slash := strings.Index(location, ":"), strings.Index(location, "/"); colon == -1 || slash <= colon {
 		// TODO Better testing for this.
 		if filepath.IsAbs(location) {
@@ -163,7 +224,13 @@ func (e *Engine) Load(location string, r io.Reader) (Object, error) {
 //
 // Once a component is loaded, component instances may be created from
 // the resulting object via its Create and CreateWindow methods.
+//
+// If the file was preloaded via Preload, the preloaded component
+// is returned.
 func (e *Engine) LoadFile(path string) (Object, error) {
+	if component := e.preloadedComponent(path); component != nil {
+		return component, nil
+	}
 	// TODO Test this.
 	f, err := os.Open(path)
 	if err != nil {
@@ -230,15 +297,15 @@ func hookRequestImage(imageFunc unsafe.Pointer, cid *C.char, cidLen, cwidth, che
 	id := unsafeString(cid, cidLen)
 	width := int(cwidth)
 	height := int(cheight)
+	return newCImage(f(id, width, height))
+}
 
-	img := f(id, width, height)
-
-	var cimage unsafe.Pointer
-
+// newCImage returns a new QImage holding a copy of img.
+func newCImage(img image.Image) unsafe.Pointer {
 	rect := img.Bounds()
-	width = rect.Max.X - rect.Min.X
-	height = rect.Max.Y - rect.Min.Y
-	cimage = C.newImage(C.int(width), C.int(height))
+	width := rect.Max.X - rect.Min.X
+	height := rect.Max.Y - rect.Min.Y
+	cimage := C.newImage(C.int(width), C.int(height))
 
 	var cbits []byte
 	cbitsh := (*reflect.SliceHeader)((unsafe.Pointer)(&cbits))
@@ -276,6 +343,9 @@ type Context struct {
 // not be garbage collected until the engine is destroyed, even if the
 // value is unused or changed.
 func (ctx *Context) SetVar(name string, value interface{}) {
+	if err := checkStrict(value, ctx.engine); err != nil {
+		panic(err.Error())
+	}
 	cname, cnamelen := unsafeStringData(name)
 	gui(func() {
 		var dvalue C.DataValue
@@ -298,6 +368,9 @@ func (ctx *Context) SetVar(name string, value interface{}) {
 // not be garbage collected until the engine is destroyed, even if the
 // value is unused or changed.
 func (ctx *Context) SetVars(value interface{}) {
+	if err := checkStrict(value, ctx.engine); err != nil {
+		panic(err.Error())
+	}
 	gui(func() {
 		C.contextSetObject(ctx.addr, wrapGoValue(ctx.engine, value, cppOwner))
 	})
@@ -317,7 +390,102 @@ func (ctx *Context) Var(name string) interface{} {
 	return unpackDataValue(&dvalue, ctx.engine)
 }
 
-// TODO Context.Spawn() => Context
+// ReplaceVar replaces the value of the context variable with the given
+// name, refreshing all bindings that depend on it. If the variable holds
+// a Go value of the same type as value, the QML object wrapping it is
+// preserved and made to wrap value instead, so that references to the
+// object held by QML logic, such as in properties and JavaScript
+// variables, observe the new value too. Otherwise ReplaceVar behaves
+// as SetVar.
+//
+// ReplaceVar is useful to swap the Go values exposed by a backend, such
+// as after reconnecting to a server, without recreating the user interface.
+func (ctx *Context) ReplaceVar(name string, value interface{}) {
+	if err := checkStrict(value, ctx.engine); err != nil {
+		panic(err.Error())
+	}
+	cname, cnamelen := unsafeStringData(name)
+	replaced := false
+	gui(func() {
+		var dvalue C.DataValue
+		qname := C.newString(cname, cnamelen)
+		defer C.delString(qname)
+		C.contextGetProperty(ctx.addr, qname, &dvalue)
+		if dvalue.dataType != C.DTGoAddr {
+			unpackDataValue(&dvalue, ctx.engine)
+			return
+		}
+		fold := *(**valueFold)(unsafe.Pointer(&dvalue.data))
+		if fold.engine != ctx.engine || value == nil || reflect.TypeOf(fold.gvalue) != reflect.TypeOf(value) || !hashable(value) {
+			return
+		}
+		if fold.gvalue != value {
+			fold.relink(value)
+			C.goValueActivateAll(fold.cvalue, typeInfo(value))
+		}
+		replaced = true
+	})
+	if !replaced {
+		ctx.SetVar(name, value)
+	}
+}
+
+// relink makes fold wrap gvalue in place of the value it wraps.
+func (fold *valueFold) relink(gvalue interface{}) {
+	values := fold.engine.values
+	if fold.prev != nil {
+		fold.prev.next = fold.next
+	} else if fold.next != nil {
+		values[fold.gvalue] = fold.next
+	} else {
+		delete(values, fold.gvalue)
+	}
+	if fold.next != nil {
+		fold.next.prev = fold.prev
+	}
+	fold.gvalue = gvalue
+	fold.prev = nil
+	fold.next = values[gvalue]
+	if fold.next != nil {
+		fold.next.prev = fold
+	}
+	values[gvalue] = fold
+}
+
+// Spawn returns a new context that has ctx as its parent, so that it
+// sees all the variables set in ctx, while variables set in it are not
+// seen by ctx. The new context is released along with ctx, or earlier
+// with its Destroy method.
+func (ctx *Context) Spawn() *Context {
+	var result Context
+	result.engine = ctx.engine
+	gui(func() {
+		result.addr = C.contextSpawn(ctx.addr)
+	})
+	return &result
+}
+
+// Destroy releases a context obtained via Spawn, along with any contexts
+// spawned from it. The context must not be used after calling this method,
+// nor should objects created within it. Destroy panics if ctx is the root
+// context of the engine, which is released by destroying the engine.
+func (ctx *Context) Destroy() {
+	root := false
+	gui(func() {
+		if ctx.addr == nilPtr {
+			return
+		}
+		if !ctx.engine.destroyed && ctx.addr == C.engineRootContext(ctx.engine.addr) {
+			root = true
+			return
+		}
+		C.delObjectLater(ctx.addr)
+		ctx.addr = nilPtr
+	})
+	if root {
+		panic("cannot destroy the root context of an engine")
+	}
+}
 
 // TODO engine.ObjectOf(&value) => *Common for the Go value
 
@@ -360,7 +528,8 @@ func (list *List) Len() int {
 
 // Slice allocates a new slice and copies the list content into it,
 // performing type conversions as possible, and then assigns the result
-// to the slice pointed to by sliceAddr.
+// to the slice pointed to by sliceAddr. Nested lists are converted
+// into nested slices.
 // Slice panics if the list values are not compatible with the
 // provided slice.
 func (list *List) Slice(sliceAddr interface{}) {
@@ -416,15 +585,22 @@ func (obj *Common) Interface() interface{} {
 }
 
 // Set changes the named object property to the given value.
+//
+// The property may also be a dotted path addressing grouped or attached
+// properties, such as "anchors.margins" or "Accessible.name".
 func (obj *Common) Set(property string, value interface{}) error {
 	cproperty := C.CString(property)
 	defer C.free(unsafe.Pointer(cproperty))
+	var cerr *C.error
 	gui(func() {
 		var dvalue C.DataValue
 		packDataValue(value, &dvalue, obj.engine, cppOwner)
-		C.objectSetProperty(obj.addr, cproperty, &dvalue)
+		cerr = C.objectSetProperty(obj.addr, cproperty, &dvalue)
 	})
-	// TODO Return an error if the value cannot be set.
+	// TODO Return an error if a simple property value cannot be set.
+	if cerr != nil {
+		return cerror(cerr)
+	}
 	return nil
 }
 
@@ -432,6 +608,9 @@ func (obj *Common) Set(property string, value interface{}) error {
 // If the property type is known, type-specific methods such as Int
 // and String are more convenient to use.
 // Property panics if the property does not exist.
+//
+// As with Set, the property may also be a dotted path addressing
+// grouped or attached properties.
 func (obj *Common) Property(name string) interface{} {
 	cname := C.CString(name)
 	defer C.free(unsafe.Pointer(cname))
@@ -561,6 +740,16 @@ func (obj *Common) Slice(property string, sliceAddr interface{}) {
 // was defined with the objectName property set to the provided value.
 // ObjectByName panics if the object is not found.
 func (obj *Common) ObjectByName(objectName string) Object {
+	object, ok := obj.findByName(objectName)
+	if !ok {
+		panic(fmt.Sprintf("cannot find descendant with objectName == %q", objectName))
+	}
+	return object
+}
+
+// findByName returns the descendant object that was defined with the
+// objectName property set to the provided value, if any.
+func (obj *Common) findByName(objectName string) (Object, bool) {
 	cname, cnamelen := unsafeStringData(objectName)
 	var dvalue C.DataValue
 	gui(func() {
@@ -569,10 +758,7 @@ func (obj *Common) ObjectByName(objectName string) Object {
 		C.objectFindChild(obj.addr, qname, &dvalue)
 	})
 	object, ok := unpackDataValue(&dvalue, obj.engine).(Object)
-	if !ok {
-		panic(fmt.Sprintf("cannot find descendant with objectName == %q", objectName))
-	}
-	return object
+	return object, ok
 }
 
 // Call calls the given object method with the provided parameters.
@@ -598,6 +784,12 @@ func (obj *Common) Call(method string, params ...interface{}) interface{} {
 // The component instance runs under the ctx context. If ctx is nil,
 // it runs under the same context as obj.
 //
+// The new instance is owned by Go code, and must be released with the
+// Destroy method. It may be handed to QML logic at will, such as by being
+// returned from a method of a Go value, and QML receives the very same
+// object every time, so Go code may implement factories and caches of
+// QML items.
+//
 // The Create method panics if called on an object that does not
 // represent a QML component.
 func (obj *Common) Create(ctx *Context) Object {
@@ -669,6 +861,8 @@ var connectedFunction = make(map[*interface{}]bool)
 //
 // Note that Go uses the real signal name, rather than the one used when
 // defining QML signal handlers ("clicked" rather than "onClicked").
+// Signals of attached objects are named with a dotted path, such as
+// "Accessible.pressAction".
 //
 // For more details regarding signals and QML see:
 //
@@ -760,6 +954,48 @@ func (win *Window) Hide() {
 	})
 }
 
+// Edge holds one or more window edges, combined with the | operator.
+type Edge int
+
+const (
+	TopEdge    Edge = 0x1
+	LeftEdge   Edge = 0x2
+	RightEdge  Edge = 0x4
+	BottomEdge Edge = 0x8
+)
+
+// StartSystemMove starts moving the window as if the user had started
+// dragging its title bar, with the platform window manager handling the
+// rest of the interaction. This allows frameless windows to implement a
+// custom title bar that behaves natively, by calling StartSystemMove
+// when the mouse is pressed on it.
+//
+// StartSystemMove returns false if the platform does not support
+// system moves, or if no mouse button or touch point is pressed.
+// It requires Qt 5.15 or later.
+func (win *Window) StartSystemMove() bool {
+	var ok C.int
+	gui(func() {
+		ok = C.windowStartSystemMove(win.addr)
+	})
+	return ok != 0
+}
+
+// StartSystemResize starts resizing the window from the provided edges,
+// as if the user had started dragging its border. Corners are selected
+// by combining two edges, such as TopEdge|LeftEdge.
+//
+// StartSystemResize returns false if the platform does not support
+// system resizes, or if no mouse button or touch point is pressed.
+// It requires Qt 5.15 or later.
+func (win *Window) StartSystemResize(edges Edge) bool {
+	var ok C.int
+	gui(func() {
+		ok = C.windowStartSystemResize(win.addr, C.int(edges))
+	})
+	return ok != 0
+}
+
 // Root returns the root object being rendered.
 //
 // If the window was defined in QML code, the root object is the window itself.
@@ -772,6 +1008,17 @@ func (win *Window) Root() Object {
 	return &obj
 }
 
+// ContentItem returns the invisible item at the root of the window scene,
+// which all visual items in the window descend from.
+func (win *Window) ContentItem() Object {
+	var obj Common
+	obj.engine = win.engine
+	gui(func() {
+		obj.addr = C.windowContentItem(win.addr)
+	})
+	return &obj
+}
+
 // Wait blocks the current goroutine until the window is closed.
 func (win *Window) Wait() {
 	// XXX Test this.
@@ -797,13 +1044,19 @@ func (win *Window) Snapshot() image.Image {
 	defer C.delImage(cimage)
 
 	// This should be safe to be done out of the main GUI thread.
+	return unpackImage(cimage)
+}
+
+// unpackImage copies the content of a QImage in the ARGB32 format
+// into a newly allocated Go image.
+func unpackImage(cimage unsafe.Pointer) *image.RGBA {
 	var cwidth, cheight C.int
 	C.imageSize(cimage, &cwidth, &cheight)
 
 	var cbits []byte
 	cbitsh := (*reflect.SliceHeader)((unsafe.Pointer)(&cbits))
 	cbitsh.Data = (uintptr)((unsafe.Pointer)(C.imageConstBits(cimage)))
-	cbitsh.Len = int(cwidth * cheight * 8) // ARGB
+	cbitsh.Len = int(cwidth * cheight * 4) // ARGB
 	cbitsh.Cap = cbitsh.Len
 
 	image := image.NewRGBA(image.Rect(0, 0, int(cwidth), int(cheight)))
@@ -891,14 +1144,17 @@ func registerType(location string, major, minor int, spec *TypeSpec) error {
 			err = fmt.Errorf("TypeSpec.New for type %q returned nil", spec.Name)
 			return
 		}
+		if err = checkStrict(sample, nil); err != nil {
+			return
+		}
 
 		cloc := C.CString(location)
 		cname := C.CString(localSpec.Name)
 		cres := C.int(0)
 		if localSpec.Singleton {
-			cres = C.registerSingleton(cloc, C.int(major), C.int(minor), cname, typeInfo(sample), unsafe.Pointer(&localSpec))
+			cres = C.registerSingleton(cloc, C.int(major), C.int(minor), cname, pinTypeInfo(sample), unsafe.Pointer(&localSpec))
 		} else {
-			cres = C.registerType(cloc, C.int(major), C.int(minor), cname, typeInfo(sample), unsafe.Pointer(&localSpec))
+			cres = C.registerType(cloc, C.int(major), C.int(minor), cname, pinTypeInfo(sample), unsafe.Pointer(&localSpec))
 		}
 		// It doesn't look like it keeps references to these, but it's undocumented and unclear.
 		C.free(unsafe.Pointer(cloc))
@@ -0,0 +1,358 @@
+// Package qmlfuzz exercises the conversion of values between Go and QML
+// with randomized inputs, and reports values that break its invariants.
+//
+// The conversion layer moves values across cgo and QVariant boundaries,
+// so subtle mistakes there corrupt data or crash the application rather
+// than failing cleanly. Running it from a test catches such mistakes:
+//
+//     func (s *S) TestConversionFuzz(c *C) {
+//         err := qmlfuzz.Run(s.engine, qmlfuzz.Config{Iterations: 5000})
+//         c.Assert(err, IsNil)
+//     }
+//
+// A failure reports the seed it was found with, so it may be reproduced
+// by running again with the same Config.
+package qmlfuzz
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/niemeyer/qml"
+)
+
+// Config holds the parameters of a fuzzing run.
+type Config struct {
+	// Seed is the seed of the random values generated, or zero for a
+	// seed based on the current time.
+	Seed int64
+
+	// Iterations is the number of values tried, or 1000 if zero.
+	Iterations int
+
+	// MaxDepth is the maximum nesting of Node values generated,
+	// or 4 if zero.
+	MaxDepth int
+}
+
+// Node is the type of the nested values handed to QML logic while
+// fuzzing, with fields of the types most commonly converted.
+type Node struct {
+	Text   string
+	Number int
+	Big    int64
+	Real   float64
+	Small  float32
+	Flag   bool
+	Child  *Node
+}
+
+// Failure describes a value that broke an invariant of the conversion.
+type Failure struct {
+	Seed      int64
+	Iteration int
+	Check     string // Description of the invariant broken.
+	Value     interface{}
+	Got       interface{}
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("qmlfuzz: %s failed at iteration %d with seed %d: sent %#v, got %#v", f.Check, f.Iteration, f.Seed, f.Value, f.Got)
+}
+
+// Run hands randomly generated values to QML logic via the provided
+// engine, and returns a *Failure describing the first value that breaks
+// an invariant of the conversion, if any. Values generated include
+// strings with unusual characters, extreme and special numbers, and
+// nested Node values.
+//
+// Run must not be called from the GUI thread.
+func Run(engine *qml.Engine, config Config) error {
+	if config.Seed == 0 {
+		config.Seed = time.Now().UnixNano()
+	}
+	if config.Iterations == 0 {
+		config.Iterations = 1000
+	}
+	if config.MaxDepth == 0 {
+		config.MaxDepth = 4
+	}
+	rnd := rand.New(rand.NewSource(config.Seed))
+	for i := 0; i < config.Iterations; i++ {
+		var value interface{}
+		if rnd.Intn(4) == 0 {
+			value = randomNode(rnd, 1+rnd.Intn(config.MaxDepth))
+		} else {
+			value = randomScalar(rnd)
+		}
+		if err := Check(engine, value); err != nil {
+			f := err.(*Failure)
+			f.Seed = config.Seed
+			f.Iteration = i
+			return f
+		}
+	}
+	return nil
+}
+
+// Check verifies the invariants of the conversion for a single value,
+// which must be a string, bool, int, int64, float64, float32, or *Node,
+// and returns a *Failure if any is broken. It may be used with the
+// fuzzing support of the testing package to explore inputs further.
+//
+// The value must be read back unchanged from a context variable, with
+// a *Node read back as the same pointer, and from a QML property of the
+// matching type, where the property type is able to hold it. Every field
+// of a *Node, at every depth, must also be seen unchanged by QML logic.
+//
+// Check must not be called from the GUI thread.
+func Check(engine *qml.Engine, value interface{}) error {
+	ctx := engine.Context().Spawn()
+	defer ctx.Destroy()
+	ctx.SetVar("fuzzValue", value)
+	got := ctx.Var("fuzzValue")
+	if !same(roundTrip(value), got) {
+		return &Failure{Check: "context variable round-trip", Value: value, Got: got}
+	}
+	if node, ok := value.(*Node); ok {
+		return checkNode(engine, ctx, node)
+	}
+	return checkProperty(engine, value)
+}
+
+const propertiesQML = `
+	import QtQuick 2.0
+	QtObject {
+		property string text
+		property int number
+		property real real
+		property bool flag
+	}
+`
+
+func checkProperty(engine *qml.Engine, value interface{}) error {
+	var name string
+	switch value := value.(type) {
+	case string:
+		name = "text"
+	case bool:
+		name = "flag"
+	case int:
+		if value != int(int32(value)) {
+			return nil
+		}
+		name = "number"
+	case float64:
+		name = "real"
+	default:
+		return nil
+	}
+	component, err := engine.LoadString("qmlfuzz.qml", propertiesQML)
+	if err != nil {
+		return err
+	}
+	obj := component.Create(nil)
+	defer obj.Destroy()
+	obj.Set(name, value)
+	got := obj.Property(name)
+	if !same(value, got) {
+		return &Failure{Check: "property " + name + " round-trip", Value: value, Got: got}
+	}
+	return nil
+}
+
+// nodeFields lists the fields of Node checked as seen by QML logic.
+var nodeFields = []string{"text", "number", "big", "real", "small", "flag"}
+
+func checkNode(engine *qml.Engine, ctx *qml.Context, node *Node) error {
+	var paths, props []string
+	var want []interface{}
+	path := "fuzzValue"
+	for n := node; n != nil; n = n.Child {
+		fields := []interface{}{n.Text, n.Number, n.Big, n.Real, n.Small, n.Flag}
+		for i, field := range nodeFields {
+			paths = append(paths, path+"."+field)
+			props = append(props, fmt.Sprintf("property var p%d: %s.%s", len(props), path, field))
+			want = append(want, fields[i])
+		}
+		path += ".child"
+	}
+	src := "import QtQuick 2.0\nQtObject {\n" + strings.Join(props, "\n") + "\n}\n"
+	component, err := engine.LoadString("qmlfuzz.qml", src)
+	if err != nil {
+		return err
+	}
+	obj := component.Create(ctx)
+	defer obj.Destroy()
+	for i := range props {
+		got := obj.Property(fmt.Sprintf("p%d", i))
+		if !sameNumber(want[i], got) && !same(want[i], got) {
+			return &Failure{Check: "reading " + paths[i], Value: node, Got: got}
+		}
+	}
+	return nil
+}
+
+// roundTrip returns the value expected when reading back value after
+// handing it to QML.
+func roundTrip(value interface{}) interface{} {
+	if v, ok := value.(int); ok && v != int(int32(v)) {
+		return int64(v)
+	}
+	return value
+}
+
+func same(want, got interface{}) bool {
+	switch want := want.(type) {
+	case float64:
+		if got, ok := got.(float64); ok && math.IsNaN(want) {
+			return math.IsNaN(got)
+		}
+		if got, ok := got.(float64); ok {
+			return want == got && math.Signbit(want) == math.Signbit(got)
+		}
+		return false
+	case float32:
+		if got, ok := got.(float32); ok && math.IsNaN(float64(want)) {
+			return math.IsNaN(float64(got))
+		}
+	}
+	return reflect.DeepEqual(want, got)
+}
+
+// sameNumber returns whether want and got are numbers of equal value,
+// as QML logic holds all numbers as float64 values.
+func sameNumber(want, got interface{}) bool {
+	w, ok1 := number(want)
+	g, ok2 := number(got)
+	if !ok1 || !ok2 {
+		return false
+	}
+	if math.IsNaN(w) {
+		return math.IsNaN(g)
+	}
+	return w == g
+}
+
+func number(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	}
+	return 0, false
+}
+
+var specialStrings = []string{
+	"",
+	"\x00",
+	"a\x00b",
+	"e\u0301\u0301",
+	"\U0001F600",
+	"\ufeff",
+	"\u202e",
+	"\uffff",
+	"\r\n\t",
+	strings.Repeat("x", 1<<16),
+}
+
+var specialFloats = []float64{
+	0,
+	math.Copysign(0, -1),
+	math.NaN(),
+	math.Inf(1),
+	math.Inf(-1),
+	math.MaxFloat64,
+	-math.MaxFloat64,
+	math.SmallestNonzeroFloat64,
+	1 << 53,
+	1<<53 + 1,
+}
+
+var specialInts = []int64{
+	0,
+	-1,
+	math.MaxInt32,
+	math.MinInt32,
+	math.MaxInt32 + 1,
+	math.MinInt32 - 1,
+	math.MaxInt64,
+	math.MinInt64,
+}
+
+func randomScalar(rnd *rand.Rand) interface{} {
+	switch rnd.Intn(6) {
+	case 0:
+		return randomString(rnd)
+	case 1:
+		return rnd.Intn(2) == 0
+	case 2:
+		return int(randomInt(rnd))
+	case 3:
+		return randomInt(rnd)
+	case 4:
+		return randomFloat(rnd)
+	}
+	return float32(randomFloat(rnd))
+}
+
+func randomString(rnd *rand.Rand) string {
+	if rnd.Intn(4) == 0 {
+		return specialStrings[rnd.Intn(len(specialStrings))]
+	}
+	runes := make([]rune, rnd.Intn(64))
+	for i := range runes {
+		switch rnd.Intn(3) {
+		case 0:
+			runes[i] = rune(rnd.Intn(128))
+		case 1:
+			runes[i] = rune(rnd.Intn(0x10000))
+		default:
+			runes[i] = rune(0x10000 + rnd.Intn(utf8.MaxRune-0x10000))
+		}
+		if !utf8.ValidRune(runes[i]) {
+			// Surrogate halves cannot be encoded as UTF-8.
+			runes[i] = utf8.RuneError
+		}
+	}
+	return string(runes)
+}
+
+func randomInt(rnd *rand.Rand) int64 {
+	if rnd.Intn(4) == 0 {
+		return specialInts[rnd.Intn(len(specialInts))]
+	}
+	return rnd.Int63() >> uint(rnd.Intn(63)) * int64(1-2*rnd.Intn(2))
+}
+
+func randomFloat(rnd *rand.Rand) float64 {
+	if rnd.Intn(4) == 0 {
+		return specialFloats[rnd.Intn(len(specialFloats))]
+	}
+	return rnd.NormFloat64() * math.Pow(10, float64(rnd.Intn(600)-300))
+}
+
+func randomNode(rnd *rand.Rand, depth int) *Node {
+	node := &Node{
+		Text:   randomString(rnd),
+		Number: int(int32(randomInt(rnd))),
+		Big:    randomInt(rnd),
+		Real:   randomFloat(rnd),
+		Small:  float32(randomFloat(rnd)),
+		Flag:   rnd.Intn(2) == 0,
+	}
+	if depth > 1 {
+		node.Child = randomNode(rnd, depth-1)
+	}
+	return node
+}
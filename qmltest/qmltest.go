@@ -0,0 +1,32 @@
+// Package qmltest offers support for testing QML logic from Go.
+//
+// Time-dependent logic, such as animations, Timer elements, and input
+// debounced with them, may be tested deterministically by driving it
+// with a fake clock:
+//
+//     defer qmltest.UseFakeClock()()
+//     ...
+//     field.Set("text", "query")
+//     qmltest.AdvanceTime(300 * time.Millisecond)
+//     c.Assert(root.Bool("searching"), Equals, true)
+package qmltest
+
+import (
+	"time"
+
+	"github.com/niemeyer/qml"
+)
+
+// UseFakeClock replaces the clock driving QML timers and animations with
+// a fake one that only advances when AdvanceTime is called, and returns
+// a function that restores the real clock.
+func UseFakeClock() (restore func()) {
+	qml.UseFakeClock(true)
+	return func() { qml.UseFakeClock(false) }
+}
+
+// AdvanceTime advances the fake clock by d, and returns once all QML
+// timers and animation frames due meanwhile have run.
+func AdvanceTime(d time.Duration) {
+	qml.AdvanceFakeClock(d)
+}
@@ -0,0 +1,119 @@
+package qml
+
+import (
+	"path/filepath"
+)
+
+// RecentFiles manages the list of files recently used by the application,
+// shared between Go logic and QML logic such as a File menu. Files added
+// to the list are also reported to the platform, so that they show in
+// the Windows jump list of the application or the macOS recent items.
+// For example:
+//
+//     recent := &qml.RecentFiles{}
+//     context.SetVar("recentFiles", recent)
+//     ...
+//     recent.Add(path)
+//
+// and in QML:
+//
+//     Instantiator {
+//         model: recentFiles.count
+//         MenuItem { text: recentFiles.name(index); onTriggered: openFile(recentFiles.file(index)) }
+//     }
+//
+// The zero value is an empty list ready to use.
+type RecentFiles struct {
+	// Max holds the maximum number of files kept, or zero for
+	// the default of 10.
+	Max int
+
+	// Count holds the number of files in the list.
+	Count int
+
+	files []string
+}
+
+// Add moves path to the front of the list, dropping the oldest files
+// if the list grows beyond the maximum.
+func (r *RecentFiles) Add(path string) {
+	gui(func() {
+		r.setFiles(append([]string{path}, r.without(path)...))
+	})
+	noteRecentFile(path)
+}
+
+// Remove removes path from the list, such as when it fails to open.
+func (r *RecentFiles) Remove(path string) {
+	gui(func() {
+		r.setFiles(r.without(path))
+	})
+}
+
+// Clear removes all files from the list, and from the platform records
+// of files recently used by the application.
+func (r *RecentFiles) Clear() {
+	gui(func() {
+		r.setFiles(nil)
+	})
+	clearRecentFiles()
+}
+
+// Files returns the files in the list, most recent first. The result
+// may be persisted and restored with SetFiles.
+func (r *RecentFiles) Files() []string {
+	var files []string
+	gui(func() {
+		files = append(files, r.files...)
+	})
+	return files
+}
+
+// SetFiles replaces the files in the list, most recent first, without
+// reporting them to the platform.
+func (r *RecentFiles) SetFiles(files []string) {
+	gui(func() {
+		r.setFiles(append([]string(nil), files...))
+	})
+}
+
+// File returns the path of the file at index i, most recent first.
+func (r *RecentFiles) File(i int) string {
+	var file string
+	gui(func() {
+		if i >= 0 && i < len(r.files) {
+			file = r.files[i]
+		}
+	})
+	return file
+}
+
+// Name returns the base name of the file at index i, for display.
+func (r *RecentFiles) Name(i int) string {
+	return filepath.Base(r.File(i))
+}
+
+func (r *RecentFiles) without(path string) []string {
+	var files []string
+	for _, file := range r.files {
+		if file != path {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+func (r *RecentFiles) setFiles(files []string) {
+	max := r.Max
+	if max <= 0 {
+		max = 10
+	}
+	if len(files) > max {
+		files = files[:max]
+	}
+	r.files = files
+	r.Count = len(files)
+	// Notify even if the count is the same, so that models
+	// depending on it are refreshed with the new files.
+	Changed(r, &r.Count)
+}
@@ -0,0 +1,29 @@
+// +build !ios
+
+package qml
+
+// #cgo LDFLAGS: -framework AppKit -framework Foundation
+//
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"path/filepath"
+)
+
+func noteRecentFile(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	cpath, cpathLen := unsafeStringData(path)
+	gui(func() {
+		C.darwinNoteRecentDocument(cpath, cpathLen)
+	})
+}
+
+func clearRecentFiles() {
+	gui(func() {
+		C.darwinClearRecentDocuments()
+	})
+}
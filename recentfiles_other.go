@@ -0,0 +1,7 @@
+// +build !windows,!darwin ios
+
+package qml
+
+func noteRecentFile(path string) {}
+
+func clearRecentFiles() {}
@@ -0,0 +1,25 @@
+package qml
+
+// #cgo LDFLAGS: -lshell32
+//
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"path/filepath"
+	"unicode/utf16"
+	"unsafe"
+)
+
+func noteRecentFile(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	wpath := utf16.Encode([]rune(path + "\x00"))
+	C.windowsAddRecentDocument((*C.ushort)(unsafe.Pointer(&wpath[0])))
+}
+
+func clearRecentFiles() {
+	C.windowsAddRecentDocument(nil)
+}
@@ -0,0 +1,146 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// Recorder records the frames rendered by a window.
+// See Window.Record and Window.RecordGIF.
+type Recorder struct {
+	addr    unsafe.Pointer
+	start   time.Time
+	frames  chan recordedFrame
+	done    chan bool
+	mu      sync.Mutex
+	dropped int
+	finish  func() error
+	err     error
+}
+
+type recordedFrame struct {
+	image     image.Image
+	timestamp time.Duration
+}
+
+var recorders = make(map[*Recorder]bool)
+
+// recorderBacklog is the number of frames that may be waiting
+// for delivery before further frames are dropped.
+const recorderBacklog = 32
+
+// Record starts recording the content of the window at the provided
+// rate, in frames per second, and calls f with every recorded frame
+// and the time elapsed since recording started.
+//
+// The frames are rendered within the main GUI thread, but f is run on
+// a separate goroutine so that slow processing, such as encoding, does
+// not affect the frame pacing. Frames are delivered in order. If f falls
+// too far behind, further frames are dropped until it catches up; see
+// the Dropped method.
+//
+// The Stop method must be called to finish recording.
+func (win *Window) Record(fps float64, f func(frame image.Image, timestamp time.Duration)) *Recorder {
+	if fps <= 0 {
+		panic(fmt.Sprintf("invalid frame rate: %v", fps))
+	}
+	r := &Recorder{
+		frames: make(chan recordedFrame, recorderBacklog),
+		done:   make(chan bool),
+	}
+	go func() {
+		for frame := range r.frames {
+			f(frame.image, frame.timestamp)
+		}
+		close(r.done)
+	}()
+	interval := time.Duration(float64(time.Second) / fps)
+	gui(func() {
+		recorders[r] = true
+		r.start = time.Now()
+		r.addr = C.windowStartRecording(win.addr, C.int(interval/time.Millisecond), unsafe.Pointer(r))
+	})
+	return r
+}
+
+// RecordGIF starts recording the content of the window at the provided
+// rate, in frames per second, and writes the recorded frames to w as an
+// animated GIF image when the Stop method is called. This is convenient
+// for producing demos and visual artifacts of automated tests.
+//
+// Frames are reduced to the web-safe color palette as they are recorded.
+func (win *Window) RecordGIF(w io.Writer, fps float64) *Recorder {
+	anim := &gif.GIF{}
+	delay := int(100/fps + 0.5)
+	r := win.Record(fps, func(frame image.Image, timestamp time.Duration) {
+		paletted := image.NewPaletted(frame.Bounds(), palette.WebSafe)
+		draw.FloydSteinberg.Draw(paletted, frame.Bounds(), frame, image.ZP)
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, delay)
+	})
+	r.finish = func() error {
+		if len(anim.Image) == 0 {
+			return fmt.Errorf("no frames were recorded")
+		}
+		return gif.EncodeAll(w, anim)
+	}
+	return r
+}
+
+// Dropped returns the number of frames dropped so far because
+// they were not consumed fast enough.
+func (r *Recorder) Dropped() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropped
+}
+
+// Stop stops recording, waits until all recorded frames have been
+// delivered, and returns any error that happened while writing them.
+//
+// It is safe to call Stop more than once.
+func (r *Recorder) Stop() error {
+	var stopped bool
+	gui(func() {
+		if r.addr != nilPtr {
+			C.windowStopRecording(r.addr)
+			r.addr = nilPtr
+			delete(recorders, r)
+			close(r.frames)
+			stopped = true
+		}
+	})
+	<-r.done
+	if stopped && r.finish != nil {
+		r.err = r.finish()
+	}
+	return r.err
+}
+
+//export hookWindowFrame
+func hookWindowFrame(recorderp unsafe.Pointer, cimage unsafe.Pointer) {
+	defer C.delImage(cimage)
+	r := (*Recorder)(recorderp)
+	if r.addr == nilPtr {
+		return
+	}
+	if len(r.frames) == cap(r.frames) {
+		r.mu.Lock()
+		r.dropped++
+		r.mu.Unlock()
+		return
+	}
+	// Only the GUI thread sends, so this won't block.
+	r.frames <- recordedFrame{unpackImage(cimage), time.Since(r.start)}
+}
@@ -0,0 +1,235 @@
+package qml
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// InteractionRecorder records the interactions of QML logic with Go values.
+// See StartRecording.
+type InteractionRecorder struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	names map[interface{}]string
+	err   error
+}
+
+// recordedEntry is a single interaction recorded by an InteractionRecorder.
+type recordedEntry struct {
+	Value  string            `json:"value"`
+	Call   string            `json:"call,omitempty"`
+	Set    string            `json:"set,omitempty"`
+	Args   []json.RawMessage `json:"args,omitempty"`
+	Assign json.RawMessage   `json:"assign,omitempty"`
+}
+
+var recording struct {
+	sync.Mutex
+	r *InteractionRecorder
+}
+
+// StartRecording starts recording into w all method calls and field
+// writes performed by QML logic on Go values, with one JSON document
+// per line, so that they may be replayed later with Replay, such as for
+// turning a manual exploratory session into a regression test.
+// For example:
+//
+//     file, err := os.Create("session.rec")
+//     ...
+//     recorder := qml.StartRecording(file)
+//     recorder.Name(controller, "controller")
+//     defer recorder.Stop()
+//
+// and later, in a test:
+//
+//     err := qml.Replay(file, map[string]interface{}{"controller": controller})
+//
+// Every method called and every field set by QML logic is recorded with
+// its arguments already converted to the Go types they were handed as.
+// Arguments that cannot be represented as JSON, such as QML objects and
+// functions, are recorded as null.
+//
+// Only one recording of interactions may be active at a time.
+func StartRecording(w io.Writer) *InteractionRecorder {
+	r := &InteractionRecorder{enc: json.NewEncoder(w), names: make(map[interface{}]string)}
+	recording.Lock()
+	defer recording.Unlock()
+	if recording.r != nil {
+		panic("StartRecording called while another recording is active")
+	}
+	recording.r = r
+	return r
+}
+
+// Name sets the name that identifies value in the recording, and that
+// must be used for the value handed to Replay. Values not named are
+// identified by their type, such as "*main.Controller".
+func (r *InteractionRecorder) Name(value interface{}, name string) {
+	r.mu.Lock()
+	r.names[value] = name
+	r.mu.Unlock()
+}
+
+// Stop stops recording, and returns any error that happened while
+// writing the recorded interactions.
+//
+// It is safe to call Stop more than once.
+func (r *InteractionRecorder) Stop() error {
+	recording.Lock()
+	if recording.r == r {
+		recording.r = nil
+	}
+	recording.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func activeRecorder() *InteractionRecorder {
+	recording.Lock()
+	defer recording.Unlock()
+	return recording.r
+}
+
+// recordCall records the call of method on value with the provided
+// arguments, if a recording is active.
+func recordCall(value interface{}, method string, args []reflect.Value) {
+	r := activeRecorder()
+	if r == nil {
+		return
+	}
+	entry := recordedEntry{Call: method, Args: make([]json.RawMessage, len(args))}
+	for i, arg := range args {
+		entry.Args[i] = recordedValue(arg)
+	}
+	r.write(value, &entry)
+}
+
+// recordSet records the write of field of value, if a recording is active.
+func recordSet(value interface{}, field string, assigned reflect.Value) {
+	r := activeRecorder()
+	if r == nil {
+		return
+	}
+	r.write(value, &recordedEntry{Set: field, Assign: recordedValue(assigned)})
+}
+
+func recordedValue(v reflect.Value) json.RawMessage {
+	if !v.IsValid() || v.Type() == typeFunc || v.Type().Implements(typeObject) {
+		return json.RawMessage("null")
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return data
+}
+
+func (r *InteractionRecorder) write(value interface{}, entry *recordedEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if name, ok := r.names[value]; ok {
+		entry.Value = name
+	} else {
+		entry.Value = fmt.Sprintf("%T", value)
+	}
+	r.err = r.enc.Encode(entry)
+}
+
+// Replay performs against the provided values the interactions recorded
+// into rd by an InteractionRecorder, in order, calling the same methods
+// and setting the same fields as QML logic did while recording. Values
+// are looked up in values by the name they were recorded with.
+//
+// The methods are called and the fields are set directly from Go, within
+// the main GUI thread, rather than by running any QML logic, so Replay
+// needs neither an engine nor the QML documents used while recording.
+// Fields set are reported with Changed, so that any QML logic observing
+// them, such as in a headless engine, is updated as well, and the
+// On<Field>Changed method of the value is called if it exists.
+//
+// Replay stops at the first interaction that cannot be performed, and
+// returns an error describing it.
+func Replay(rd io.Reader, values map[string]interface{}) error {
+	dec := json.NewDecoder(rd)
+	for {
+		var entry recordedEntry
+		err := dec.Decode(&entry)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot parse recording: %v", err)
+		}
+		value, ok := values[entry.Value]
+		if !ok {
+			return fmt.Errorf("cannot replay interaction with %s: value not provided", entry.Value)
+		}
+		gui(func() {
+			if entry.Call != "" {
+				err = replayCall(value, &entry)
+			} else {
+				err = replaySet(value, &entry)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func replayCall(value interface{}, entry *recordedEntry) error {
+	method := reflect.ValueOf(value).MethodByName(entry.Call)
+	if !method.IsValid() {
+		return fmt.Errorf("cannot replay call to %s.%s: no such method", entry.Value, entry.Call)
+	}
+	methodt := method.Type()
+	first := 0
+	if takesContext(methodt, 0) {
+		first = 1
+	}
+	if methodt.NumIn()-first != len(entry.Args) {
+		return fmt.Errorf("cannot replay call to %s.%s: method takes %d arguments, recorded with %d", entry.Value, entry.Call, methodt.NumIn()-first, len(entry.Args))
+	}
+	params := make([]reflect.Value, methodt.NumIn())
+	if first == 1 {
+		params[0] = reflect.ValueOf(context.Background())
+	}
+	for i, arg := range entry.Args {
+		param := reflect.New(methodt.In(first + i))
+		if err := json.Unmarshal(arg, param.Interface()); err != nil {
+			return fmt.Errorf("cannot replay call to %s.%s: argument %d: %v", entry.Value, entry.Call, i, err)
+		}
+		params[first+i] = param.Elem()
+	}
+	method.Call(params)
+	return nil
+}
+
+func replaySet(value interface{}, entry *recordedEntry) error {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot replay write to %s.%s: value is not a pointer to a struct", entry.Value, entry.Set)
+	}
+	field := v.Elem().FieldByName(entry.Set)
+	if !field.IsValid() || !field.CanSet() {
+		return fmt.Errorf("cannot replay write to %s.%s: no such field", entry.Value, entry.Set)
+	}
+	assign := reflect.New(field.Type())
+	if err := json.Unmarshal(entry.Assign, assign.Interface()); err != nil {
+		return fmt.Errorf("cannot replay write to %s.%s: %v", entry.Value, entry.Set, err)
+	}
+	field.Set(assign.Elem())
+	if onChanged := v.MethodByName("On" + entry.Set + "Changed"); onChanged.IsValid() {
+		onChanged.Call(nil)
+	}
+	Changed(value, field.Addr().Interface())
+	return nil
+}
@@ -0,0 +1,219 @@
+package qml
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Router drives the navigation between the pages of an application,
+// shown by a QtQuick.Controls StackView. Routes map names to the
+// components instantiated as pages, and pages are pushed, popped, and
+// replaced by either Go or QML logic. For example:
+//
+//     router := qml.NewRouter(root.ObjectByName("stack"))
+//     router.Handle("home", homeComponent, nil)
+//     router.Handle("user", userComponent, UserParams{})
+//     context.SetVar("router", router)
+//     router.Push("home", nil)
+//
+// and in QML:
+//
+//     Button { text: "Profile"; onClicked: router.push("user", {id: 42}) }
+//     Label { text: "User " + router.params.id }
+//
+// The exported fields describe the page at the top of the stack, and
+// may be bound by QML elements. The zero value is not usable; create
+// routers with NewRouter.
+type Router struct {
+	Depth   int         // Number of pages in the stack.
+	Current string      // Name of the route at the top of the stack.
+	Params  interface{} // Parameters of the route at the top of the stack.
+
+	view     Object
+	routes   map[string]*route
+	stack    []routeEntry
+	handlers []func(event NavigationEvent)
+}
+
+type route struct {
+	component Object
+	params    reflect.Type
+}
+
+type routeEntry struct {
+	name   string
+	params interface{}
+}
+
+// NavigationKind identifies the operation that changed the pages in
+// a router.
+type NavigationKind int
+
+const (
+	NavigationPush NavigationKind = iota
+	NavigationPop
+	NavigationReplace
+)
+
+// NavigationEvent describes a change in the pages of a router.
+type NavigationEvent struct {
+	Kind   NavigationKind
+	From   string      // Route at the top of the stack before the change, if any.
+	To     string      // Route at the top of the stack after the change, if any.
+	Params interface{} // Parameters of the To route.
+}
+
+// NewRouter returns a router that shows its pages in view, which must
+// be a StackView from the QtQuick.Controls module. The view should be
+// initially empty.
+func NewRouter(view Object) *Router {
+	return &Router{
+		view:   view,
+		routes: make(map[string]*route),
+	}
+}
+
+// Handle registers the route name, which is shown by instantiating
+// component. If params is not nil, it must be a struct value or a pointer
+// to one, and parameters provided when navigating to the route are
+// converted into a new pointer to a value of the same type. Parameters
+// provided by QML logic as a JavaScript object have their properties
+// assigned to the struct fields with matching names, as if QML logic
+// was setting the fields of the value itself.
+func (r *Router) Handle(name string, component Object, params interface{}) {
+	rt := &route{component: component}
+	if params != nil {
+		rt.params = reflect.TypeOf(params)
+		for rt.params.Kind() == reflect.Ptr {
+			rt.params = rt.params.Elem()
+		}
+		if rt.params.Kind() != reflect.Struct {
+			panic(fmt.Sprintf("route %q parameters must be a struct, got %T", name, params))
+		}
+	}
+	gui(func() {
+		r.routes[name] = rt
+	})
+}
+
+// Push instantiates the component of the route name with the provided
+// parameters, and shows it on top of the current page.
+func (r *Router) Push(name string, params interface{}) error {
+	return r.navigate(NavigationPush, name, params)
+}
+
+// Replace instantiates the component of the route name with the provided
+// parameters, and shows it in place of the current page. If there are
+// no pages yet, it's shown as the first one.
+func (r *Router) Replace(name string, params interface{}) error {
+	return r.navigate(NavigationReplace, name, params)
+}
+
+// Pop removes the current page, showing again the one below it.
+// An error is returned if the current page is the last one.
+func (r *Router) Pop() error {
+	var err error
+	gui(func() {
+		if len(r.stack) < 2 {
+			err = fmt.Errorf("cannot pop the last page of the router")
+			return
+		}
+		from := r.stack[len(r.stack)-1]
+		r.view.Call("pop")
+		r.stack = r.stack[:len(r.stack)-1]
+		r.changed(NavigationPop, from.name)
+	})
+	return err
+}
+
+// OnNavigate arranges for f to be called whenever the pages of the
+// router change, whether by Go or QML logic. As with all signal
+// handlers, f is run within the main GUI thread.
+func (r *Router) OnNavigate(f func(event NavigationEvent)) {
+	gui(func() {
+		r.handlers = append(r.handlers, f)
+	})
+}
+
+func (r *Router) navigate(kind NavigationKind, name string, params interface{}) error {
+	var err error
+	gui(func() {
+		rt, ok := r.routes[name]
+		if !ok {
+			err = fmt.Errorf("router has no route named %q", name)
+			return
+		}
+		params, err = rt.convertParams(name, params)
+		if err != nil {
+			return
+		}
+		var from string
+		if n := len(r.stack); n > 0 {
+			from = r.stack[n-1].name
+		}
+		entry := routeEntry{name: name, params: params}
+		if kind == NavigationReplace && len(r.stack) > 0 {
+			r.view.Call("replace", rt.component)
+			r.stack[len(r.stack)-1] = entry
+		} else {
+			kind = NavigationPush
+			r.view.Call("push", rt.component)
+			r.stack = append(r.stack, entry)
+		}
+		r.changed(kind, from)
+	})
+	return err
+}
+
+// changed refreshes the exported fields after a change in the stack,
+// and notifies the registered handlers.
+func (r *Router) changed(kind NavigationKind, from string) {
+	var top routeEntry
+	if n := len(r.stack); n > 0 {
+		top = r.stack[n-1]
+	}
+	if r.Depth != len(r.stack) {
+		r.Depth = len(r.stack)
+		Changed(r, &r.Depth)
+	}
+	if r.Current != top.name {
+		r.Current = top.name
+		Changed(r, &r.Current)
+	}
+	r.Params = top.params
+	Changed(r, &r.Params)
+
+	event := NavigationEvent{Kind: kind, From: from, To: top.name, Params: top.params}
+	for _, f := range r.handlers {
+		f(event)
+	}
+}
+
+// convertParams converts params provided when navigating to the route
+// name into the parameters type of the route, if it has one.
+func (rt *route) convertParams(name string, params interface{}) (result interface{}, err error) {
+	if rt.params == nil {
+		return params, nil
+	}
+	ptrt := reflect.PtrTo(rt.params)
+	switch value := params.(type) {
+	case nil:
+		return reflect.New(rt.params).Interface(), nil
+	case map[string]interface{}:
+		v := reflect.New(rt.params)
+		if err := assignFields(v.Elem(), value); err != nil {
+			return nil, fmt.Errorf("cannot set parameters of route %q: %v", name, err)
+		}
+		return v.Interface(), nil
+	}
+	v := reflect.ValueOf(params)
+	switch v.Type() {
+	case ptrt:
+		return params, nil
+	case rt.params:
+		ptr := reflect.New(rt.params)
+		ptr.Elem().Set(v)
+		return ptr.Interface(), nil
+	}
+	return nil, fmt.Errorf("route %q takes parameters of type %s, got %T", name, ptrt, params)
+}
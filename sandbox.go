@@ -0,0 +1,138 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// Sandbox restricts what the QML content loaded by an engine may do,
+// so that applications may load third-party or remote QML content
+// with limited capabilities. The zero value restricts nothing.
+type Sandbox struct {
+	// DenyNetwork prevents QML content from loading documents, scripts,
+	// images, or any other resource from the network, and from making
+	// network requests via XMLHttpRequest. Resources compiled into the
+	// application (qrc:), data URLs, and image providers remain available.
+	DenyNetwork bool
+
+	// AllowedDirs, if not nil, restricts the local files QML content may
+	// read, whether as documents, scripts, images, or via XMLHttpRequest,
+	// to the ones within these directories. Files within the directory
+	// where Qt installs QML modules and within the directories listed in
+	// the QML2_IMPORT_PATH environment variable are always allowed.
+	AllowedDirs []string
+
+	// DenyXMLHttpRequest fails every request made by QML content via
+	// XMLHttpRequest, whatever its URL. Requests made by other types
+	// that load resources from the main thread, such as FontLoader and
+	// AnimatedImage, are failed as well.
+	DenyXMLHttpRequest bool
+}
+
+// sandboxes is accessed from the threads loading content for the
+// engines, so it's guarded by its own mutex instead of relying on
+// the main GUI thread.
+var sandboxes = struct {
+	sync.Mutex
+	m map[unsafe.Pointer]*Sandbox
+}{m: make(map[unsafe.Pointer]*Sandbox)}
+
+// SetSandbox restricts what the QML content loaded by the engine may do,
+// as defined by sandbox. A nil sandbox lifts all restrictions.
+//
+// The restrictions are enforced by the engine on every URL the content
+// refers to and on every request it makes, so they also apply to the
+// resources loaded by objects created from QML text at runtime, such as
+// via Qt.createQmlObject. The sandbox does not prevent the creation of
+// such objects, though, as QML compiles that text without involving the
+// engine, and lint hooks only see the documents and scripts loaded from
+// files, not text built at runtime.
+//
+// SetSandbox must be called before the engine loads any content, as the
+// network configuration of the engine cannot be changed afterwards.
+// The restrictions may be changed later with further calls.
+func (e *Engine) SetSandbox(sandbox *Sandbox) {
+	if sandbox != nil {
+		s := *sandbox
+		s.AllowedDirs = nil
+		for _, dir := range sandbox.AllowedDirs {
+			s.AllowedDirs = append(s.AllowedDirs, sandboxPath(dir))
+		}
+		if sandbox.AllowedDirs != nil && s.AllowedDirs == nil {
+			s.AllowedDirs = []string{}
+		}
+		sandbox = &s
+	}
+	sandboxes.Lock()
+	if sandbox == nil {
+		delete(sandboxes.m, e.addr)
+	} else {
+		sandboxes.m[e.addr] = sandbox
+	}
+	sandboxes.Unlock()
+	if sandbox != nil {
+		gui(func() {
+			C.engineInterceptUrls(e.addr)
+		})
+	}
+}
+
+// sandboxPath returns the absolute path of path with any symbolic links
+// resolved, so that paths may be compared reliably.
+func sandboxPath(path string) string {
+	if abspath, err := filepath.Abs(path); err == nil {
+		path = abspath
+	}
+	if realpath, err := filepath.EvalSymlinks(path); err == nil {
+		path = realpath
+	}
+	return filepath.Clean(path)
+}
+
+//export hookSandboxAllows
+func hookSandboxAllows(enginep unsafe.Pointer, curl *C.char, curlLen C.int, xhr C.int) C.int {
+	sandboxes.Lock()
+	sandbox := sandboxes.m[enginep]
+	sandboxes.Unlock()
+	if sandbox == nil {
+		return 1
+	}
+	if xhr != 0 && sandbox.DenyXMLHttpRequest {
+		return 0
+	}
+	u, err := url.Parse(C.GoStringN(curl, curlLen))
+	if err != nil {
+		return 0
+	}
+	switch u.Scheme {
+	case "qrc", "data", "image":
+		return 1
+	case "file":
+		if sandbox.AllowedDirs != nil && !sandbox.allowsFile(u.Path) {
+			return 0
+		}
+		return 1
+	}
+	if sandbox.DenyNetwork {
+		return 0
+	}
+	return 1
+}
+
+// allowsFile returns whether path is within the allowed directories.
+func (s *Sandbox) allowsFile(path string) bool {
+	path = sandboxPath(path)
+	for _, dir := range s.AllowedDirs {
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"os"
+	"strconv"
+)
+
+// ReleaseResources asks the scene graph of the window to release the
+// graphics resources it holds in caches, such as glyph caches and
+// textures of items that are not visible, to reduce memory usage. The
+// resources are recreated as needed, at some cost to rendering speed.
+func (win *Window) ReleaseResources() {
+	gui(func() {
+		C.windowReleaseResources(win.addr)
+	})
+}
+
+// ReleaseResources asks the scene graphs of all windows to release the
+// graphics resources held in caches, as done by Window.ReleaseResources.
+// It's meant as a response to the system warning that memory is low,
+// such as with OnMemoryWarning on iOS:
+//
+//     qml.OnMemoryWarning(qml.ReleaseResources)
+//
+func ReleaseResources() {
+	gui(func() {
+		C.applicationReleaseResources()
+	})
+}
+
+// SetPersistentResources defines whether the scene graph and graphics
+// context of the window are kept while the window is hidden. They are
+// kept by default, so showing the window again is fast. Devices with
+// tight memory budgets may release them instead.
+func (win *Window) SetPersistentResources(persistent bool) {
+	gui(func() {
+		C.windowSetPersistentResources(win.addr, cbool(persistent))
+	})
+}
+
+// TextureMemory returns an estimate of the texture memory in bytes used
+// by the items in the window, including images, layers, and shader
+// effect sources, accounted as four bytes per pixel. Images sharing the
+// same source and size are accounted once, as their texture is shared.
+// Memory held by the scene graph in caches and texture atlases is not
+// included, as it's not exposed by Qt.
+func (win *Window) TextureMemory() int64 {
+	var bytes C.int64_t
+	gui(func() {
+		bytes = C.windowTextureMemory(win.addr)
+	})
+	return int64(bytes)
+}
+
+// SetTextureAtlasSize defines the size in pixels of the texture atlases
+// in which the scene graph packs small images. Smaller atlases waste less
+// memory on devices with tight budgets, while larger ones allow more
+// images to be batched together. A zero value restores the default, which
+// depends on the graphics hardware.
+//
+// SetTextureAtlasSize must be called before any window is shown.
+func SetTextureAtlasSize(width, height int) {
+	setEnvInt("QSG_ATLAS_WIDTH", width)
+	setEnvInt("QSG_ATLAS_HEIGHT", height)
+}
+
+func setEnvInt(name string, value int) {
+	if value > 0 {
+		os.Setenv(name, strconv.Itoa(value))
+	} else {
+		os.Unsetenv(name)
+	}
+}
@@ -0,0 +1,82 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"image"
+)
+
+// Screen describes a monitor connected to the system.
+type Screen struct {
+	Index      int             // Position in the list returned by Screens.
+	Name       string          // Platform name, such as "HDMI-1".
+	Geometry   image.Rectangle // Area in the virtual desktop, in device-independent pixels.
+	PixelRatio float64         // Ratio between physical and device-independent pixels.
+	Primary    bool
+}
+
+// Screens returns the monitors currently connected to the system.
+func Screens() []Screen {
+	var screens []Screen
+	gui(func() {
+		n := int(C.screenCount())
+		screens = make([]Screen, n)
+		for i := 0; i < n; i++ {
+			var x, y, width, height, primary C.int
+			var pixelRatio C.double
+			name := cstringResult(C.screenInfo(C.int(i), &x, &y, &width, &height, &pixelRatio, &primary))
+			screens[i] = Screen{
+				Index:      i,
+				Name:       name,
+				Geometry:   image.Rect(int(x), int(y), int(x+width), int(y+height)),
+				PixelRatio: float64(pixelRatio),
+				Primary:    primary != 0,
+			}
+		}
+	})
+	return screens
+}
+
+// SetFullScreenOn moves the window to the screen with the provided
+// index, as returned by Screens, and shows it in full screen mode there.
+func (win *Window) SetFullScreenOn(screen int) error {
+	var cerr *C.error
+	gui(func() {
+		cerr = C.windowSetFullScreenOn(win.addr, C.int(screen))
+	})
+	if cerr != nil {
+		return cerror(cerr)
+	}
+	return nil
+}
+
+// EnterKiosk turns the window into a kiosk covering the screen with
+// the provided index, as commonly done in digital signage and
+// self-service terminals. The window is made frameless, kept above
+// other windows, and shown in full screen mode. The mouse cursor is
+// hidden, and the keyboard is grabbed so that system shortcuts are
+// delivered to the window where the platform allows it.
+//
+// The previous window state is restored by ExitKiosk.
+func (win *Window) EnterKiosk(screen int) error {
+	var cerr *C.error
+	gui(func() {
+		cerr = C.windowEnterKiosk(win.addr, C.int(screen))
+	})
+	if cerr != nil {
+		return cerror(cerr)
+	}
+	return nil
+}
+
+// ExitKiosk restores the window and the mouse cursor to the state they
+// were in before EnterKiosk was called, and releases the keyboard.
+//
+// It is safe to call ExitKiosk more than once.
+func (win *Window) ExitKiosk() {
+	gui(func() {
+		C.windowExitKiosk(win.addr)
+	})
+}
@@ -0,0 +1,148 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"time"
+	"unsafe"
+)
+
+// ScreenCapture periodically grabs the content of the desktop or of a
+// native window, and delivers the captured frames both to Go functions
+// registered with OnFrame and to QML via an image provider.
+//
+// For example, to show the desktop within a QML scene:
+//
+//     capture := qml.NewScreenCapture(0)
+//     capture.Provide(engine, "screen")
+//     capture.Start(15)
+//     engine.Context().SetVar("capture", capture)
+//
+// and in QML:
+//
+//     Image {
+//         cache: false
+//         source: "image://screen/" + capture.frame
+//     }
+//
+// Capturing other windows and the desktop is not possible on some
+// platforms, such as with Wayland compositors, in which case the
+// captured frames are empty.
+type ScreenCapture struct {
+	// Frame is incremented whenever a new frame is captured, so
+	// bindings depending on it are reevaluated.
+	Frame int
+
+	addr     unsafe.Pointer
+	handlers []func(frame image.Image)
+}
+
+var screenCaptures = make(map[*ScreenCapture]bool)
+
+// NewScreenCapture returns a new capture of the native window with the
+// provided platform identifier, or of the primary screen if window is zero.
+// Capturing starts when the Start method is called.
+//
+// The Destroy method must be called to release the capture.
+func NewScreenCapture(window uintptr) *ScreenCapture {
+	capture := &ScreenCapture{}
+	gui(func() {
+		capture.addr = C.newScreenCapture(unsafe.Pointer(capture), C.uintptr_t(window))
+		screenCaptures[capture] = true
+	})
+	return capture
+}
+
+// SetRegion restricts the capture to the provided region, in the
+// coordinates of the captured window or screen. The zero rectangle
+// captures the whole window or screen.
+func (capture *ScreenCapture) SetRegion(r image.Rectangle) {
+	if r.Empty() {
+		r = image.Rect(0, 0, -1, -1)
+	}
+	gui(func() {
+		C.screenCaptureSetRegion(capture.addr, C.int(r.Min.X), C.int(r.Min.Y), C.int(r.Dx()), C.int(r.Dy()))
+	})
+}
+
+// Start starts capturing frames at the provided rate, in frames
+// per second. The first frame is captured immediately.
+func (capture *ScreenCapture) Start(fps float64) {
+	if fps <= 0 {
+		panic(fmt.Sprintf("invalid frame rate: %v", fps))
+	}
+	interval := time.Duration(float64(time.Second) / fps)
+	gui(func() {
+		C.screenCaptureStart(capture.addr, C.int(interval/time.Millisecond))
+	})
+}
+
+// Stop stops capturing frames. The last captured frame remains
+// available to QML.
+func (capture *ScreenCapture) Stop() {
+	gui(func() {
+		C.screenCaptureStop(capture.addr)
+	})
+}
+
+// OnFrame arranges for f to be called with every captured frame.
+// Each frame is copied into a newly allocated image, so f may hold
+// on to it after returning. As with signal handlers, f is run within
+// the main GUI thread.
+func (capture *ScreenCapture) OnFrame(f func(frame image.Image)) {
+	gui(func() {
+		capture.handlers = append(capture.handlers, f)
+	})
+}
+
+// Provide registers an image provider in engine with the provided
+// identifier, that serves the last captured frame for any image
+// requested from it. Frames are served without being copied into Go.
+// It is a runtime error to register the same provider identifier
+// multiple times.
+func (capture *ScreenCapture) Provide(engine *Engine, prvId string) {
+	if _, ok := engine.imageProviders[prvId]; ok {
+		panic(fmt.Sprintf("engine already has an image provider with id %q", prvId))
+	}
+	engine.imageProviders[prvId] = nil
+	cprvId, cprvIdLen := unsafeStringData(prvId)
+	gui(func() {
+		qprvId := C.newString(cprvId, cprvIdLen)
+		defer C.delString(qprvId)
+		C.engineAddScreenCaptureProvider(engine.addr, qprvId, capture.addr)
+	})
+}
+
+// Destroy stops capturing frames and releases the capture. Image
+// providers registered with Provide serve empty images afterwards.
+//
+// It is safe to call Destroy more than once.
+func (capture *ScreenCapture) Destroy() {
+	gui(func() {
+		if capture.addr != nilPtr {
+			C.delScreenCapture(capture.addr)
+			capture.addr = nilPtr
+			delete(screenCaptures, capture)
+		}
+	})
+}
+
+//export hookScreenCaptured
+func hookScreenCaptured(capturep unsafe.Pointer) {
+	capture := (*ScreenCapture)(capturep)
+	capture.Frame++
+	Changed(capture, &capture.Frame)
+	if len(capture.handlers) == 0 {
+		return
+	}
+	cimage := C.screenCaptureImage(capture.addr)
+	defer C.delImage(cimage)
+	frame := unpackImage(cimage)
+	for _, f := range capture.handlers {
+		f(frame)
+	}
+}
@@ -0,0 +1,195 @@
+package qml
+
+// #include <stdlib.h>
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"errors"
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+// Position holds a geographic position reported by a PositionSource.
+type Position struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  float64 // NaN if unknown.
+	Time      time.Time
+}
+
+// PositionSource delivers position updates from the default
+// positioning backend of the platform (GPS, network, etc).
+type PositionSource struct {
+	addr unsafe.Pointer
+	f    func(pos Position)
+}
+
+var positionSources = make(map[*PositionSource]bool)
+
+// NewPositionSource returns a position source that calls f with every
+// position update received after Start is called. As with signal
+// handlers, f is run within the main GUI thread.
+//
+// NewPositionSource returns an error if the platform offers no
+// positioning backend, or if the package was built without the
+// qmlpositioning build tag, which requires the Qt Positioning module.
+// The Destroy method must be called to release the source.
+func NewPositionSource(f func(pos Position)) (*PositionSource, error) {
+	source := &PositionSource{f: f}
+	gui(func() {
+		source.addr = C.newPositionSource(unsafe.Pointer(&source.f))
+		if source.addr != nilPtr {
+			positionSources[source] = true
+		}
+	})
+	if source.addr == nilPtr {
+		return nil, errors.New("no positioning backend available")
+	}
+	return source, nil
+}
+
+// Start starts delivering position updates, at most once per interval.
+// An interval of zero lets the backend pick its own update frequency.
+// Start does nothing once the source is destroyed.
+func (source *PositionSource) Start(interval time.Duration) {
+	gui(func() {
+		if source.addr != nilPtr {
+			C.positionSourceStart(source.addr, C.int(interval/time.Millisecond))
+		}
+	})
+}
+
+// Stop stops delivering position updates.
+// Stop does nothing once the source is destroyed.
+func (source *PositionSource) Stop() {
+	gui(func() {
+		if source.addr != nilPtr {
+			C.positionSourceStop(source.addr)
+		}
+	})
+}
+
+// Destroy stops the source and releases any resources used.
+//
+// It is safe to call Destroy more than once.
+func (source *PositionSource) Destroy() {
+	gui(func() {
+		if source.addr != nilPtr {
+			C.delPositionSource(source.addr)
+			source.addr = nilPtr
+			delete(positionSources, source)
+		}
+	})
+}
+
+//export hookPositionUpdated
+func hookPositionUpdated(funcp unsafe.Pointer, latitude, longitude, altitude C.double, timestamp C.int64_t) {
+	f := *(*func(pos Position))(funcp)
+	msecs := int64(timestamp)
+	f(Position{
+		Latitude:  float64(latitude),
+		Longitude: float64(longitude),
+		Altitude:  float64(altitude),
+		Time:      time.Unix(msecs/1000, (msecs%1000)*int64(time.Millisecond)),
+	})
+}
+
+// Sensor types supported by NewSensor. Other types known to the
+// QtSensors module may be provided as well.
+const (
+	Accelerometer   = "QAccelerometer"
+	Compass         = "QCompass"
+	Gyroscope       = "QGyroscope"
+	Magnetometer    = "QMagnetometer"
+	LightSensor     = "QLightSensor"
+	ProximitySensor = "QProximitySensor"
+	RotationSensor  = "QRotationSensor"
+)
+
+// Sensor delivers readings from a hardware sensor.
+type Sensor struct {
+	addr unsafe.Pointer
+	f    func(values []float64)
+}
+
+var sensors = make(map[*Sensor]bool)
+
+// NewSensor returns a sensor of the given type that calls f with the
+// values of every reading taken after Start is called. The meaning of
+// each value depends on the sensor type. For example, an Accelerometer
+// reports the x, y, and z acceleration in m/s^2, while a Compass reports
+// the azimuth in degrees followed by the calibration level.
+//
+// The values slice must not be used after f returns. As with signal
+// handlers, f is run within the main GUI thread.
+//
+// NewSensor returns an error if no backend is available for the given
+// sensor type, or if the package was built without the qmlsensors build
+// tag, which requires the Qt Sensors module. The Destroy method must be
+// called to release the sensor.
+func NewSensor(sensorType string, f func(values []float64)) (*Sensor, error) {
+	sensor := &Sensor{f: f}
+	ctype := C.CString(sensorType)
+	defer C.free(unsafe.Pointer(ctype))
+	gui(func() {
+		sensor.addr = C.newSensor(ctype, unsafe.Pointer(&sensor.f))
+		if sensor.addr != nilPtr {
+			sensors[sensor] = true
+		}
+	})
+	if sensor.addr == nilPtr {
+		return nil, errors.New("no backend available for sensor type " + sensorType)
+	}
+	return sensor, nil
+}
+
+// Start starts taking readings from the sensor.
+// Start returns an error if the sensor was destroyed.
+func (sensor *Sensor) Start() error {
+	var err error
+	gui(func() {
+		if sensor.addr == nilPtr {
+			err = errors.New("sensor was destroyed")
+		} else if C.sensorStart(sensor.addr) == 0 {
+			err = errors.New("cannot start sensor")
+		}
+	})
+	return err
+}
+
+// Stop stops taking readings from the sensor.
+// Stop does nothing once the sensor is destroyed.
+func (sensor *Sensor) Stop() {
+	gui(func() {
+		if sensor.addr != nilPtr {
+			C.sensorStop(sensor.addr)
+		}
+	})
+}
+
+// Destroy stops the sensor and releases any resources used.
+//
+// It is safe to call Destroy more than once.
+func (sensor *Sensor) Destroy() {
+	gui(func() {
+		if sensor.addr != nilPtr {
+			C.delSensor(sensor.addr)
+			sensor.addr = nilPtr
+			delete(sensors, sensor)
+		}
+	})
+}
+
+//export hookSensorReading
+func hookSensorReading(funcp unsafe.Pointer, values *C.double, valuesLen C.int) {
+	f := *(*func(values []float64))(funcp)
+	var slice []float64
+	sliceh := (*reflect.SliceHeader)(unsafe.Pointer(&slice))
+	sliceh.Data = uintptr(unsafe.Pointer(values))
+	sliceh.Len = int(valuesLen)
+	sliceh.Cap = int(valuesLen)
+	f(slice)
+}
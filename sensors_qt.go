@@ -0,0 +1,7 @@
+// +build qmlsensors
+
+package qml
+
+// #cgo pkg-config: Qt5Sensors
+//
+import "C"
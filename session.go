@@ -0,0 +1,190 @@
+package qml
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Session persists the geometry of windows and other user interface
+// state across runs of the application. For example:
+//
+//     session, err := qml.OpenSession(filepath.Join(configDir, "session.json"))
+//     ...
+//     session.Track(win)
+//     session.AddState("sidebar", saveSidebar, restoreSidebar)
+//     win.Show()
+//     win.Wait()
+//     session.Save()
+//
+// Windows are identified by their objectName, and their geometry is
+// saved automatically whenever they are hidden.
+type Session struct {
+	mu      sync.Mutex
+	path    string
+	data    sessionData
+	windows map[string]*Window
+	savers  map[string]func() []byte
+}
+
+type sessionData struct {
+	Windows map[string]*windowGeometry `json:"windows"`
+	State   map[string][]byte          `json:"state"`
+}
+
+type windowGeometry struct {
+	X          int `json:"x"`
+	Y          int `json:"y"`
+	Width      int `json:"width"`
+	Height     int `json:"height"`
+	Visibility int `json:"visibility"`
+}
+
+// Window visibility values, as defined by QWindow.
+const (
+	windowed  = 2
+	minimized = 3
+)
+
+// OpenSession returns a session that persists state into the file at
+// path. If the file exists, the state previously saved in it is loaded
+// and restored as windows and state are registered with the session.
+func OpenSession(path string) (*Session, error) {
+	s := &Session{
+		path:    path,
+		windows: make(map[string]*Window),
+		savers:  make(map[string]func() []byte),
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &s.data); err != nil {
+			return nil, errors.New("cannot parse session file " + path + ": " + err.Error())
+		}
+	}
+	if s.data.Windows == nil {
+		s.data.Windows = make(map[string]*windowGeometry)
+	}
+	if s.data.State == nil {
+		s.data.State = make(map[string][]byte)
+	}
+	return s, nil
+}
+
+// Track restores the geometry previously saved for win, if any, and
+// keeps track of its geometry from then on. Windows are identified by
+// their objectName, or by the objectName of their root object if the
+// window itself has none.
+func (s *Session) Track(win *Window) error {
+	name := win.String("objectName")
+	if name == "" {
+		name = win.Root().String("objectName")
+	}
+	if name == "" {
+		return errors.New("cannot track window without an objectName")
+	}
+	s.mu.Lock()
+	geom := s.data.Windows[name]
+	s.windows[name] = win
+	s.mu.Unlock()
+
+	gui(func() {
+		if geom != nil {
+			win.Set("x", geom.X)
+			win.Set("y", geom.Y)
+			win.Set("width", geom.Width)
+			win.Set("height", geom.Height)
+			if geom.Visibility > windowed && geom.Visibility != minimized && win.Bool("visible") {
+				win.Set("visibility", geom.Visibility)
+			}
+		}
+		win.On("visibleChanged", func(visible bool) {
+			if !visible {
+				s.Save()
+			}
+		})
+	})
+	return nil
+}
+
+// AddState registers a named piece of user interface state managed by
+// the application. If state was previously saved under name, restore is
+// called with it right away. The save function is called whenever the
+// session is saved, and its result is persisted under name.
+func (s *Session) AddState(name string, save func() []byte, restore func(state []byte)) {
+	s.mu.Lock()
+	state, ok := s.data.State[name]
+	s.savers[name] = save
+	s.mu.Unlock()
+	if ok && restore != nil {
+		restore(state)
+	}
+}
+
+// Save writes the state of the session into its file.
+// It is called automatically whenever a tracked window is hidden.
+func (s *Session) Save() error {
+	s.mu.Lock()
+	windows := make(map[string]*Window, len(s.windows))
+	for name, win := range s.windows {
+		windows[name] = win
+	}
+	savers := make(map[string]func() []byte, len(s.savers))
+	for name, save := range s.savers {
+		savers[name] = save
+	}
+	old := make(map[string]*windowGeometry, len(s.data.Windows))
+	for name, geom := range s.data.Windows {
+		old[name] = geom
+	}
+	s.mu.Unlock()
+
+	geoms := make(map[string]*windowGeometry)
+	gui(func() {
+		for name, win := range windows {
+			if win.addr == nilPtr {
+				continue
+			}
+			geom := &windowGeometry{Visibility: win.Int("visibility")}
+			if geom.Visibility == windowed || geom.Visibility == 0 {
+				geom.X, geom.Y = win.Int("x"), win.Int("y")
+				geom.Width, geom.Height = win.Int("width"), win.Int("height")
+			} else if old := old[name]; old != nil {
+				// Keep the normal geometry of maximized and
+				// full screen windows.
+				geom.X, geom.Y, geom.Width, geom.Height = old.X, old.Y, old.Width, old.Height
+			}
+			geoms[name] = geom
+		}
+	})
+	states := make(map[string][]byte)
+	for name, save := range savers {
+		states[name] = save()
+	}
+
+	s.mu.Lock()
+	for name, geom := range geoms {
+		s.data.Windows[name] = geom
+	}
+	for name, state := range states {
+		s.data.State[name] = state
+	}
+	data, err := json.MarshalIndent(&s.data, "", "\t")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
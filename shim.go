@@ -0,0 +1,107 @@
+package qml
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// TypeShim holds static accessors for the fields and methods of a Go
+// type, which are used instead of reflection when QML logic reads or
+// writes fields or calls methods of values of that type.
+//
+// Shims are normally not written by hand. The qmlbind tool generates
+// them from the Go type definitions, and reports at build time any
+// members that cannot be handed to QML:
+//
+//     //go:generate qmlbind -type Person,Account
+//
+// Members without an accessor in the shim continue to be handled via
+// reflection.
+type TypeShim struct {
+	Fields  []FieldShim
+	Methods []MethodShim
+}
+
+// FieldShim holds static accessors for a struct field.
+type FieldShim struct {
+	Name string
+
+	// Get returns the field value of the provided Go value.
+	Get func(value interface{}) interface{}
+
+	// Set assigns the field value of the provided Go value.
+	Set func(value interface{}, assign interface{})
+}
+
+// MethodShim holds a static caller for a method.
+type MethodShim struct {
+	Name  string
+	NumIn int
+
+	// Call calls the method of the provided Go value with args, and
+	// returns its result, or nil if it has none.
+	Call func(value interface{}, args []interface{}) interface{}
+}
+
+// typeShim holds the accessors of a TypeShim indexed as the respective
+// members are indexed by reflection.
+type typeShim struct {
+	getters []func(value interface{}) interface{}
+	setters []func(value interface{}, assign interface{})
+	methods []*MethodShim
+}
+
+var typeShims struct {
+	sync.Mutex
+	m map[reflect.Type]*typeShim
+}
+
+// RegisterShim registers shim to be used with Go values of the same
+// type as sample, which must be a pointer to a struct.
+//
+// RegisterShim panics if the shim refers to members that sample does
+// not have, which happens when the shim is stale. Unlike most functions
+// in this package, RegisterShim may be called before Init, so that
+// generated shims are registered on package initialization.
+func RegisterShim(sample interface{}, shim *TypeShim) {
+	ptrt := reflect.TypeOf(sample)
+	if ptrt == nil || ptrt.Kind() != reflect.Ptr || ptrt.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("cannot register shim for %T; must be a pointer to a struct", sample))
+	}
+	vt := ptrt.Elem()
+	s := &typeShim{
+		getters: make([]func(value interface{}) interface{}, vt.NumField()),
+		setters: make([]func(value interface{}, assign interface{}), vt.NumField()),
+		methods: make([]*MethodShim, ptrt.NumMethod()),
+	}
+	for _, field := range shim.Fields {
+		f, ok := vt.FieldByName(field.Name)
+		if !ok || len(f.Index) != 1 {
+			panic(fmt.Sprintf("shim for %s refers to unknown field %s; regenerate it", vt.Name(), field.Name))
+		}
+		s.getters[f.Index[0]] = field.Get
+		s.setters[f.Index[0]] = field.Set
+	}
+	for i := range shim.Methods {
+		method := &shim.Methods[i]
+		m, ok := ptrt.MethodByName(method.Name)
+		if !ok || m.Type.NumIn()-1 != method.NumIn {
+			panic(fmt.Sprintf("shim for %s refers to unknown method %s; regenerate it", vt.Name(), method.Name))
+		}
+		s.methods[m.Index] = method
+	}
+	typeShims.Lock()
+	if typeShims.m == nil {
+		typeShims.m = make(map[reflect.Type]*typeShim)
+	}
+	typeShims.m[ptrt] = s
+	typeShims.Unlock()
+}
+
+// shimFor returns the shim registered for the type of gvalue, or nil.
+func shimFor(gvalue interface{}) *typeShim {
+	typeShims.Lock()
+	defer typeShims.Unlock()
+	return typeShims.m[reflect.TypeOf(gvalue)]
+}
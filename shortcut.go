@@ -0,0 +1,93 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Shortcut describes a keyboard shortcut declared in QML logic by a
+// Shortcut element or by a Qt Quick Controls 2 Action element.
+type Shortcut struct {
+	Object Object
+
+	// Name holds the objectName of the element, which identifies
+	// the shortcut when remapping it.
+	Name string
+
+	// Sequence holds the key sequence in portable text form,
+	// such as "Ctrl+S".
+	Sequence string
+}
+
+// Shortcuts returns all keyboard shortcuts declared by root and its
+// descendants. For example, a user-configurable key bindings screen
+// may be built by listing the shortcuts, and the changes made by the
+// user applied with RemapShortcuts.
+func Shortcuts(root Object) []Shortcut {
+	common := root.Common()
+	var shortcuts []Shortcut
+	gui(func() {
+		var dvalue C.DataValue
+		C.objectFindShortcuts(common.addr, &dvalue)
+		list := unpackDataValue(&dvalue, common.engine).(*List)
+		for _, value := range list.data {
+			obj := value.(Object)
+			shortcuts = append(shortcuts, Shortcut{
+				Object:   obj,
+				Name:     obj.String("objectName"),
+				Sequence: cstringResult(C.shortcutSequence(obj.Common().addr)),
+			})
+		}
+	})
+	return shortcuts
+}
+
+// ShortcutMap returns the key sequences of all shortcuts declared by
+// root and its descendants that have an objectName, keyed by it. The
+// map is suitable for persisting the user-configured key bindings and
+// restoring them later with RemapShortcuts.
+func ShortcutMap(root Object) map[string]string {
+	m := make(map[string]string)
+	for _, shortcut := range Shortcuts(root) {
+		if shortcut.Name != "" {
+			m[shortcut.Name] = shortcut.Sequence
+		}
+	}
+	return m
+}
+
+// RemapShortcuts changes the key sequences of the shortcuts declared by
+// root and its descendants, with sequences mapping shortcut names to the
+// new key sequences in portable text form, such as "Ctrl+Shift+S".
+// All known shortcuts are changed even if some names are not found, in
+// which case an error naming them is returned.
+func RemapShortcuts(root Object, sequences map[string]string) error {
+	found := make(map[string]bool)
+	gui(func() {
+		for _, shortcut := range Shortcuts(root) {
+			seq, ok := sequences[shortcut.Name]
+			if !ok || shortcut.Name == "" {
+				continue
+			}
+			cseq, cseqLen := unsafeStringData(seq)
+			C.shortcutSetSequence(shortcut.Object.Common().addr, cseq, cseqLen)
+			found[shortcut.Name] = true
+		}
+	})
+	var missing []string
+	for name := range sequences {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("cannot find shortcuts: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
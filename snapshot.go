@@ -0,0 +1,163 @@
+package qml
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Snapshot captures the state of value, which must be a pointer to a
+// struct handed to QML logic, so that it may be restored later with
+// Restore, such as for undo checkpoints or crash recovery. The state
+// includes all exported fields seen by QML logic, and the state of the
+// structs they point to, recursively. Fields holding QML objects,
+// functions, channels, or interfaces are not part of the state.
+//
+// The state is encoded as JSON, with fields named as in Go.
+func Snapshot(value interface{}) ([]byte, error) {
+	v := snapshotTarget(value, "Snapshot")
+	var state map[string]interface{}
+	gui(func() {
+		state = captureStruct(v.Elem(), make(map[uintptr]bool))
+	})
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("cannot snapshot %s: %v", v.Type(), err)
+	}
+	return data, nil
+}
+
+// Restore restores into value the state captured by Snapshot. Fields
+// that change are reported with Changed, so that bindings depending
+// on them are refreshed. Fields missing from data are left unchanged.
+func Restore(value interface{}, data []byte) error {
+	v := snapshotTarget(value, "Restore")
+	var state map[string]json.RawMessage
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("cannot restore %s: %v", v.Type(), err)
+	}
+	var err error
+	gui(func() {
+		err = restoreStruct(v, state, v.Elem().Type().Name())
+	})
+	return err
+}
+
+func snapshotTarget(value interface{}, funcName string) reflect.Value {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("%s must be given a non-nil pointer to a struct, got %T", funcName, value))
+	}
+	return v
+}
+
+// snapshotKind classifies the type of a field for Snapshot and Restore.
+type snapshotKind int
+
+const (
+	snapshotSkip   snapshotKind = iota // Not part of the state.
+	snapshotValue                      // Encoded as a plain JSON value.
+	snapshotStruct                     // A struct, encoded recursively.
+	snapshotPtr                        // A pointer to a struct, encoded recursively.
+)
+
+func snapshotKindOf(t reflect.Type) snapshotKind {
+	if t == typeFunc || t == typePromise || t.Implements(typeObject) {
+		return snapshotSkip
+	}
+	if t.Implements(typeTextMarshaler) || reflect.PtrTo(t).Implements(typeTextMarshaler) {
+		return snapshotValue
+	}
+	switch t.Kind() {
+	case reflect.Func, reflect.Chan, reflect.Interface, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return snapshotSkip
+	case reflect.Struct:
+		return snapshotStruct
+	case reflect.Ptr:
+		if t.Elem().Kind() == reflect.Struct && !t.Implements(typeTextMarshaler) {
+			return snapshotPtr
+		}
+		return snapshotKindOf(t.Elem())
+	}
+	return snapshotValue
+}
+
+func captureStruct(v reflect.Value, seen map[uintptr]bool) map[string]interface{} {
+	state := make(map[string]interface{})
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // not exported
+		}
+		fv := v.Field(i)
+		switch snapshotKindOf(field.Type) {
+		case snapshotValue:
+			state[field.Name] = fv.Interface()
+		case snapshotStruct:
+			state[field.Name] = captureStruct(fv, seen)
+		case snapshotPtr:
+			if fv.IsNil() {
+				state[field.Name] = nil
+			} else if !seen[fv.Pointer()] {
+				// Values reachable via several paths are captured once.
+				seen[fv.Pointer()] = true
+				state[field.Name] = captureStruct(fv.Elem(), seen)
+			}
+		}
+	}
+	return state
+}
+
+// restoreStruct restores state into the struct pointed to by ptr,
+// which is reachable via path.
+func restoreStruct(ptr reflect.Value, state map[string]json.RawMessage, path string) error {
+	v := ptr.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		raw, ok := state[field.Name]
+		if field.PkgPath != "" || !ok {
+			continue
+		}
+		fpath := path + "." + field.Name
+		fv := v.Field(i)
+		switch kind := snapshotKindOf(field.Type); kind {
+		case snapshotValue:
+			nv := reflect.New(field.Type)
+			if err := json.Unmarshal(raw, nv.Interface()); err != nil {
+				return fmt.Errorf("cannot restore %s: %v", fpath, err)
+			}
+			if !reflect.DeepEqual(fv.Interface(), nv.Elem().Interface()) {
+				fv.Set(nv.Elem())
+				Changed(ptr.Interface(), fv.Addr().Interface())
+			}
+		case snapshotStruct, snapshotPtr:
+			var fstate map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &fstate); err != nil {
+				return fmt.Errorf("cannot restore %s: %v", fpath, err)
+			}
+			if kind == snapshotStruct {
+				if err := restoreStruct(fv.Addr(), fstate, fpath); err != nil {
+					return err
+				}
+				continue
+			}
+			if fstate == nil {
+				if !fv.IsNil() {
+					fv.Set(reflect.Zero(field.Type))
+					Changed(ptr.Interface(), fv.Addr().Interface())
+				}
+				continue
+			}
+			if fv.IsNil() {
+				fv.Set(reflect.New(field.Type.Elem()))
+				Changed(ptr.Interface(), fv.Addr().Interface())
+			}
+			if err := restoreStruct(fv, fstate, fpath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
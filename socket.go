@@ -0,0 +1,175 @@
+package qml
+
+// SocketConn is a message-oriented connection, such as a WebSocket,
+// as used by the GoSocket QML element registered by RegisterSocket.
+// It is easily implemented on top of any WebSocket package.
+type SocketConn interface {
+	// ReadMessage blocks until a message is received.
+	ReadMessage() ([]byte, error)
+	// WriteMessage sends a message.
+	WriteMessage(data []byte) error
+	// Close closes the connection, interrupting any pending calls.
+	Close() error
+}
+
+// SocketDialer opens a new connection to url. It is responsible for
+// any TLS configuration and authentication required by the server.
+type SocketDialer func(url string) (SocketConn, error)
+
+// SocketStatus holds the connection status of a Socket.
+type SocketStatus int
+
+const (
+	SocketClosed SocketStatus = iota
+	SocketConnecting
+	SocketOpen
+)
+
+// Socket is the Go value behind the GoSocket QML element registered by
+// RegisterSocket.
+type Socket struct {
+	// Url holds the address the socket connects to.
+	Url string
+
+	// Status holds the connection status as a SocketStatus.
+	Status int
+
+	// Message holds the last message received. QML logic observes
+	// every message as a change of this field, even if repeated.
+	Message string
+
+	// Error holds the error that caused the last connection to close,
+	// or is empty if it was closed normally.
+	Error string
+
+	dial    SocketDialer
+	conn    SocketConn
+	attempt int
+}
+
+// RegisterSocket registers a GoSocket element for use by QML code,
+// which offers a message-oriented connection opened by the dial function.
+// This allows QML logic to use WebSockets while TLS, authentication and
+// the WebSocket implementation itself are controlled by Go code. The
+// element is available under the provided location and major.minor
+// version numbers, as with RegisterTypes.
+//
+// For example, after registering a socket under "GoExtensions" 1.0:
+//
+//     import GoExtensions 1.0
+//
+//     GoSocket {
+//         id: socket
+//         url: "wss://chat.example.com/"
+//         onMessageChanged: console.log("Received:", message)
+//         Component.onCompleted: socket.connect()
+//     }
+//
+// and then socket.send("Hello!") sends a message.
+func RegisterSocket(location string, major, minor int, dial SocketDialer) {
+	RegisterTypes(location, major, minor, []TypeSpec{{
+		Name: "GoSocket",
+		New:  func() interface{} { return &Socket{dial: dial} },
+	}})
+}
+
+// Connect opens a new connection to the socket URL, closing any
+// existing connection first. The connection is opened in the
+// background, and the Status field is updated when it completes.
+func (s *Socket) Connect() {
+	var url string
+	var attempt int
+	gui(func() {
+		s.Close()
+		s.attempt++
+		url, attempt = s.Url, s.attempt
+		s.setStatus(SocketConnecting, "")
+	})
+	go func() {
+		conn, err := s.dial(url)
+		gui(func() {
+			if s.attempt != attempt || s.Status != int(SocketConnecting) {
+				// Closed or reconnected in the meantime.
+				if conn != nil {
+					conn.Close()
+				}
+				return
+			}
+			if err != nil {
+				s.setStatus(SocketClosed, err.Error())
+				return
+			}
+			s.conn = conn
+			s.setStatus(SocketOpen, "")
+			go s.read(conn)
+		})
+	}()
+}
+
+func (s *Socket) read(conn SocketConn) {
+	for {
+		data, err := conn.ReadMessage()
+		stop := false
+		gui(func() {
+			if s.conn != conn {
+				stop = true
+				return
+			}
+			if err != nil {
+				conn.Close()
+				s.conn = nil
+				s.setStatus(SocketClosed, err.Error())
+				stop = true
+				return
+			}
+			s.Message = string(data)
+			Changed(s, &s.Message)
+		})
+		if stop {
+			return
+		}
+	}
+}
+
+// Send sends message over the open connection.
+// It returns false if the socket is not open or the message could not
+// be sent.
+func (s *Socket) Send(message string) (ok bool) {
+	gui(func() {
+		if s.conn == nil {
+			return
+		}
+		if err := s.conn.WriteMessage([]byte(message)); err != nil {
+			s.conn.Close()
+			s.conn = nil
+			s.setStatus(SocketClosed, err.Error())
+			return
+		}
+		ok = true
+	})
+	return ok
+}
+
+// Close closes the connection, if any.
+//
+// It is safe to call Close more than once.
+func (s *Socket) Close() {
+	gui(func() {
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+		if s.Status != int(SocketClosed) {
+			s.setStatus(SocketClosed, "")
+		}
+	})
+}
+
+func (s *Socket) setStatus(status SocketStatus, err string) {
+	s.Status = int(status)
+	Changed(s, &s.Status)
+	if s.Error != err {
+		s.Error = err
+		Changed(s, &s.Error)
+	}
+}
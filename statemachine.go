@@ -0,0 +1,112 @@
+package qml
+
+import (
+	"fmt"
+)
+
+// StateMachine drives the states of a QML item from Go logic. States
+// are the ones declared in the states property of the item, and
+// transitions between them are defined in Go with AddTransition and
+// triggered with Fire, so that the item's QML transitions animate the
+// changes made by application logic. For example:
+//
+//     machine := qml.NewStateMachine(root.ObjectByName("door"))
+//     machine.AddTransition("closed", "open", "opened")
+//     machine.AddTransition("opened", "close", "closed")
+//     machine.OnStateChanged(func(from, to string) { log.Printf("door %s", to) })
+//     machine.Fire("open")
+//
+// State machines managed by other Go packages may instead be mirrored
+// into the item by calling SetState whenever their state changes.
+type StateMachine struct {
+	target      Object
+	state       string
+	transitions map[stateEvent]string
+	handlers    []func(from, to string)
+}
+
+type stateEvent struct {
+	state, event string
+}
+
+// AnyState may be used as the source state of a transition to have
+// the event accepted in all states.
+const AnyState = "*"
+
+// NewStateMachine returns a state machine driving the state property
+// of target, which starts in the current state of target.
+func NewStateMachine(target Object) *StateMachine {
+	m := &StateMachine{
+		target:      target,
+		transitions: make(map[stateEvent]string),
+	}
+	gui(func() {
+		m.state = target.String("state")
+		// Report changes made by QML logic as well, such as those
+		// caused by the when property of a state.
+		target.On("stateChanged", m.changed)
+	})
+	return m
+}
+
+// AddTransition defines that firing event while in the from state
+// changes the state to to. The from state may be AnyState.
+func (m *StateMachine) AddTransition(from, event, to string) {
+	gui(func() {
+		m.transitions[stateEvent{from, event}] = to
+	})
+}
+
+// Fire changes the state as defined by the transition for event from
+// the current state. An error is returned if no such transition exists.
+func (m *StateMachine) Fire(event string) error {
+	var err error
+	gui(func() {
+		to, ok := m.transitions[stateEvent{m.state, event}]
+		if !ok {
+			to, ok = m.transitions[stateEvent{AnyState, event}]
+		}
+		if !ok {
+			err = fmt.Errorf("no transition for event %q in state %q", event, m.state)
+			return
+		}
+		m.SetState(to)
+	})
+	return err
+}
+
+// SetState changes the state of the target item to state, regardless
+// of the defined transitions.
+func (m *StateMachine) SetState(state string) {
+	m.target.Set("state", state)
+}
+
+// State returns the current state.
+func (m *StateMachine) State() string {
+	var state string
+	gui(func() {
+		state = m.state
+	})
+	return state
+}
+
+// OnStateChanged arranges for f to be called with the previous and the
+// new state whenever the state changes, whether by Go or QML logic.
+// As with all signal handlers, f is run within the main GUI thread.
+func (m *StateMachine) OnStateChanged(f func(from, to string)) {
+	gui(func() {
+		m.handlers = append(m.handlers, f)
+	})
+}
+
+func (m *StateMachine) changed() {
+	to := m.target.String("state")
+	if to == m.state {
+		return
+	}
+	from := m.state
+	m.state = to
+	for _, f := range m.handlers {
+		f(from, to)
+	}
+}
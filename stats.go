@@ -1,7 +1,12 @@
 package qml
 
+// #include "capi.h"
+//
+import "C"
+
 import (
 	"sync"
+	"sync/atomic"
 )
 
 var stats *Statistics
@@ -11,6 +16,10 @@ func Stats() (snapshot Statistics) {
 	statsMutex.Lock()
 	snapshot = *stats
 	statsMutex.Unlock()
+	snapshot.ObjectsAlive = int(C.statsObjectsAlive())
+	snapshot.ComponentsAlive = int(C.statsComponentsAlive())
+	snapshot.TypesCached = int(atomic.LoadInt32(&typeInfoTypes))
+	snapshot.CAllocations = int(atomic.LoadInt32(&typeInfoAllocs))
 	return
 }
 
@@ -41,6 +50,14 @@ type Statistics struct {
 	EnginesAlive     int
 	ValuesAlive      int
 	ConnectionsAlive int
+
+	// The following values are absolute and always collected,
+	// so they are not affected by ResetStats.
+
+	ObjectsAlive    int // Objects created via Object.Create and Object.CreateWindow.
+	ComponentsAlive int // Components loaded and not yet released with their engine.
+	TypesCached     int // Go types with cached QML type information.
+	CAllocations    int // C allocations held by the cached type information.
 }
 
 func (stats *Statistics) enginesAlive(delta int) {
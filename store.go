@@ -0,0 +1,185 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// Store holds named values shared by all components and windows of an
+// engine, and is seen by QML logic as an object with one property per
+// key. Both Go and QML logic may change the values, and bindings as well
+// as Go callbacks observe the changes, so windows may communicate without
+// defining dedicated types. For example:
+//
+//     store := engine.Store()
+//     store.Put("selection", "")
+//     store.Watch("selection", func(value interface{}) { preview.Load(value.(string)) })
+//     engine.Context().SetVar("store", store)
+//
+// and in QML:
+//
+//     ListView { onCurrentItemChanged: store.selection = currentItem.path }
+//     Text { text: store.selection }
+//
+// Store values may optionally be persisted into a file with Persist.
+type Store struct {
+	Common
+	values   map[string]interface{}
+	watchers map[string][]func(value interface{})
+	changed  []func(key string, value interface{})
+	path     string
+}
+
+// Store returns the store of the engine, creating it on first use.
+func (e *Engine) Store() *Store {
+	e.assertValid()
+	gui(func() {
+		if e.store != nil {
+			return
+		}
+		store := &Store{
+			values:   make(map[string]interface{}),
+			watchers: make(map[string][]func(value interface{})),
+		}
+		store.engine = e
+		store.addr = C.newPropertyMap(e.addr)
+		store.On("valueChanged", store.qmlChanged)
+		e.store = store
+	})
+	return e.store
+}
+
+// Put sets the value of key in the store.
+func (s *Store) Put(key string, value interface{}) {
+	gui(func() {
+		var dvalue C.DataValue
+		packDataValue(value, &dvalue, s.engine, cppOwner)
+		ckey, ckeyLen := unsafeStringData(key)
+		C.propertyMapInsert(s.addr, ckey, ckeyLen, &dvalue)
+		s.notify(key, value)
+	})
+}
+
+// Get returns the value of key in the store, and whether it is set.
+func (s *Store) Get(key string) (value interface{}, ok bool) {
+	gui(func() {
+		value, ok = s.values[key]
+	})
+	return value, ok
+}
+
+// Delete removes key from the store. Watchers of key observe a nil value.
+func (s *Store) Delete(key string) {
+	gui(func() {
+		if _, ok := s.values[key]; !ok {
+			return
+		}
+		ckey, ckeyLen := unsafeStringData(key)
+		C.propertyMapClear(s.addr, ckey, ckeyLen)
+		delete(s.values, key)
+		s.notifyWatchers(key, nil)
+	})
+}
+
+// Keys returns all keys set in the store, sorted.
+func (s *Store) Keys() []string {
+	var keys []string
+	gui(func() {
+		for key := range s.values {
+			keys = append(keys, key)
+		}
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// Watch arranges for f to be called with the new value of key whenever
+// it changes, whether by Go or QML logic.
+// As with all signal handlers, f is run within the main GUI thread.
+func (s *Store) Watch(key string, f func(value interface{})) {
+	gui(func() {
+		s.watchers[key] = append(s.watchers[key], f)
+	})
+}
+
+// OnChange arranges for f to be called with the key and the new value
+// whenever any value changes, whether by Go or QML logic.
+// As with all signal handlers, f is run within the main GUI thread.
+func (s *Store) OnChange(f func(key string, value interface{})) {
+	gui(func() {
+		s.changed = append(s.changed, f)
+	})
+}
+
+// Persist loads the values previously saved into the file at path, if
+// it exists, and saves all values into it from then on whenever they
+// change. Values are persisted as JSON, so numbers are restored as
+// float64 values, and values that cannot be represented as JSON must
+// not be put into a persisted store.
+func (s *Store) Persist(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var values map[string]interface{}
+	if err == nil {
+		if err := json.Unmarshal(data, &values); err != nil {
+			return errors.New("cannot parse store file " + path + ": " + err.Error())
+		}
+	}
+	gui(func() {
+		s.path = ""
+		for key, value := range values {
+			s.Put(key, value)
+		}
+		s.path = path
+	})
+	return nil
+}
+
+// Save writes all values into the file the store is persisted into,
+// if any. It is called automatically whenever a value changes.
+func (s *Store) Save() error {
+	var path string
+	var data []byte
+	var err error
+	gui(func() {
+		path = s.path
+		if path != "" {
+			data, err = json.MarshalIndent(s.values, "", "\t")
+		}
+	})
+	if path == "" || err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// qmlChanged is called when QML logic changes a value.
+func (s *Store) qmlChanged(key string, value interface{}) {
+	s.notify(key, value)
+}
+
+func (s *Store) notify(key string, value interface{}) {
+	s.values[key] = value
+	s.notifyWatchers(key, value)
+}
+
+func (s *Store) notifyWatchers(key string, value interface{}) {
+	for _, f := range s.watchers[key] {
+		f(value)
+	}
+	for _, f := range s.changed {
+		f(key, value)
+	}
+	if s.path != "" {
+		s.Save()
+	}
+}
@@ -0,0 +1,66 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// isStreamChan returns whether v is a channel that may be received from,
+// and thus handed to QML as a stream of values.
+//
+// Methods of Go values called by QML logic may return such a channel to
+// deliver their results progressively. QML observes the result as an
+// object with an element(value) signal, emitted for every value received
+// from the channel, and a finished() signal, emitted once the channel is
+// closed. For example:
+//
+//     func (b *Backend) Search(query string) <-chan string {
+//         results := make(chan string)
+//         go func() {
+//             defer close(results)
+//             for _, match := range search(query) {
+//                 results <- match
+//             }
+//         }()
+//         return results
+//     }
+//
+// and in QML:
+//
+//     var stream = backend.search(query)
+//     stream.element.connect(function(match) { matches.append({text: match}) })
+//     stream.finished.connect(function() { busy = false })
+//
+// Values are only received after the method returns, so no values are
+// lost while the QML logic connects to the signals.
+func isStreamChan(v reflect.Value) bool {
+	return v.Kind() == reflect.Chan && v.Type().ChanDir()&reflect.RecvDir != 0
+}
+
+// newStream creates the QML object that forwards the values received
+// from ch, and starts forwarding them. It must be run from the main
+// GUI thread.
+func newStream(engine *Engine, ch reflect.Value) unsafe.Pointer {
+	addr := C.newGoStream()
+	go func() {
+		var dvalue C.DataValue
+		for {
+			v, ok := ch.Recv()
+			if !ok {
+				break
+			}
+			gui(func() {
+				packDataValue(v.Interface(), &dvalue, engine, jsOwner)
+				C.goStreamEmit(addr, &dvalue)
+			})
+		}
+		gui(func() {
+			C.goStreamFinish(addr)
+		})
+	}()
+	return addr
+}
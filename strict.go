@@ -0,0 +1,218 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+var strict int32
+
+var (
+	typeFunc    = reflect.TypeOf(&Func{})
+	typePromise = reflect.TypeOf(&Promise{})
+	typeObject  = reflect.TypeOf(new(Object)).Elem()
+
+	typeTextMarshaler = reflect.TypeOf(new(encoding.TextMarshaler)).Elem()
+	typeStringer      = reflect.TypeOf(new(fmt.Stringer)).Elem()
+)
+
+// SetStrict enables or disables the strict mode. In strict mode, types
+// registered with RegisterTypes and Go values handed to QML logic are
+// checked as a whole as soon as they are seen.
+// Fields and method results with types that cannot be handed to QML are
+// then reported with the chain of fields leading to them, rather than
+// causing a panic only when they are first accessed by QML logic.
+//
+// Values are checked according to the conversion policy of the engine
+// they are handed to, so that for example maps with integer keys are
+// accepted by engines using JSONValues. Registered types, which may be
+// created by any engine, are checked as if WrapValues was used.
+//
+// Strict mode is meant for development and testing, since checking
+// deeply nested types is expensive the first time they are seen.
+func SetStrict(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&strict, 1)
+	} else {
+		atomic.StoreInt32(&strict, 0)
+	}
+}
+
+type strictKey struct {
+	t      reflect.Type
+	policy ConversionPolicy
+}
+
+// strictChecked caches the result of checking types in strict mode.
+var strictChecked = struct {
+	sync.Mutex
+	m map[strictKey]error
+}{m: make(map[strictKey]error)}
+
+// checkStrict returns an error describing the first member of the type
+// of value that cannot be handed to QML by engine, if the strict mode
+// is enabled. A nil engine checks value as if WrapValues was used.
+func checkStrict(value interface{}, engine *Engine) error {
+	if value == nil || atomic.LoadInt32(&strict) == 0 {
+		return nil
+	}
+	t := reflect.TypeOf(value)
+	if packsAsArray(t) {
+		return nil
+	}
+	policy := WrapValues
+	if engine != nil {
+		gui(func() { policy = engine.conversion })
+	}
+	strictChecked.Lock()
+	defer strictChecked.Unlock()
+	key := strictKey{t, policy}
+	err, ok := strictChecked.m[key]
+	if !ok {
+		vt := t
+		for vt.Kind() == reflect.Ptr {
+			vt = vt.Elem()
+		}
+		name := vt.Name()
+		if name == "" {
+			name = vt.String()
+		}
+		err = checkMemberType(t, name, policy, make(map[reflect.Type]bool))
+		if err != nil {
+			err = fmt.Errorf("cannot hand %s value to QML: %v", t, err)
+		}
+		strictChecked.m[key] = err
+	}
+	return err
+}
+
+// checkValueType checks the fields and methods of the type t of a value
+// that is wrapped for QML, which is reachable via path.
+func checkValueType(t reflect.Type, path string, policy ConversionPolicy, seen map[reflect.Type]bool) error {
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	vt := t
+	for vt.Kind() == reflect.Ptr {
+		vt = vt.Elem()
+	}
+	if vt.Kind() != reflect.Struct {
+		return fmt.Errorf("%s has unsupported type %s", path, t)
+	}
+	for i := 0; i < vt.NumField(); i++ {
+		field := vt.Field(i)
+		if field.PkgPath != "" {
+			continue // not exported
+		}
+		if err := checkMemberType(field.Type, path+"."+field.Name, policy, seen); err != nil {
+			return err
+		}
+	}
+
+	ptrt := reflect.PtrTo(vt)
+	for i := 0; i < ptrt.NumMethod(); i++ {
+		method := ptrt.Method(i)
+		methodt := method.Type
+		mpath := path + "." + method.Name
+		if methodt.IsVariadic() {
+			return fmt.Errorf("method %s is variadic", mpath)
+		}
+		if methodt.NumIn()-1 > C.MaxParams {
+			return fmt.Errorf("method %s has more than %d parameters", mpath, C.MaxParams)
+		}
+		for j := 1; j < methodt.NumIn(); j++ {
+			switch in := methodt.In(j); in.Kind() {
+			case reflect.Map:
+				if in.Key().Kind() == reflect.String {
+					break // Converted from a JavaScript object.
+				}
+				return fmt.Errorf("method %s parameter %d has unsupported type %s", mpath, j, in)
+			case reflect.Chan, reflect.Func, reflect.Array, reflect.Complex64, reflect.Complex128:
+				return fmt.Errorf("method %s parameter %d has unsupported type %s", mpath, j, in)
+			}
+		}
+		for j := 0; j < methodt.NumOut(); j++ {
+			outt := methodt.Out(j)
+			if outt.Kind() == reflect.Chan && outt.ChanDir()&reflect.RecvDir != 0 {
+				continue // Handed over as a stream.
+			}
+			if packsAsArray(outt) {
+				continue
+			}
+			if err := checkMemberType(outt, fmt.Sprintf("%s result %d", mpath, j+1), policy, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkMemberType checks the type t of a field or method result that
+// is reachable via path, when handed over according to policy.
+func checkMemberType(t reflect.Type, path string, policy ConversionPolicy, seen map[reflect.Type]bool) error {
+	switch t {
+	case typeString, typeBytes, typeBool, typeInt, typeInt64, typeInt32, typeFloat64, typeFloat32, typeRGBA, typeTime, typeObjSlice, typeFunc, typePromise:
+		return nil
+	}
+	if t.Kind() != reflect.Ptr && t.Implements(typeColor) {
+		return nil
+	}
+	if t.Implements(typeObject) || t.Implements(typeValuer) || t.Implements(typeTextMarshaler) || reflect.PtrTo(t).Implements(typeTextMarshaler) {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		// Checked when the value is handed over.
+		return nil
+	case reflect.Ptr:
+		return checkMemberType(t.Elem(), path, policy, seen)
+	case reflect.Struct:
+		// Struct fields are wrapped by address whatever the policy.
+		return checkValueType(t, path, policy, seen)
+	}
+	switch policy {
+	case StringValues:
+		if t.Implements(typeStringer) {
+			return nil
+		}
+	case JSONValues:
+		switch t.Kind() {
+		case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128:
+			return fmt.Errorf("%s has type %s, which cannot be marshaled to JSON", path, t)
+		}
+		return nil
+	case RejectValues:
+		return fmt.Errorf("%s has type %s, which the conversion policy rejects", path, t)
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		return checkMemberType(t.Elem(), path+" element", policy, seen)
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			return checkMemberType(t.Elem(), path+" value", policy, seen)
+		}
+	}
+	return fmt.Errorf("%s has unsupported type %s", path, t)
+}
+
+// packsAsArray returns whether values of type t are packed as a list
+// by packArray.
+func packsAsArray(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice {
+		return false
+	}
+	switch reflect.Zero(t).Interface().(type) {
+	case []string, []bool, []int, []int64, []int32, []float64, []float32:
+		return true
+	}
+	return false
+}
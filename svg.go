@@ -0,0 +1,55 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"context"
+	"errors"
+	"image"
+	"unsafe"
+)
+
+// RenderSVG renders the SVG document in data into a new image, so that
+// icons may be kept as SVG in Go and rendered crisply at any scale.
+// The document is scaled to fit within width and height while keeping
+// its aspect ratio. If either dimension is zero, it's computed from the
+// other one, and if both are zero, the default size of the document
+// is used.
+//
+// SVG documents are only supported when the package is built with the
+// qmlsvg build tag, which requires the Qt SVG module. Otherwise
+// RenderSVG always returns an error.
+//
+// RenderSVG may be called from any goroutine.
+func RenderSVG(data []byte, width, height int) (*image.RGBA, error) {
+	if len(data) == 0 {
+		return nil, errors.New("invalid SVG document")
+	}
+	var cimage unsafe.Pointer
+	cerr := C.svgRender((*C.char)(unsafe.Pointer(&data[0])), C.int(len(data)), C.int(width), C.int(height), &cimage)
+	if cerr != nil {
+		return nil, cerror(cerr)
+	}
+	defer C.delImage(cimage)
+	return unpackImage(cimage), nil
+}
+
+// AddSVGProvider registers an asynchronous image provider with the
+// specified identifier, which renders the SVG documents returned by f
+// at the size requested by the image, such as via the sourceSize
+// property of Image items. For example, with a provider registered as
+// "icons", an image source of "image://icons/home" calls f with "home"
+// as the image identifier.
+//
+// See AddAsyncImageProvider for details.
+func (e *Engine) AddSVGProvider(prvId string, f func(imgId string) ([]byte, error)) {
+	e.AddAsyncImageProvider(prvId, func(ctx context.Context, imgId string, width, height int) (image.Image, error) {
+		data, err := f(imgId)
+		if err != nil {
+			return nil, err
+		}
+		return RenderSVG(data, width, height)
+	})
+}
@@ -0,0 +1,7 @@
+// +build qmlsvg
+
+package qml
+
+// #cgo pkg-config: Qt5Svg
+//
+import "C"
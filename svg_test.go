@@ -0,0 +1,30 @@
+// +build qmlsvg
+
+package qml_test
+
+import (
+	"github.com/niemeyer/qml"
+	"image"
+	"image/color"
+	. "launchpad.net/gocheck"
+)
+
+func (s *S) TestRenderSVG(c *C) {
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="20" height="10"><rect width="20" height="10" fill="#ff0000"/></svg>`)
+
+	img, err := qml.RenderSVG(svg, 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(img.Bounds(), Equals, image.Rect(0, 0, 20, 10))
+
+	img, err = qml.RenderSVG(svg, 200, 200)
+	c.Assert(err, IsNil)
+	c.Assert(img.Bounds(), Equals, image.Rect(0, 0, 200, 100))
+	c.Assert(img.RGBAAt(100, 50), Equals, color.RGBA{255, 0, 0, 255})
+
+	img, err = qml.RenderSVG(svg, 0, 30)
+	c.Assert(err, IsNil)
+	c.Assert(img.Bounds(), Equals, image.Rect(0, 0, 60, 30))
+
+	_, err = qml.RenderSVG([]byte("<bogus"), 10, 10)
+	c.Assert(err, ErrorMatches, "invalid SVG document")
+}
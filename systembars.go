@@ -0,0 +1,31 @@
+package qml
+
+import (
+	"image/color"
+)
+
+// SystemBar identifies one of the system bars shown around the
+// application window on mobile platforms.
+type SystemBar int
+
+const (
+	StatusBar SystemBar = iota
+	NavigationBar
+)
+
+// SetSystemBarStyle changes the background color of the provided system
+// bar and whether its icons are drawn dark, for use over light
+// backgrounds, or light, for use over dark backgrounds. It may be called
+// at any time, such as when the application theme is changed.
+//
+// On Android both the color and the icon style are changed (the
+// navigation bar icon style requires Android 8.0). On iOS the bars are
+// transparent, so only the status bar icon style is changed, and only if
+// UIViewControllerBasedStatusBarAppearance is disabled in the Info.plist
+// file. On other platforms SetSystemBarStyle does nothing.
+func SetSystemBarStyle(bar SystemBar, background color.RGBA, darkIcons bool) {
+	argb := uint32(background.A)<<24 | uint32(background.R)<<16 | uint32(background.G)<<8 | uint32(background.B)
+	gui(func() {
+		setSystemBarStyle(bar, argb, darkIcons)
+	})
+}
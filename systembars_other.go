@@ -0,0 +1,5 @@
+// +build !android,!ios
+
+package qml
+
+func setSystemBarStyle(bar SystemBar, argb uint32, darkIcons bool) {}
@@ -0,0 +1,46 @@
+package qml
+
+// The state shown in the taskbar entry or dock icon. Only accessed
+// within the main GUI thread.
+var (
+	taskbarProgress = -1.0
+	taskbarBadge    int
+)
+
+// SetTaskbarProgress shows the progress of a long running operation,
+// such as a download or an export, in the taskbar entry or dock icon of
+// the application. The progress ranges from 0.0 to 1.0, and a negative
+// value hides the progress indicator.
+//
+// On Windows the progress is shown in the taskbar buttons of all visible
+// windows. On Linux it is published via the Unity launcher API over D-Bus,
+// which is supported by several docks and desktops, and requires the
+// application to have a desktop file named after the application or
+// set with QGuiApplication's desktopFileName. On macOS it is drawn over
+// the dock icon. On other platforms SetTaskbarProgress does nothing.
+func SetTaskbarProgress(progress float64) {
+	if progress > 1 {
+		progress = 1
+	}
+	gui(func() {
+		taskbarProgress = progress
+		updateTaskbar(taskbarProgress, taskbarBadge)
+	})
+}
+
+// SetBadge shows count as a badge in the taskbar entry or dock icon of
+// the application, such as the number of unread messages. A count of zero
+// hides the badge.
+//
+// The badge is supported on Linux via the Unity launcher API, on macOS,
+// and on iOS, where the application must have been granted permission to
+// badge its icon. On other platforms SetBadge does nothing.
+func SetBadge(count int) {
+	if count < 0 {
+		count = 0
+	}
+	gui(func() {
+		taskbarBadge = count
+		updateTaskbar(taskbarProgress, taskbarBadge)
+	})
+}
@@ -0,0 +1,11 @@
+// +build !ios
+
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+func updateTaskbar(progress float64, badge int) {
+	C.darwinUpdateDockTile(C.double(progress), C.int(badge))
+}
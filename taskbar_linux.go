@@ -0,0 +1,11 @@
+// +build !android
+
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+func updateTaskbar(progress float64, badge int) {
+	C.dbusUpdateLauncherEntry(C.double(progress), C.int(badge))
+}
@@ -0,0 +1,5 @@
+// +build !linux,!darwin,!windows android
+
+package qml
+
+func updateTaskbar(progress float64, badge int) {}
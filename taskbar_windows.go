@@ -0,0 +1,11 @@
+package qml
+
+// #cgo LDFLAGS: -lole32
+//
+// #include "capi.h"
+//
+import "C"
+
+func updateTaskbar(progress float64, badge int) {
+	C.windowsSetTaskbarProgress(C.double(progress))
+}
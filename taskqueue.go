@@ -0,0 +1,233 @@
+package qml
+
+import (
+	"fmt"
+)
+
+// TaskQueue runs background tasks submitted by Go logic, and holds them
+// in a form that may be bound directly by QML logic, such as by a panel
+// listing the running and finished operations with their progress and
+// cancel buttons. For example:
+//
+//     tasks := qml.NewTaskQueue(2)
+//     context.SetVar("tasks", tasks)
+//     tasks.Submit("Upload photos", func(progress *qml.Progress) error {
+//         for i, photo := range photos {
+//             if err := upload(progress.Context(), photo); err != nil {
+//                 return err
+//             }
+//             progress.Set(float64(i+1), float64(len(photos)))
+//         }
+//         return nil
+//     })
+//
+// and in QML:
+//
+//     Repeater {
+//         model: tasks.ids
+//         Row {
+//             property var task: tasks.get(modelData)
+//             Text { text: task.name + ": " + task.state }
+//             ProgressBar { value: task.progress.fraction }
+//             Button { text: "Cancel"; visible: !task.done; onClicked: task.cancel() }
+//         }
+//     }
+//
+// Finished tasks are kept in the queue until removed with Remove or
+// ClearFinished, so that their outcome may be displayed.
+type TaskQueue struct {
+	// Ids holds the identifiers of all tasks in the queue, in the order
+	// they were submitted.
+	Ids []int
+
+	// Running is the number of tasks currently running.
+	Running int
+
+	limit  int
+	lastId int
+	tasks  map[int]*Task
+}
+
+// States of a Task.
+const (
+	TaskQueued    = "queued"
+	TaskRunning   = "running"
+	TaskFinished  = "finished"
+	TaskFailed    = "failed"
+	TaskCancelled = "cancelled"
+)
+
+// Task is a background task submitted to a TaskQueue.
+type Task struct {
+	Id       int       // Identifier of the task within its queue.
+	Name     string    // Description of the task shown to the user.
+	State    string    // One of TaskQueued, TaskRunning, TaskFinished, TaskFailed or TaskCancelled.
+	Done     bool      // Whether the task is no longer queued or running.
+	Error    string    // Error message of a failed task.
+	Progress *Progress // Progress of the task, updated by the task itself.
+
+	queue *TaskQueue
+	run   func(progress *Progress) error
+	err   error
+	done  chan struct{}
+}
+
+// NewTaskQueue returns a new empty task queue running up to limit tasks
+// at once, or any number of tasks if limit is zero.
+func NewTaskQueue(limit int) *TaskQueue {
+	return &TaskQueue{limit: limit, tasks: make(map[int]*Task)}
+}
+
+// Submit adds a task described by name to the queue, which is run on
+// its own goroutine as soon as the queue limit allows. The task reports
+// its progress via the provided Progress value, and should return early
+// once its context is cancelled.
+func (q *TaskQueue) Submit(name string, run func(progress *Progress) error) *Task {
+	task := &Task{
+		Name:     name,
+		State:    TaskQueued,
+		Progress: NewProgress(),
+		queue:    q,
+		run:      run,
+		done:     make(chan struct{}),
+	}
+	gui(func() {
+		q.lastId++
+		task.Id = q.lastId
+		q.tasks[task.Id] = task
+		q.Ids = append(q.Ids, task.Id)
+		Changed(q, &q.Ids)
+		q.schedule()
+	})
+	return task
+}
+
+// Get returns the task with the provided identifier, or nil if there is
+// no such task in the queue.
+func (q *TaskQueue) Get(id int) *Task {
+	var task *Task
+	gui(func() {
+		task = q.tasks[id]
+	})
+	return task
+}
+
+// Remove cancels the task with the provided identifier, if it's not yet
+// done, and removes it from the queue.
+func (q *TaskQueue) Remove(id int) {
+	gui(func() {
+		if task, ok := q.tasks[id]; ok {
+			task.Cancel()
+			q.remove(func(task *Task) bool { return task.Id == id })
+		}
+	})
+}
+
+// ClearFinished removes all tasks that are done from the queue.
+func (q *TaskQueue) ClearFinished() {
+	gui(func() {
+		q.remove(func(task *Task) bool { return task.Done })
+	})
+}
+
+func (q *TaskQueue) remove(match func(task *Task) bool) {
+	ids := make([]int, 0, len(q.Ids))
+	for _, id := range q.Ids {
+		if match(q.tasks[id]) {
+			delete(q.tasks, id)
+		} else {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) != len(q.Ids) {
+		q.Ids = ids
+		Changed(q, &q.Ids)
+	}
+}
+
+// schedule starts queued tasks while the queue limit allows it.
+func (q *TaskQueue) schedule() {
+	for _, id := range q.Ids {
+		if q.limit > 0 && q.Running >= q.limit {
+			return
+		}
+		task := q.tasks[id]
+		if task.State != TaskQueued {
+			continue
+		}
+		task.setState(TaskRunning)
+		q.Running++
+		Changed(q, &q.Running)
+		go task.start()
+	}
+}
+
+func (task *Task) start() {
+	err := func() (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = fmt.Errorf("task panicked: %v", v)
+			}
+		}()
+		return task.run(task.Progress)
+	}()
+	gui(func() {
+		q := task.queue
+		q.Running--
+		Changed(q, &q.Running)
+		switch {
+		case task.Progress.Cancelled:
+			task.finish(TaskCancelled, nil)
+		case err != nil:
+			task.finish(TaskFailed, err)
+		default:
+			task.finish(TaskFinished, nil)
+		}
+		q.schedule()
+	})
+}
+
+func (task *Task) setState(state string) {
+	task.State = state
+	Changed(task, &task.State)
+}
+
+func (task *Task) finish(state string, err error) {
+	task.setState(state)
+	task.err = err
+	if err != nil {
+		task.Error = err.Error()
+		Changed(task, &task.Error)
+	}
+	task.Done = true
+	Changed(task, &task.Done)
+	close(task.done)
+}
+
+// Cancel requests the cancellation of the task. A queued task is
+// cancelled at once, while a running task is cancelled once it returns.
+// Cancel is usually called by QML logic, such as when a cancel button
+// is clicked.
+//
+// It is safe to call Cancel more than once.
+func (task *Task) Cancel() {
+	task.Progress.Cancel()
+	gui(func() {
+		if task.State == TaskQueued {
+			task.finish(TaskCancelled, nil)
+		}
+	})
+}
+
+// Wait waits until the task is done, and returns the error returned
+// by it, if any.
+//
+// Wait must not be called from the GUI thread, as it waits for the GUI
+// thread to make progress.
+func (task *Task) Wait() error {
+	if onGuiThread() {
+		panic("Task.Wait must not be called from the GUI thread")
+	}
+	<-task.done
+	return task.err
+}
@@ -0,0 +1,296 @@
+package qml
+
+// #include <stdlib.h>
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"errors"
+	"image/color"
+	"unsafe"
+)
+
+// TextDocument offers access to the document displayed and edited by a
+// QML TextEdit or TextArea element, so that editors may inspect, modify,
+// and format the text at document granularity instead of replacing the
+// whole text property.
+//
+// Positions in the document are counted in UTF-16 code units, as they
+// are in QML, and blocks are the paragraphs of the document.
+type TextDocument struct {
+	addr     unsafe.Pointer
+	handlers []func(position, removed, added int)
+
+	highlighter     Highlighter
+	highlighterAddr unsafe.Pointer
+}
+
+var textDocuments = make(map[unsafe.Pointer]*TextDocument)
+
+// NewTextDocument returns the document held by item, which must be a
+// QML TextEdit or TextArea element. Calling NewTextDocument again for
+// the same item returns the same document value.
+func NewTextDocument(item Object) (*TextDocument, error) {
+	var doc *TextDocument
+	gui(func() {
+		addr := C.objectTextDocument(item.Common().addr)
+		if addr == nilPtr {
+			return
+		}
+		doc = textDocuments[addr]
+		if doc == nil {
+			doc = &TextDocument{addr: addr}
+			textDocuments[addr] = doc
+			C.textDocumentConnect(addr)
+		}
+	})
+	if doc == nil {
+		return nil, errors.New("object has no text document")
+	}
+	return doc, nil
+}
+
+func (doc *TextDocument) assertValid() {
+	if doc.addr == nilPtr {
+		panic("text document already destroyed")
+	}
+}
+
+// Text returns the plain text of the document, with blocks separated
+// by newlines.
+func (doc *TextDocument) Text() string {
+	var text string
+	gui(func() {
+		doc.assertValid()
+		text = cstringResult(C.textDocumentText(doc.addr))
+	})
+	return text
+}
+
+// Len returns the length of the document text.
+func (doc *TextDocument) Len() int {
+	var length C.int
+	gui(func() {
+		doc.assertValid()
+		length = C.textDocumentLength(doc.addr)
+	})
+	return int(length)
+}
+
+// BlockCount returns the number of blocks in the document.
+func (doc *TextDocument) BlockCount() int {
+	var count C.int
+	gui(func() {
+		doc.assertValid()
+		count = C.textDocumentBlockCount(doc.addr)
+	})
+	return int(count)
+}
+
+// Block returns the text of the block with the provided index, and the
+// position in the document where the block starts.
+func (doc *TextDocument) Block(index int) (text string, position int) {
+	var cposition C.int
+	gui(func() {
+		doc.assertValid()
+		text = cstringResult(C.textDocumentBlockText(doc.addr, C.int(index), &cposition))
+	})
+	return text, int(cposition)
+}
+
+// Replace replaces length characters starting at position with text,
+// as a single undoable operation. The formatting of the replaced text
+// is preserved in the new text where possible.
+func (doc *TextDocument) Replace(position, length int, text string) {
+	cursor := doc.Cursor()
+	defer cursor.Close()
+	cursor.Edit(func() {
+		cursor.Select(position, position+length)
+		cursor.InsertText(text)
+	})
+}
+
+// SetFormat applies format to length characters starting at position.
+func (doc *TextDocument) SetFormat(position, length int, format TextFormat) {
+	cursor := doc.Cursor()
+	defer cursor.Close()
+	cursor.Select(position, position+length)
+	cursor.MergeFormat(format)
+}
+
+// OnChanged arranges for f to be called whenever the document content
+// changes, with the position where the change happened and the number
+// of characters removed and added there. This is run both for changes
+// made by the user and by Go code. As with signal handlers, f is run
+// within the main GUI thread.
+func (doc *TextDocument) OnChanged(f func(position, removed, added int)) {
+	gui(func() {
+		doc.handlers = append(doc.handlers, f)
+	})
+}
+
+//export hookTextDocumentChanged
+func hookTextDocumentChanged(addr unsafe.Pointer, position, removed, added C.int) {
+	doc := textDocuments[addr]
+	if doc == nil {
+		return
+	}
+	for _, f := range doc.handlers {
+		f(int(position), int(removed), int(added))
+	}
+}
+
+//export hookTextDocumentDestroyed
+func hookTextDocumentDestroyed(addr unsafe.Pointer) {
+	if doc := textDocuments[addr]; doc != nil {
+		doc.addr = nilPtr
+		delete(textDocuments, addr)
+	}
+}
+
+// TextFormat holds character formatting to apply to a span of text.
+// Zero values leave the respective formatting unchanged.
+type TextFormat struct {
+	Foreground color.RGBA
+	Background color.RGBA
+	Bold       bool
+	Italic     bool
+	Underline  bool
+}
+
+func packTextFormat(format *TextFormat, cformat *C.TextFormat) {
+	cformat.foreground = C.uint(packColor(format.Foreground))
+	cformat.background = C.uint(packColor(format.Background))
+	cformat.bold = cbool(format.Bold)
+	cformat.italic = cbool(format.Italic)
+	cformat.underline = cbool(format.Underline)
+}
+
+func cbool(b bool) C.int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// TextCursor edits a TextDocument at a given position, optionally with
+// a selection extending from the anchor to the position. The cursor
+// position is adjusted as the document is edited, and is independent
+// from the cursor shown to the user.
+type TextCursor struct {
+	doc  *TextDocument
+	addr unsafe.Pointer
+}
+
+// Cursor returns a new cursor at the start of the document.
+//
+// The Close method must be called to release the cursor.
+func (doc *TextDocument) Cursor() *TextCursor {
+	cursor := &TextCursor{doc: doc}
+	gui(func() {
+		doc.assertValid()
+		cursor.addr = C.newTextCursor(doc.addr)
+	})
+	return cursor
+}
+
+// Close releases the cursor.
+//
+// It is safe to call Close more than once.
+func (cursor *TextCursor) Close() {
+	gui(func() {
+		if cursor.addr != nilPtr {
+			C.delTextCursor(cursor.addr)
+			cursor.addr = nilPtr
+		}
+	})
+}
+
+// Position returns the current cursor position.
+func (cursor *TextCursor) Position() int {
+	var position C.int
+	gui(func() {
+		position = C.textCursorPosition(cursor.addr)
+	})
+	return int(position)
+}
+
+// Anchor returns the position where the selection starts, which equals
+// the cursor position if there is no selection.
+func (cursor *TextCursor) Anchor() int {
+	var anchor C.int
+	gui(func() {
+		anchor = C.textCursorAnchor(cursor.addr)
+	})
+	return int(anchor)
+}
+
+// SetPosition moves the cursor to position, clearing the selection.
+func (cursor *TextCursor) SetPosition(position int) {
+	gui(func() {
+		C.textCursorSetPosition(cursor.addr, C.int(position), 0)
+	})
+}
+
+// Select selects the text between anchor and position, leaving the
+// cursor at position.
+func (cursor *TextCursor) Select(anchor, position int) {
+	gui(func() {
+		C.textCursorSetPosition(cursor.addr, C.int(anchor), 0)
+		C.textCursorSetPosition(cursor.addr, C.int(position), 1)
+	})
+}
+
+// SelectedText returns the selected text, with blocks separated by newlines.
+func (cursor *TextCursor) SelectedText() string {
+	var text string
+	gui(func() {
+		text = cstringResult(C.textCursorSelectedText(cursor.addr))
+	})
+	return text
+}
+
+// InsertText inserts text at the cursor position, replacing the
+// selection if any.
+func (cursor *TextCursor) InsertText(text string) {
+	ctext, ctextLen := unsafeStringData(text)
+	gui(func() {
+		C.textCursorInsertText(cursor.addr, ctext, ctextLen)
+	})
+}
+
+// RemoveSelectedText removes the selected text.
+func (cursor *TextCursor) RemoveSelectedText() {
+	gui(func() {
+		C.textCursorRemoveSelectedText(cursor.addr)
+	})
+}
+
+// MergeFormat applies format to the selected text.
+func (cursor *TextCursor) MergeFormat(format TextFormat) {
+	var cformat C.TextFormat
+	packTextFormat(&format, &cformat)
+	gui(func() {
+		C.textCursorMergeFormat(cursor.addr, &cformat)
+	})
+}
+
+// ClearFormat removes all formatting from the selected text.
+func (cursor *TextCursor) ClearFormat() {
+	gui(func() {
+		C.textCursorClearFormat(cursor.addr)
+	})
+}
+
+// Edit runs f with all the changes made through the cursor grouped
+// into a single undoable operation.
+func (cursor *TextCursor) Edit(f func()) {
+	gui(func() {
+		C.textCursorBeginEditBlock(cursor.addr)
+	})
+	defer gui(func() {
+		C.textCursorEndEditBlock(cursor.addr)
+	})
+	f()
+}
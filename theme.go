@@ -0,0 +1,133 @@
+package qml
+
+// #include <stdlib.h>
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"unsafe"
+)
+
+// Scheme holds the overall color scheme of the user interface.
+type Scheme int
+
+const (
+	UnknownScheme Scheme = iota
+	LightScheme
+	DarkScheme
+)
+
+// ColorScheme returns the color scheme currently preferred by the
+// system, such as DarkScheme when the operating system is in dark mode.
+//
+// With Qt versions before 6.5 the scheme is inferred from the
+// lightness of the system palette.
+func ColorScheme() Scheme {
+	var scheme C.int
+	gui(func() {
+		scheme = C.applicationColorScheme()
+	})
+	return Scheme(scheme)
+}
+
+var colorSchemeHandlers []func(scheme Scheme)
+
+// OnColorSchemeChanged arranges for f to be called whenever the color
+// scheme preferred by the system changes. As with signal handlers,
+// f is run within the main GUI thread.
+//
+// Notifications require Qt 5.13 or later.
+func OnColorSchemeChanged(f func(scheme Scheme)) {
+	gui(func() {
+		if colorSchemeHandlers == nil {
+			C.applicationConnectColorScheme()
+		}
+		colorSchemeHandlers = append(colorSchemeHandlers, f)
+	})
+}
+
+//export hookColorSchemeChanged
+func hookColorSchemeChanged(scheme C.int) {
+	for _, f := range colorSchemeHandlers {
+		f(Scheme(scheme))
+	}
+}
+
+// SetColorScheme forces the color scheme used by the application,
+// regardless of the system preference. UnknownScheme restores the
+// system preference.
+//
+// The Material and Universal styles of Qt Quick Controls 2 follow the
+// forced scheme when SetColorScheme is called before any controls are
+// loaded. With Qt 6.8 or later the scheme may also be changed at runtime.
+func SetColorScheme(scheme Scheme) {
+	theme := "System"
+	switch scheme {
+	case LightScheme:
+		theme = "Light"
+	case DarkScheme:
+		theme = "Dark"
+	}
+	os.Setenv("QT_QUICK_CONTROLS_MATERIAL_THEME", theme)
+	os.Setenv("QT_QUICK_CONTROLS_UNIVERSAL_THEME", theme)
+	gui(func() {
+		C.applicationSetColorScheme(C.int(scheme))
+	})
+}
+
+// ControlsStyle holds the configuration of the Qt Quick Controls 2 style.
+// Zero values leave the respective setting unchanged.
+type ControlsStyle struct {
+	// Name is the style name, such as "Material", "Universal",
+	// or "Fusion", or the path to a custom style.
+	Name string
+
+	// The theme colors used by the Material and Universal styles.
+	Accent     color.RGBA
+	Primary    color.RGBA
+	Foreground color.RGBA
+	Background color.RGBA
+
+	// The default font used by all controls.
+	FontFamily    string
+	FontPointSize float64
+}
+
+// SetControlsStyle configures the Qt Quick Controls 2 style used by
+// the application, as an alternative to a qtquickcontrols2.conf file.
+//
+// The style name and colors are only taken into account if SetControlsStyle
+// is called before any controls are loaded. Changes to the font affect
+// controls loaded afterwards.
+func SetControlsStyle(style ControlsStyle) {
+	if style.Name != "" {
+		os.Setenv("QT_QUICK_CONTROLS_STYLE", style.Name)
+	}
+	setStyleColor("ACCENT", style.Accent)
+	setStyleColor("PRIMARY", style.Primary)
+	setStyleColor("FOREGROUND", style.Foreground)
+	setStyleColor("BACKGROUND", style.Background)
+	if style.FontFamily != "" || style.FontPointSize > 0 {
+		cfamily := C.CString(style.FontFamily)
+		defer C.free(unsafe.Pointer(cfamily))
+		gui(func() {
+			C.applicationSetFont(cfamily, C.double(style.FontPointSize))
+		})
+	}
+}
+
+func setStyleColor(name string, c color.RGBA) {
+	if c == (color.RGBA{}) {
+		return
+	}
+	value := fmt.Sprintf("#%02x%02x%02x%02x", c.A, c.R, c.G, c.B)
+	os.Setenv("QT_QUICK_CONTROLS_MATERIAL_"+name, value)
+	if name != "PRIMARY" {
+		// The Universal style has no primary color.
+		os.Setenv("QT_QUICK_CONTROLS_UNIVERSAL_"+name, value)
+	}
+}
@@ -0,0 +1,189 @@
+package qml
+
+import (
+	"fmt"
+	"image/color"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// RegisterTheme registers tokens, a pointer to a struct holding the design
+// tokens of the application, as a QML singleton with the provided name, so
+// that colors, spacing, typography, and other tokens maintained outside QML
+// reach every component consistently. The singleton is available under the
+// provided location and major.minor version numbers, as with RegisterTypes.
+// Groups of tokens may be organized as nested struct fields.
+//
+// For example, with these tokens registered as "Theme" under "GoExtensions" 1.0:
+//
+//     type Theme struct {
+//         Colors  ThemeColors
+//         Spacing ThemeSpacing
+//     }
+//
+//     type ThemeColors struct {
+//         Primary, Background color.RGBA
+//     }
+//
+//     type ThemeSpacing struct {
+//         Small, Medium, Large int
+//     }
+//
+// components may use them as:
+//
+//     import GoExtensions 1.0
+//
+//     Rectangle {
+//         color: Theme.colors.background
+//         anchors.margins: Theme.spacing.medium
+//     }
+//
+// Tokens changed later with SetTheme or LoadTheme update all bindings
+// depending on them.
+func RegisterTheme(location string, major, minor int, name string, tokens interface{}) {
+	snapshotTarget(tokens, "RegisterTheme")
+	RegisterTypes(location, major, minor, []TypeSpec{{
+		Name:      name,
+		Singleton: true,
+		New:       func() interface{} { return tokens },
+	}})
+}
+
+// SetTheme changes the design tokens pointed to by tokens to match theme,
+// a value of the same struct type, such as when switching between a light
+// and a dark theme. Only the tokens that change are reported with Changed.
+func SetTheme(tokens, theme interface{}) {
+	v := snapshotTarget(tokens, "SetTheme")
+	tv := reflect.ValueOf(theme)
+	if tv.Kind() == reflect.Ptr {
+		tv = tv.Elem()
+	}
+	if tv.Type() != v.Elem().Type() {
+		panic(fmt.Sprintf("SetTheme must be given a %s theme, got %T", v.Elem().Type(), theme))
+	}
+	gui(func() {
+		updateTokens(v, tv)
+	})
+}
+
+// LoadTheme changes the design tokens pointed to by tokens to the values in
+// data, decoded by unmarshal, which is usually json.Unmarshal or the Unmarshal
+// function of a YAML package. Tokens are matched by their field name or by the
+// name known to QML, and tokens missing from data are left unchanged. Colors
+// are provided as strings in the "#rgb", "#rrggbb", or "#aarrggbb" forms.
+// As with SetTheme, only the tokens that change are reported with Changed.
+func LoadTheme(tokens interface{}, data []byte, unmarshal func(data []byte, v interface{}) error) error {
+	v := snapshotTarget(tokens, "LoadTheme")
+	var m map[string]interface{}
+	if err := unmarshal(data, &m); err != nil {
+		return fmt.Errorf("cannot parse theme: %v", err)
+	}
+	var err error
+	gui(func() {
+		theme := reflect.New(v.Elem().Type()).Elem()
+		theme.Set(v.Elem())
+		if err = decodeTokens(theme, m, ""); err == nil {
+			updateTokens(v, theme)
+		}
+	})
+	return err
+}
+
+// isTokenGroup returns whether fields of type t hold a group of tokens.
+func isTokenGroup(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != typeRGBA
+}
+
+// decodeTokens assigns the values in m to the fields of the struct v,
+// which are reached via path.
+func decodeTokens(v reflect.Value, m map[string]interface{}, path string) error {
+	for key, value := range m {
+		field, ok := fieldByMemberName(v, key)
+		if !ok {
+			return fmt.Errorf("cannot load theme: unknown token %s%s", path, key)
+		}
+		switch {
+		case isTokenGroup(field.Type()):
+			group, ok := tokenGroup(value)
+			if !ok {
+				return fmt.Errorf("cannot load theme: %s%s must be a group of tokens", path, key)
+			}
+			if err := decodeTokens(field, group, path+key+"."); err != nil {
+				return err
+			}
+		case field.Type() == typeRGBA:
+			s, _ := value.(string)
+			c, ok := parseColor(s)
+			if !ok {
+				return fmt.Errorf("cannot load theme: invalid color for %s%s: %#v", path, key, value)
+			}
+			field.Set(reflect.ValueOf(c))
+		default:
+			if err := assignValue(field, value); err != nil {
+				return fmt.Errorf("cannot load theme: %s%s: %v", path, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// tokenGroup returns value as a map keyed by token name, as decoded by
+// encoding/json and by the usual YAML packages.
+func tokenGroup(value interface{}) (map[string]interface{}, bool) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return value, true
+	case map[interface{}]interface{}:
+		group := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			group[fmt.Sprint(k)] = v
+		}
+		return group, true
+	}
+	return nil, false
+}
+
+// parseColor parses a color in the "#rgb", "#rrggbb", or "#aarrggbb" forms,
+// as accepted by QML.
+func parseColor(s string) (color.RGBA, bool) {
+	if !strings.HasPrefix(s, "#") {
+		return color.RGBA{}, false
+	}
+	hex := s[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex = "ff" + hex
+	}
+	if len(hex) != 8 {
+		return color.RGBA{}, false
+	}
+	argb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{byte(argb >> 16), byte(argb >> 8), byte(argb), byte(argb >> 24)}, true
+}
+
+// updateTokens sets the fields of the struct pointed to by ptr that differ
+// from theme, and reports them with Changed.
+func updateTokens(ptr, theme reflect.Value) {
+	v := ptr.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath != "" {
+			continue // not exported
+		}
+		field := v.Field(i)
+		if isTokenGroup(field.Type()) {
+			updateTokens(field.Addr(), theme.Field(i))
+			continue
+		}
+		if !reflect.DeepEqual(field.Interface(), theme.Field(i).Interface()) {
+			field.Set(theme.Field(i))
+			Changed(ptr.Interface(), field.Addr().Interface())
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package qml
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ThumbnailFunc renders a thumbnail of the file at path that fits within
+// the provided width and height, for a file format not supported by the
+// Go image package, such as camera RAW files or the first page of PDF
+// documents. See RegisterThumbnailer.
+type ThumbnailFunc func(ctx context.Context, path string, width, height int) (image.Image, error)
+
+var thumbnailers = struct {
+	sync.Mutex
+	m map[string]ThumbnailFunc
+}{m: make(map[string]ThumbnailFunc)}
+
+// RegisterThumbnailer registers f to render thumbnails for files with the
+// provided extension, such as ".pdf", in place of the Go image package.
+// Extensions are matched ignoring case.
+func RegisterThumbnailer(ext string, f ThumbnailFunc) {
+	thumbnailers.Lock()
+	thumbnailers.m[strings.ToLower(ext)] = f
+	thumbnailers.Unlock()
+}
+
+// AddThumbnailProvider registers in the engine an asynchronous image
+// provider with the "thumb" identifier, which serves thumbnails of local
+// image files, so that delegates may use them right away:
+//
+//     Image { source: "image://thumb/" + model.path + "?w=128&h=128" }
+//
+// Thumbnails are scaled to fit within the size defined by the w and h
+// query parameters, or otherwise by the sourceSize of the requesting item,
+// while keeping the aspect ratio. Files are decoded with DecodeImage, so
+// formats known to the Go image package are supported, and thumbnailers
+// registered with RegisterThumbnailer handle other formats.
+//
+// If cache is not nil, thumbnails are held in it under the "thumb" name.
+func (e *Engine) AddThumbnailProvider(cache *ImageCache) {
+	f := ImageFunc(thumbnail)
+	if cache != nil {
+		f = cache.AsyncProvider("thumb", f)
+	}
+	e.AddAsyncImageProvider("thumb", f)
+}
+
+// thumbnail serves the thumbnail requested via imgId, in the form
+// "<path>?w=<width>&h=<height>".
+func thumbnail(ctx context.Context, imgId string, width, height int) (image.Image, error) {
+	path := imgId
+	if i := strings.LastIndex(imgId, "?"); i >= 0 {
+		path = imgId[:i]
+		query, err := url.ParseQuery(imgId[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid thumbnail request %q: %v", imgId, err)
+		}
+		if w := query.Get("w"); w != "" {
+			if width, err = strconv.Atoi(w); err != nil {
+				return nil, fmt.Errorf("invalid thumbnail width in %q", imgId)
+			}
+		}
+		if h := query.Get("h"); h != "" {
+			if height, err = strconv.Atoi(h); err != nil {
+				return nil, fmt.Errorf("invalid thumbnail height in %q", imgId)
+			}
+		}
+	}
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	path = filepath.FromSlash(path)
+
+	thumbnailers.Lock()
+	f := thumbnailers.m[strings.ToLower(filepath.Ext(path))]
+	thumbnailers.Unlock()
+	if f != nil {
+		return f(ctx, path, width, height)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	img, err := DecodeImage(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode %s: %v", path, err)
+	}
+	return fitImage(img, width, height), nil
+}
+
+// fitImage returns img scaled down to fit within width and height while
+// keeping its aspect ratio, by averaging the pixels covered by each
+// resulting pixel. A zero width or height leaves that dimension
+// unconstrained.
+func fitImage(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	scale := 1.0
+	if width > 0 && w > width {
+		scale = float64(width) / float64(w)
+	}
+	if height > 0 && float64(h)*scale > float64(height) {
+		scale = float64(height) / float64(h)
+	}
+	if scale == 1.0 || w == 0 || h == 0 {
+		return img
+	}
+	dw := int(float64(w)*scale + 0.5)
+	dh := int(float64(h)*scale + 0.5)
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		sy0, sy1 := dy*h/dh, (dy+1)*h/dh
+		for dx := 0; dx < dw; dx++ {
+			sx0, sx1 := dx*w/dw, (dx+1)*w/dw
+			var r, g, b, a, n uint32
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					pr, pg, pb, pa := img.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b, a, n = r+pr>>8, g+pg>>8, b+pb>>8, a+pa>>8, n+1
+				}
+			}
+			i := dst.PixOffset(dx, dy)
+			dst.Pix[i+0] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,240 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"math"
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+// TouchState holds the state of a touch point within a touch event.
+type TouchState int
+
+const (
+	TouchPressed    TouchState = 0x1
+	TouchMoved      TouchState = 0x2
+	TouchStationary TouchState = 0x4
+	TouchReleased   TouchState = 0x8
+)
+
+// TouchPoint holds the state of a single finger touching the screen.
+// Coordinates are relative to the item handling the touch.
+type TouchPoint struct {
+	ID       int
+	State    TouchState
+	X, Y     float64
+	Pressure float64 // From 0.0 to 1.0, or 1.0 if not supported by the device.
+}
+
+// GestureType identifies a gesture recognized from touch events.
+type GestureType int
+
+const (
+	PinchGesture GestureType = iota
+	SwipeGesture
+	LongPressGesture
+)
+
+// GestureState holds the progress of a gesture. Swipe and long-press
+// gestures are only reported once, as GestureFinished.
+type GestureState int
+
+const (
+	GestureStarted GestureState = iota
+	GestureUpdated
+	GestureFinished
+)
+
+// Gesture holds the details of a recognized gesture.
+// Coordinates are relative to the item handling the touch.
+type Gesture struct {
+	Type  GestureType
+	State GestureState
+
+	// X and Y hold the position of the gesture: the center between both
+	// fingers of a pinch, the end of a swipe, or the long-press position.
+	X, Y float64
+
+	// Scale and Angle hold the change in distance, as a factor, and the
+	// rotation, in degrees, of the fingers since a pinch started.
+	Scale float64
+	Angle float64
+
+	// DX and DY hold the distance traveled by a swipe.
+	DX, DY float64
+}
+
+// TouchHandler holds the functions called when an item is touched.
+// Nil functions are ignored. All functions are run within the main
+// GUI thread.
+type TouchHandler struct {
+	// Touch is called with all the points touching the item, whenever
+	// any of them changes.
+	Touch func(points []TouchPoint)
+
+	// Gesture is called when a pinch, swipe, or long-press gesture
+	// is recognized.
+	Gesture func(gesture Gesture)
+}
+
+// These thresholds follow the defaults used by the Qt gesture recognizers.
+const (
+	swipeMinDistance   = 50
+	swipeMaxDuration   = 500 * time.Millisecond
+	longPressMaxMove   = 10
+	longPressThreshold = 800 * time.Millisecond
+)
+
+type touchTracker struct {
+	handler TouchHandler
+
+	pinching     bool
+	pinchDist    float64
+	pinchAngle   float64
+	start        TouchPoint
+	startTime    time.Time
+	last         TouchPoint
+	longPress    *time.Timer
+	longPressGen int
+}
+
+var touchTrackers = make(map[*touchTracker]bool)
+
+// HandleTouch arranges for the functions in h to be called when the
+// provided item, which must be a visual QML item, is touched. The touch
+// events are consumed and not delivered to the item itself.
+func HandleTouch(item Object, h TouchHandler) error {
+	tracker := &touchTracker{handler: h}
+	var cerr *C.error
+	gui(func() {
+		cerr = C.itemHandleTouch(item.Common().addr, unsafe.Pointer(tracker))
+		if cerr == nil {
+			touchTrackers[tracker] = true
+		}
+	})
+	if cerr != nil {
+		return cerror(cerr)
+	}
+	return nil
+}
+
+//export hookTouchEvent
+func hookTouchEvent(trackerp unsafe.Pointer, cpoints *C.TouchPoint, cpointsLen C.int) {
+	var cslice []C.TouchPoint
+	sh := (*reflect.SliceHeader)(unsafe.Pointer(&cslice))
+	sh.Data = uintptr(unsafe.Pointer(cpoints))
+	sh.Len = int(cpointsLen)
+	sh.Cap = int(cpointsLen)
+
+	points := make([]TouchPoint, len(cslice))
+	for i, cpoint := range cslice {
+		points[i] = TouchPoint{
+			ID:       int(cpoint.id),
+			State:    TouchState(cpoint.state),
+			X:        float64(cpoint.x),
+			Y:        float64(cpoint.y),
+			Pressure: float64(cpoint.pressure),
+		}
+	}
+	tracker := (*touchTracker)(trackerp)
+	if tracker.handler.Touch != nil {
+		tracker.handler.Touch(points)
+	}
+	if tracker.handler.Gesture != nil {
+		tracker.recognize(points)
+	}
+}
+
+func (t *touchTracker) recognize(points []TouchPoint) {
+	active := 0
+	for _, p := range points {
+		if p.State != TouchReleased {
+			active++
+		}
+	}
+
+	// Pinch while exactly two fingers are down.
+	if len(points) == 2 {
+		p0, p1 := points[0], points[1]
+		dist := math.Hypot(p1.X-p0.X, p1.Y-p0.Y)
+		angle := math.Atan2(p1.Y-p0.Y, p1.X-p0.X) * 180 / math.Pi
+		g := Gesture{Type: PinchGesture, X: (p0.X + p1.X) / 2, Y: (p0.Y + p1.Y) / 2}
+		switch {
+		case !t.pinching && active == 2:
+			t.stopLongPress()
+			t.pinching = true
+			t.pinchDist = dist
+			t.pinchAngle = angle
+			g.State = GestureStarted
+		case t.pinching && active == 2:
+			g.State = GestureUpdated
+		case t.pinching:
+			t.pinching = false
+			g.State = GestureFinished
+		default:
+			return
+		}
+		if t.pinchDist > 0 {
+			g.Scale = dist / t.pinchDist
+		}
+		g.Angle = angle - t.pinchAngle
+		t.handler.Gesture(g)
+		return
+	}
+	if t.pinching {
+		t.pinching = false
+		t.handler.Gesture(Gesture{Type: PinchGesture, State: GestureFinished, Scale: 1})
+	}
+	if len(points) != 1 {
+		t.stopLongPress()
+		return
+	}
+
+	// Swipe and long-press with a single finger.
+	p := points[0]
+	switch p.State {
+	case TouchPressed:
+		t.start = p
+		t.last = p
+		t.startTime = time.Now()
+		t.startLongPress()
+	case TouchMoved, TouchStationary:
+		t.last = p
+		if math.Hypot(p.X-t.start.X, p.Y-t.start.Y) > longPressMaxMove {
+			t.stopLongPress()
+		}
+	case TouchReleased:
+		t.stopLongPress()
+		dx, dy := p.X-t.start.X, p.Y-t.start.Y
+		if math.Hypot(dx, dy) >= swipeMinDistance && time.Since(t.startTime) <= swipeMaxDuration {
+			t.handler.Gesture(Gesture{Type: SwipeGesture, State: GestureFinished, X: p.X, Y: p.Y, DX: dx, DY: dy, Scale: 1})
+		}
+	}
+}
+
+func (t *touchTracker) startLongPress() {
+	t.stopLongPress()
+	gen := t.longPressGen
+	t.longPress = time.AfterFunc(longPressThreshold, func() {
+		gui(func() {
+			// The timer may have fired while being stopped.
+			if t.longPressGen != gen {
+				return
+			}
+			t.longPress = nil
+			t.handler.Gesture(Gesture{Type: LongPressGesture, State: GestureFinished, X: t.last.X, Y: t.last.Y, Scale: 1})
+		})
+	})
+}
+
+func (t *touchTracker) stopLongPress() {
+	if t.longPress != nil {
+		t.longPress.Stop()
+		t.longPress = nil
+		t.longPressGen++
+	}
+}
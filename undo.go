@@ -0,0 +1,181 @@
+package qml
+
+// UndoStack holds a history of commands that may be undone and redone,
+// shared between Go logic and QML logic. Commands are pushed by Go code,
+// while the exported fields may be bound by QML elements such as toolbar
+// buttons, which may also call the Undo and Redo methods. For example:
+//
+//     stack := &qml.UndoStack{}
+//     context.SetVar("undoStack", stack)
+//     ...
+//     old := doc.Title
+//     stack.Push("Rename", func() { doc.SetTitle(title) }, func() { doc.SetTitle(old) })
+//
+// and in QML:
+//
+//     Button { text: "Undo " + undoStack.undoText; enabled: undoStack.canUndo; onClicked: undoStack.undo() }
+//
+// The zero value is an empty stack ready to use. Commands are run within
+// the main GUI thread.
+type UndoStack struct {
+	CanUndo  bool   // Whether there is a command to undo.
+	CanRedo  bool   // Whether there is a command to redo.
+	UndoText string // Description of the command undone by Undo.
+	RedoText string // Description of the command redone by Redo.
+	Modified bool   // Whether the stack is away from the point marked with SetClean.
+
+	commands []*undoCommand
+	index    int // Commands before index are done.
+	clean    int // Index marked clean, or -1 if unreachable.
+	macros   []*undoCommand
+}
+
+type undoCommand struct {
+	text     string
+	do, undo func()
+	children []*undoCommand
+}
+
+func (c *undoCommand) redo() {
+	if c.do != nil {
+		c.do()
+	}
+	for _, child := range c.children {
+		child.redo()
+	}
+}
+
+func (c *undoCommand) revert() {
+	for i := len(c.children) - 1; i >= 0; i-- {
+		c.children[i].revert()
+	}
+	if c.undo != nil {
+		c.undo()
+	}
+}
+
+// Push runs do and records it in the stack as a command described by text,
+// which is reverted by running undo. Any commands previously undone are
+// discarded. If a macro is being recorded, the command becomes part of it.
+func (s *UndoStack) Push(text string, do, undo func()) {
+	gui(func() {
+		cmd := &undoCommand{text: text, do: do, undo: undo}
+		cmd.redo()
+		s.push(cmd)
+	})
+}
+
+func (s *UndoStack) push(cmd *undoCommand) {
+	if n := len(s.macros); n > 0 {
+		s.macros[n-1].children = append(s.macros[n-1].children, cmd)
+		return
+	}
+	if s.clean > s.index {
+		s.clean = -1
+	}
+	s.commands = append(s.commands[:s.index], cmd)
+	s.index++
+	s.update()
+}
+
+// BeginMacro starts recording a macro described by text. All commands
+// pushed until the matching call to EndMacro are undone and redone
+// together as a single command. Macros may be nested.
+func (s *UndoStack) BeginMacro(text string) {
+	gui(func() {
+		s.macros = append(s.macros, &undoCommand{text: text})
+	})
+}
+
+// EndMacro finishes recording the macro started by the last call to
+// BeginMacro, and pushes it into the stack.
+func (s *UndoStack) EndMacro() {
+	gui(func() {
+		n := len(s.macros)
+		if n == 0 {
+			panic("UndoStack.EndMacro called without a macro being recorded")
+		}
+		macro := s.macros[n-1]
+		s.macros = s.macros[:n-1]
+		s.push(macro)
+	})
+}
+
+// Undo reverts the last command done, if any.
+func (s *UndoStack) Undo() {
+	gui(func() {
+		if s.index == 0 || len(s.macros) > 0 {
+			return
+		}
+		s.index--
+		s.commands[s.index].revert()
+		s.update()
+	})
+}
+
+// Redo runs again the last command undone, if any.
+func (s *UndoStack) Redo() {
+	gui(func() {
+		if s.index == len(s.commands) || len(s.macros) > 0 {
+			return
+		}
+		s.commands[s.index].redo()
+		s.index++
+		s.update()
+	})
+}
+
+// SetClean marks the current point in the history as clean, such as when
+// a document is saved. The Modified field reports whether the stack is
+// away from that point, and is initially false.
+func (s *UndoStack) SetClean() {
+	gui(func() {
+		s.clean = s.index
+		s.update()
+	})
+}
+
+// Clear discards all commands without running them.
+func (s *UndoStack) Clear() {
+	gui(func() {
+		s.commands = nil
+		s.index = 0
+		s.clean = 0
+		s.macros = nil
+		s.update()
+	})
+}
+
+// update refreshes the exported fields after a change in the history.
+func (s *UndoStack) update() {
+	canUndo := s.index > 0
+	canRedo := s.index < len(s.commands)
+	var undoText, redoText string
+	if canUndo {
+		undoText = s.commands[s.index-1].text
+	}
+	if canRedo {
+		redoText = s.commands[s.index].text
+	}
+	modified := s.clean != s.index
+	if s.CanUndo != canUndo {
+		s.CanUndo = canUndo
+		Changed(s, &s.CanUndo)
+	}
+	if s.CanRedo != canRedo {
+		s.CanRedo = canRedo
+		Changed(s, &s.CanRedo)
+	}
+	if s.UndoText != undoText {
+		s.UndoText = undoText
+		Changed(s, &s.UndoText)
+	}
+	if s.RedoText != redoText {
+		s.RedoText = redoText
+		Changed(s, &s.RedoText)
+	}
+	if s.Modified != modified {
+		s.Modified = modified
+		Changed(s, &s.Modified)
+	}
+}
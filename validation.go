@@ -0,0 +1,186 @@
+package qml
+
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Validation checks the fields of a Go struct with rules defined in Go,
+// and exposes the resulting error messages to QML logic, so that invalid
+// input may be highlighted while the rules themselves stay in Go.
+// For example:
+//
+//     validation := qml.NewValidation(engine, account)
+//     validation.Rule("Email", func(value interface{}) error {
+//             if !strings.Contains(value.(string), "@") {
+//                     return errors.New("not an email address")
+//             }
+//             return nil
+//     })
+//     validation.CrossRule("Confirm", func() error {
+//             if account.Confirm != account.Password {
+//                     return errors.New("passwords do not match")
+//             }
+//             return nil
+//     })
+//     validation.Watch(form)
+//     context.SetVar("validation", validation)
+//
+// and in QML:
+//
+//     TextField { objectName: "email"; color: validation.errors.email ? "red" : "black" }
+//     Button { text: "Save"; enabled: validation.isValid }
+//
+type Validation struct {
+	// IsValid is whether all rules passed when last validated.
+	IsValid bool
+
+	// Errors is seen by QML logic as an object with one property per
+	// field with rules, named after the QML name of the field, and
+	// holding the error message of the first failed rule of the field,
+	// or an empty string if the field is valid.
+	Errors Object
+
+	engine *Engine
+	value  reflect.Value
+	rules  []validationRule
+	errors map[string]string
+}
+
+type validationRule struct {
+	field string
+	value func(value interface{}) error
+	cross func() error
+}
+
+// NewValidation returns a new validation for the fields of the struct
+// pointed to by ptr, for use with engine. It starts out valid, with no
+// rules. The validation must be destroyed with Destroy once it's no
+// longer needed.
+func NewValidation(engine *Engine, ptr interface{}) *Validation {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("NewValidation must be given a pointer to a struct, got %T", ptr))
+	}
+	validation := &Validation{
+		IsValid: true,
+		engine:  engine,
+		value:   v.Elem(),
+		errors:  make(map[string]string),
+	}
+	gui(func() {
+		validation.Errors = &Common{engine: engine, addr: C.newPropertyMap(engine.addr)}
+	})
+	return validation
+}
+
+// Rule adds a rule that checks the value of the named field, which
+// passes if f returns nil. Rules are checked in the order they were
+// added, and only the first error of each field is reported.
+func (v *Validation) Rule(field string, f func(value interface{}) error) {
+	v.addRule(validationRule{field: field, value: f})
+}
+
+// CrossRule adds a rule involving several fields, which passes if f
+// returns nil. A failure is reported as an error of the named field.
+func (v *Validation) CrossRule(field string, f func() error) {
+	v.addRule(validationRule{field: field, cross: f})
+}
+
+func (v *Validation) addRule(rule validationRule) {
+	if !v.value.FieldByName(rule.field).IsValid() {
+		panic(fmt.Sprintf("%s has no field %s", v.value.Type(), rule.field))
+	}
+	gui(func() {
+		v.rules = append(v.rules, rule)
+		if _, ok := v.errors[rule.field]; !ok {
+			v.setError(rule.field, "")
+		}
+	})
+}
+
+// Validate checks all rules against the current field values, updates
+// Errors and IsValid accordingly, and returns whether all rules passed.
+func (v *Validation) Validate() bool {
+	var valid bool
+	gui(func() {
+		valid = v.validate()
+	})
+	return valid
+}
+
+func (v *Validation) validate() bool {
+	if v.Errors == nil {
+		panic("validation was destroyed")
+	}
+	errors := make(map[string]string)
+	for _, rule := range v.rules {
+		if errors[rule.field] != "" {
+			continue
+		}
+		var err error
+		if rule.cross != nil {
+			err = rule.cross()
+		} else {
+			err = rule.value(v.value.FieldByName(rule.field).Interface())
+		}
+		if err != nil {
+			errors[rule.field] = err.Error()
+		} else {
+			errors[rule.field] = ""
+		}
+	}
+	valid := true
+	for field, message := range errors {
+		v.setError(field, message)
+		if message != "" {
+			valid = false
+		}
+	}
+	if v.IsValid != valid {
+		v.IsValid = valid
+		Changed(v, &v.IsValid)
+	}
+	return valid
+}
+
+func (v *Validation) setError(field, message string) {
+	if old, ok := v.errors[field]; ok && old == message {
+		return
+	}
+	v.errors[field] = message
+	var dvalue C.DataValue
+	packDataValue(message, &dvalue, v.engine, cppOwner)
+	cname, cnamelen := unsafeStringData(memberName(field))
+	C.propertyMapInsert(v.Errors.Common().addr, cname, cnamelen, &dvalue)
+}
+
+// Error returns the error message of the named field when last
+// validated, or an empty string if the field is valid.
+func (v *Validation) Error(field string) string {
+	var message string
+	gui(func() {
+		message = v.errors[field]
+	})
+	return message
+}
+
+// Watch arranges for the validation to be checked again whenever a field
+// is changed by editing its item in form.
+func (v *Validation) Watch(form *Form) {
+	form.OnChange(func(field string) { v.validate() })
+}
+
+// Destroy releases the resources used by the validation.
+func (v *Validation) Destroy() {
+	gui(func() {
+		if v.Errors != nil {
+			v.Errors.Destroy()
+			v.Errors = nil
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package qml
+
+// #include <stdlib.h>
+// #include "capi.h"
+//
+import "C"
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+// Watcher monitors files and directories for changes, and reports them
+// both to Go functions registered with OnChanged and to QML bindings.
+//
+// A Watcher may be made available to QML with Context.SetVar, and its
+// Path and Revision fields may then be used in bindings. For example,
+// to reload an image whenever it changes on disk:
+//
+//     watcher, err := qml.NewWatcher("/tmp/photo.png")
+//     ...
+//     engine.Context().SetVar("watcher", watcher)
+//
+// and in QML:
+//
+//     Image {
+//         cache: false
+//         source: "file:///tmp/photo.png?" + watcher.revision
+//     }
+//
+type Watcher struct {
+	// Path holds the file or directory that changed most recently.
+	Path string
+
+	// Revision is incremented whenever a watched file or directory
+	// changes, so bindings depending on it are reevaluated.
+	Revision int
+
+	addr     unsafe.Pointer
+	handlers []func(path string)
+}
+
+var watchers = make(map[*Watcher]bool)
+
+// NewWatcher returns a new Watcher monitoring the provided files and
+// directories. Changes to a directory include files being added,
+// removed, or renamed within it, but not changes to their content.
+//
+// The Close method must be called to release the watcher.
+func NewWatcher(paths ...string) (*Watcher, error) {
+	w := &Watcher{}
+	gui(func() {
+		w.addr = C.newFileWatcher(unsafe.Pointer(w))
+		watchers[w] = true
+	})
+	for _, path := range paths {
+		if err := w.Add(path); err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Add starts monitoring the provided file or directory.
+func (w *Watcher) Add(path string) error {
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	var ok C.int
+	gui(func() {
+		if w.addr != nilPtr {
+			ok = C.fileWatcherAdd(w.addr, cpath)
+		}
+	})
+	if ok == 0 {
+		return fmt.Errorf("cannot watch %s", path)
+	}
+	return nil
+}
+
+// Remove stops monitoring the provided file or directory.
+func (w *Watcher) Remove(path string) {
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	gui(func() {
+		if w.addr != nilPtr {
+			C.fileWatcherRemove(w.addr, cpath)
+		}
+	})
+}
+
+// Paths returns the files and directories being monitored.
+func (w *Watcher) Paths() []string {
+	var paths string
+	gui(func() {
+		if w.addr != nilPtr {
+			paths = cstringResult(C.fileWatcherPaths(w.addr))
+		}
+	})
+	if paths == "" {
+		return nil
+	}
+	return strings.Split(paths, "\n")
+}
+
+// OnChanged arranges for f to be called with the path of the file
+// or directory that changed, whenever a change is detected. As with
+// signal handlers, f is run within the main GUI thread.
+func (w *Watcher) OnChanged(f func(path string)) {
+	gui(func() {
+		w.handlers = append(w.handlers, f)
+	})
+}
+
+// Close stops monitoring all paths and releases the watcher.
+//
+// It is safe to call Close more than once.
+func (w *Watcher) Close() {
+	gui(func() {
+		if w.addr != nilPtr {
+			C.delFileWatcher(w.addr)
+			w.addr = nilPtr
+			delete(watchers, w)
+		}
+	})
+}
+
+//export hookFileChanged
+func hookFileChanged(watcherp unsafe.Pointer, cpath *C.char, cpathLen C.int) {
+	w := (*Watcher)(watcherp)
+	w.Path = C.GoStringN(cpath, cpathLen)
+	w.Revision++
+	Changed(w, &w.Path)
+	Changed(w, &w.Revision)
+	for _, f := range w.handlers {
+		f(w.Path)
+	}
+}
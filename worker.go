@@ -0,0 +1,107 @@
+package qml
+
+import (
+	"reflect"
+	"sync"
+)
+
+// worker runs the method calls made by QML logic on a Go value that
+// was moved to a worker, one at a time and in the order they were made.
+type worker struct {
+	mu      sync.Mutex
+	queue   []func()
+	running bool
+}
+
+var workers = make(map[interface{}]*worker)
+
+// MoveToWorker arranges for methods of value called by QML logic to be
+// run on a dedicated goroutine rather than within the main GUI thread,
+// so that heavy computations do not block the user interface. The calls
+// are run one at a time, in the order they were made.
+//
+// The value must be a pointer to a Go value handed to QML. Methods of
+// value with a result return a JavaScript Promise to QML, which is resolved
+// with the method result once the call completes. Methods run by a worker
+// must have at most one result. Methods that may fail can return a
+// *Promise themselves, which is then adopted by the promise seen by QML.
+// For example:
+//
+//     func (c *Calculator) Digits(n int) string { ... }
+//
+//     qml.MoveToWorker(calculator)
+//
+// and in QML:
+//
+//     calculator.digits(1000).then(function(digits) { ... })
+//
+// Reading and writing fields of value from QML is still done within the
+// main GUI thread, so methods run by the worker must synchronize their
+// access to fields with Lock and Unlock, and report changes with Changed.
+//
+// JavaScript promises require Qt 5.12 or later.
+func MoveToWorker(value interface{}) {
+	if reflect.ValueOf(value).Kind() != reflect.Ptr {
+		panic("MoveToWorker must be given a pointer to a Go value")
+	}
+	gui(func() {
+		if workers[value] == nil {
+			workers[value] = &worker{}
+		}
+	})
+}
+
+// MoveToGUI reverts the effect of MoveToWorker, so that methods of value
+// called by QML logic are run again within the main GUI thread. Calls
+// already queued in the worker are still run there.
+func MoveToGUI(value interface{}) {
+	gui(func() {
+		delete(workers, value)
+	})
+}
+
+// call queues the method call for running in the worker goroutine,
+// and returns a promise for its result if the method has one.
+func (w *worker) call(method reflect.Value, params []reflect.Value) *Promise {
+	if method.Type().NumOut() > 1 {
+		panic("methods run by a worker must have at most one result")
+	}
+	var promise *Promise
+	if method.Type().NumOut() == 1 {
+		promise = NewPromise()
+	}
+	w.run(func() {
+		result := method.Call(params)
+		if promise != nil {
+			promise.Resolve(result[0].Interface())
+		}
+	})
+	return promise
+}
+
+func (w *worker) run(f func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.queue = append(w.queue, f)
+	if !w.running {
+		w.running = true
+		go w.loop()
+	}
+}
+
+// loop runs the queued calls until the queue is empty, so that idle
+// workers hold no goroutines.
+func (w *worker) loop() {
+	for {
+		w.mu.Lock()
+		if len(w.queue) == 0 {
+			w.running = false
+			w.mu.Unlock()
+			return
+		}
+		f := w.queue[0]
+		w.queue = w.queue[1:]
+		w.mu.Unlock()
+		f()
+	}
+}